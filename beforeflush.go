@@ -0,0 +1,81 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// ErrFlushAborted is wrapped by the error of a flush aborted by ChannelOptions.BeforeFlush.
+var ErrFlushAborted = errors.New("netty: flush aborted")
+
+// beforeFlushTransport buffers the writes until flushed, the buffer is passed to the hook before it is written.
+type beforeFlushTransport struct {
+	transport.Transport
+	hook   func(buf []byte) ([]byte, error)
+	buffer []byte
+}
+
+func (b *beforeFlushTransport) Write(p []byte) (int, error) {
+	b.buffer = append(b.buffer, p...)
+	return len(p), nil
+}
+
+func (b *beforeFlushTransport) Writev(buffs transport.Buffers) (n int64, err error) {
+	for _, buff := range buffs.Buffers {
+		b.buffer = append(b.buffer, buff...)
+		n += int64(len(buff))
+	}
+	return
+}
+
+func (b *beforeFlushTransport) Flush() error {
+	if err := b.flushHook(); nil != err {
+		return err
+	}
+	return b.Transport.Flush()
+}
+
+// Push to push the flushed bytes if the transport supports it.
+func (b *beforeFlushTransport) Push() error {
+	if err := b.flushHook(); nil != err {
+		return err
+	}
+	if pt, ok := b.Transport.(pushTransport); ok {
+		return pt.Push()
+	}
+	return b.Transport.Flush()
+}
+
+// flushHook to pass the buffer to the hook and write the result to the transport, the buffer is reused.
+func (b *beforeFlushTransport) flushHook() error {
+	if 0 == len(b.buffer) {
+		return nil
+	}
+
+	data, err := b.hook(b.buffer)
+	defer func() { b.buffer = b.buffer[:0] }()
+	if nil != err {
+		return fmt.Errorf("%w: %v", ErrFlushAborted, err)
+	}
+
+	_, err = b.Transport.Write(data)
+	return err
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestChannelBeforeFlush(t *testing.T) {
+
+	// append the length of the whole batch, reject the batch contains "bad".
+	hook := func(buf []byte) ([]byte, error) {
+		if bytes.Contains(buf, []byte("bad")) {
+			return nil, errors.New("bad batch")
+		}
+		return append(buf, "#"+strconv.Itoa(len(buf))...), nil
+	}
+
+	ch, peer := pipeChannel(NewChannelWith(ChannelOptions{FlushDelay: time.Hour, BeforeFlush: hook}), "127.0.0.1:9527", discardHandler{})
+	defer ch.Close(nil)
+	received := readPeer(peer)
+
+	for _, batch := range []struct {
+		writes []string
+		want   string
+		err    error
+	}{
+		{writes: []string{"hello", ",", "world"}, want: "hello,world#11"},
+		{writes: []string{"bad", "message"}, err: ErrFlushAborted},
+		{writes: []string{"netty"}, want: "netty#5"},
+	} {
+		for _, message := range batch.writes {
+			if err := ch.Write(message); nil != err {
+				t.Fatal(err)
+			}
+		}
+
+		if err := ch.Flush(); !errors.Is(err, batch.err) {
+			t.Fatal("unexpected flush error:", err, "want:", batch.err)
+		}
+
+		if nil != batch.err {
+			continue
+		}
+
+		select {
+		case got := <-received:
+			if batch.want != got {
+				t.Fatalf("unexpected batch: %q, want: %q", got, batch.want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("batch is not flushed:", batch.want)
+		}
+	}
+}
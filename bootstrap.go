@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/mijingduI/go-netty/transport"
 	"github.com/mijingduI/go-netty/transport/tcp"
@@ -51,6 +52,7 @@ func NewBootstrap(option ...Option) Bootstrap {
 		transportFactory: tcp.New(),
 		executor:         AsyncExecutor(),
 		holder:           NewChannelHolder(128),
+		acceptBackoff:    [2]time.Duration{5 * time.Millisecond, time.Second},
 	}
 	opts.bootstrapCtx, opts.bootstrapCancel = context.WithCancel(context.Background())
 
@@ -89,8 +91,18 @@ func (bs *bootstrap) ServeChannel(ctx context.Context, transport transport.Trans
 		ch.SetAttachment(attachment)
 	}
 
+	// tag the channel with the name of bootstrap.
+	if "" != bs.name {
+		ch.SetAttribute(NameAttribute, bs.name)
+	}
+
+	// expose the tls state if necessary
+	protocol := exposeTLSState(ch)
+
 	// initialization pipeline
-	if childChannel {
+	if initializer, ok := bs.protocolInits[protocol]; ok && childChannel && "" != protocol {
+		initializer(ch)
+	} else if childChannel {
 		bs.childInitializer(ch)
 	} else {
 		bs.clientInitializer(ch)
@@ -129,7 +141,7 @@ func (bs *bootstrap) Listen(url string, option ...transport.Option) Listener {
 	if _, ok := bs.listeners.Load(url); ok {
 		panic(fmt.Errorf("duplicate listener: %s", url))
 	}
-	l := &listener{bs: bs, url: url, option: option}
+	l := &listener{bs: bs, url: url, option: option, closed: make(chan struct{})}
 	if _, loaded := bs.listeners.LoadOrStore(url, l); loaded {
 		panic(fmt.Errorf("duplicate listener: %s", url))
 	}
@@ -153,6 +165,21 @@ func (bs *bootstrap) Shutdown() {
 	}
 }
 
+// filterTransport check the accepted transport with filters, returns the delay to serve the transport.
+func (bs *bootstrap) filterTransport(t transport.Transport) (time.Duration, error) {
+	var delay time.Duration
+	for _, filter := range bs.acceptFilters {
+		if err := filter(t); nil != err {
+			if d, ok := err.(acceptDelay); ok {
+				delay += time.Duration(d)
+				continue
+			}
+			return 0, err
+		}
+	}
+	return delay, nil
+}
+
 // removeListener close the listener with url
 func (bs *bootstrap) removeListener(url string) {
 	bs.listeners.Delete(url)
@@ -174,6 +201,8 @@ type listener struct {
 	option   []transport.Option
 	options  *transport.Options
 	acceptor transport.Acceptor
+	once     sync.Once
+	closed   chan struct{} // to interrupt the backoff of accept
 }
 
 // Acceptor returned the acceptor
@@ -183,6 +212,7 @@ func (l *listener) Acceptor() transport.Acceptor {
 
 // Close listener
 func (l *listener) Close() error {
+	l.once.Do(func() { close(l.closed) })
 	l.bs.removeListener(l.url)
 	if l.acceptor != nil {
 		return l.acceptor.Close()
@@ -206,6 +236,19 @@ func (l *listener) Sync() error {
 		return err
 	}
 
+	// close the listener to unblock Accept when the context is done.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-l.options.Context.Done():
+			_ = l.Close()
+		case <-stop:
+		}
+	}()
+
+	var tempDelay time.Duration // how long to sleep on accept failure
+
 	for {
 		// accept the transport
 		t, err := l.acceptor.Accept()
@@ -214,11 +257,62 @@ func (l *listener) Sync() error {
 			case <-l.options.Context.Done():
 				return ErrServerClosed
 			default:
+			}
+
+			if !transport.IsTemporary(err) {
 				return err
 			}
+
+			// retry the transient error with backoff.
+			if tempDelay *= 2; 0 == tempDelay {
+				tempDelay = l.bs.acceptBackoff[0]
+			} else if max := l.bs.acceptBackoff[1]; tempDelay > max {
+				tempDelay = max
+			}
+
+			// the backoff is interrupted by the shutdown or close, e.g. in a storm of fd exhaustion.
+			timer := time.NewTimer(tempDelay)
+			select {
+			case <-l.options.Context.Done():
+				timer.Stop()
+				return ErrServerClosed
+			case <-l.closed:
+				timer.Stop()
+				return ErrServerClosed
+			case <-timer.C:
+			}
+			continue
+		}
+		tempDelay = 0
+
+		// filter the transport
+		delay, err := l.bs.filterTransport(t)
+		if nil != err {
+			_ = t.Close()
+			continue
 		}
 
+		if delay > 0 {
+			go l.serveAfter(t, delay)
+			continue
+		}
+
+		l.bs.ServeChannel(l.options.Context, t, l.options.Attachment, true)
+	}
+}
+
+// serveAfter serve the transport after the delay off the accept loop, it is closed if the listener is done.
+func (l *listener) serveAfter(t transport.Transport, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
 		l.bs.ServeChannel(l.options.Context, t, l.options.Attachment, true)
+	case <-l.options.Context.Done():
+		_ = t.Close()
+	case <-l.closed:
+		_ = t.Close()
 	}
 }
 
@@ -18,15 +18,22 @@ package netty
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/mijingduI/go-netty/transport"
 	"github.com/mijingduI/go-netty/transport/tcp"
+	_ "github.com/mijingduI/go-netty/transport/websocket"
 	"github.com/mijingduI/go-netty/utils"
 )
 
@@ -76,6 +83,220 @@ func TestBootstrap(t *testing.T) {
 	time.Sleep(time.Second)
 }
 
+func TestListenerContextCancel(t *testing.T) {
+
+	bs := NewBootstrap(WithChildInitializer(func(ch Channel) {}))
+	defer bs.Shutdown()
+
+	ctx, cancel := context.WithCancel(bs.Context())
+	result := make(chan error, 1)
+	bs.Listen("127.0.0.1:9532", transport.WithContext(ctx)).Async(func(err error) {
+		result <- err
+	})
+
+	// cancel while blocking in accept.
+	time.Sleep(time.Millisecond * 100)
+	cancel()
+
+	select {
+	case err := <-result:
+		if ErrServerClosed != err {
+			t.Fatal("unexpected error:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("accept is not unblocked")
+	}
+
+	// the address is released.
+	l, err := net.Listen("tcp", "127.0.0.1:9532")
+	if nil != err {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+}
+
+func TestListenerTemporaryError(t *testing.T) {
+
+	acceptor := &mockAcceptor{
+		errs: []error{
+			&net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)},
+			&net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.ENFILE)},
+			&net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.ECONNABORTED)},
+		},
+		done: make(chan struct{}),
+	}
+
+	accepted := make(chan Channel, 1)
+	bs := NewBootstrap(
+		WithTransport(&mockFactory{acceptor: acceptor}),
+		WithChildInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(discardHandler{})
+			accepted <- ch
+		}),
+	)
+
+	result := make(chan error, 1)
+	bs.Listen("mock://127.0.0.1:9527").Async(func(err error) {
+		result <- err
+	})
+
+	select {
+	case <-accepted:
+	case err := <-result:
+		t.Fatal("accept loop exited:", err)
+	case <-time.After(time.Second):
+		t.Fatal("transport is not accepted")
+	}
+
+	bs.Shutdown()
+	if err := <-result; ErrServerClosed != err {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+func TestListenerAcceptBackoff(t *testing.T) {
+
+	temporary := &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	acceptor := &mockAcceptor{errs: []error{temporary, temporary, temporary, temporary}, done: make(chan struct{})}
+
+	accepted := make(chan Channel, 1)
+	bs := NewBootstrap(
+		WithTransport(&mockFactory{acceptor: acceptor}),
+		WithAcceptBackoff(time.Millisecond*20, time.Millisecond*40),
+		WithChildInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(discardHandler{})
+			accepted <- ch
+		}),
+	)
+	defer bs.Shutdown()
+
+	bs.Listen("mock://127.0.0.1:9527").Async(func(err error) {})
+
+	select {
+	case <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("transport is not accepted")
+	}
+
+	// 4 errors + 1 accepted transport
+	calls := acceptor.acceptCalls()
+	if len(calls) < 5 {
+		t.Fatal("unexpected accept calls:", len(calls))
+	}
+
+	for i, want := range []time.Duration{20, 40, 40, 40} {
+		if gap := calls[i+1].Sub(calls[i]); gap < want*time.Millisecond {
+			t.Fatalf("accept #%d retried after %s, want: %dms", i+1, gap, want)
+		}
+	}
+}
+
+func TestListenerCloseDuringBackoff(t *testing.T) {
+
+	temporary := &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	acceptor := &mockAcceptor{errs: []error{temporary, temporary, temporary}, done: make(chan struct{})}
+
+	bs := NewBootstrap(WithTransport(&mockFactory{acceptor: acceptor}), WithAcceptBackoff(time.Second, time.Second))
+	defer bs.Shutdown()
+
+	l := bs.Listen("mock://127.0.0.1:9527")
+	result := make(chan error, 1)
+	l.Async(func(err error) { result <- err })
+
+	// wait for the backoff of the first error.
+	for deadline := time.Now().Add(time.Second); 0 == len(acceptor.acceptCalls()); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("listener is not accepting")
+		}
+	}
+
+	start := time.Now()
+	_ = l.Close()
+
+	select {
+	case err := <-result:
+		if ErrServerClosed != err {
+			t.Fatal("unexpected error:", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("backoff is not interrupted by close")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatal("listener closed after:", elapsed)
+	}
+}
+
+func TestListenerFatalError(t *testing.T) {
+
+	fatal := fmt.Errorf("fatal accept error")
+	acceptor := &mockAcceptor{errs: []error{fatal}, done: make(chan struct{})}
+
+	bs := NewBootstrap(WithTransport(&mockFactory{acceptor: acceptor}))
+	defer bs.Shutdown()
+
+	if err := bs.Listen("mock://127.0.0.1:9527").Sync(); fatal != err {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+type mockFactory struct {
+	acceptor *mockAcceptor
+}
+
+func (f *mockFactory) Schemes() transport.Schemes {
+	return transport.Schemes{"mock"}
+}
+
+func (f *mockFactory) Connect(options *transport.Options) (transport.Transport, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (f *mockFactory) Listen(options *transport.Options) (transport.Acceptor, error) {
+	return f.acceptor, nil
+}
+
+// mockAcceptor returns the errors in order, then a transport, then blocks until closed.
+type mockAcceptor struct {
+	errs     []error
+	accepted bool
+	mutex    sync.Mutex
+	calls    []time.Time
+	once     sync.Once
+	done     chan struct{}
+}
+
+func (a *mockAcceptor) Accept() (transport.Transport, error) {
+	a.mutex.Lock()
+	a.calls = append(a.calls, time.Now())
+	a.mutex.Unlock()
+	if len(a.errs) > 0 {
+		err := a.errs[0]
+		a.errs = a.errs[1:]
+		return nil, err
+	}
+
+	if !a.accepted {
+		a.accepted = true
+		local, _ := net.Pipe()
+		return transport.NewTransport(local, 0, 0), nil
+	}
+
+	<-a.done
+	return nil, net.ErrClosed
+}
+
+func (a *mockAcceptor) acceptCalls() []time.Time {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return append([]time.Time(nil), a.calls...)
+}
+
+func (a *mockAcceptor) Close() error {
+	a.once.Do(func() { close(a.done) })
+	return nil
+}
+
 type eventHandler struct {
 	idleEvent int32
 }
@@ -223,3 +444,57 @@ func (textCodec) HandleWrite(ctx OutboundContext, message Message) {
 		ctx.HandleWrite(message)
 	}
 }
+
+func TestTransportByName(t *testing.T) {
+
+	// the same pipeline over the transports selected by config.
+	var configs = []struct {
+		name    string
+		address string
+	}{
+		{name: "tcp", address: "tcp://127.0.0.1:9558"},
+		{name: "ws", address: "ws://127.0.0.1:9559/echo"},
+	}
+
+	for _, c := range configs {
+		factory, err := transport.TransportByName(c.name)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		server, _ := echoServer(AsyncExecutor(), c.address, WithTransport(factory))
+
+		received := make(chan string, 1)
+		client := NewBootstrap(WithTransport(factory), WithClientInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				buffer := make([]byte, 64)
+				received <- string(buffer[:utils.AssertLength(utils.MustToReader(message).Read(buffer))])
+			}))
+		}))
+
+		ch, err := connectRetry(client, c.address)
+		if nil != err {
+			t.Fatal(c.name, err)
+		}
+
+		if err := ch.Write([]byte("hello " + c.name)); nil != err {
+			t.Fatal(c.name, err)
+		}
+
+		select {
+		case message := <-received:
+			if "hello "+c.name != message {
+				t.Fatal(c.name, "unexpected echo:", message)
+			}
+		case <-time.After(time.Second):
+			t.Fatal(c.name, "no echo")
+		}
+
+		client.Shutdown()
+		server.Shutdown()
+	}
+
+	if _, err := transport.TransportByName("carrier-pigeon"); !errors.Is(err, transport.ErrUnknownTransport) {
+		t.Fatal("unexpected error:", err)
+	}
+}
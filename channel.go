@@ -22,8 +22,11 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/mijingduI/go-netty/transport"
 	"github.com/mijingduI/go-netty/utils"
@@ -33,20 +36,45 @@ import (
 // ErrAsyncNoSpace is returned when an write queue full if not writeForever flags.
 var ErrAsyncNoSpace = errors.New("async write queue is full")
 
+// ErrSlowConsumer is the close cause of channel if a write is not finished in ChannelOptions.WriteTimeout.
+var ErrSlowConsumer = errors.New("netty: slow consumer")
+
 // Channel is defines a server-side-channel & client-side-channel
 type Channel interface {
 	// ID channel id
 	ID() int64
 
-	// Write a message through the Pipeline
+	// Write a message through the Pipeline, returns the error if the message could not be written.
+	// the concurrent writes are serialized, a message passes the outbound handlers and is written to
+	// the transport as a whole before the next one, so the bytes of messages are never interleaved,
+	// and the messages are written in FIFO order of entering the pipeline. a ctx.Write in HandleWrite
+	// is nested in the current traversal, writing through the channel in HandleWrite deadlocks.
 	Write(Message) error
 
+	// WritePriority write an urgent message through the Pipeline, the message is sent ahead of the
+	// messages queued by Write but not yet sent, e.g. a ping or a cancellation behind a backlog, the
+	// priority messages are sent in FIFO order among themselves. it is the same as Write for the
+	// channel without write queue.
+	WritePriority(Message) error
+
+	// WriteAndClose write the last message through the Pipeline, the channel is closed after
+	// the message is flushed or failed to write, the messages written after it are discarded.
+	WriteAndClose(Message) error
+
+	// Peek returns the next n bytes of the inbound stream without consuming them, the returned
+	// bytes are read again by the handlers, it blocks until n bytes arrived or the read failed.
+	Peek(n int) ([]byte, error)
+
 	// Trigger user event
 	Trigger(event Event)
 
-	// Close through the Pipeline
+	// Close through the Pipeline, err is the cause reported to ChannelInactive, ErrChannelClosed if nil.
 	Close(err error)
 
+	// CloseFuture returns the future completed when the channel is fully closed, after the inactive and
+	// cleanup handlers are called, the Err of it is the close cause, ErrChannelClosed if closed by Close(nil).
+	CloseFuture() Future
+
 	// IsActive return true if the Channel is active and so connected
 	IsActive() bool
 
@@ -56,6 +84,22 @@ type Channel interface {
 	// Write1 to write []byte to channel
 	Write1(p []byte) (n int, err error)
 
+	// Flush the write buffer of transport, the writes are flushed immediately unless ChannelOptions.FlushDelay is set.
+	Flush() error
+
+	// Pause the processing of channel without closing it, no more bytes are read from the transport,
+	// so no inbound events are fired, the bytes of the read in progress are held until resumed, and the
+	// read deadline of transport is left as is.
+	// the writes of async write channel are held in the write queue, which is bounded by WriteQueueSize,
+	// so the writes block or fail with ErrAsyncNoSpace if it is full, the sync writes are not paused.
+	Pause()
+
+	// Resume the channel paused by Pause, the held writes are flushed in order.
+	Resume()
+
+	// SetInboundTransformer set the transformer applied to the inbound messages before the pipeline, nil to remove it.
+	SetInboundTransformer(fn InboundTransformer)
+
 	// LocalAddr local address
 	LocalAddr() string
 
@@ -74,6 +118,12 @@ type Channel interface {
 	// SetAttachment set attachment
 	SetAttachment(Attachment)
 
+	// Attribute get the attribute of key, nil if not exists
+	Attribute(key interface{}) interface{}
+
+	// SetAttribute set the attribute of key, nil value to delete the attribute
+	SetAttribute(key interface{}, value interface{})
+
 	// Context channel context
 	Context() context.Context
 
@@ -81,71 +131,180 @@ type Channel interface {
 	serveChannel()
 }
 
+// InboundTransformer transforms the inbound message before it is passed to the pipeline, it is a light
+// replacement of the first InboundHandler, e.g. to wrap the bytes or add a receive timestamp.
+// the message is the inbound stream of channel, the transformer must consume the bytes it drops by
+// returning nil, otherwise the same bytes are read again. the returned utils.ReferenceCounted message
+// is owned by the pipeline, which is released by the tail if it is not consumed by the handlers.
+type InboundTransformer func(message Message) Message
+
+// AttributeKey defines the key type of builtin channel attributes
+type AttributeKey string
+
+// RawConn returns the underlying connection of channel if the transport has one, e.g. *net.TCPConn,
+// *tls.Conn over tls, or the parent connection of a multiplexed stream. It is for advanced usages
+// like setting socket options, the bytes read or written bypass the pipeline and break the channel,
+// and the connection could be shared by other channels, e.g. the udp listener.
+func RawConn(ch Channel) (net.Conn, bool) {
+	var raw interface{} = ch.Transport()
+	for {
+		switch t := raw.(type) {
+		case transport.Transport:
+			if next := t.RawTransport(); nil != next && next != raw {
+				raw = next
+				continue
+			}
+			return t, true
+		case net.Conn:
+			return t, true
+		default:
+			return nil, false
+		}
+	}
+}
+
 // NewChannel create a ChannelFactory
 func NewChannel() ChannelFactory {
-	return func(id int64, ctx context.Context, pipeline Pipeline, transport transport.Transport, executor Executor) Channel {
-		return newChannelWith(ctx, pipeline, transport, executor, id, 0, false)
-	}
+	return NewChannelWith(ChannelOptions{})
 }
 
 // NewAsyncWriteChannel create an async write ChannelFactory.
 func NewAsyncWriteChannel(writeQueueSize int, writeForever bool) ChannelFactory {
+	return NewChannelWith(ChannelOptions{WriteQueueSize: writeQueueSize, WriteForever: writeForever})
+}
+
+// ChannelOptions defines the options of channel
+type ChannelOptions struct {
+	// WriteQueueSize enables async write if > 0.
+	WriteQueueSize int
+	// WriteForever blocks the writes if the write queue is full, otherwise ErrAsyncNoSpace is returned.
+	WriteForever bool
+	// MaxReadsPerLoop yields the read loop to other channels after the number of reads if > 0, like the
+	// maxMessagesPerRead of Netty, the channel served by EventLoopGroup releases the worker and is dispatched
+	// again, so that a busy channel does not starve the others.
+	MaxReadsPerLoop int
+	// FlushDelay batches the writes in the write buffer of transport if > 0, e.g. tcp.Options.WriteBufferSize,
+	// the buffer is written to the connection when it is full, by Flush, by Close, or after the delay at most.
+	FlushDelay time.Duration
+	// WriteTimeout closes the channel with ErrSlowConsumer if a write or flush of the transport is not finished
+	// in time if > 0, e.g. the peer stops reading, it bounds the time that the writes are stalled, the writes
+	// held by Pause are not counted.
+	WriteTimeout time.Duration
+	// BeforeFlush is called with the bytes written since the last flush right before they are written to the
+	// transport if not nil, it could observe or rewrite the bytes, e.g. append a MAC over the whole batch.
+	// the writes are buffered by the channel until flushed, an error aborts the flush, the bytes are dropped.
+	BeforeFlush func(buf []byte) ([]byte, error)
+}
+
+// NewChannelWith create a ChannelFactory with the options.
+func NewChannelWith(options ChannelOptions) ChannelFactory {
 	return func(id int64, ctx context.Context, pipeline Pipeline, transport transport.Transport, executor Executor) Channel {
-		return newChannelWith(ctx, pipeline, transport, executor, id, writeQueueSize, writeForever)
+		return newChannelWith(ctx, pipeline, transport, executor, id, options)
 	}
 }
 
-// newChannelWith internal method for NewChannel & NewBufferedChannel
-func newChannelWith(ctx context.Context, pipeline Pipeline, transport transport.Transport, executor Executor, id int64, writeQueueSize int, writeForever bool) Channel {
+// newChannelWith internal method for NewChannel & NewAsyncWriteChannel
+func newChannelWith(ctx context.Context, pipeline Pipeline, transport transport.Transport, executor Executor, id int64, options ChannelOptions) Channel {
 	childCtx, cancel := context.WithCancel(ctx)
 
 	var (
-		writeQueue   chan [][]byte
-		writeBuffers net.Buffers
-		writeIndexes []int
+		writeQueueSize = options.WriteQueueSize
+		writeQueue     chan [][]byte
+		priorityQueue  chan [][]byte
+		writeBuffers   net.Buffers
+		writeIndexes   []int
+		smallPackets   chan [][]byte
+		writePackets   [][][]byte
 	)
 
 	// enable async write
 	if writeQueueSize > 0 {
 		writeQueue = make(chan [][]byte, writeQueueSize)
+		priorityQueue = make(chan [][]byte, writeQueueSize)
 		writeBuffers = make(net.Buffers, 0, (writeQueueSize/5)*2+1)
 		writeIndexes = make([]int, 0, writeQueueSize/5+1)
+		smallPackets = make(chan [][]byte, writeQueueSize)
+		writePackets = make([][][]byte, 0, writeQueueSize)
+	}
+
+	c := &channel{
+		id:            id,
+		ctx:           childCtx,
+		cancel:        cancel,
+		pipeline:      pipeline,
+		transport:     transport,
+		output:        transport,
+		gate:          &pauseGate{},
+		closeFuture:   newPromise(),
+		executor:      executor,
+		writeQueue:    writeQueue,
+		priorityQueue: priorityQueue,
+		writeBuffers:  writeBuffers,
+		writeIndexes:  writeIndexes,
+		smallPackets:  smallPackets,
+		writePackets:  writePackets,
+		writeForever:  options.WriteForever,
+		maxReads:      options.MaxReadsPerLoop,
+		flushDelay:    options.FlushDelay,
+		writeTimeout:  options.WriteTimeout,
+	}
+
+	if nil != options.BeforeFlush {
+		c.output = &beforeFlushTransport{Transport: transport, hook: options.BeforeFlush}
 	}
 
-	return &channel{
-		id:           id,
-		ctx:          childCtx,
-		cancel:       cancel,
-		pipeline:     pipeline,
-		transport:    transport,
-		executor:     executor,
-		writeQueue:   writeQueue,
-		writeBuffers: writeBuffers,
-		writeIndexes: writeIndexes,
-		writeForever: writeForever,
+	c.reader = &peekReader{reader: &pausedReader{reader: transport, gate: c.gate, done: childCtx.Done()}}
+
+	if c.flushDelay > 0 {
+		c.flushTimer = time.AfterFunc(c.flushDelay, c.delayedFlush)
+		c.flushTimer.Stop()
 	}
+	return c
 }
 
 const idle = 0
 const running = 1
 
+// closeSentinel is an empty packet in the write queue to close the channel after flushed.
+var closeSentinel = [][]byte{}
+
+// smallWriteSize is the max size of the small writes, which are copied to the scratch buffers of
+// channel instead of the pooled buffers, so that the small writes do not allocate.
+const smallWriteSize = 256
+
 // implement of Channel
 type channel struct {
-	id           int64
-	ctx          context.Context
-	cancel       context.CancelFunc
-	transport    transport.Transport
-	executor     Executor
-	pipeline     Pipeline
-	attachment   Attachment
-	writeQueue   chan [][]byte
-	writeBuffers net.Buffers
-	writeIndexes []int
-	writeForever bool
-	closed       int32
-	running      int32
-	closeErr     error
-	writeLock    sync.Mutex // for sync write
+	id            int64
+	ctx           context.Context
+	cancel        context.CancelFunc
+	transport     transport.Transport
+	output        transport.Transport // the transport to write, it buffers the writes for BeforeFlush.
+	reader        *peekReader
+	gate          *pauseGate
+	executor      Executor
+	pipeline      Pipeline
+	attachment    Attachment
+	attributes    sync.Map
+	writeQueue    chan [][]byte
+	priorityQueue chan [][]byte // the urgent packets, sent ahead of the write queue
+	writeBuffers  net.Buffers
+	writeIndexes  []int
+	smallPackets  chan [][]byte // the free scratch packets of small async writes
+	writePackets  [][][]byte    // the packets being written by writeOnce
+	scratch       []byte        // the scratch buffer of small sync string writes
+	writeForever  bool
+	maxReads      int
+	closed        int32
+	running       int32
+	closeErr      error
+	closeFuture   *promise
+	writeLock     sync.Mutex // for the writes & flushes of transport
+	flushDelay    time.Duration
+	writeTimeout  time.Duration
+	flushTimer    *time.Timer
+	flushPending  bool         // the delayed flush is scheduled, protected by writeLock
+	registration  atomic.Value // *registration of EventLoopGroup
+	transformer   atomic.Value // InboundTransformer
 }
 
 // ID get channel id
@@ -162,12 +321,57 @@ func (c *channel) Write(message Message) error {
 		}
 	}
 
-	c.invokeMethod(func() {
+	return c.invokeMethod(func() {
 		c.pipeline.FireChannelWrite(message)
 	})
+}
+
+// WritePriority write an urgent message through the Pipeline, ahead of the queued messages
+func (c *channel) WritePriority(message Message) error {
+	if !c.IsActive() {
+		select {
+		case <-c.ctx.Done():
+			return c.closeErr
+		}
+	}
+
+	p, ok := c.pipeline.(*pipeline)
+	if !ok {
+		return c.Write(message)
+	}
+
+	return c.invokeMethod(func() {
+		p.firePriorityWrite(message)
+	})
+}
+
+// WriteAndClose write the last message and close the channel after flushed
+func (c *channel) WriteAndClose(message Message) error {
+	if err := c.Write(message); nil != err {
+		c.Close(err)
+		return err
+	}
+
+	// sync write: the message is flushed already.
+	if nil == c.writeQueue {
+		c.Close(nil)
+		return nil
+	}
+
+	// the channel is closed by writeOnce after the previous packets are flushed, the sentinel is queued
+	// as the other packets, so it fails with ErrAsyncNoSpace if the queue is full and not writeForever.
+	if _, err := c.enqueue(c.writeQueue, closeSentinel, 0); nil != err {
+		c.Close(err)
+		return err
+	}
 	return nil
 }
 
+// Peek the next n bytes of channel
+func (c *channel) Peek(n int) ([]byte, error) {
+	return c.reader.Peek(n)
+}
+
 // Trigger trigger event
 func (c *channel) Trigger(event Event) {
 	c.invokeMethod(func() {
@@ -178,58 +382,281 @@ func (c *channel) Trigger(event Event) {
 // Close through the Pipeline
 func (c *channel) Close(err error) {
 	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		if nil == err {
+			err = ErrChannelClosed
+		}
 		c.closeErr = err
-		c.transport.Close()
+		c.SetAttribute(CloseCauseAttribute, err)
+		// stop polling before the fd is closed and reused.
+		if r, _ := c.registration.Load().(*registration); nil != r {
+			r.remove()
+		}
+		// flush the delayed writes, unless a writer is in progress which is failed by closing.
+		if nil != c.flushTimer {
+			c.flushTimer.Stop()
+			if c.writeLock.TryLock() {
+				c.armWriteLocked()
+				_ = c.output.Flush()
+				c.writeLock.Unlock()
+			}
+		}
+		closeTransport(c.transport, err)
 		c.cancel()
 
 		c.invokeMethod(func() {
 			c.pipeline.FireChannelInactive(err)
 		})
+
+		c.pipeline.FireChannelCleanup(err)
+		c.closeFuture.complete(err)
+	}
+}
+
+// SetInboundTransformer set the transformer of inbound messages
+func (c *channel) SetInboundTransformer(fn InboundTransformer) {
+	c.transformer.Store(fn)
+}
+
+// fireRead fire the inbound stream to the pipeline, the message dropped by the transformer is not fired.
+func (c *channel) fireRead() {
+	var message Message = c.reader
+	if fn, _ := c.transformer.Load().(InboundTransformer); nil != fn {
+		if message = fn(message); nil == message {
+			return
+		}
+	}
+	c.pipeline.FireChannelRead(message)
+}
+
+// CloseFuture returns the future of closing
+func (c *channel) CloseFuture() Future {
+	return c.closeFuture
+}
+
+// Flush the write buffer of transport
+func (c *channel) Flush() error {
+	select {
+	case <-c.ctx.Done():
+		return c.closeErr
+	default:
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	c.flushPending = false
+	c.armWriteLocked()
+	return c.checkWriteLocked(c.output.Flush())
+}
+
+// pushTransport defines the transport which could push the written bytes immediately, e.g. tcp.PushTransport
+type pushTransport interface {
+	Push() error
+}
+
+// Push flush the bytes written to the channel and send them immediately if the transport supports it,
+// e.g. the tcp transport with Nagle's algorithm enabled, otherwise it is the same as Flush. the writes
+// of async write channel are pushed only if they are already written by the writer.
+func Push(ch Channel) error {
+	c, ok := ch.(*channel)
+	if !ok {
+		return ch.Flush()
+	}
+
+	select {
+	case <-c.ctx.Done():
+		return c.closeErr
+	default:
+	}
+
+	pt, ok := c.output.(pushTransport)
+	if !ok {
+		return c.Flush()
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	c.flushPending = false
+	c.armWriteLocked()
+	return c.checkWriteLocked(pt.Push())
+}
+
+// flushLocked flush the transport, or schedule a flush after the delay if FlushDelay is set.
+func (c *channel) flushLocked() error {
+	if c.flushDelay <= 0 {
+		return c.output.Flush()
+	}
+
+	if !c.flushPending {
+		c.flushPending = true
+		c.flushTimer.Reset(c.flushDelay)
+	}
+	return nil
+}
+
+// armWriteLocked set the write deadline of transport before writing if WriteTimeout is set.
+func (c *channel) armWriteLocked() {
+	if c.writeTimeout > 0 {
+		_ = c.transport.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+}
+
+// checkWriteLocked close the channel with ErrSlowConsumer if the write is timed out, the close does not
+// wait for the write lock.
+func (c *channel) checkWriteLocked(err error) error {
+	if nil != err && c.writeTimeout > 0 && errors.Is(err, os.ErrDeadlineExceeded) {
+		err = fmt.Errorf("%w: the write is not finished in %v", ErrSlowConsumer, c.writeTimeout)
+		c.Close(err)
+	}
+	return err
+}
+
+// delayedFlush flush the writes after FlushDelay.
+func (c *channel) delayedFlush() {
+	c.writeLock.Lock()
+	if !c.flushPending {
+		c.writeLock.Unlock()
+		return
+	}
+	c.flushPending = false
+	c.armWriteLocked()
+	err := c.checkWriteLocked(c.output.Flush())
+	c.writeLock.Unlock()
+
+	if nil != err && c.IsActive() {
+		c.Close(err)
 	}
 }
 
 // Writev to write [][]byte for optimize syscall
 func (c *channel) Writev(p [][]byte) (n int64, err error) {
-	if nil != c.closeErr {
+	select {
+	case <-c.ctx.Done():
 		return 0, c.closeErr
+	default:
 	}
 
 	// enable async write
 	if nil != c.writeQueue {
-		return c.asyncWrite(p)
+		return c.asyncWrite(c.writeQueue, p)
 	}
 
 	// sync write
 	c.writeLock.Lock()
 	defer c.writeLock.Unlock()
-	if n, err = c.transport.Writev(transport.Buffers{Buffers: p, Indexes: []int{len(p)}}); nil == err {
-		err = c.transport.Flush()
+	c.armWriteLocked()
+	if n, err = c.output.Writev(transport.Buffers{Buffers: p, Indexes: []int{len(p)}}); nil == err {
+		err = c.flushLocked()
 	}
+	err = c.checkWriteLocked(err)
 	return
 }
 
 // Write1 to write []byte to channel
 func (c *channel) Write1(p []byte) (n int, err error) {
-	if nil != c.closeErr {
+	select {
+	case <-c.ctx.Done():
 		return 0, c.closeErr
+	default:
 	}
 
 	// enable async write
 	if nil != c.writeQueue {
-		wn, err := c.asyncWrite([][]byte{p})
+		if len(p) <= smallWriteSize {
+			packet := c.smallPacket()
+			packet[0] = append(packet[0], p...)
+			wn, err := c.enqueue(c.writeQueue, packet, int64(len(p)))
+			return int(wn), err
+		}
+		wn, err := c.asyncWrite(c.writeQueue, [][]byte{p})
 		return int(wn), err
 	}
 
 	// sync write
 	c.writeLock.Lock()
 	defer c.writeLock.Unlock()
-	if n, err = c.transport.Write(p); nil == err {
-		err = c.transport.Flush()
+	c.armWriteLocked()
+	if n, err = c.output.Write(p); nil == err {
+		err = c.flushLocked()
+	}
+	err = c.checkWriteLocked(err)
+	return
+}
+
+// writeString to write the string without converting to []byte if it is small.
+func (c *channel) writeString(s string) (n int, err error) {
+	if len(s) > smallWriteSize {
+		return c.Write1([]byte(s))
+	}
+
+	select {
+	case <-c.ctx.Done():
+		return 0, c.closeErr
+	default:
+	}
+
+	// enable async write
+	if nil != c.writeQueue {
+		packet := c.smallPacket()
+		packet[0] = append(packet[0], s...)
+		wn, err := c.enqueue(c.writeQueue, packet, int64(len(s)))
+		return int(wn), err
+	}
+
+	// sync write, the scratch buffer is protected by the write lock.
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	c.scratch = append(c.scratch[:0], s...)
+	c.armWriteLocked()
+	if n, err = c.output.Write(c.scratch); nil == err {
+		err = c.flushLocked()
 	}
+	err = c.checkWriteLocked(err)
 	return
 }
 
-func (c *channel) asyncWrite(p [][]byte) (int64, error) {
+// writePriority to queue [][]byte ahead of the normal writes, it is the same as Writev for sync write.
+func (c *channel) writePriority(p [][]byte) (int64, error) {
+	if nil == c.priorityQueue {
+		return c.Writev(p)
+	}
+
+	select {
+	case <-c.ctx.Done():
+		return 0, c.closeErr
+	default:
+	}
+	return c.asyncWrite(c.priorityQueue, p)
+}
+
+// smallPacket get a free scratch packet, the packet is recycled by writeOnce after written.
+func (c *channel) smallPacket() [][]byte {
+	select {
+	case packet := <-c.smallPackets:
+		return packet
+	default:
+		return [][]byte{make([]byte, 0, smallWriteSize)}
+	}
+}
+
+// recycle the written packet, a pooled buffer of the scratch size is recycled as a scratch packet
+// either, which is fine as both are owned by the channel.
+func (c *channel) recycle(packet [][]byte) {
+	if 1 == len(packet) && smallWriteSize == cap(packet[0]) {
+		packet[0] = packet[0][:0]
+		select {
+		case c.smallPackets <- packet:
+			return
+		default:
+		}
+	}
+
+	for _, buf := range packet {
+		buf := buf[:0]
+		pbytes.Put(&buf)
+	}
+}
+
+func (c *channel) asyncWrite(queue chan [][]byte, p [][]byte) (int64, error) {
 	// count of data length
 	dataLen := utils.CountOf(p)
 
@@ -247,31 +674,45 @@ func (c *channel) asyncWrite(p [][]byte) (int64, error) {
 	}
 
 	// put packet to send queue
-	var packet = [][]byte{dataBuff[:offset]}
+	return c.enqueue(queue, [][]byte{dataBuff[:offset]}, dataLen)
+}
 
+// enqueue the packet to the send queue, the packet is owned by the channel.
+func (c *channel) enqueue(queue chan [][]byte, packet [][]byte, dataLen int64) (int64, error) {
 	if c.writeForever {
 		select {
 		case <-c.ctx.Done():
 			return 0, c.closeErr
-		case c.writeQueue <- packet:
+		case queue <- packet:
 			// write queue
 		}
 	} else {
 		select {
 		case <-c.ctx.Done():
 			return 0, c.closeErr
-		case c.writeQueue <- packet:
+		case queue <- packet:
 			// write queue
 		default:
 			return 0, ErrAsyncNoSpace
 		}
 	}
 
-	// try send
+	c.startWriter()
+	return dataLen, nil
+}
+
+// queued returns true if there are packets to send.
+func (c *channel) queued() bool {
+	return len(c.writeQueue) > 0 || len(c.priorityQueue) > 0
+}
+
+// startWriter to send the queued packets, the registered channel is written once the connection is writable.
+func (c *channel) startWriter() {
 	if atomic.CompareAndSwapInt32(&c.running, idle, running) {
-		c.executor.Exec(c.writeOnce)
+		if r, _ := c.registration.Load().(*registration); nil == r || !r.waitWritable() {
+			c.executor.Exec(c.writeOnce)
+		}
 	}
-	return dataLen, nil
 }
 
 // IsActive return true if the Channel is active and so connected
@@ -309,6 +750,21 @@ func (c *channel) SetAttachment(v Attachment) {
 	c.attachment = v
 }
 
+// Attribute get the attribute of key
+func (c *channel) Attribute(key interface{}) interface{} {
+	v, _ := c.attributes.Load(key)
+	return v
+}
+
+// SetAttribute set the attribute of key
+func (c *channel) SetAttribute(key interface{}, value interface{}) {
+	if nil == value {
+		c.attributes.Delete(key)
+		return
+	}
+	c.attributes.Store(key, value)
+}
+
 // Context get context of channel
 func (c *channel) Context() context.Context {
 	return c.ctx
@@ -316,6 +772,11 @@ func (c *channel) Context() context.Context {
 
 // serveChannel start write & read routines
 func (c *channel) serveChannel() {
+	if group, ok := c.executor.(*eventLoopGroup); ok && group.Size() > 0 {
+		c.serveOnLoop(group)
+		return
+	}
+
 	signal := make(chan struct{})
 	defer func() { <-signal }()
 
@@ -326,11 +787,17 @@ func (c *channel) serveChannel() {
 	})
 }
 
-func (c *channel) invokeMethod(fn func()) {
+// invokeMethod to call fn with the panic recovered, returns the recovered exception.
+func (c *channel) invokeMethod(fn func()) (ex Exception) {
 
 	defer func() {
-		if err := recover(); nil != err && 0 == atomic.LoadInt32(&c.closed) {
-			c.pipeline.FireChannelException(AsException(err))
+		if err := recover(); nil != err {
+			ex = AsException(err)
+			if 0 != atomic.LoadInt32(&c.closed) {
+				return
+			}
+
+			c.pipeline.FireChannelException(ex)
 
 			if e, ok := err.(error); ok {
 				var ne net.Error
@@ -342,28 +809,54 @@ func (c *channel) invokeMethod(fn func()) {
 	}()
 
 	fn()
+	return nil
 }
 
 // readLoop reading message of channel
 func (c *channel) readLoop(done func()) {
 
-	defer func() {
-		c.Close(AsException(recover()))
-	}()
-
 	func() {
 		defer done()
 		c.invokeMethod(c.pipeline.FireChannelActive)
 	}()
 
-	for {
+	c.readForever()
+}
+
+// serveOnLoop to activate the channel and register it to an event loop, a goroutine is started if failed.
+func (c *channel) serveOnLoop(group *eventLoopGroup) {
+	c.invokeMethod(c.pipeline.FireChannelActive)
+
+	select {
+	case <-c.ctx.Done():
+		return
+	default:
+	}
+
+	if !group.register(c) {
+		go c.readForever()
+	}
+}
+
+// readForever reading messages until the channel is closed.
+func (c *channel) readForever() {
+
+	defer func() {
+		c.Close(AsException(recover()))
+	}()
+
+	for reads := 1; ; reads++ {
 		select {
 		case <-c.ctx.Done():
 			return
 		default:
-			c.invokeMethod(func() {
-				c.pipeline.FireChannelRead(c.transport)
-			})
+			c.invokeMethod(c.fireRead)
+		}
+
+		// yield to other channels.
+		if reads == c.maxReads {
+			reads = 0
+			runtime.Gosched()
 		}
 	}
 }
@@ -378,15 +871,40 @@ func (c *channel) writeOnce() {
 	}()
 
 	for {
+		// the queued packets are sent by Resume.
+		if c.gate.isPaused() {
+			atomic.StoreInt32(&c.running, idle)
+			if !c.gate.isPaused() && atomic.CompareAndSwapInt32(&c.running, idle, running) {
+				continue
+			}
+			break
+		}
+
 		// reuse buffer.
 		sendBuffers := c.writeBuffers[:0]
 		sendIndexes := c.writeIndexes[:0]
+		closing := false
 
 		// more packet will be merged
-		for len(sendBuffers) < cap(c.writeQueue) {
+		for !closing && len(sendBuffers) < cap(c.writeQueue) {
+			// poll priority packet first
+			select {
+			case pkts := <-c.priorityQueue:
+				c.writePackets = append(c.writePackets, pkts)
+				sendBuffers = append(sendBuffers, pkts...)
+				sendIndexes = append(sendIndexes, len(sendBuffers))
+				continue
+			default:
+			}
+
 			// poll packet
 			select {
 			case pkts := <-c.writeQueue:
+				if closing = 0 == len(pkts); closing {
+					break
+				}
+				// the packets are recycled after written.
+				c.writePackets = append(c.writePackets, pkts)
 				// combine send bytes to reduce syscall.
 				sendBuffers = append(sendBuffers, pkts...)
 				sendIndexes = append(sendIndexes, len(sendBuffers))
@@ -399,13 +917,15 @@ func (c *channel) writeOnce() {
 		}
 
 		if len(sendBuffers) > 0 {
-			utils.AssertLong(c.transport.Writev(transport.Buffers{Buffers: sendBuffers, Indexes: sendIndexes}))
+			c.writeLock.Lock()
+			c.armWriteLocked()
+			_, err := c.output.Writev(transport.Buffers{Buffers: sendBuffers, Indexes: sendIndexes})
+			err = c.checkWriteLocked(err)
+			c.writeLock.Unlock()
+			utils.Assert(err)
 
 			// clear buffer ref
-			for index, buf := range sendBuffers {
-				// reuse buffer
-				buf := buf[:0]
-				pbytes.Put(&buf)
+			for index := range sendBuffers {
 				// avoid memory leak
 				sendBuffers[index] = nil
 				// for safety
@@ -414,18 +934,42 @@ func (c *channel) writeOnce() {
 				}
 			}
 
+			// reuse buffer, the written buffers are consumed by Writev, so the packets are recycled.
+			for index, packet := range c.writePackets {
+				c.recycle(packet)
+				c.writePackets[index] = nil
+			}
+			c.writePackets = c.writePackets[:0]
+
 			// continue to send remain packets
-			if len(c.writeQueue) > 0 {
+			if !closing && c.queued() {
 				continue
 			}
 		}
 
-		// flush transport buffer
-		utils.Assert(c.transport.Flush())
+		// flush transport buffer, the last message is flushed immediately.
+		c.writeLock.Lock()
+		c.armWriteLocked()
+		var err error
+		if closing {
+			c.flushPending = false
+			err = c.output.Flush()
+		} else {
+			err = c.flushLocked()
+		}
+		err = c.checkWriteLocked(err)
+		c.writeLock.Unlock()
+		utils.Assert(err)
+
+		// the last message is flushed.
+		if closing {
+			c.Close(nil)
+			return
+		}
 
 		// double check
 		atomic.StoreInt32(&c.running, idle)
-		if size := len(c.writeQueue); size > 0 {
+		if c.queued() {
 			if atomic.CompareAndSwapInt32(&c.running, idle, running) {
 				continue
 			}
@@ -435,3 +979,131 @@ func (c *channel) writeOnce() {
 		break
 	}
 }
+
+// readAheadSize is the least size of the read-ahead buffer of ReadFull.
+const readAheadSize = 4096
+
+// ReadFull reads exactly len(p) bytes from the inbound message into p, io.EOF is returned if no bytes are read,
+// io.ErrUnexpectedEOF if the stream ends before filled. it is like io.ReadFull, but the stream of channel,
+// the message of the first handler, is read ahead into a buffer, so the small reads of codecs do not read
+// the transport each time, the bytes read ahead are read by the next reads of channel.
+func ReadFull(message Message, p []byte) error {
+	if r, ok := message.(interface{ ReadFull(p []byte) error }); ok {
+		return r.ReadFull(p)
+	}
+	_, err := io.ReadFull(utils.MustToReader(message), p)
+	return err
+}
+
+// peekReader reads the peeked bytes first, the mutex serializes Peek & Read so that
+// no bytes are lost or duplicated if Peek is called out of the read loop, the bytes read
+// ahead by ReadFull are kept as the peeked bytes.
+type peekReader struct {
+	mutex  sync.Mutex
+	reader io.Reader
+	peeked []byte
+	buffer []byte // the backing buffer of peeked
+}
+
+// reserve to make sure the capacity of peeked is at least n.
+func (r *peekReader) reserve(n int) {
+	if cap(r.peeked) >= n {
+		return
+	}
+	if cap(r.buffer) < n {
+		r.buffer = make([]byte, n)
+	}
+	r.peeked = append(r.buffer[:0], r.peeked...)
+}
+
+// maxRetainedPeekSize is the max size of the backing buffer kept after the peeked bytes are consumed,
+// the larger buffer grown by a large Peek is released, so a long-lived channel does not hold it.
+const maxRetainedPeekSize = 16 * readAheadSize
+
+// consume the first n bytes of peeked.
+func (r *peekReader) consume(n int) {
+	if r.peeked = r.peeked[n:]; 0 == len(r.peeked) {
+		if cap(r.buffer) > maxRetainedPeekSize {
+			r.buffer = nil
+		}
+		r.peeked = r.buffer[:0]
+	}
+}
+
+// Peek to read n bytes into the peeked buffer without consuming them.
+func (r *peekReader) Peek(n int) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var err error
+	if len(r.peeked) < n {
+		buffered := len(r.peeked)
+		r.reserve(n)
+		var rn int
+		rn, err = io.ReadAtLeast(r.reader, r.peeked[buffered:n], n-buffered)
+		r.peeked = r.peeked[:buffered+rn]
+		n = len(r.peeked)
+	}
+
+	return append([]byte(nil), r.peeked[:n]...), err
+}
+
+// ReadFull to fill p by the peeked bytes, and read ahead the rest from the reader.
+func (r *peekReader) ReadFull(p []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	n := copy(p, r.peeked)
+	r.consume(n)
+
+	var err error
+	switch rest := p[n:]; {
+	case 0 == len(rest):
+	case len(rest) >= readAheadSize:
+		// large enough to read directly.
+		var rn int
+		rn, err = io.ReadFull(r.reader, rest)
+		n += rn
+	default:
+		r.reserve(readAheadSize)
+		var rn int
+		rn, err = io.ReadAtLeast(r.reader, r.peeked[:cap(r.peeked)], len(rest))
+		r.peeked = r.peeked[:rn]
+		rn = copy(rest, r.peeked)
+		r.consume(rn)
+		n += rn
+	}
+
+	if nil != err && n > 0 && n < len(p) && io.EOF == err {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// unread to push the bytes back, they are read before the peeked bytes.
+func (r *peekReader) unread(p []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.buffer = append(append(make([]byte, 0, len(p)+len(r.peeked)), p...), r.peeked...)
+	r.peeked = r.buffer
+}
+
+// buffered returns the number of peeked bytes.
+func (r *peekReader) buffered() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.peeked)
+}
+
+// Read to impl io.Reader
+func (r *peekReader) Read(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.peeked) > 0 {
+		n := copy(p, r.peeked)
+		r.consume(n)
+		return n, nil
+	}
+	return r.reader.Read(p)
+}
@@ -0,0 +1,984 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+// addrConn is a net.Conn which reports the specified addresses.
+type addrConn struct {
+	net.Conn
+	local  net.Addr
+	remote net.Addr
+}
+
+func (c addrConn) LocalAddr() net.Addr  { return c.local }
+func (c addrConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c addrConn) Close() error {
+	if nil != c.Conn {
+		return c.Conn.Close()
+	}
+	return nil
+}
+
+// testChannelID to generate channel id for testing
+var testChannelID = SequenceID()
+
+// pipeChannel serve a channel over net.Pipe, returns the channel and the peer side connection.
+func pipeChannel(factory ChannelFactory, remote string, handlers ...Handler) (Channel, net.Conn) {
+	return pipeChannelWith(NewPipeline(), factory, remote, handlers...)
+}
+
+// pipeChannelWith serve a channel of the pipeline over net.Pipe.
+func pipeChannelWith(pl Pipeline, factory ChannelFactory, remote string, handlers ...Handler) (Channel, net.Conn) {
+	addr, err := net.ResolveTCPAddr("tcp", remote)
+	if nil != err {
+		panic(err)
+	}
+
+	local, peer := net.Pipe()
+	t := transport.NewTransport(addrConn{Conn: local, local: addr, remote: addr}, 0, 0)
+
+	pl.AddLast(handlers...)
+	ch := factory(testChannelID(), context.Background(), pl, t, AsyncExecutor())
+	pl.ServeChannel(ch)
+	return ch, peer
+}
+
+// discardHandler reads and drops the inbound bytes.
+type discardHandler struct{}
+
+func (discardHandler) HandleRead(ctx InboundContext, message Message) {
+	utils.AssertLength(utils.MustToReader(message).Read(make([]byte, 1024)))
+}
+
+// connectRetry to connect the listener which may not be ready.
+func connectRetry(bs Bootstrap, url string, option ...transport.Option) (ch Channel, err error) {
+	for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+		if ch, err = bs.Connect(url, option...); nil == err {
+			return
+		}
+	}
+	return
+}
+
+func TestChannelWriteAndClose(t *testing.T) {
+
+	var factories = map[string]ChannelFactory{
+		"sync":  NewChannel(),
+		"async": NewAsyncWriteChannel(8, true),
+	}
+
+	for name, factory := range factories {
+		ch, peer := pipeChannel(factory, "127.0.0.1:9527", discardHandler{})
+
+		received := make(chan []byte, 1)
+		go func() {
+			data, _ := io.ReadAll(peer)
+			received <- data
+		}()
+
+		var expected bytes.Buffer
+		for i := 0; i < 100; i++ {
+			message := []byte(fmt.Sprintf("message-%d;", i))
+			expected.Write(message)
+			if err := ch.Write(message); nil != err {
+				t.Fatal(name, err)
+			}
+		}
+
+		expected.WriteString("goodbye")
+		if err := ch.WriteAndClose([]byte("goodbye")); nil != err {
+			t.Fatal(name, err)
+		}
+
+		select {
+		case data := <-received:
+			if !bytes.Equal(expected.Bytes(), data) {
+				t.Fatalf("%s: unexpected data before EOF: %q", name, data)
+			}
+		case <-time.After(time.Second):
+			t.Fatal(name, "channel not closed")
+		}
+
+		select {
+		case <-ch.Context().Done():
+		case <-time.After(time.Second):
+			t.Fatal(name, "channel is still active")
+		}
+	}
+}
+
+func TestChannelWriteAndCloseFullQueue(t *testing.T) {
+
+	ch, peer := pipeChannel(NewAsyncWriteChannel(1, false), "127.0.0.1:9527", discardHandler{})
+	defer peer.Close()
+
+	// the writer is blocked by the first packet since the peer is not read.
+	if err := ch.Write([]byte("first")); nil != err {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); 0 != len(ch.(*channel).writeQueue); {
+		if time.Now().After(deadline) {
+			t.Fatal("the first packet is not taken by the writer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the last message fills the queue, there is no room for the close.
+	done := make(chan error, 1)
+	go func() { done <- ch.WriteAndClose([]byte("last")) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrAsyncNoSpace) {
+			t.Fatal("unexpected error:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WriteAndClose is blocked by the full queue")
+	}
+
+	select {
+	case <-ch.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("channel is still active")
+	}
+}
+
+func TestChannelPeek(t *testing.T) {
+
+	peeked := make(chan string, 2)
+	received := make(chan []byte, 1)
+
+	var buffer bytes.Buffer
+	_, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			if 0 == buffer.Len() {
+				// peek across partial writes of peer.
+				for _, n := range []int{3, 5} {
+					p, err := ctx.Channel().Peek(n)
+					if nil != err {
+						t.Error(err)
+					}
+					peeked <- string(p)
+				}
+			}
+
+			p := make([]byte, 2)
+			n, err := utils.MustToReader(message).Read(p)
+			if buffer.Write(p[:n]); nil != err {
+				received <- buffer.Bytes()
+				ctx.Close(err)
+			}
+		}),
+	)
+
+	go func() {
+		for _, chunk := range []string{"G", "ET", " /index", ".html"} {
+			_, _ = peer.Write([]byte(chunk))
+			time.Sleep(time.Millisecond * 10)
+		}
+		_ = peer.Close()
+	}()
+
+	for _, want := range []string{"GET", "GET /"} {
+		if p := <-peeked; want != p {
+			t.Fatal("unexpected peeked:", p, "want:", want)
+		}
+	}
+
+	select {
+	case data := <-received:
+		if "GET /index.html" != string(data) {
+			t.Fatalf("unexpected received: %q", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+}
+
+// busyConn always has data to read.
+type busyConn struct {
+	net.Conn
+}
+
+func (busyConn) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func TestChannelMaxReadsPerLoop(t *testing.T) {
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	var busyReads int64
+	local, peer := net.Pipe()
+	defer peer.Close()
+
+	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:9527")
+	pl := NewPipeline().AddLast(InboundHandlerFunc(func(ctx InboundContext, message Message) {
+		atomic.AddInt64(&busyReads, 1)
+		_, _ = utils.MustToReader(message).Read(make([]byte, 64))
+	}))
+	busy := NewChannelWith(ChannelOptions{MaxReadsPerLoop: 16})(testChannelID(), context.Background(), pl,
+		transport.NewTransport(addrConn{Conn: busyConn{Conn: local}, local: addr, remote: addr}, 0, 0), AsyncExecutor())
+	pl.ServeChannel(busy)
+	defer busy.Close(nil)
+
+	serviced := make(chan struct{}, 1)
+	quiet, quietPeer := pipeChannel(NewChannelWith(ChannelOptions{MaxReadsPerLoop: 16}), "127.0.0.1:9527",
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			_, _ = utils.MustToReader(message).Read(make([]byte, 16))
+			serviced <- struct{}{}
+		}),
+	)
+	defer quiet.Close(nil)
+
+	go func() { _, _ = quietPeer.Write([]byte("ping")) }()
+
+	select {
+	case <-serviced:
+	case <-time.After(time.Second):
+		t.Fatal("quiet channel is starved")
+	}
+
+	if atomic.LoadInt64(&busyReads) < 16 {
+		t.Fatal("busy channel is not reading:", atomic.LoadInt64(&busyReads))
+	}
+}
+
+func TestChannelSmallWrites(t *testing.T) {
+
+	var factories = map[string]ChannelFactory{
+		"sync":  NewChannel(),
+		"async": NewAsyncWriteChannel(4, true),
+	}
+
+	for name, factory := range factories {
+		ch, peer := pipeChannel(factory, "127.0.0.1:9527", discardHandler{})
+
+		received := make(chan []byte, 1)
+		go func() {
+			data, _ := io.ReadAll(peer)
+			received <- data
+		}()
+
+		// the buffer of caller is reused after written.
+		var expected bytes.Buffer
+		buffer := make([]byte, smallWriteSize)
+		for i := 0; i < 200; i++ {
+			message := buffer[:copy(buffer, fmt.Sprintf("message-%d;", i))]
+			expected.Write(message)
+			if err := ch.Write(message); nil != err {
+				t.Fatal(name, err)
+			}
+			for j := range buffer {
+				buffer[j] = '!'
+			}
+
+			text := fmt.Sprintf("text-%d;", i)
+			expected.WriteString(text)
+			if err := ch.Write(text); nil != err {
+				t.Fatal(name, err)
+			}
+		}
+
+		large := string(bytes.Repeat([]byte("large;"), smallWriteSize))
+		expected.WriteString(large)
+		if err := ch.WriteAndClose(large); nil != err {
+			t.Fatal(name, err)
+		}
+
+		select {
+		case data := <-received:
+			if !bytes.Equal(expected.Bytes(), data) {
+				t.Fatalf("%s: unexpected data: %q", name, data)
+			}
+		case <-time.After(time.Second):
+			t.Fatal(name, "channel not closed")
+		}
+	}
+}
+
+// discardConn is a net.Conn which discards the written bytes, and blocks the reads until closed.
+type discardConn struct {
+	closed chan struct{}
+}
+
+func (c discardConn) Read(p []byte) (int, error)         { <-c.closed; return 0, io.EOF }
+func (c discardConn) Write(p []byte) (int, error)        { return len(p), nil }
+func (c discardConn) Close() error                       { return nil }
+func (c discardConn) LocalAddr() net.Addr                { return &net.TCPAddr{} }
+func (c discardConn) RemoteAddr() net.Addr               { return &net.TCPAddr{} }
+func (c discardConn) SetDeadline(t time.Time) error      { return nil }
+func (c discardConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c discardConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func BenchmarkChannelSmallWrite(b *testing.B) {
+
+	var factories = []struct {
+		name    string
+		factory ChannelFactory
+	}{
+		{name: "sync", factory: NewChannel()},
+		{name: "async", factory: NewAsyncWriteChannel(64, true)},
+	}
+
+	var messages = map[string]Message{
+		"bytes":  []byte("hello, go-netty"),
+		"string": "hello, go-netty",
+	}
+
+	for _, f := range factories {
+		for kind, message := range messages {
+			b.Run(f.name+"/"+kind, func(b *testing.B) {
+				conn := discardConn{closed: make(chan struct{})}
+				defer close(conn.closed)
+
+				pl := NewPipeline().AddLast(discardHandler{})
+				ch := f.factory(testChannelID(), context.Background(), pl, transport.NewTransport(conn, 0, 0), AsyncExecutor())
+				pl.ServeChannel(ch)
+				defer ch.Close(nil)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := ch.Write(message); nil != err {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestChannelSmallWriteAllocs(t *testing.T) {
+
+	for _, factory := range []ChannelFactory{NewChannel(), NewAsyncWriteChannel(64, true)} {
+		conn := discardConn{closed: make(chan struct{})}
+		pl := NewPipeline().AddLast(discardHandler{})
+		ch := factory(testChannelID(), context.Background(), pl, transport.NewTransport(conn, 0, 0), AsyncExecutor())
+		pl.ServeChannel(ch)
+
+		var message Message = []byte("hello, go-netty")
+		if allocs := testing.AllocsPerRun(1000, func() { _ = ch.Write(message) }); 0 != allocs {
+			t.Fatal("allocations of a small write:", allocs)
+		}
+
+		ch.Close(nil)
+		close(conn.closed)
+	}
+}
+
+// countConn is a net.Conn which records the written bytes and counts the writes.
+type countConn struct {
+	discardConn
+	mutex  sync.Mutex
+	writes int
+	data   bytes.Buffer
+}
+
+func (c *countConn) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.writes++
+	return c.data.Write(p)
+}
+
+func (c *countConn) written() (int, string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.writes, c.data.String()
+}
+
+func TestChannelFlushDelay(t *testing.T) {
+
+	var factories = map[string]ChannelOptions{
+		"sync":  {FlushDelay: time.Millisecond * 50},
+		"async": {FlushDelay: time.Millisecond * 50, WriteQueueSize: 8, WriteForever: true},
+	}
+
+	for name, options := range factories {
+		conn := &countConn{discardConn: discardConn{closed: make(chan struct{})}}
+		pl := NewPipeline().AddLast(discardHandler{})
+		ch := NewChannelWith(options)(testChannelID(), context.Background(), pl, transport.NewTransport(conn, 0, 1024), AsyncExecutor())
+		pl.ServeChannel(ch)
+
+		// the small writes are batched.
+		var expected bytes.Buffer
+		for i := 0; i < 100; i++ {
+			message := fmt.Sprintf("%d;", i)
+			expected.WriteString(message)
+			if err := ch.Write(message); nil != err {
+				t.Fatal(name, err)
+			}
+		}
+
+		if writes, _ := conn.written(); 0 != writes {
+			t.Fatal(name, "writes are not delayed:", writes)
+		}
+
+		time.Sleep(time.Millisecond * 100)
+		if writes, data := conn.written(); 1 != writes || expected.String() != data {
+			t.Fatalf("%s: %d writes of %q, want 1 write of %q", name, writes, data, expected.String())
+		}
+
+		// the full buffer is written without delay.
+		if err := ch.Write(bytes.Repeat([]byte{'x'}, 1500)); nil != err {
+			t.Fatal(name, err)
+		}
+		time.Sleep(time.Millisecond * 10)
+		if writes, _ := conn.written(); writes < 2 {
+			t.Fatal(name, "full buffer is not written:", writes)
+		}
+
+		// explicit flush.
+		_ = ch.Write("flush;")
+		time.Sleep(time.Millisecond * 10)
+		if err := ch.Flush(); nil != err {
+			t.Fatal(name, err)
+		}
+		if _, data := conn.written(); !strings.HasSuffix(data, "flush;") {
+			t.Fatal(name, "buffer is not flushed")
+		}
+
+		// flush on close.
+		if err := ch.WriteAndClose("goodbye"); nil != err {
+			t.Fatal(name, err)
+		}
+		select {
+		case <-ch.Context().Done():
+		case <-time.After(time.Second):
+			t.Fatal(name, "channel is not closed")
+		}
+		if _, data := conn.written(); !strings.HasSuffix(data, "goodbye") {
+			t.Fatal(name, "buffer is not flushed before closed")
+		}
+		close(conn.closed)
+	}
+}
+
+func TestChannelRawConn(t *testing.T) {
+
+	accepted := make(chan Channel, 1)
+	bs := NewBootstrap(
+		WithChildInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(discardHandler{})
+			accepted <- ch
+		}),
+		WithClientInitializer(func(ch Channel) { ch.Pipeline().AddLast(discardHandler{}) }),
+	)
+	defer bs.Shutdown()
+	bs.Listen("127.0.0.1:9553").Async(func(err error) {})
+
+	client, err := connectRetry(bs, "tcp://127.0.0.1:9553")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer client.Close(nil)
+
+	var server Channel
+	select {
+	case server = <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("server channel not initialized")
+	}
+
+	clientConn, ok := RawConn(client)
+	if !ok {
+		t.Fatal("raw conn of client not found")
+	}
+	serverConn, ok := RawConn(server)
+	if !ok {
+		t.Fatal("raw conn of server not found")
+	}
+
+	if _, ok := clientConn.(*net.TCPConn); !ok {
+		t.Fatalf("unexpected raw conn: %T", clientConn)
+	}
+
+	if "127.0.0.1:9553" != clientConn.RemoteAddr().String() || serverConn.LocalAddr().String() != clientConn.RemoteAddr().String() {
+		t.Fatal("unexpected remote address:", clientConn.RemoteAddr(), serverConn.LocalAddr())
+	}
+	if serverConn.RemoteAddr().String() != clientConn.LocalAddr().String() {
+		t.Fatal("unexpected local address:", clientConn.LocalAddr(), serverConn.RemoteAddr())
+	}
+}
+
+// chunkReader returns the chunks one by one, and counts the reads.
+type chunkReader struct {
+	chunks [][]byte
+	reads  int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	r.reads++
+	if 0 == len(r.chunks) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	if r.chunks[0] = r.chunks[0][n:]; 0 == len(r.chunks[0]) {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+func TestReadFull(t *testing.T) {
+
+	source := &chunkReader{chunks: [][]byte{[]byte("01"), []byte("2345"), []byte("6789abcdef"), []byte("g")}}
+	reader := &peekReader{reader: source}
+
+	// split across the reads of source.
+	header := make([]byte, 8)
+	if err := ReadFull(reader, header); nil != err || "01234567" != string(header) {
+		t.Fatal("unexpected read:", string(header), err)
+	}
+
+	// the bytes read ahead are consumed without reading the source.
+	reads := source.reads
+	if err := ReadFull(reader, header[:4]); nil != err || "89ab" != string(header[:4]) || reads != source.reads {
+		t.Fatal("unexpected read:", string(header[:4]), err, source.reads-reads)
+	}
+
+	// the read ahead bytes are read by Read & Peek either.
+	if p, _ := reader.Peek(2); "cd" != string(p) {
+		t.Fatal("unexpected peeked:", string(p))
+	}
+	if n, _ := reader.Read(header); "cdef" != string(header[:n]) {
+		t.Fatal("unexpected read:", string(header[:n]))
+	}
+
+	if err := ReadFull(reader, header[:2]); io.ErrUnexpectedEOF != err {
+		t.Fatal("expect unexpected eof, got:", err)
+	}
+	if err := ReadFull(reader, header[:2]); io.EOF != err {
+		t.Fatal("expect eof, got:", err)
+	}
+
+	// the other messages are read by io.ReadFull.
+	if err := ReadFull([]byte("xyz"), header[:3]); nil != err || "xyz" != string(header[:3]) {
+		t.Fatal("unexpected read:", string(header[:3]), err)
+	}
+}
+
+func TestPeekReaderCompaction(t *testing.T) {
+
+	reader := &peekReader{reader: bytes.NewReader(make([]byte, 1<<20+16))}
+
+	// the buffer grown by a large peek is released after consumed.
+	if p, err := reader.Peek(1 << 20); nil != err || 1<<20 != len(p) {
+		t.Fatal("unexpected peek:", len(p), err)
+	}
+	if _, err := io.ReadFull(reader, make([]byte, 1<<20)); nil != err {
+		t.Fatal(err)
+	}
+	if size := cap(reader.buffer); size > maxRetainedPeekSize {
+		t.Fatal("the buffer is retained:", size)
+	}
+
+	// the read-ahead buffer is kept.
+	if err := ReadFull(reader, make([]byte, 8)); nil != err || readAheadSize != cap(reader.buffer) {
+		t.Fatal("unexpected read-ahead buffer:", cap(reader.buffer), err)
+	}
+	if err := ReadFull(reader, make([]byte, 8)); nil != err || readAheadSize != cap(reader.buffer) {
+		t.Fatal("unexpected read-ahead buffer:", cap(reader.buffer), err)
+	}
+}
+
+func TestChannelReadFull(t *testing.T) {
+
+	received := make(chan string, 4)
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			frame := make([]byte, 6)
+			utils.Assert(ReadFull(message, frame))
+			received <- string(frame)
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	// two frames split across three writes.
+	for _, data := range []string{"fra", "me1fr", "ame2"} {
+		if _, err := peer.Write([]byte(data)); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []string{"frame1", "frame2"} {
+		select {
+		case frame := <-received:
+			if want != frame {
+				t.Fatal("unexpected frame:", frame, "want:", want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("frame is not received:", want)
+		}
+	}
+}
+
+func TestChannelConcurrentWrite(t *testing.T) {
+
+	const writers, messages = 16, 64
+
+	for _, factory := range []ChannelFactory{NewChannel(), NewAsyncWriteChannel(16, true)} {
+
+		// every message is written in three parts, they are interleaved if the writes are not serialized.
+		ch, peer := pipeChannel(factory, "127.0.0.1:9527",
+			discardHandler{},
+			OutboundHandlerFunc(func(ctx OutboundContext, message Message) {
+				body := utils.MustToBytes(message)
+				ctx.HandleWrite([]byte{byte(len(body))})
+				ctx.HandleWrite(body[:len(body)/2])
+				runtime.Gosched()
+				ctx.HandleWrite(body[len(body)/2:])
+			}),
+		)
+
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for m := 0; m < messages; m++ {
+					if err := ch.Write(fmt.Sprintf("writer-%02d:message-%03d", w, m)); nil != err {
+						t.Error(err)
+						return
+					}
+				}
+			}(w)
+		}
+
+		// the messages of each writer are received in order.
+		next := make([]int, writers)
+		header := make([]byte, 1)
+		for i := 0; i < writers*messages; i++ {
+			if _, err := io.ReadFull(peer, header); nil != err {
+				t.Fatal(err)
+			}
+			body := make([]byte, header[0])
+			if _, err := io.ReadFull(peer, body); nil != err {
+				t.Fatal(err)
+			}
+
+			var w, m int
+			if _, err := fmt.Sscanf(string(body), "writer-%02d:message-%03d", &w, &m); nil != err || w >= writers {
+				t.Fatalf("corrupted frame: %q", body)
+			}
+			if next[w] != m {
+				t.Fatalf("writer %d: got message %d, want: %d", w, m, next[w])
+			}
+			next[w]++
+		}
+
+		wg.Wait()
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
+
+func TestChannelReentrantWrite(t *testing.T) {
+
+	for _, factory := range []ChannelFactory{NewChannel(), NewAsyncWriteChannel(16, true)} {
+
+		// the handler sends the extra frames before each message.
+		ch, peer := pipeChannel(factory, "127.0.0.1:9527",
+			discardHandler{},
+			OutboundHandlerFunc(func(ctx OutboundContext, message Message) {
+				if text, ok := message.(string); ok && strings.HasPrefix(text, "message") {
+					ctx.Write("[ctx]")
+					ctx.Write("[channel]")
+				}
+				ctx.HandleWrite(message)
+			}),
+		)
+		messages := readPeer(peer)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 3; i++ {
+				if err := ch.Write("message"); nil != err {
+					t.Error(err)
+				}
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("nested write is blocked")
+		}
+
+		var received string
+		for deadline := time.After(time.Second); len(received) < 3*len("[ctx][channel]message"); {
+			select {
+			case m := <-messages:
+				received += m
+			case <-deadline:
+				t.Fatal("unexpected messages:", received)
+			}
+		}
+
+		if strings.Repeat("[ctx][channel]message", 3) != received {
+			t.Fatal("unexpected messages:", received)
+		}
+
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
+
+// closeTracker records whether the reader is closed.
+type closeTracker struct {
+	io.Reader
+	closed int32
+}
+
+func (c *closeTracker) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+func TestChannelWriteReaders(t *testing.T) {
+
+	body := strings.Repeat("go-netty", 16*1024)
+
+	for _, factory := range []ChannelFactory{NewChannel(), NewAsyncWriteChannel(16, true)} {
+		ch, peer := pipeChannel(factory, "127.0.0.1:9527", discardHandler{})
+
+		header := &closeTracker{Reader: strings.NewReader("header|")}
+		footer := &closeTracker{Reader: strings.NewReader("|footer")}
+
+		expected := "header|" + body + "|footer"
+		received := make(chan string, 1)
+		go func() {
+			buffer := make([]byte, len(expected))
+			_, _ = io.ReadFull(peer, buffer)
+			received <- string(buffer)
+		}()
+
+		if err := ch.Write([]io.Reader{header, strings.NewReader(body), footer}); nil != err {
+			t.Fatal(err)
+		}
+
+		select {
+		case data := <-received:
+			if expected != data {
+				t.Fatal("unexpected data length:", len(data))
+			}
+		case <-time.After(time.Second):
+			t.Fatal("data not received")
+		}
+
+		if 1 != atomic.LoadInt32(&header.closed) || 1 != atomic.LoadInt32(&footer.closed) {
+			t.Fatal("readers are not closed")
+		}
+
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
+
+// receivedByte is the inbound message transformed by InboundTransformer.
+type receivedByte struct {
+	value byte
+	at    time.Time
+}
+
+func TestChannelInboundTransformer(t *testing.T) {
+
+	received := make(chan Message, 4)
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		ActiveHandlerFunc(func(ctx ActiveContext) {
+			// the transformer is set before the bytes arrived.
+			ctx.Channel().SetInboundTransformer(func(message Message) Message {
+				buffer := make([]byte, 1)
+				utils.AssertLength(utils.MustToReader(message).Read(buffer))
+				switch buffer[0] {
+				case '-':
+					return nil
+				case 'b':
+					// the stream is passed on after the transformer removed.
+					ctx.Channel().SetInboundTransformer(nil)
+				}
+				return receivedByte{value: buffer[0], at: time.Now()}
+			})
+			ctx.HandleActive()
+		}),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			if reader, ok := message.(io.Reader); ok {
+				buffer := make([]byte, 1)
+				utils.AssertLength(reader.Read(buffer))
+				message = string(buffer)
+			}
+			received <- message
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	if _, err := peer.Write([]byte("a-bc")); nil != err {
+		t.Fatal(err)
+	}
+
+	for _, want := range []byte("ab") {
+		select {
+		case message := <-received:
+			if b, ok := message.(receivedByte); !ok || want != b.value || b.at.IsZero() {
+				t.Fatalf("unexpected message: %#v, want: %c", message, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("message %c is not received", want)
+		}
+	}
+
+	select {
+	case message := <-received:
+		if "c" != message {
+			t.Fatalf("unexpected message: %#v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not received")
+	}
+}
+
+func TestChannelWritePriority(t *testing.T) {
+
+	for _, factory := range []ChannelFactory{NewChannel(), NewAsyncWriteChannel(16, true)} {
+		ch, peer := pipeChannel(factory, "127.0.0.1:9527", discardHandler{})
+
+		// the writes are queued while paused.
+		async := nil != ch.(*channel).writeQueue
+		if async {
+			ch.Pause()
+		}
+
+		go func() {
+			for _, w := range []struct {
+				message  string
+				priority bool
+			}{{"n1", false}, {"p1", true}, {"n2", false}, {"p2", true}, {"n3", false}} {
+				var err error
+				if w.priority {
+					err = ch.WritePriority(w.message)
+				} else {
+					err = ch.Write(w.message)
+				}
+				if nil != err {
+					t.Error(err)
+				}
+			}
+			ch.Resume()
+		}()
+
+		want := "n1p1n2p2n3"
+		if async {
+			want = "p1p2n1n2n3"
+		}
+
+		buffer := make([]byte, len(want))
+		_ = peer.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := io.ReadFull(peer, buffer); nil != err || want != string(buffer) {
+			t.Fatal("unexpected order:", string(buffer), "want:", want, err)
+		}
+
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
+
+func TestChannelWriteTimeout(t *testing.T) {
+
+	for _, options := range []ChannelOptions{
+		{WriteTimeout: time.Millisecond * 100},
+		{WriteTimeout: time.Millisecond * 100, WriteQueueSize: 16, WriteForever: true},
+	} {
+		ch, peer := pipeChannel(NewChannelWith(options), "127.0.0.1:9527", discardHandler{})
+
+		// the writes are drained by a reading peer.
+		received := readPeer(peer)
+		if err := ch.Write("fast"); nil != err {
+			t.Fatal(err)
+		}
+		<-received
+		time.Sleep(time.Millisecond * 150)
+		if !ch.IsActive() {
+			t.Fatal("closed after the write is drained")
+		}
+
+		// the peer stops reading.
+		_ = peer.SetReadDeadline(time.Now())
+		start := time.Now()
+		_ = ch.Write("stalled")
+
+		select {
+		case <-ch.CloseFuture().Done():
+			if err := ch.CloseFuture().Err(); !errors.Is(err, ErrSlowConsumer) {
+				t.Fatal("unexpected close cause:", err)
+			}
+			if elapsed := time.Since(start); elapsed < time.Millisecond*90 {
+				t.Fatal("closed too early:", elapsed)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("slow consumer is not closed")
+		}
+		_ = peer.Close()
+	}
+}
+
+func TestChannelWriteReleasesBuffer(t *testing.T) {
+
+	for _, factory := range []ChannelFactory{NewChannel(), NewAsyncWriteChannel(16, true)} {
+		ch, peer := pipeChannel(factory, "127.0.0.1:9527", discardHandler{})
+		received := readPeer(peer)
+
+		buffer := pbytes.NewBuffer(5)
+		copy(buffer.Bytes(), "hello")
+		// hold a reference to check the release.
+		buffer.Retain()
+
+		if err := ch.Write(buffer); nil != err {
+			t.Fatal(err)
+		}
+
+		if got := <-received; "hello" != got {
+			t.Fatal("unexpected write:", got)
+		}
+		if 1 != buffer.RefCnt() {
+			t.Fatal("the buffer is not released after written:", buffer.RefCnt())
+		}
+		buffer.Release()
+		ch.Close(nil)
+	}
+}
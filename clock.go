@@ -0,0 +1,224 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"sync"
+	"time"
+)
+
+// ClockAttribute overrides the Clock of the time-based handlers of channel, e.g. the idle, timeout and
+// throttling handlers, set it before the channel is active, e.g. in the ChannelInitializer.
+const ClockAttribute AttributeKey = "netty.clock"
+
+// Clock defines the source of time of the time-based handlers, the handlers use SystemClock unless
+// ClockAttribute is set, a FakeClock drives the handlers deterministically in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel receives the time after the duration.
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer create a Timer sends the time to its channel after the duration.
+	NewTimer(d time.Duration) Timer
+
+	// AfterFunc create a Timer calls the function after the duration.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer defines a timer created by Clock, like time.Timer.
+type Timer interface {
+	// C returns the channel of timer, nil if the timer is created by AfterFunc.
+	C() <-chan time.Time
+
+	// Stop the timer, false if the timer has already expired or been stopped.
+	Stop() bool
+
+	// Reset the timer to expire after the duration, false if the timer had expired or been stopped.
+	Reset(d time.Duration) bool
+}
+
+// SystemClock returns the Clock of the system time.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// ClockOf returns the Clock of channel, SystemClock if ClockAttribute is not set.
+func ClockOf(ch Channel) Clock {
+	if clock, ok := ch.Attribute(ClockAttribute).(Clock); ok {
+		return clock
+	}
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{Timer: time.NewTimer(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{Timer: time.AfterFunc(d, f)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// FakeClock is a Clock moves only by Advance, the expired timers are fired by Advance in order of the
+// deadlines, and the functions of AfterFunc are called by the goroutine of Advance.
+type FakeClock struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock create a FakeClock starts at the time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mutex)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, f: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance the time by the duration, and fire the timers expired.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	deadline := c.now.Add(d)
+	for {
+		// the earliest expired timer, it could be added by the functions of the fired timers.
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.deadline.After(deadline) && (nil == next || t.deadline.Before(next.deadline)) {
+				next = t
+			}
+		}
+
+		if nil == next {
+			break
+		}
+
+		if next.deadline.After(c.now) {
+			c.now = next.deadline
+		}
+		c.removeLocked(next)
+		now := c.now
+		c.mutex.Unlock()
+
+		if nil != next.f {
+			next.f()
+		} else {
+			select {
+			case next.c <- now:
+			default:
+			}
+		}
+		c.mutex.Lock()
+	}
+	c.now = deadline
+	c.mutex.Unlock()
+}
+
+// BlockUntil blocks until n timers are pending, e.g. to wait the handler running in another goroutine to
+// start its timer before Advance.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// Timers returns the number of pending timers.
+func (c *FakeClock) Timers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+func (c *FakeClock) removeLocked(t *fakeTimer) bool {
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	c        chan time.Time
+	f        func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	return t.clock.removeLocked(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	active := c.removeLocked(t)
+	t.deadline = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return active
+}
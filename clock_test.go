@@ -0,0 +1,137 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+
+	start := time.Unix(1700000000, 0)
+	clock := NewFakeClock(start)
+
+	var fired []string
+	clock.AfterFunc(3*time.Second, func() { fired = append(fired, "3s") })
+	clock.AfterFunc(time.Second, func() {
+		fired = append(fired, "1s")
+		// the timer added by a fired one is fired in the same Advance if expired.
+		clock.AfterFunc(time.Second, func() { fired = append(fired, "1s+1s") })
+	})
+	stopped := clock.AfterFunc(2*time.Second, func() { fired = append(fired, "stopped") })
+	reset := clock.AfterFunc(time.Second, func() { fired = append(fired, "reset") })
+	after := clock.After(4 * time.Second)
+
+	if !stopped.Stop() || stopped.Stop() {
+		t.Fatal("unexpected result of Stop")
+	}
+	if !reset.Reset(5 * time.Second) {
+		t.Fatal("unexpected result of Reset")
+	}
+
+	clock.Advance(3 * time.Second)
+	if "[1s 1s+1s 3s]" != fmt.Sprint(fired) {
+		t.Fatal("unexpected fired timers:", fired)
+	}
+	if !start.Add(3 * time.Second).Equal(clock.Now()) {
+		t.Fatal("unexpected now:", clock.Now())
+	}
+
+	select {
+	case <-after:
+		t.Fatal("timer fired too early")
+	default:
+	}
+
+	clock.Advance(2 * time.Second)
+	select {
+	case now := <-after:
+		if !start.Add(4 * time.Second).Equal(now) {
+			t.Fatal("unexpected time of timer:", now)
+		}
+	default:
+		t.Fatal("timer not fired")
+	}
+
+	if "[1s 1s+1s 3s reset]" != fmt.Sprint(fired) || 0 != clock.Timers() {
+		t.Fatal("unexpected fired timers:", fired, clock.Timers())
+	}
+}
+
+func TestIdleHandlerFakeClock(t *testing.T) {
+
+	clock := NewFakeClock(time.Now())
+	events := make(chan Event, 4)
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		ActiveHandlerFunc(func(ctx ActiveContext) {
+			ctx.Channel().SetAttribute(ClockAttribute, clock)
+			ctx.HandleActive()
+		}),
+		ReadIdleHandler(time.Minute),
+		WriteIdleHandler(time.Minute),
+		discardHandler{},
+		EventHandlerFunc(func(ctx EventContext, event Event) {
+			events <- event
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+	go func() { _, _ = io.Copy(ioutil.Discard, peer) }()
+
+	// the timers are started at activation.
+	clock.BlockUntil(2)
+
+	expect := func(want ...Event) {
+		t.Helper()
+		for _, event := range want {
+			select {
+			case e := <-events:
+				if event != e {
+					t.Fatalf("unexpected event: %T, want: %T", e, event)
+				}
+			default:
+				t.Fatalf("event %T is not triggered", event)
+			}
+		}
+		if 0 != len(events) {
+			t.Fatalf("unexpected event: %T", <-events)
+		}
+	}
+
+	clock.Advance(59 * time.Second)
+	expect()
+
+	clock.Advance(time.Second)
+	expect(ReadIdleEvent{}, WriteIdleEvent{})
+
+	// the write postpones the write idle.
+	clock.Advance(30 * time.Second)
+	if err := ch.Write([]byte("ping")); nil != err {
+		t.Fatal(err)
+	}
+
+	clock.Advance(30 * time.Second)
+	expect(ReadIdleEvent{})
+
+	clock.Advance(30 * time.Second)
+	expect(WriteIdleEvent{})
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// ErrChannelClosed is the cause of the channel closed by Close(nil), e.g. closed by the application.
+var ErrChannelClosed = errors.New("netty: channel closed")
+
+// CloseCauseAttribute holds the error caused the channel closed, it is set before ChannelInactive is fired.
+const CloseCauseAttribute AttributeKey = "netty.close-cause"
+
+// CloseReason classifies the cause of channel closed
+type CloseReason int
+
+const (
+	// CloseByApplication the channel is closed by Close(nil).
+	CloseByApplication CloseReason = iota
+	// CloseByEOF the peer closed the connection cleanly.
+	CloseByEOF
+	// CloseByTimeout the read or write deadline exceeded.
+	CloseByTimeout
+	// CloseByReset the connection is reset or aborted by peer.
+	CloseByReset
+	// CloseByServer the bootstrap is shutdown.
+	CloseByServer
+	// CloseByError the other errors.
+	CloseByError
+)
+
+// String of CloseReason
+func (r CloseReason) String() string {
+	switch r {
+	case CloseByApplication:
+		return "application"
+	case CloseByEOF:
+		return "eof"
+	case CloseByTimeout:
+		return "timeout"
+	case CloseByReset:
+		return "reset"
+	case CloseByServer:
+		return "server"
+	default:
+		return "error"
+	}
+}
+
+// CloseReasonOf classify the cause of channel closed, e.g. the exception of ChannelInactive.
+func CloseReasonOf(cause error) CloseReason {
+	var ne net.Error
+	switch {
+	case nil == cause, errors.Is(cause, ErrChannelClosed):
+		return CloseByApplication
+	case errors.Is(cause, io.EOF):
+		return CloseByEOF
+	case errors.As(cause, &ne) && ne.Timeout():
+		return CloseByTimeout
+	case errors.Is(cause, syscall.ECONNRESET), errors.Is(cause, syscall.ECONNABORTED), errors.Is(cause, syscall.EPIPE):
+		return CloseByReset
+	case errors.Is(cause, ErrServerClosed):
+		return CloseByServer
+	default:
+		return CloseByError
+	}
+}
+
+// abortTransport defines the transport which could be closed without the graceful shutdown, e.g. tcp.AbortTransport
+type abortTransport interface {
+	Abort() error
+}
+
+// closeTransport to close the transport gracefully if the channel is closed by the application, the peer or the
+// server, e.g. send the close_notify of tls, otherwise abort it.
+func closeTransport(t transport.Transport, cause error) {
+	if a, ok := t.(abortTransport); ok {
+		switch CloseReasonOf(cause) {
+		case CloseByApplication, CloseByEOF, CloseByServer:
+		default:
+			_ = a.Abort()
+			return
+		}
+	}
+	_ = t.Close()
+}
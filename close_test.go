@@ -0,0 +1,157 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCloseCause(t *testing.T) {
+
+	var cases = []struct {
+		name   string
+		close  func(ch Channel, peer net.Conn)
+		reason CloseReason
+	}{
+		{name: "eof", close: func(ch Channel, peer net.Conn) { _ = peer.Close() }, reason: CloseByEOF},
+		{name: "timeout", close: func(ch Channel, peer net.Conn) {
+			_ = ch.Transport().SetReadDeadline(time.Now().Add(time.Millisecond * 50))
+		}, reason: CloseByTimeout},
+		{name: "application", close: func(ch Channel, peer net.Conn) { ch.Close(nil) }, reason: CloseByApplication},
+	}
+
+	for _, c := range cases {
+		inactive := make(chan Exception, 1)
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+			discardHandler{},
+			ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+				ctx.Close(ex)
+			}),
+			InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+				if cause := ctx.Channel().Attribute(CloseCauseAttribute); cause != ex {
+					t.Errorf("%s: unexpected close cause attribute: %v", c.name, cause)
+				}
+				inactive <- ex
+			}),
+		)
+
+		c.close(ch, peer)
+
+		select {
+		case ex := <-inactive:
+			if nil == ex {
+				t.Fatal(c.name, "close cause should not be nil")
+			}
+			if reason := CloseReasonOf(ex); c.reason != reason {
+				t.Fatalf("%s: unexpected close reason: %s, cause: %v", c.name, reason, ex)
+			}
+		case <-time.After(time.Second):
+			t.Fatal(c.name, "channel not closed")
+		}
+		_ = peer.Close()
+
+		// write to the closed channel
+		if err := ch.Write([]byte("closed")); nil == err {
+			t.Fatal(c.name, "write to a closed channel should fail")
+		}
+	}
+}
+
+func TestCloseReasonOf(t *testing.T) {
+
+	var cases = []struct {
+		cause  error
+		reason CloseReason
+	}{
+		{cause: nil, reason: CloseByApplication},
+		{cause: ErrChannelClosed, reason: CloseByApplication},
+		{cause: fmt.Errorf("read: %w", syscall.ECONNRESET), reason: CloseByReset},
+		{cause: ErrServerClosed, reason: CloseByServer},
+		{cause: errors.New("other"), reason: CloseByError},
+	}
+
+	for _, c := range cases {
+		if reason := CloseReasonOf(c.cause); c.reason != reason {
+			t.Fatalf("%v: unexpected close reason: %s, want: %s", c.cause, reason, c.reason)
+		}
+	}
+}
+
+func TestCloseFuture(t *testing.T) {
+
+	cause := errors.New("reconnect")
+	cleaned := make(chan struct{})
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		discardHandler{},
+		CleanupHandlerFunc(func(ch Channel, ex Exception) {
+			close(cleaned)
+		}),
+	)
+	defer peer.Close()
+
+	fired := make(chan error, 3)
+	for i := 0; i < 2; i++ {
+		ch.CloseFuture().AddListener(func(f Future) {
+			select {
+			case <-cleaned:
+			default:
+				t.Error("listener is called before cleanup")
+			}
+			fired <- f.Err()
+		})
+	}
+
+	select {
+	case <-ch.CloseFuture().Done():
+		t.Fatal("future completed before closed")
+	default:
+	}
+
+	ch.Close(cause)
+	ch.Close(errors.New("closed again"))
+
+	// the listener added after closed is called immediately.
+	ch.CloseFuture().AddListener(func(f Future) { fired <- f.Err() })
+
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-fired:
+			if cause != err {
+				t.Fatal("unexpected close cause:", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("listener not fired")
+		}
+	}
+
+	if err := ch.CloseFuture().Wait(); cause != err {
+		t.Fatal("unexpected close cause:", err)
+	}
+
+	// closed without error.
+	ch, peer = pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+	defer peer.Close()
+	ch.Close(nil)
+	if err := ch.CloseFuture().Wait(); ErrChannelClosed != err {
+		t.Fatal("unexpected close cause:", err)
+	}
+}
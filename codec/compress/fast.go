@@ -0,0 +1,132 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+	"github.com/pierrec/lz4/v4"
+)
+
+// ErrDecompressedTooLarge is raised if the decompressed message exceeds the max size, e.g. a compression bomb.
+var ErrDecompressedTooLarge = errors.New("decompressed message too large")
+
+// Algo defines the fast compression algorithm
+type Algo int
+
+const (
+	// LZ4 frame format
+	LZ4 Algo = iota
+	// Snappy framing format
+	Snappy
+)
+
+// String of Algo
+func (a Algo) String() string {
+	switch a {
+	case LZ4:
+		return "lz4"
+	case Snappy:
+		return "snappy"
+	default:
+		return fmt.Sprintf("algo(%d)", int(a))
+	}
+}
+
+// FastCompressionCodec create a codec to compress the outbound messages and decompress the inbound
+// messages, each message is a complete frame, so it should be placed after a frame codec, the inbound
+// message is decompressed in streaming and rejected if it exceeds maxDecompressedSize.
+func FastCompressionCodec(algo Algo, maxDecompressedSize int) codec.Codec {
+	utils.AssertIf(maxDecompressedSize <= 0, "maxDecompressedSize must be a positive integer")
+
+	c := &fastCompressionCodec{algo: algo, maxDecompressedSize: int64(maxDecompressedSize)}
+	switch algo {
+	case LZ4:
+		c.writers.New = func() interface{} { return lz4.NewWriter(nil) }
+		c.readers.New = func() interface{} { return lz4.NewReader(nil) }
+	case Snappy:
+		c.writers.New = func() interface{} { return snappy.NewBufferedWriter(nil) }
+		c.readers.New = func() interface{} { return snappy.NewReader(nil) }
+	default:
+		utils.Assert(fmt.Errorf("unsupported compression algo: %s", algo))
+	}
+	return c
+}
+
+// compressWriter defines the reusable writer of lz4 & snappy
+type compressWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// compressReader defines the reusable reader of lz4 & snappy
+type compressReader interface {
+	io.Reader
+	Reset(r io.Reader)
+}
+
+type fastCompressionCodec struct {
+	algo                Algo
+	maxDecompressedSize int64
+	writers             sync.Pool
+	readers             sync.Pool
+}
+
+func (c *fastCompressionCodec) CodecName() string {
+	return c.algo.String() + "-compression-codec"
+}
+
+func (c *fastCompressionCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	reader := c.readers.Get().(compressReader)
+	reader.Reset(utils.MustToReader(message))
+	defer func() {
+		reader.Reset(nil)
+		c.readers.Put(reader)
+	}()
+
+	// read one more byte to detect the oversize.
+	data, err := io.ReadAll(io.LimitReader(reader, c.maxDecompressedSize+1))
+	utils.Assert(err)
+	utils.AssertIf(int64(len(data)) > c.maxDecompressedSize, "%w: > %d", ErrDecompressedTooLarge, c.maxDecompressedSize)
+
+	ctx.HandleRead(data)
+}
+
+func (c *fastCompressionCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	var buffer bytes.Buffer
+	writer := c.writers.Get().(compressWriter)
+	writer.Reset(&buffer)
+	defer func() {
+		writer.Reset(nil)
+		c.writers.Put(writer)
+	}()
+
+	utils.AssertLength(writer.Write(utils.MustToBytes(message)))
+	utils.Assert(writer.Close())
+
+	ctx.HandleWrite(buffer.Bytes())
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestFastCompressionCodec(t *testing.T) {
+
+	var inputs = [][]byte{
+		[]byte("go-netty"),
+		bytes.Repeat([]byte("0123456789"), 10000),
+		{},
+	}
+
+	for _, algo := range []Algo{LZ4, Snappy} {
+		codec := FastCompressionCodec(algo, 1024*1024)
+		t.Run(codec.CodecName(), func(t *testing.T) {
+			for _, input := range inputs {
+				var compressed []byte
+				ctx := MockHandlerContext{
+					MockHandleRead: func(message netty.Message) {
+						if dst := utils.MustToBytes(message); !bytes.Equal(dst, input) {
+							t.Fatalf("decompressed %d bytes != %d bytes", len(dst), len(input))
+						}
+					},
+					MockHandleWrite: func(message netty.Message) {
+						compressed = utils.MustToBytes(message)
+					},
+				}
+
+				codec.HandleWrite(ctx, input)
+				if len(input) > 1000 && len(compressed) >= len(input) {
+					t.Fatalf("not compressed: %d >= %d", len(compressed), len(input))
+				}
+				codec.HandleRead(ctx, bytes.NewReader(compressed))
+			}
+		})
+	}
+}
+
+func TestFastCompressionCodecBomb(t *testing.T) {
+
+	for _, algo := range []Algo{LZ4, Snappy} {
+		var compressed []byte
+		ctx := MockHandlerContext{
+			MockHandleRead: func(message netty.Message) {
+				t.Fatal("bomb should not be decompressed")
+			},
+			MockHandleWrite: func(message netty.Message) {
+				compressed = utils.MustToBytes(message)
+			},
+		}
+
+		// 16MB zeros
+		FastCompressionCodec(algo, 32*1024*1024).HandleWrite(ctx, make([]byte, 16*1024*1024))
+
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrDecompressedTooLarge) {
+					t.Fatal(algo, "unexpected error:", err)
+				}
+			}()
+			FastCompressionCodec(algo, 1024*1024).HandleRead(ctx, compressed)
+		}()
+	}
+}
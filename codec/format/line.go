@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// LineCodec create a codec to decode the inbound lines ended with \n or \r\n to strings,
+// and encode the outbound string with \n appended, see LineCodecWith.
+func LineCodec(maxLength int, stripEOL bool) codec.Codec {
+	return LineCodecWith(maxLength, stripEOL, "\n")
+}
+
+// LineCodecWith create a line codec which appends the lineEnding to outbound messages,
+// the inbound lines are always split on \n with an optional \r before it, maxLength is
+// the max length of a line excluding the line ending.
+func LineCodecWith(maxLength int, stripEOL bool, lineEnding string) codec.Codec {
+	utils.AssertIf(maxLength <= 0, "maxLength must be a positive integer")
+	utils.AssertIf(!strings.HasSuffix(lineEnding, "\n"), "lineEnding must end with \\n")
+	return &lineCodec{
+		maxLength:  maxLength,
+		stripEOL:   stripEOL,
+		lineEnding: []byte(lineEnding),
+	}
+}
+
+type lineCodec struct {
+	maxLength  int
+	stripEOL   bool
+	lineEnding []byte
+}
+
+func (*lineCodec) CodecName() string {
+	return "line-codec"
+}
+
+func (l *lineCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	// wrap to io.Reader
+	reader := utils.MustToReader(message)
+
+	readBuff := make([]byte, 0, 16)
+	tempBuff := make([]byte, 1)
+	for {
+		// read 1 byte
+		if 0 == utils.AssertLength(reader.Read(tempBuff)) {
+			continue
+		}
+
+		if '\n' == tempBuff[0] {
+			break
+		}
+
+		readBuff = append(readBuff, tempBuff[0])
+
+		// the last byte could be the \r of \r\n.
+		if n := len(readBuff); n > l.maxLength+1 || (n == l.maxLength+1 && '\r' != readBuff[n-1]) {
+			utils.Assert(fmt.Errorf("line length too large, lineLength(%d) > maxLength(%d)", n, l.maxLength))
+		}
+	}
+
+	if l.stripEOL {
+		readBuff = bytes.TrimSuffix(readBuff, []byte{'\r'})
+	} else {
+		readBuff = append(readBuff, '\n')
+	}
+
+	// post line
+	ctx.HandleRead(string(readBuff))
+}
+
+func (l *lineCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	switch r := message.(type) {
+	case string:
+		ctx.HandleWrite([][]byte{[]byte(r), l.lineEnding})
+	case []byte:
+		ctx.HandleWrite([][]byte{r, l.lineEnding})
+	default:
+		ctx.HandleWrite(io.MultiReader(utils.MustToReader(message), bytes.NewReader(l.lineEnding)))
+	}
+}
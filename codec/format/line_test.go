@@ -0,0 +1,101 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestLineCodec(t *testing.T) {
+
+	var cases = []struct {
+		stripEOL bool
+		lines    []string
+	}{
+		{stripEOL: true, lines: []string{"abc", "", "de", "f"}},
+		{stripEOL: false, lines: []string{"abc\r\n", "\n", "de\n", "f\r\n"}},
+	}
+
+	for index, c := range cases {
+		codec := LineCodec(3, c.stripEOL)
+		t.Run(fmt.Sprint(codec.CodecName(), "#", index), func(t *testing.T) {
+			var lines []string
+			ctx := MockHandlerContext{
+				MockHandleRead: func(message netty.Message) {
+					lines = append(lines, message.(string))
+				},
+			}
+
+			reader := strings.NewReader("abc\r\n\nde\nf\r\n")
+			for range c.lines {
+				codec.HandleRead(ctx, reader)
+			}
+
+			if fmt.Sprint(c.lines) != fmt.Sprint(lines) {
+				t.Fatalf("%q != %q", lines, c.lines)
+			}
+		})
+	}
+}
+
+func TestLineCodecTooLong(t *testing.T) {
+
+	for _, line := range []string{"abcd\n", "abcd\r\n", "abc\rd\n"} {
+		func() {
+			defer func() {
+				if nil == recover() {
+					t.Fatalf("%q should be too long", line)
+				}
+			}()
+			LineCodec(3, true).HandleRead(MockHandlerContext{}, line)
+		}()
+	}
+}
+
+func TestLineCodecWrite(t *testing.T) {
+
+	var cases = []struct {
+		lineEnding string
+		message    interface{}
+		output     []byte
+	}{
+		{lineEnding: "\n", message: "abc", output: []byte("abc\n")},
+		{lineEnding: "\r\n", message: "abc", output: []byte("abc\r\n")},
+		{lineEnding: "\r\n", message: []byte("abc"), output: []byte("abc\r\n")},
+		{lineEnding: "\n", message: strings.NewReader("abc"), output: []byte("abc\n")},
+	}
+
+	for _, c := range cases {
+		var output []byte
+		ctx := MockHandlerContext{
+			MockHandleWrite: func(message netty.Message) {
+				output = utils.MustToBytes(message)
+			},
+		}
+
+		LineCodecWith(8, true, c.lineEnding).HandleWrite(ctx, c.message)
+		if !bytes.Equal(c.output, output) {
+			t.Fatalf("%q != %q", output, c.output)
+		}
+	}
+}
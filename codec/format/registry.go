@@ -0,0 +1,168 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrUnknownTypeTag is returned when the inbound type tag is not registered.
+var ErrUnknownTypeTag = errors.New("format: unknown type tag")
+
+// Serializer defines the payload serializer of a message type
+type Serializer interface {
+	Marshal(message interface{}) ([]byte, error)
+	Unmarshal(payload []byte) (interface{}, error)
+}
+
+// TypeRegistry maps the type tags to the go types of messages for TypeCodec
+type TypeRegistry interface {
+	// Register the go type of prototype with the tag, panics if the tag or type is registered.
+	Register(tag uint16, prototype interface{}, serializer Serializer) TypeRegistry
+
+	// TagOf get the tag & serializer of the message by its go type.
+	TagOf(message interface{}) (uint16, Serializer, bool)
+
+	// SerializerOf get the serializer of the tag.
+	SerializerOf(tag uint16) (Serializer, bool)
+}
+
+// NewTypeRegistry create an empty TypeRegistry
+func NewTypeRegistry() TypeRegistry {
+	return &typeRegistry{
+		tags:  make(map[reflect.Type]uint16),
+		types: make(map[uint16]Serializer),
+	}
+}
+
+type typeRegistry struct {
+	mutex sync.RWMutex
+	tags  map[reflect.Type]uint16
+	types map[uint16]Serializer
+}
+
+func (r *typeRegistry) Register(tag uint16, prototype interface{}, serializer Serializer) TypeRegistry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	typ := reflect.TypeOf(prototype)
+	_, tagExists := r.types[tag]
+	utils.AssertIf(tagExists, "type tag %d is already registered", tag)
+	_, typeExists := r.tags[typ]
+	utils.AssertIf(typeExists, "type %v is already registered", typ)
+
+	r.tags[typ] = tag
+	r.types[tag] = serializer
+	return r
+}
+
+func (r *typeRegistry) TagOf(message interface{}) (uint16, Serializer, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tag, ok := r.tags[reflect.TypeOf(message)]
+	return tag, r.types[tag], ok
+}
+
+func (r *typeRegistry) SerializerOf(tag uint16) (Serializer, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	serializer, ok := r.types[tag]
+	return serializer, ok
+}
+
+// JSONSerializer create a json serializer of the type of prototype, the payload is decoded to a
+// pointer if the prototype is a pointer.
+func JSONSerializer(prototype interface{}) Serializer {
+	return &jsonSerializer{typ: reflect.TypeOf(prototype)}
+}
+
+type jsonSerializer struct {
+	typ reflect.Type
+}
+
+func (j *jsonSerializer) Marshal(message interface{}) ([]byte, error) {
+	return json.Marshal(message)
+}
+
+func (j *jsonSerializer) Unmarshal(payload []byte) (interface{}, error) {
+	if reflect.Ptr == j.typ.Kind() {
+		v := reflect.New(j.typ.Elem())
+		return v.Interface(), json.Unmarshal(payload, v.Interface())
+	}
+
+	v := reflect.New(j.typ)
+	err := json.Unmarshal(payload, v.Interface())
+	return v.Elem().Interface(), err
+}
+
+// TypeCodec create a codec of the frames of a 2 bytes big-endian type tag followed by the payload,
+// the inbound payload is deserialized by the serializer of the tag, and the outbound message is
+// serialized by the serializer of its go type, pair it with a frame codec, e.g. LengthFieldCodec.
+func TypeCodec(registry TypeRegistry) codec.Codec {
+	return &typeCodec{registry: registry}
+}
+
+type typeCodec struct {
+	registry TypeRegistry
+}
+
+func (*typeCodec) CodecName() string {
+	return "type-codec"
+}
+
+func (t *typeCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	frame := utils.MustToBytes(message)
+	utils.AssertIf(len(frame) < 2, "frame is too short for the type tag: %d", len(frame))
+
+	tag := binary.BigEndian.Uint16(frame)
+	serializer, ok := t.registry.SerializerOf(tag)
+	if !ok {
+		utils.Assert(fmt.Errorf("%w: %d", ErrUnknownTypeTag, tag))
+	}
+
+	object, err := serializer.Unmarshal(frame[2:])
+	if nil != err {
+		utils.Assert(fmt.Errorf("unmarshal type tag %d: %w", tag, err))
+	}
+
+	// post object
+	ctx.HandleRead(object)
+}
+
+func (t *typeCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	tag, serializer, ok := t.registry.TagOf(message)
+	utils.AssertIf(!ok, "unregistered message type: %T", message)
+
+	payload := utils.AssertBytes(serializer.Marshal(message))
+
+	var header [2]byte
+	binary.BigEndian.PutUint16(header[:], tag)
+	ctx.HandleWrite([][]byte{header[:], payload})
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+type testPing struct {
+	Seq int `json:"seq"`
+}
+
+type testLogin struct {
+	User string `json:"user"`
+}
+
+// testHeartbeat is serialized as 4 bytes big-endian.
+type testHeartbeat uint32
+
+type heartbeatSerializer struct{}
+
+func (heartbeatSerializer) Marshal(message interface{}) ([]byte, error) {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, uint32(message.(testHeartbeat)))
+	return payload, nil
+}
+
+func (heartbeatSerializer) Unmarshal(payload []byte) (interface{}, error) {
+	if 4 != len(payload) {
+		return nil, errors.New("invalid heartbeat")
+	}
+	return testHeartbeat(binary.BigEndian.Uint32(payload)), nil
+}
+
+func TestTypeCodec(t *testing.T) {
+
+	registry := NewTypeRegistry().
+		Register(1, testPing{}, JSONSerializer(testPing{})).
+		Register(2, &testLogin{}, JSONSerializer(&testLogin{})).
+		Register(3, testHeartbeat(0), heartbeatSerializer{})
+
+	var frames [][]byte
+	var decoded []interface{}
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			decoded = append(decoded, message)
+		},
+		MockHandleWrite: func(message netty.Message) {
+			frames = append(frames, utils.MustToBytes(message))
+		},
+	}
+
+	messages := []interface{}{testPing{Seq: 1}, &testLogin{User: "go-netty"}, testHeartbeat(7), testPing{Seq: 2}}
+
+	codec := TypeCodec(registry)
+	for _, message := range messages {
+		codec.HandleWrite(ctx, message)
+	}
+
+	for index, tag := range []uint16{1, 2, 3, 1} {
+		if binary.BigEndian.Uint16(frames[index]) != tag {
+			t.Fatalf("unexpected tag of #%d: %x", index, frames[index])
+		}
+		codec.HandleRead(ctx, frames[index])
+	}
+
+	if !reflect.DeepEqual(messages, decoded) {
+		t.Fatal(decoded, "!=", messages)
+	}
+}
+
+func TestTypeCodecUnknown(t *testing.T) {
+
+	codec := TypeCodec(NewTypeRegistry().Register(1, testPing{}, JSONSerializer(testPing{})))
+
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrUnknownTypeTag) {
+				t.Fatal("expect unknown type tag, got:", err)
+			}
+		}()
+		codec.HandleRead(MockHandlerContext{}, []byte{0, 9, '{', '}'})
+	}()
+
+	func() {
+		defer func() {
+			if nil == recover() {
+				t.Fatal("expect unregistered type error")
+			}
+		}()
+		codec.HandleWrite(MockHandlerContext{}, testLogin{})
+	}()
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// StringCodec create a codec to decode the inbound bytes of charset to string, and encode the
+// outbound string to the charset, a multibyte character split across frames is decoded when
+// the rest bytes arrive, the codec holds the incomplete bytes, so create a new one for each channel.
+func StringCodec(charset encoding.Encoding) codec.Codec {
+	return &stringCodec{
+		decoder: charset.NewDecoder(),
+		encoder: charset.NewEncoder(),
+		buffer:  make([]byte, 4096),
+	}
+}
+
+type stringCodec struct {
+	decoder *encoding.Decoder
+	encoder *encoding.Encoder
+	buffer  []byte
+	pending []byte
+}
+
+func (*stringCodec) CodecName() string {
+	return "string-codec"
+}
+
+func (s *stringCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	src := utils.MustToBytes(message)
+	if len(s.pending) > 0 {
+		src = append(s.pending, src...)
+		s.pending = nil
+	}
+
+	sb := strings.Builder{}
+	for len(src) > 0 {
+		nDst, nSrc, err := s.decoder.Transform(s.buffer, src, false)
+		sb.Write(s.buffer[:nDst])
+		src = src[nSrc:]
+
+		switch err {
+		case nil, transform.ErrShortDst:
+		case transform.ErrShortSrc:
+			// wait for the rest bytes of the character.
+			s.pending = append([]byte(nil), src...)
+			src = nil
+		default:
+			utils.Assert(err)
+		}
+	}
+
+	if sb.Len() > 0 {
+		ctx.HandleRead(sb.String())
+	}
+}
+
+func (s *stringCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	switch r := message.(type) {
+	case string:
+		ctx.HandleWrite(utils.AssertBytes(s.encoder.Bytes([]byte(r))))
+	default:
+		ctx.HandleWrite(message)
+	}
+}
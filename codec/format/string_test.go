@@ -0,0 +1,77 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestStringCodec(t *testing.T) {
+
+	var cases = []struct {
+		name    string
+		charset encoding.Encoding
+		text    string
+	}{
+		{name: "shift-jis", charset: japanese.ShiftJIS, text: "こんにちは、世界"},
+		{name: "gbk", charset: simplifiedchinese.GBK, text: "你好，世界"},
+		{name: "latin-1", charset: charmap.ISO8859_1, text: "Grüße, café"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var encoded []byte
+			var decoded []string
+
+			codec := StringCodec(c.charset)
+			ctx := MockHandlerContext{
+				MockHandleRead: func(message netty.Message) {
+					decoded = append(decoded, message.(string))
+				},
+				MockHandleWrite: func(message netty.Message) {
+					encoded = utils.MustToBytes(message)
+				},
+			}
+
+			codec.HandleWrite(ctx, c.text)
+			if expect, _ := c.charset.NewEncoder().String(c.text); expect != string(encoded) {
+				t.Fatalf("unexpected encoded bytes: %x", encoded)
+			}
+
+			// feed byte by byte to split the multibyte characters.
+			for i := range encoded {
+				codec.HandleRead(ctx, encoded[i:i+1])
+			}
+
+			var text string
+			for _, s := range decoded {
+				text += s
+			}
+			if c.text != text {
+				t.Fatal(text, "!=", c.text)
+			}
+		})
+	}
+}
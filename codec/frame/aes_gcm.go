@@ -0,0 +1,171 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrDecryptFailed is raised if the inbound frame failed to be authenticated or the key is unknown.
+var ErrDecryptFailed = errors.New("decrypt failed")
+
+// NonceStrategy generates the 12 bytes nonce of AES-GCM for each frame, the nonce must never be
+// reused with the same key.
+type NonceStrategy interface {
+	Next(nonce []byte)
+}
+
+// RandomNonce generates the random nonce, it's safe for about 2^32 frames per key.
+func RandomNonce() NonceStrategy {
+	return randomNonce{}
+}
+
+type randomNonce struct{}
+
+func (randomNonce) Next(nonce []byte) {
+	utils.AssertLength(io.ReadFull(rand.Reader, nonce))
+}
+
+// CounterNonce generates the nonce of a random 4 bytes prefix and a 8 bytes counter, it's unique
+// for 2^64 frames of the codec, the prefix avoids the collision of codecs with the same key.
+func CounterNonce() NonceStrategy {
+	c := &counterNonce{}
+	utils.AssertLength(io.ReadFull(rand.Reader, c.prefix[:]))
+	return c
+}
+
+type counterNonce struct {
+	prefix  [4]byte
+	counter uint64
+}
+
+func (c *counterNonce) Next(nonce []byte) {
+	copy(nonce, c.prefix[:])
+	binary.BigEndian.PutUint64(nonce[4:], atomic.AddUint64(&c.counter, 1))
+}
+
+// AEADCodec defines the encryption codec which supports key rotation
+type AEADCodec interface {
+	codec.Codec
+
+	// RotateKey encrypt the outbound frames with the new key, the inbound frames of the previous key are
+	// still decrypted, so the peers could rotate the keys in turn, the key id increases on each rotation,
+	// so the peers must rotate the same keys in the same order.
+	RotateKey(key []byte)
+}
+
+// aesGCMHeaderSize is the size of key id + nonce
+const aesGCMHeaderSize = 4 + 12
+
+// AESGCMCodec create a codec to encrypt each outbound frame and decrypt each inbound frame by AES-GCM,
+// the key must be 16, 24 or 32 bytes for AES-128, AES-192 or AES-256, it should be placed after a frame codec.
+//
+// Frame: | KEY ID (4 bytes) | NONCE (12 bytes) | CIPHERTEXT | TAG (16 bytes) |, the key id is authenticated as well.
+func AESGCMCodec(key []byte, nonce NonceStrategy) AEADCodec {
+	utils.AssertIf(nil == nonce, "nonce strategy is required")
+	c := &aesGCMCodec{nonce: nonce, keys: make(map[uint32]cipher.AEAD)}
+	c.RotateKey(key)
+	return c
+}
+
+type aesGCMCodec struct {
+	nonce   NonceStrategy
+	mutex   sync.RWMutex
+	keyID   uint32
+	current cipher.AEAD
+	keys    map[uint32]cipher.AEAD // current & previous key
+}
+
+func (*aesGCMCodec) CodecName() string {
+	return "aes-gcm-codec"
+}
+
+func (c *aesGCMCodec) RotateKey(key []byte) {
+	block, err := aes.NewCipher(key)
+	utils.Assert(err)
+	aead, err := cipher.NewGCM(block)
+	utils.Assert(err)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// keep the previous key only.
+	delete(c.keys, c.keyID-1)
+	if nil != c.current {
+		c.keyID++
+	}
+	c.current = aead
+	c.keys[c.keyID] = aead
+}
+
+func (c *aesGCMCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	frame := utils.MustToBytes(message)
+	if len(frame) < aesGCMHeaderSize {
+		c.fail(ctx, fmt.Errorf("%w: frame too short: %d", ErrDecryptFailed, len(frame)))
+	}
+
+	keyID := binary.BigEndian.Uint32(frame)
+	c.mutex.RLock()
+	aead, ok := c.keys[keyID]
+	c.mutex.RUnlock()
+	if !ok {
+		c.fail(ctx, fmt.Errorf("%w: unknown key id: %d", ErrDecryptFailed, keyID))
+	}
+
+	header, ciphertext := frame[:aesGCMHeaderSize], frame[aesGCMHeaderSize:]
+	plaintext, err := aead.Open(nil, header[4:], ciphertext, header[:4])
+	if nil != err {
+		c.fail(ctx, fmt.Errorf("%w: %v", ErrDecryptFailed, err))
+	}
+
+	ctx.HandleRead(plaintext)
+}
+
+// fail to raise the decrypt error.
+func (c *aesGCMCodec) fail(ctx netty.InboundContext, err error) {
+	triggerDecodeError(ctx, err)
+	utils.Assert(err)
+}
+
+func (c *aesGCMCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	plaintext := utils.MustToBytes(message)
+
+	c.mutex.RLock()
+	keyID, aead := c.keyID, c.current
+	c.mutex.RUnlock()
+
+	frame := make([]byte, aesGCMHeaderSize, aesGCMHeaderSize+len(plaintext)+aead.Overhead())
+	binary.BigEndian.PutUint32(frame, keyID)
+	c.nonce.Next(frame[4:aesGCMHeaderSize])
+
+	// KEY ID | NONCE | CIPHERTEXT | TAG
+	ctx.HandleWrite(aead.Seal(frame, frame[4:aesGCMHeaderSize], plaintext, frame[:4]))
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestAESGCMCodec(t *testing.T) {
+
+	key := bytes.Repeat([]byte{0x01}, 32)
+
+	for name, nonce := range map[string]NonceStrategy{"random": RandomNonce(), "counter": CounterNonce()} {
+		codec := AESGCMCodec(key, nonce)
+
+		var frames [][]byte
+		var decoded []byte
+		ctx := MockHandlerContext{
+			MockHandleRead: func(message netty.Message) {
+				decoded = utils.MustToBytes(message)
+			},
+			MockHandleWrite: func(message netty.Message) {
+				frames = append(frames, utils.MustToBytes(message))
+			},
+		}
+
+		codec.HandleWrite(ctx, []byte("go-netty"))
+		codec.HandleWrite(ctx, []byte("go-netty"))
+		if bytes.Equal(frames[0], frames[1]) {
+			t.Fatal(name, "nonce should be unique")
+		}
+
+		codec.HandleRead(ctx, frames[0])
+		if "go-netty" != string(decoded) {
+			t.Fatalf("%s: unexpected decoded: %q", name, decoded)
+		}
+
+		// tampered ciphertext, nonce and key id.
+		for _, index := range []int{len(frames[1]) - 1, aesGCMHeaderSize, 4, 3} {
+			tampered := append([]byte(nil), frames[1]...)
+			tampered[index] ^= 0x01
+			func() {
+				defer func() {
+					if err, _ := recover().(error); !errors.Is(err, ErrDecryptFailed) {
+						t.Fatal(name, "unexpected error:", err)
+					}
+				}()
+				codec.HandleRead(ctx, tampered)
+			}()
+		}
+	}
+}
+
+func TestAESGCMCodecRotateKey(t *testing.T) {
+
+	sender := AESGCMCodec(bytes.Repeat([]byte{0x01}, 16), CounterNonce())
+	receiver := AESGCMCodec(bytes.Repeat([]byte{0x01}, 16), CounterNonce())
+
+	var frame []byte
+	var decoded []byte
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			decoded = utils.MustToBytes(message)
+		},
+		MockHandleWrite: func(message netty.Message) {
+			frame = utils.MustToBytes(message)
+		},
+	}
+
+	// the receiver rotates first, the frames of previous key are still decrypted.
+	sender.HandleWrite(ctx, []byte("key-0"))
+	receiver.RotateKey(bytes.Repeat([]byte{0x02}, 16))
+	receiver.HandleRead(ctx, frame)
+	if "key-0" != string(decoded) {
+		t.Fatalf("unexpected decoded: %q", decoded)
+	}
+
+	sender.RotateKey(bytes.Repeat([]byte{0x02}, 16))
+	sender.HandleWrite(ctx, []byte("key-1"))
+	receiver.HandleRead(ctx, frame)
+	if "key-1" != string(decoded) {
+		t.Fatalf("unexpected decoded: %q", decoded)
+	}
+
+	// the key before previous is dropped.
+	sender.HandleWrite(ctx, []byte("key-1"))
+	receiver.RotateKey(bytes.Repeat([]byte{0x03}, 16))
+	receiver.RotateKey(bytes.Repeat([]byte{0x04}, 16))
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrDecryptFailed) {
+			t.Fatal("unexpected error:", err)
+		}
+	}()
+	receiver.HandleRead(ctx, frame)
+}
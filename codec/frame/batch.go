@@ -0,0 +1,82 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// Batch is an outbound message of messages, which are encoded as frames one by one by the codec of BatchCodec
+// and written to the transport in a single write.
+type Batch []netty.Message
+
+// BatchCodec wrap the frame codec to coalesce the frames of Batch into one buffer, so the burst of small
+// frames costs one write instead of one for each, the frame boundaries are kept as encoded by the codec.
+// The other outbound messages and the inbound messages are handled by the codec directly.
+func BatchCodec(frameCodec codec.Codec) codec.Codec {
+	utils.AssertIf(nil == frameCodec, "codec is required")
+	return &batchCodec{Codec: frameCodec}
+}
+
+type batchCodec struct {
+	codec.Codec
+}
+
+func (b *batchCodec) CodecName() string {
+	return "batch-" + b.Codec.CodecName()
+}
+
+func (b *batchCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	batch, ok := message.(Batch)
+	if !ok {
+		b.Codec.HandleWrite(ctx, message)
+		return
+	}
+
+	if 0 == len(batch) {
+		return
+	}
+
+	collector := &batchContext{OutboundContext: ctx}
+	for _, m := range batch {
+		b.Codec.HandleWrite(collector, m)
+	}
+	ctx.HandleWrite(collector.buffer.Bytes())
+}
+
+// batchContext collects the encoded frames instead of writing them to the next handler.
+type batchContext struct {
+	netty.OutboundContext
+	buffer bytes.Buffer
+}
+
+func (b *batchContext) HandleWrite(message netty.Message) {
+	switch m := message.(type) {
+	case []byte:
+		b.buffer.Write(m)
+	case [][]byte:
+		for _, buf := range m {
+			b.buffer.Write(buf)
+		}
+	default:
+		b.buffer.Write(utils.MustToBytes(m))
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestBatchCodec(t *testing.T) {
+
+	codec := BatchCodec(DelimiterCodec(1024, "\n", true))
+
+	var writes [][]byte
+	var decoded []string
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			decoded = append(decoded, string(utils.MustToBytes(message)))
+		},
+		MockHandleWrite: func(message netty.Message) {
+			writes = append(writes, utils.MustToBytes(message))
+		},
+	}
+
+	// the frames of batch are written once.
+	codec.HandleWrite(ctx, Batch{"a", []byte("bb"), bytes.NewBufferString("ccc")})
+	if 1 != len(writes) || "a\nbb\nccc\n" != string(writes[0]) {
+		t.Fatalf("unexpected writes: %q", writes)
+	}
+
+	// the frame boundaries are kept.
+	reader := bytes.NewReader(writes[0])
+	for reader.Len() > 0 {
+		codec.HandleRead(ctx, reader)
+	}
+	if 3 != len(decoded) || "a" != decoded[0] || "bb" != decoded[1] || "ccc" != decoded[2] {
+		t.Fatalf("unexpected frames: %q", decoded)
+	}
+
+	// the other messages are written directly.
+	codec.HandleWrite(ctx, "d")
+	if 2 != len(writes) || "d\n" != string(writes[1]) {
+		t.Fatalf("unexpected writes: %q", writes)
+	}
+
+	// empty batch
+	codec.HandleWrite(ctx, Batch{})
+	if 2 != len(writes) {
+		t.Fatalf("unexpected writes: %q", writes)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrChecksumMismatch is raised if the checksum of frame is mismatched.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumAlgo defines the 32 bits checksum algorithm of ChecksumCodec
+type ChecksumAlgo int
+
+const (
+	// CRC32C the crc32 of Castagnoli polynomial, hardware accelerated on most platforms.
+	CRC32C ChecksumAlgo = iota
+	// CRC32 the crc32 of IEEE polynomial
+	CRC32
+	// Adler32 checksum
+	Adler32
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumCodec create a codec to append the 4 bytes checksum to each outbound frame, and verify & strip it
+// from each inbound frame, so it should be placed after a frame codec. The mismatched frame raises
+// ErrChecksumMismatch to the exception handlers, or closes the channel directly if closeOnMismatch is true.
+// the checksum is big endian unless WithByteOrder is given.
+func ChecksumCodec(algo ChecksumAlgo, closeOnMismatch bool, option ...DecoderOption) codec.Codec {
+	var newHash func() hash.Hash32
+	switch algo {
+	case CRC32C:
+		newHash = func() hash.Hash32 { return crc32.New(castagnoliTable) }
+	case CRC32:
+		newHash = crc32.NewIEEE
+	case Adler32:
+		newHash = adler32.New
+	default:
+		utils.Assert(fmt.Errorf("unsupported checksum algo: %d", algo))
+	}
+	return &checksumCodec{newHash: newHash, closeOnMismatch: closeOnMismatch, byteOrder: newDecoderOptions(option...).byteOrder}
+}
+
+type checksumCodec struct {
+	newHash         func() hash.Hash32
+	closeOnMismatch bool
+	byteOrder       binary.ByteOrder
+}
+
+func (*checksumCodec) CodecName() string {
+	return "checksum-codec"
+}
+
+func (c *checksumCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	frame := utils.MustToBytes(message)
+	utils.AssertIf(len(frame) < 4, "frame too short to contain checksum: %d", len(frame))
+
+	body, checksum := frame[:len(frame)-4], c.byteOrder.Uint32(frame[len(frame)-4:])
+
+	h := c.newHash()
+	_, _ = h.Write(body)
+	if sum := h.Sum32(); sum != checksum {
+		err := fmt.Errorf("%w: expect 0x%08x, got 0x%08x", ErrChecksumMismatch, checksum, sum)
+		triggerDecodeError(ctx, err)
+		if c.closeOnMismatch {
+			ctx.Close(err)
+			return
+		}
+		panic(err)
+	}
+
+	ctx.HandleRead(body)
+}
+
+func (c *checksumCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	body := utils.MustToBytes(message)
+
+	h := c.newHash()
+	_, _ = h.Write(body)
+
+	checksum := make([]byte, 4)
+	c.byteOrder.PutUint32(checksum, h.Sum32())
+
+	// BODY | CHECKSUM
+	ctx.HandleWrite([][]byte{body, checksum})
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestChecksumCodec(t *testing.T) {
+
+	for _, algo := range []ChecksumAlgo{CRC32C, CRC32, Adler32} {
+		codec := ChecksumCodec(algo, false)
+
+		var frame []byte
+		var decoded []byte
+		ctx := MockHandlerContext{
+			MockHandleRead: func(message netty.Message) {
+				decoded = utils.MustToBytes(message)
+			},
+			MockHandleWrite: func(message netty.Message) {
+				frame = utils.MustToBytes(message)
+			},
+		}
+
+		codec.HandleWrite(ctx, []byte("go-netty"))
+		if 4+len("go-netty") != len(frame) {
+			t.Fatal("unexpected frame length:", len(frame))
+		}
+
+		codec.HandleRead(ctx, frame)
+		if !bytes.Equal([]byte("go-netty"), decoded) {
+			t.Fatalf("unexpected decoded: %q", decoded)
+		}
+
+		// corrupt a byte in transit.
+		frame[2] ^= 0xff
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrChecksumMismatch) {
+					t.Fatal(algo, "unexpected error:", err)
+				}
+			}()
+			codec.HandleRead(ctx, frame)
+		}()
+	}
+}
+
+func TestChecksumCodecCloseOnMismatch(t *testing.T) {
+
+	codec := ChecksumCodec(CRC32C, true)
+
+	var frame []byte
+	var closeErr error
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			t.Fatal("corrupted frame should not be decoded")
+		},
+		MockHandleWrite: func(message netty.Message) {
+			frame = utils.MustToBytes(message)
+		},
+		MockClose: func(err error) {
+			closeErr = err
+		},
+	}
+
+	codec.HandleWrite(ctx, []byte("go-netty"))
+	frame[len(frame)-1] ^= 0x01
+	codec.HandleRead(ctx, frame)
+
+	if !errors.Is(closeErr, ErrChecksumMismatch) {
+		t.Fatal("unexpected close error:", closeErr)
+	}
+}
+
+func TestChecksumCodecByteOrder(t *testing.T) {
+
+	sum := crc32.ChecksumIEEE([]byte("go-netty"))
+
+	var cases = []struct {
+		byteOrder binary.ByteOrder
+		checksum  []byte
+	}{
+		{byteOrder: binary.BigEndian, checksum: []byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8), byte(sum)}},
+		{byteOrder: binary.LittleEndian, checksum: []byte{byte(sum), byte(sum >> 8), byte(sum >> 16), byte(sum >> 24)}},
+		// network byte order by default.
+		{byteOrder: nil, checksum: []byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8), byte(sum)}},
+	}
+
+	for _, c := range cases {
+		codec := ChecksumCodec(CRC32, false, WithByteOrder(c.byteOrder))
+
+		var frame []byte
+		var decoded []byte
+		ctx := MockHandlerContext{
+			MockHandleRead: func(message netty.Message) {
+				decoded = utils.MustToBytes(message)
+			},
+			MockHandleWrite: func(message netty.Message) {
+				frame = utils.MustToBytes(message)
+			},
+		}
+
+		codec.HandleWrite(ctx, []byte("go-netty"))
+		if checksum := frame[len(frame)-4:]; !bytes.Equal(c.checksum, checksum) {
+			t.Fatalf("%v: checksum %x, want: %x", c.byteOrder, checksum, c.checksum)
+		}
+
+		codec.HandleRead(ctx, frame)
+		if !bytes.Equal([]byte("go-netty"), decoded) {
+			t.Fatalf("unexpected decoded: %q", decoded)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// COBSCodec create a codec of Consistent Overhead Byte Stuffing, the outbound frames are encoded without
+// zero bytes and delimited by a zero byte, the inbound frames are decoded back to the raw bytes, the empty
+// frames between the zero delimiters are skipped, maxFrameLength limits the length of the encoded frames.
+func COBSCodec(maxFrameLength int, option ...DecoderOption) codec.Codec {
+	utils.AssertIf(maxFrameLength <= 0, "maxFrameLength must be a positive integer")
+	return &cobsCodec{maxFrameLength: maxFrameLength, options: newDecoderOptions(option...)}
+}
+
+type cobsCodec struct {
+	maxFrameLength int
+	options        decoderOptions
+}
+
+func (*cobsCodec) CodecName() string {
+	return "cobs-codec"
+}
+
+func (c *cobsCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	reader := utils.MustToReader(message)
+
+	readBuff := make([]byte, 0, 16)
+	tempBuff := make([]byte, 1)
+	for len(readBuff) < c.maxFrameLength {
+		if 0 == utils.AssertLength(reader.Read(tempBuff)) {
+			continue
+		}
+
+		if 0 != tempBuff[0] {
+			readBuff = append(readBuff, tempBuff[0])
+			continue
+		}
+
+		// skip the empty frame.
+		if 0 == len(readBuff) {
+			continue
+		}
+
+		frame, err := cobsDecode(readBuff)
+		if nil != err {
+			// the bad frame is consumed already.
+			c.options.fail(ctx, err, func() {})
+			return
+		}
+
+		ctx.HandleRead(bytes.NewReader(frame))
+		return
+	}
+
+	err := fmt.Errorf("%w: readBuffLength(%d) >= maxFrameLength(%d)", ErrTooLongFrame, len(readBuff), c.maxFrameLength)
+	c.options.fail(ctx, err, func() {
+		c.discard(reader)
+	})
+}
+
+// discard to skip the bytes until the zero delimiter.
+func (c *cobsCodec) discard(reader io.Reader) {
+	tempBuff := make([]byte, 1)
+	for {
+		if n := utils.AssertLength(reader.Read(tempBuff)); n > 0 && 0 == tempBuff[0] {
+			return
+		}
+	}
+}
+
+func (c *cobsCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	ctx.HandleWrite(cobsEncode(utils.MustToBytes(message)))
+}
+
+// cobsEncode to encode the data with the trailing zero delimiter, each block starts with a code byte
+// which is the distance to the next zero, 0xFF for a block of 254 non-zero bytes without the zero.
+func cobsEncode(data []byte) []byte {
+	encoded := make([]byte, 1, len(data)+len(data)/254+2)
+
+	codeIndex, code := 0, byte(1)
+	for i, b := range data {
+		if 0 == b {
+			encoded[codeIndex] = code
+			codeIndex, code = len(encoded), 1
+			encoded = append(encoded, 0)
+			continue
+		}
+
+		encoded = append(encoded, b)
+		if code++; 0xFF == code && i < len(data)-1 {
+			encoded[codeIndex] = code
+			codeIndex, code = len(encoded), 1
+			encoded = append(encoded, 0)
+		}
+	}
+
+	encoded[codeIndex] = code
+	return append(encoded, 0)
+}
+
+// cobsDecode to decode the data without the zero delimiter.
+func cobsDecode(data []byte) ([]byte, error) {
+	decoded := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); {
+		code := int(data[i])
+		if 0 == code || i+code > len(data) {
+			return nil, fmt.Errorf("%w: invalid cobs code %d at %d", ErrCorruptedFrame, code, i)
+		}
+
+		decoded = append(decoded, data[i+1:i+code]...)
+		if i += code; 0xFF != code && i < len(data) {
+			decoded = append(decoded, 0)
+		}
+	}
+	return decoded, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// sequence returns the bytes from 1 to n, wrapped to 1 after 255.
+func sequence(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i%255 + 1)
+	}
+	return data
+}
+
+func TestCOBSEncode(t *testing.T) {
+
+	var cases = []struct {
+		input   []byte
+		encoded []byte
+	}{
+		{input: []byte{}, encoded: []byte{0x01, 0x00}},
+		{input: []byte{0x00}, encoded: []byte{0x01, 0x01, 0x00}},
+		{input: []byte{0x00, 0x00}, encoded: []byte{0x01, 0x01, 0x01, 0x00}},
+		{input: []byte{0x00, 0x11, 0x00}, encoded: []byte{0x01, 0x02, 0x11, 0x01, 0x00}},
+		{input: []byte{0x11, 0x22, 0x00, 0x33}, encoded: []byte{0x03, 0x11, 0x22, 0x02, 0x33, 0x00}},
+		{input: []byte{0x11, 0x00, 0x00, 0x00}, encoded: []byte{0x02, 0x11, 0x01, 0x01, 0x01, 0x00}},
+		// 254 non-zero bytes fit in one block.
+		{input: sequence(254), encoded: append(append([]byte{0xFF}, sequence(254)...), 0x00)},
+		{input: append([]byte{0x00}, sequence(254)...), encoded: append(append([]byte{0x01, 0xFF}, sequence(254)...), 0x00)},
+		{input: sequence(255), encoded: append(append(append([]byte{0xFF}, sequence(254)...), 0x02, 0xFF), 0x00)},
+		{input: append(sequence(254), 0x00), encoded: append(append([]byte{0xFF}, sequence(254)...), 0x01, 0x01, 0x00)},
+	}
+
+	for index, c := range cases {
+		if encoded := cobsEncode(c.input); !bytes.Equal(c.encoded, encoded) {
+			t.Fatalf("#%d encoded: %x, want: %x", index, encoded, c.encoded)
+		}
+
+		if bytes.IndexByte(c.encoded, 0) != len(c.encoded)-1 {
+			t.Fatalf("#%d zero byte in the encoded frame", index)
+		}
+
+		decoded, err := cobsDecode(c.encoded[:len(c.encoded)-1])
+		if nil != err {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(c.input, decoded) {
+			t.Fatalf("#%d decoded: %x, want: %x", index, decoded, c.input)
+		}
+	}
+}
+
+func TestCOBSCodec(t *testing.T) {
+
+	payloads := [][]byte{
+		[]byte("hello"),
+		make([]byte, 600),
+		sequence(1000),
+		append(append(sequence(300), make([]byte, 300)...), sequence(300)...),
+		{0x00},
+	}
+
+	codec := COBSCodec(2048)
+
+	var stream []byte
+	for _, payload := range payloads {
+		codec.HandleWrite(MockHandlerContext{
+			MockHandleWrite: func(message netty.Message) {
+				stream = append(stream, utils.MustToBytes(message)...)
+			},
+		}, payload)
+	}
+
+	// the empty frames are skipped.
+	stream = append([]byte{0x00, 0x00}, stream...)
+
+	// the frames are split across reads.
+	var decoded [][]byte
+	reader := iotest.OneByteReader(bytes.NewReader(stream))
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			decoded = append(decoded, utils.MustToBytes(message))
+		},
+	}
+	for range payloads {
+		codec.HandleRead(ctx, reader)
+	}
+
+	for index, payload := range payloads {
+		if !bytes.Equal(payload, decoded[index]) {
+			t.Fatalf("#%d decoded: %x, want: %x", index, decoded[index], payload)
+		}
+	}
+}
+
+func TestCOBSCodecError(t *testing.T) {
+
+	var cases = []struct {
+		input    []byte
+		strategy DecoderErrorStrategy
+		decoded  []string
+		closed   error
+		raised   error
+	}{
+		{input: []byte("\x09too long\x00\x03ok\x00"), strategy: FailFast, raised: ErrTooLongFrame},
+		{input: []byte("\x09too long\x00\x03ok\x00"), strategy: CloseOnError, closed: ErrTooLongFrame},
+		{input: []byte("\x09too long\x00\x03ok\x00"), strategy: DiscardAndContinue, decoded: []string{"ok"}},
+		{input: []byte("\x05ok\x00\x03ok\x00"), strategy: FailFast, raised: ErrCorruptedFrame},
+		{input: []byte("\x05ok\x00\x03ok\x00"), strategy: DiscardAndContinue, decoded: []string{"ok"}},
+	}
+
+	for index, c := range cases {
+		t.Run(fmt.Sprint("cobs#", index), func(t *testing.T) {
+			decoded, closed, raised := decodeAll(COBSCodec(4, WithErrorStrategy(c.strategy)), c.input, 2)
+			if fmt.Sprint(c.decoded) != fmt.Sprint(decoded) {
+				t.Fatal("decoded:", decoded, "want:", c.decoded)
+			}
+			if !errors.Is(closed, c.closed) || (nil == c.closed) != (nil == closed) {
+				t.Fatal("closed:", closed, "want:", c.closed)
+			}
+			if !errors.Is(raised, c.raised) || (nil == c.raised) != (nil == raised) {
+				t.Fatal("raised:", raised, "want:", c.raised)
+			}
+		})
+	}
+}
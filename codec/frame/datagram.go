@@ -0,0 +1,60 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/transport/udp"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// DatagramCodec create a codec of udp.Datagram for the channel of udp transport, the inbound datagrams are
+// decoded as udp.Datagram with the remote address, and the outbound udp.Datagram is sent to the address,
+// the other outbound messages are passed to the next handler and sent to the remote address of channel.
+func DatagramCodec() codec.Codec {
+	return datagramCodec{}
+}
+
+type datagramCodec struct{}
+
+func (datagramCodec) CodecName() string {
+	return "datagram-codec"
+}
+
+func (datagramCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+	datagram, err := packetTransport(ctx.Channel()).ReadDatagram()
+	utils.Assert(err)
+	ctx.HandleRead(datagram)
+}
+
+func (datagramCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	switch m := message.(type) {
+	case udp.Datagram:
+		utils.Assert(packetTransport(ctx.Channel()).WriteDatagram(m))
+	case *udp.Datagram:
+		utils.Assert(packetTransport(ctx.Channel()).WriteDatagram(*m))
+	default:
+		ctx.HandleWrite(message)
+	}
+}
+
+func packetTransport(ch netty.Channel) udp.PacketTransport {
+	t, ok := ch.Transport().(udp.PacketTransport)
+	utils.AssertIf(!ok, "datagram codec requires udp transport, got: %T", ch.Transport())
+	return t
+}
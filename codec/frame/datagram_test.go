@@ -0,0 +1,70 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/transport/udp"
+)
+
+func TestDatagramCodec(t *testing.T) {
+
+	// echo the datagrams in upper case to their sources.
+	bs := netty.NewBootstrap(netty.WithTransport(udp.New()), netty.WithChildInitializer(func(ch netty.Channel) {
+		ch.Pipeline().AddLast(DatagramCodec(), netty.InboundHandlerFunc(func(ctx netty.InboundContext, message netty.Message) {
+			datagram := message.(udp.Datagram)
+			ctx.Write(udp.Datagram{Addr: datagram.Addr, Payload: bytes.ToUpper(datagram.Payload)})
+		}))
+	}))
+	defer bs.Shutdown()
+
+	bs.Listen("udp://127.0.0.1:9541").Async(func(err error) {})
+
+	addr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9541")
+	for _, message := range []string{"hello", "world"} {
+		conn, err := net.DialUDP("udp", nil, addr)
+		if nil != err {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		buffer := make([]byte, 16)
+		// the listener may not be ready.
+		for i := 0; ; i++ {
+			if _, err := conn.Write([]byte(message)); nil != err {
+				t.Fatal(err)
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(time.Millisecond * 100))
+			n, err := conn.Read(buffer)
+			if nil == err {
+				if want := bytes.ToUpper([]byte(message)); !bytes.Equal(want, buffer[:n]) {
+					t.Fatalf("unexpected reply: %q, want: %q", buffer[:n], want)
+				}
+				break
+			}
+			if i > 20 {
+				t.Fatal(err)
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+}
@@ -27,13 +27,14 @@ import (
 )
 
 // DelimiterCodec create delimiter codec
-func DelimiterCodec(maxFrameLength int, delimiter string, stripDelimiter bool) codec.Codec {
+func DelimiterCodec(maxFrameLength int, delimiter string, stripDelimiter bool, option ...DecoderOption) codec.Codec {
 	utils.AssertIf(maxFrameLength <= 0, "maxFrameLength must be a positive integer")
 	utils.AssertIf(len(delimiter) <= 0, "delimiter must be nonempty string")
 	return &delimiterCodec{
 		maxFrameLength: maxFrameLength,
 		delimiter:      []byte(delimiter),
 		stripDelimiter: stripDelimiter,
+		options:        newDecoderOptions(option...),
 	}
 }
 
@@ -41,6 +42,7 @@ type delimiterCodec struct {
 	maxFrameLength int
 	delimiter      []byte
 	stripDelimiter bool
+	options        decoderOptions
 }
 
 func (*delimiterCodec) CodecName() string {
@@ -75,9 +77,34 @@ func (d *delimiterCodec) HandleRead(ctx netty.InboundContext, message netty.Mess
 		}
 	}
 
-	utils.Assert(fmt.Errorf("frame length too large, readBuffLength(%d) >= maxFrameLength(%d)",
-		len(readBuff), d.maxFrameLength))
+	err := fmt.Errorf("%w: readBuffLength(%d) >= maxFrameLength(%d)", ErrTooLongFrame, len(readBuff), d.maxFrameLength)
+	d.options.fail(ctx, err, func() {
+		// the delimiter may be read partially.
+		tail := len(readBuff) - len(d.delimiter) + 1
+		if tail < 0 {
+			tail = 0
+		}
+		d.discard(reader, readBuff[tail:])
+	})
+}
+
+// discard to skip the bytes until the delimiter, tail is the last bytes read.
+func (d *delimiterCodec) discard(reader io.Reader, tail []byte) {
+	window := append(make([]byte, 0, len(d.delimiter)), tail...)
+	tempBuff := make([]byte, 1)
+	for {
+		if 0 == utils.AssertLength(reader.Read(tempBuff)) {
+			continue
+		}
 
+		if window = append(window, tempBuff[0]); len(window) > len(d.delimiter) {
+			window = window[1:]
+		}
+
+		if bytes.Equal(d.delimiter, window) {
+			return
+		}
+	}
 }
 
 func (d *delimiterCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrHeaderMismatch is raised if the inbound frame does not start with the expected header.
+var ErrHeaderMismatch = errors.New("header mismatch")
+
+// HeaderCodec create a codec to prepend the fixed header to each outbound frame, and strip it from each
+// inbound frame, so it should be placed after a frame codec. The header of inbound frame is verified if
+// validateInbound is true, the mismatched frame raises ErrHeaderMismatch to the exception handlers.
+func HeaderCodec(header []byte, validateInbound bool) codec.Codec {
+	utils.AssertIf(0 == len(header), "header must not be empty")
+	return &headerCodec{header: append([]byte(nil), header...), validateInbound: validateInbound}
+}
+
+type headerCodec struct {
+	header          []byte
+	validateInbound bool
+}
+
+func (*headerCodec) CodecName() string {
+	return "header-codec"
+}
+
+func (h *headerCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	frame := utils.MustToBytes(message)
+	utils.AssertIf(len(frame) < len(h.header), "frame too short to contain header: %d", len(frame))
+
+	if h.validateInbound && !bytes.Equal(h.header, frame[:len(h.header)]) {
+		err := fmt.Errorf("%w: expect %x, got %x", ErrHeaderMismatch, h.header, frame[:len(h.header)])
+		triggerDecodeError(ctx, err)
+		panic(err)
+	}
+
+	ctx.HandleRead(frame[len(h.header):])
+}
+
+func (h *headerCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	// HEADER | BODY
+	ctx.HandleWrite([][]byte{h.header, utils.MustToBytes(message)})
+}
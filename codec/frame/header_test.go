@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestHeaderCodec(t *testing.T) {
+
+	codec := HeaderCodec([]byte{0xCA, 0xFE}, true)
+
+	var frame []byte
+	var decoded []byte
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			decoded = utils.MustToBytes(message)
+		},
+		MockHandleWrite: func(message netty.Message) {
+			frame = utils.MustToBytes(message)
+		},
+	}
+
+	codec.HandleWrite(ctx, []byte("go-netty"))
+	if !bytes.Equal(append([]byte{0xCA, 0xFE}, "go-netty"...), frame) {
+		t.Fatalf("unexpected frame: %x", frame)
+	}
+
+	codec.HandleRead(ctx, frame)
+	if !bytes.Equal([]byte("go-netty"), decoded) {
+		t.Fatalf("unexpected decoded: %q", decoded)
+	}
+
+	// the header is the only content.
+	codec.HandleRead(ctx, []byte{0xCA, 0xFE})
+	if 0 != len(decoded) {
+		t.Fatalf("unexpected decoded: %q", decoded)
+	}
+}
+
+func TestHeaderCodecMismatch(t *testing.T) {
+
+	var cases = []struct {
+		frame []byte
+		err   error
+	}{
+		{frame: []byte{0xCA, 0xFF, 'o', 'k'}, err: ErrHeaderMismatch},
+		{frame: []byte{0xCA}},
+	}
+
+	codec := HeaderCodec([]byte{0xCA, 0xFE}, true)
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			t.Fatal("invalid frame should not be decoded")
+		},
+	}
+
+	for _, c := range cases {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if nil == err || (nil != c.err && !errors.Is(err, c.err)) {
+					t.Fatalf("%x: unexpected error: %v", c.frame, err)
+				}
+			}()
+			codec.HandleRead(ctx, c.frame)
+		}()
+	}
+
+	// the header is stripped without validation.
+	var decoded []byte
+	ctx.MockHandleRead = func(message netty.Message) {
+		decoded = utils.MustToBytes(message)
+	}
+	HeaderCodec([]byte{0xCA, 0xFE}, false).HandleRead(ctx, []byte{0xCA, 0xFF, 'o', 'k'})
+	if "ok" != string(decoded) {
+		t.Fatalf("unexpected decoded: %q", decoded)
+	}
+}
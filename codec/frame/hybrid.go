@@ -0,0 +1,171 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ControlFrame is a delimited control frame of HybridFrameCodec, it is written with the delimiter
+// appended, and read with the delimiter stripped.
+type ControlFrame []byte
+
+// AmbiguityRule decides the frame of HybridFrameCodec if the delimiter ends at the last byte of the length field.
+type AmbiguityRule int
+
+const (
+	// PreferControl decodes the bytes as a control frame, it is the default.
+	PreferControl AmbiguityRule = iota
+	// PreferLength decodes the bytes as the length field of a data frame.
+	PreferLength
+)
+
+// HybridFrameOptions defines the frame format of HybridFrameCodec
+type HybridFrameOptions struct {
+	// LengthFieldLength is the length of the length field of data frames, 1, 2, 4 or 8, the value
+	// of the field is the length of the body following it.
+	LengthFieldLength int `json:"lengthFieldLength"`
+	// MaxFrameLength is the max length of the body of data frames and the control frames.
+	MaxFrameLength int `json:"maxFrameLength"`
+	// Delimiter terminates the control frames.
+	Delimiter string `json:"delimiter"`
+	// ControlPrefix marks the control frames longer than the length field if not empty, the frame
+	// starts with it is read until the delimiter, it is a part of the control frame.
+	ControlPrefix string `json:"controlPrefix"`
+	// Ambiguity is the rule if the delimiter ends at the last byte of the length field.
+	Ambiguity AmbiguityRule `json:"ambiguity"`
+}
+
+// HybridFrameCodec create a codec of the length-prefixed data frames mixed with the delimited control
+// frames, the frame is decided by the leading bytes in order:
+//  1. a frame starts with the ControlPrefix is a control frame.
+//  2. the delimiter appears before the length field is complete, the bytes are a control frame.
+//  3. the delimiter ends at the last byte of the length field, decided by the AmbiguityRule.
+//  4. otherwise, the bytes are the length field of a data frame.
+//
+// the data frames are read as io.Reader and the control frames as ControlFrame, the ControlFrame is written
+// as a control frame and the others are written as data frames.
+func HybridFrameCodec(options HybridFrameOptions, option ...DecoderOption) codec.Codec {
+	utils.AssertIf(options.MaxFrameLength <= 0, "maxFrameLength must be a positive integer")
+	utils.AssertIf(len(options.Delimiter) <= 0, "delimiter must be nonempty string")
+	utils.AssertIf(options.LengthFieldLength != 1 && options.LengthFieldLength != 2 &&
+		options.LengthFieldLength != 4 && options.LengthFieldLength != 8, "lengthFieldLength must be either 1, 2, 4, or 8")
+	utils.AssertIf(len(options.ControlPrefix) > options.LengthFieldLength, "controlPrefix must not be longer than the length field")
+
+	decoderOptions := newDecoderOptions(option...)
+	return &hybridFrameCodec{
+		options:         options,
+		delimiter:       []byte(options.Delimiter),
+		prefix:          []byte(options.ControlPrefix),
+		decoderOptions:  decoderOptions,
+		OutboundHandler: LengthFieldPrepender(decoderOptions.byteOrder, options.LengthFieldLength, 0, false),
+	}
+}
+
+type hybridFrameCodec struct {
+	options        HybridFrameOptions
+	delimiter      []byte
+	prefix         []byte
+	decoderOptions decoderOptions
+
+	// encoder of data frames
+	netty.OutboundHandler
+}
+
+func (*hybridFrameCodec) CodecName() string {
+	return "hybrid-frame-codec"
+}
+
+func (h *hybridFrameCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	// unwrap to reader
+	reader := utils.MustToReader(message)
+
+	header := make([]byte, 0, h.options.LengthFieldLength)
+	tempBuff := make([]byte, 1)
+	for len(header) < h.options.LengthFieldLength {
+		if 0 == utils.AssertLength(reader.Read(tempBuff)) {
+			continue
+		}
+		header = append(header, tempBuff[0])
+
+		if len(h.prefix) > 0 && bytes.Equal(h.prefix, header) {
+			h.readControl(ctx, reader, header)
+			return
+		}
+
+		if bytes.HasSuffix(header, h.delimiter) &&
+			(len(header) < h.options.LengthFieldLength || PreferControl == h.options.Ambiguity) {
+			ctx.HandleRead(ControlFrame(header[:len(header)-len(h.delimiter)]))
+			return
+		}
+	}
+
+	frameLength := unpackFieldLength(h.decoderOptions.byteOrder, h.options.LengthFieldLength, header)
+	if frameLength < 0 {
+		h.decoderOptions.fail(ctx, fmt.Errorf("%w: negative length field: %d", ErrCorruptedFrame, frameLength), nil)
+		return
+	}
+
+	if frameLength > int64(h.options.MaxFrameLength) {
+		h.decoderOptions.fail(ctx, fmt.Errorf("%w: frameLength(%d) > maxFrameLength(%d)",
+			ErrTooLongFrame, frameLength, h.options.MaxFrameLength), func() {
+			n, err := io.CopyN(ioutil.Discard, reader, frameLength)
+			utils.AssertIf(nil != err, "discard frame: %d -> %d, %w", frameLength, n, err)
+		})
+		return
+	}
+
+	ctx.HandleRead(io.LimitReader(reader, frameLength))
+}
+
+// readControl to read the prefixed control frame until the delimiter.
+func (h *hybridFrameCodec) readControl(ctx netty.InboundContext, reader io.Reader, frame []byte) {
+	tempBuff := make([]byte, 1)
+	for len(frame) < h.options.MaxFrameLength+len(h.delimiter) {
+		if 0 == utils.AssertLength(reader.Read(tempBuff)) {
+			continue
+		}
+
+		if frame = append(frame, tempBuff[0]); bytes.HasSuffix(frame, h.delimiter) {
+			ctx.HandleRead(ControlFrame(frame[:len(frame)-len(h.delimiter)]))
+			return
+		}
+	}
+
+	// reuse the discarding of delimiter codec.
+	d := &delimiterCodec{delimiter: h.delimiter}
+	h.decoderOptions.fail(ctx, fmt.Errorf("%w: control frame >= maxFrameLength(%d)", ErrTooLongFrame, h.options.MaxFrameLength), func() {
+		tail := len(frame) - len(h.delimiter) + 1
+		d.discard(reader, frame[tail:])
+	})
+}
+
+func (h *hybridFrameCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	if frame, ok := message.(ControlFrame); ok {
+		ctx.HandleWrite([][]byte{frame, h.delimiter})
+		return
+	}
+	h.OutboundHandler.HandleWrite(ctx, message)
+}
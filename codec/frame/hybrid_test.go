@@ -0,0 +1,131 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// encodeFrames to write the messages by the codec into a stream.
+func encodeFrames(c codec.Codec, messages ...netty.Message) *bytes.Reader {
+	var stream bytes.Buffer
+	ctx := MockHandlerContext{
+		MockHandleWrite: func(message netty.Message) {
+			stream.Write(utils.MustToBytes(message))
+		},
+	}
+	for _, message := range messages {
+		c.HandleWrite(ctx, message)
+	}
+	return bytes.NewReader(stream.Bytes())
+}
+
+// decodeFrames to read the frames until the end of stream, the data frames are read as []byte.
+func decodeFrames(c codec.Codec, stream *bytes.Reader) (frames []netty.Message, err error) {
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			if reader, ok := message.(io.Reader); ok {
+				message = utils.MustToBytes(reader)
+			}
+			frames = append(frames, message)
+		},
+	}
+
+	defer func() {
+		if e := recover(); nil != e && !errors.Is(e.(error), io.EOF) {
+			err = e.(error)
+		}
+	}()
+
+	for stream.Len() > 0 {
+		c.HandleRead(ctx, stream)
+	}
+	return
+}
+
+func TestHybridFrameCodec(t *testing.T) {
+
+	codec := HybridFrameCodec(HybridFrameOptions{LengthFieldLength: 4, MaxFrameLength: 1024, Delimiter: "\r\n", ControlPrefix: "#"})
+
+	messages := []netty.Message{
+		[]byte("hello"),
+		// shorter than the length field.
+		ControlFrame("A"),
+		// the delimiter ends at the last byte of the length field.
+		ControlFrame("OK"),
+		// longer than the length field.
+		ControlFrame("#STATUS ready"),
+		[]byte(""),
+		[]byte("world"),
+		ControlFrame(""),
+	}
+
+	frames, err := decodeFrames(codec, encodeFrames(codec, messages...))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(messages, frames) {
+		t.Fatalf("%q != %q", frames, messages)
+	}
+}
+
+func TestHybridFrameCodecAmbiguity(t *testing.T) {
+
+	// the length field of the data frame is \r\n.
+	data := bytes.Repeat([]byte("x"), 0x0d0a)
+
+	var cases = []struct {
+		rule  AmbiguityRule
+		first netty.Message
+	}{
+		{rule: PreferLength, first: data},
+		{rule: PreferControl, first: ControlFrame("")},
+	}
+
+	for _, c := range cases {
+		codec := HybridFrameCodec(HybridFrameOptions{LengthFieldLength: 2, MaxFrameLength: len(data), Delimiter: "\r\n", Ambiguity: c.rule})
+		frames, _ := decodeFrames(codec, encodeFrames(codec, data, ControlFrame("#")))
+		if 0 == len(frames) || !reflect.DeepEqual(c.first, frames[0]) {
+			t.Fatalf("rule %d: unexpected first frame: %.16q", c.rule, frames)
+		}
+	}
+}
+
+func TestHybridFrameCodecTooLong(t *testing.T) {
+
+	codec := HybridFrameCodec(HybridFrameOptions{LengthFieldLength: 1, MaxFrameLength: 4, Delimiter: "\n", ControlPrefix: "#"},
+		WithErrorStrategy(DiscardAndContinue))
+
+	stream := bytes.NewReader(append([]byte{5}, "12345\x02ok#too long\n#ok\n"...))
+	frames, err := decodeFrames(codec, stream)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if want := []netty.Message{[]byte("ok"), ControlFrame("#ok")}; !reflect.DeepEqual(want, frames) {
+		t.Fatalf("%q != %q", frames, want)
+	}
+}
@@ -30,12 +30,13 @@ import (
 
 // LengthFieldCodec create a length field based codec
 func LengthFieldCodec(
-	byteOrder binary.ByteOrder, // 字节序，大端 & 小端
+	byteOrder binary.ByteOrder, // 字节序，大端 & 小端，nil 为网络字节序（大端）
 	maxFrameLength int, // 最大允许数据包长度
 	lengthFieldOffset int, // 长度域的偏移量，表示跳过指定长度个字节之后的才是长度域
 	lengthFieldLength int, // 记录该帧数据长度的字段本身的长度 1, 2, 4, 8
 	lengthAdjustment int, // 包体长度调整的大小，长度域的数值表示的长度加上这个修正值表示的就是带header的包长度
 	initialBytesToStrip int, // 拿到一个完整的数据包之后向业务解码器传递之前，应该跳过多少字节
+	option ...DecoderOption, // 解码错误的处理策略
 ) codec.Codec {
 	utils.AssertIf(maxFrameLength <= 0, "maxFrameLength must be a positive integer")
 	utils.AssertIf(lengthFieldOffset < 0, "lengthFieldOffset must be a non-negative integer")
@@ -45,6 +46,7 @@ func LengthFieldCodec(
 	utils.AssertIf(lengthFieldOffset > maxFrameLength-lengthFieldLength,
 		"maxFrameLength must be equal to or greater than lengthFieldOffset + lengthFieldLength")
 
+	byteOrder = byteOrderOf(byteOrder)
 	return &lengthFieldCodec{
 		byteOrder:           byteOrder,
 		maxFrameLength:      maxFrameLength,
@@ -52,6 +54,7 @@ func LengthFieldCodec(
 		lengthFieldLength:   lengthFieldLength,
 		lengthAdjustment:    lengthAdjustment,
 		initialBytesToStrip: initialBytesToStrip,
+		options:             newDecoderOptions(option...),
 		OutboundHandler:     LengthFieldPrepender(byteOrder, lengthFieldLength, 0, false),
 	}
 }
@@ -63,6 +66,7 @@ type lengthFieldCodec struct {
 	lengthFieldLength   int
 	lengthAdjustment    int
 	initialBytesToStrip int
+	options             decoderOptions
 
 	// default encoder
 	netty.OutboundHandler
@@ -80,27 +84,46 @@ func (l *lengthFieldCodec) HandleRead(ctx netty.InboundContext, message netty.Me
 	// lengthFieldEndOffset
 	lengthFieldEndOffset := l.lengthFieldOffset + l.lengthFieldLength
 
+	// the small header is read ahead from the stream of channel.
 	headerBuffer := make([]byte, lengthFieldEndOffset)
-	n, err := io.ReadFull(reader, headerBuffer)
+	err := netty.ReadFull(reader, headerBuffer)
 
-	utils.AssertIf(n != len(headerBuffer) || nil != err, "read header fail, headerLength: %d, read: %d, error: %w", len(headerBuffer), n, err)
+	utils.AssertIf(nil != err, "read header fail, headerLength: %d, error: %w", len(headerBuffer), err)
 
 	lengthFieldBuff := headerBuffer[l.lengthFieldOffset:lengthFieldEndOffset]
 
 	frameLength := unpackFieldLength(l.byteOrder, l.lengthFieldLength, lengthFieldBuff)
 
-	utils.AssertIf(frameLength < 0, "negative pre-adjustment length field: %d", frameLength)
+	if frameLength < 0 {
+		l.options.fail(ctx, fmt.Errorf("%w: negative pre-adjustment length field: %d", ErrCorruptedFrame, frameLength), nil)
+		return
+	}
 
 	frameLength += int64(l.lengthAdjustment + lengthFieldEndOffset)
 
-	utils.AssertIf(frameLength < int64(lengthFieldEndOffset),
-		"Adjusted frame length (%d) is less than lengthFieldEndOffset: %d", frameLength, lengthFieldEndOffset)
+	if frameLength < int64(lengthFieldEndOffset) {
+		l.options.fail(ctx, fmt.Errorf("%w: Adjusted frame length (%d) is less than lengthFieldEndOffset: %d",
+			ErrCorruptedFrame, frameLength, lengthFieldEndOffset), nil)
+		return
+	}
 
-	utils.AssertIf(frameLength > int64(l.maxFrameLength),
-		"Frame length too large, frameLength(%d) > maxFrameLength(%d)", frameLength, l.maxFrameLength)
+	// skip the body of the bad frame.
+	discard := func() {
+		n, err := io.CopyN(ioutil.Discard, reader, frameLength-int64(lengthFieldEndOffset))
+		utils.AssertIf(nil != err, "discard frame: %d -> %d, %w", frameLength, n, err)
+	}
+
+	if frameLength > int64(l.maxFrameLength) {
+		l.options.fail(ctx, fmt.Errorf("%w: frameLength(%d) > maxFrameLength(%d)",
+			ErrTooLongFrame, frameLength, l.maxFrameLength), discard)
+		return
+	}
 
-	utils.AssertIf(int64(l.initialBytesToStrip) > frameLength,
-		"Adjusted frame length (%d) is less than initialBytesToStrip: %d", frameLength, l.initialBytesToStrip)
+	if int64(l.initialBytesToStrip) > frameLength {
+		l.options.fail(ctx, fmt.Errorf("%w: Adjusted frame length (%d) is less than initialBytesToStrip: %d",
+			ErrCorruptedFrame, frameLength, l.initialBytesToStrip), discard)
+		return
+	}
 
 	frameReader := io.MultiReader(
 		// lengthFieldOffset + lengthFieldLength
@@ -136,17 +159,22 @@ func unpackFieldLength(byteOrder binary.ByteOrder, fieldLen int, buff []byte) (f
 
 func packFieldLength(byteOrder binary.ByteOrder, fieldLen int, dataLen int64) []byte {
 	lengthBuff := make([]byte, fieldLen)
+	putFieldLength(byteOrder, fieldLen, lengthBuff, dataLen)
+	return lengthBuff
+}
+
+// putFieldLength to encode the length field into buff.
+func putFieldLength(byteOrder binary.ByteOrder, fieldLen int, buff []byte, dataLen int64) {
 	switch fieldLen {
 	case 1:
-		lengthBuff[0] = byte(dataLen)
+		buff[0] = byte(dataLen)
 	case 2:
-		byteOrder.PutUint16(lengthBuff, uint16(dataLen))
+		byteOrder.PutUint16(buff, uint16(dataLen))
 	case 4:
-		byteOrder.PutUint32(lengthBuff, uint32(dataLen))
+		byteOrder.PutUint32(buff, uint32(dataLen))
 	case 8:
-		byteOrder.PutUint64(lengthBuff, uint64(dataLen))
+		byteOrder.PutUint64(buff, uint64(dataLen))
 	default:
 		utils.Assert(fmt.Errorf("should not reach here"))
 	}
-	return lengthBuff
 }
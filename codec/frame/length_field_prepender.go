@@ -18,13 +18,15 @@ package frame
 
 import (
 	"encoding/binary"
+
 	"github.com/mijingduI/go-netty"
 	"github.com/mijingduI/go-netty/utils"
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
 )
 
 // LengthFieldPrepender for LengthFieldCodec
 //
-// An encoder to prepends the length of the message
+// An encoder to prepends the length of the message, the byteOrder defaults to binary.BigEndian if nil.
 func LengthFieldPrepender(
 	byteOrder binary.ByteOrder,
 	lengthFieldLength int,
@@ -34,18 +36,34 @@ func LengthFieldPrepender(
 	utils.AssertIf(lengthFieldLength != 1 && lengthFieldLength != 2 &&
 		lengthFieldLength != 4 && lengthFieldLength != 8, "lengthFieldLength must be either 1, 2, 3, 4, or 8")
 	return &lengthFieldPrepender{
-		byteOrder:                       byteOrder,
+		byteOrder:                       byteOrderOf(byteOrder),
 		lengthFieldLength:               lengthFieldLength,
 		lengthAdjustment:                lengthAdjustment,
 		lengthIncludesLengthFieldLength: lengthIncludesLengthFieldLength,
 	}
 }
 
+// PooledLengthFieldPrepender create a LengthFieldPrepender which encodes the frame into a *pbytes.Buffer borrowed
+// from pool instead of allocating the length field, the buffer is released by the pipeline after written, so the
+// outbound handlers after it must Retain the buffer to hold it, and Release the buffer which is not passed on.
+// the reference counted message is released once it is copied into the frame.
+func PooledLengthFieldPrepender(
+	byteOrder binary.ByteOrder,
+	lengthFieldLength int,
+	lengthAdjustment int,
+	lengthIncludesLengthFieldLength bool,
+) netty.OutboundHandler {
+	prepender := LengthFieldPrepender(byteOrder, lengthFieldLength, lengthAdjustment, lengthIncludesLengthFieldLength).(*lengthFieldPrepender)
+	prepender.pooled = true
+	return prepender
+}
+
 type lengthFieldPrepender struct {
 	byteOrder                       binary.ByteOrder
 	lengthFieldLength               int
 	lengthAdjustment                int
 	lengthIncludesLengthFieldLength bool
+	pooled                          bool
 }
 
 func (l *lengthFieldPrepender) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
@@ -57,6 +75,20 @@ func (l *lengthFieldPrepender) HandleWrite(ctx netty.OutboundContext, message ne
 		length += l.lengthFieldLength
 	}
 
+	if l.pooled {
+		// HEAD | BODY in one buffer, which is released after written.
+		frame := pbytes.NewBuffer(l.lengthFieldLength + len(bodyBytes))
+		data := frame.Bytes()
+		putFieldLength(l.byteOrder, l.lengthFieldLength, data, int64(length))
+		copy(data[l.lengthFieldLength:], bodyBytes)
+
+		if rc, ok := message.(utils.ReferenceCounted); ok {
+			rc.Release()
+		}
+		ctx.HandleWrite(frame)
+		return
+	}
+
 	// head buffer
 	lengthBuff := packFieldLength(l.byteOrder, l.lengthFieldLength, int64(length))
 
@@ -67,3 +67,54 @@ func TestLengthFieldCodec(t *testing.T) {
 		})
 	}
 }
+
+func TestLengthFieldByteOrder(t *testing.T) {
+
+	body := bytes.Repeat([]byte("x"), 0x0102)
+
+	var cases = []struct {
+		byteOrder binary.ByteOrder
+		fieldLen  int
+		header    []byte
+	}{
+		{byteOrder: binary.BigEndian, fieldLen: 2, header: []byte{0x01, 0x02}},
+		{byteOrder: binary.LittleEndian, fieldLen: 2, header: []byte{0x02, 0x01}},
+		{byteOrder: binary.BigEndian, fieldLen: 4, header: []byte{0, 0, 0x01, 0x02}},
+		{byteOrder: binary.LittleEndian, fieldLen: 4, header: []byte{0x02, 0x01, 0, 0}},
+		{byteOrder: binary.BigEndian, fieldLen: 8, header: []byte{0, 0, 0, 0, 0, 0, 0x01, 0x02}},
+		{byteOrder: binary.LittleEndian, fieldLen: 8, header: []byte{0x02, 0x01, 0, 0, 0, 0, 0, 0}},
+		// network byte order by default.
+		{byteOrder: nil, fieldLen: 4, header: []byte{0, 0, 0x01, 0x02}},
+	}
+
+	for _, c := range cases {
+		var encoded []byte
+		ctx := MockHandlerContext{
+			MockHandleRead: func(message netty.Message) {
+				if dst := utils.MustToBytes(message); !bytes.Equal(dst, body) {
+					t.Fatalf("%v: decoded %d bytes, want: %d", c.byteOrder, len(dst), len(body))
+				}
+			},
+			MockHandleWrite: func(message netty.Message) {
+				encoded = utils.MustToBytes(message)
+			},
+		}
+
+		LengthFieldPrepender(c.byteOrder, c.fieldLen, 0, false).HandleWrite(ctx, body)
+		if !bytes.Equal(c.header, encoded[:c.fieldLen]) {
+			t.Fatalf("%v: header %x, want: %x", c.byteOrder, encoded[:c.fieldLen], c.header)
+		}
+
+		LengthFieldCodec(c.byteOrder, 1024, 0, c.fieldLen, 0, c.fieldLen).HandleRead(ctx, encoded)
+
+		// the pooled codec has a 4 bytes length field only.
+		if 4 == c.fieldLen {
+			codec := PooledLengthFieldCodec(c.byteOrder, 1024)
+			codec.HandleWrite(ctx, body)
+			if !bytes.Equal(c.header, encoded[:4]) {
+				t.Fatalf("%v: pooled header %x, want: %x", c.byteOrder, encoded[:4], c.header)
+			}
+			codec.HandleRead(ctx, encoded)
+		}
+	}
+}
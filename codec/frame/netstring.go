@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// NetstringCodec create a codec of netstring, the frame is encoded as `<decimal length>:<payload>,`,
+// e.g. "5:hello,", maxLength limits the length of payload.
+func NetstringCodec(maxLength int, option ...DecoderOption) codec.Codec {
+	utils.AssertIf(maxLength <= 0, "maxLength must be a positive integer")
+	return &netstringCodec{
+		maxLength: maxLength,
+		maxDigits: len(strconv.Itoa(maxLength)),
+		options:   newDecoderOptions(option...),
+	}
+}
+
+type netstringCodec struct {
+	maxLength int
+	maxDigits int
+	options   decoderOptions
+}
+
+func (*netstringCodec) CodecName() string {
+	return "netstring-codec"
+}
+
+func (n *netstringCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	reader := utils.MustToReader(message)
+
+	length, err := n.readLength(reader)
+	if nil != err {
+		// the boundary of frame is unknown.
+		n.options.fail(ctx, err, nil)
+		return
+	}
+
+	if length > n.maxLength {
+		err = fmt.Errorf("%w: length(%d) > maxLength(%d)", ErrTooLongFrame, length, n.maxLength)
+		n.options.fail(ctx, err, func() {
+			// skip the payload and the trailing comma.
+			utils.AssertLong(io.CopyN(io.Discard, reader, int64(length)+1))
+		})
+		return
+	}
+
+	frame := make([]byte, length+1)
+	utils.AssertLength(io.ReadFull(reader, frame))
+
+	if ',' != frame[length] {
+		err = fmt.Errorf("%w: expect ',' after the payload, got: %q", ErrCorruptedFrame, frame[length])
+		n.options.fail(ctx, err, nil)
+		return
+	}
+
+	ctx.HandleRead(bytes.NewReader(frame[:length]))
+}
+
+// readLength to parse the decimal length before the colon.
+func (n *netstringCodec) readLength(reader io.Reader) (int, error) {
+	var digits []byte
+	tempBuff := make([]byte, 1)
+	for {
+		if 0 == utils.AssertLength(reader.Read(tempBuff)) {
+			continue
+		}
+
+		switch b := tempBuff[0]; {
+		case ':' == b:
+			if 0 == len(digits) {
+				return 0, fmt.Errorf("%w: empty length", ErrCorruptedFrame)
+			}
+			return strconv.Atoi(string(digits))
+		case b < '0' || b > '9':
+			return 0, fmt.Errorf("%w: invalid length byte: %q", ErrCorruptedFrame, b)
+		case 1 == len(digits) && '0' == digits[0]:
+			return 0, fmt.Errorf("%w: leading zero of length", ErrCorruptedFrame)
+		case len(digits) == n.maxDigits:
+			// the length of more digits exceeds the max length, and the boundary is unknown.
+			return 0, fmt.Errorf("%w: length(%s%c...) > maxLength(%d)", ErrTooLongFrame, digits, b, n.maxLength)
+		default:
+			digits = append(digits, b)
+		}
+	}
+}
+
+func (n *netstringCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	payload := utils.MustToBytes(message)
+	ctx.HandleWrite([][]byte{
+		[]byte(strconv.Itoa(len(payload)) + ":"),
+		payload,
+		{','},
+	})
+}
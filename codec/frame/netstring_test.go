@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestNetstringCodec(t *testing.T) {
+
+	codec := NetstringCodec(16)
+
+	var encoded []byte
+	for _, payload := range []string{"hello", "", "world,with:colon"} {
+		codec.HandleWrite(MockHandlerContext{
+			MockHandleWrite: func(message netty.Message) {
+				encoded = append(encoded, utils.MustToBytes(message)...)
+			},
+		}, []byte(payload))
+	}
+
+	if want := "5:hello,0:,16:world,with:colon,"; want != string(encoded) {
+		t.Fatal("encoded:", string(encoded), "want:", want)
+	}
+
+	// the payload spans multiple reads.
+	var decoded []string
+	reader := iotest.OneByteReader(bytes.NewReader(encoded))
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			decoded = append(decoded, string(utils.MustToBytes(message)))
+		},
+	}
+	for i := 0; i < 3; i++ {
+		codec.HandleRead(ctx, reader)
+	}
+
+	if want := "[hello  world,with:colon]"; want != fmt.Sprint(decoded) {
+		t.Fatal("decoded:", decoded, "want:", want)
+	}
+}
+
+func TestNetstringCodecError(t *testing.T) {
+
+	var cases = []struct {
+		input    string
+		strategy DecoderErrorStrategy
+		decoded  []string
+		closed   error
+		raised   error
+	}{
+		{input: "2:ok;2:ok,", strategy: FailFast, raised: ErrCorruptedFrame},
+		{input: "2:ok;2:ok,", strategy: DiscardAndContinue, closed: ErrCorruptedFrame},
+		{input: "x:ok,", strategy: FailFast, raised: ErrCorruptedFrame},
+		{input: ":ok,", strategy: FailFast, raised: ErrCorruptedFrame},
+		{input: "02:ok,", strategy: FailFast, raised: ErrCorruptedFrame},
+		{input: "12:too long!!!!,2:ok,", strategy: FailFast, raised: ErrTooLongFrame},
+		{input: "12:too long!!!!,2:ok,", strategy: CloseOnError, closed: ErrTooLongFrame},
+		{input: "12:too long!!!!,2:ok,", strategy: DiscardAndContinue, decoded: []string{"ok"}},
+		{input: "123:", strategy: DiscardAndContinue, closed: ErrTooLongFrame},
+	}
+
+	for index, c := range cases {
+		t.Run(fmt.Sprint("netstring#", index), func(t *testing.T) {
+			decoded, closed, raised := decodeAll(NetstringCodec(10, WithErrorStrategy(c.strategy)), []byte(c.input), 2)
+			if fmt.Sprint(c.decoded) != fmt.Sprint(decoded) {
+				t.Fatal("decoded:", decoded, "want:", c.decoded)
+			}
+			if !errors.Is(closed, c.closed) || (nil == c.closed) != (nil == closed) {
+				t.Fatal("closed:", closed, "want:", c.closed)
+			}
+			if !errors.Is(raised, c.raised) || (nil == c.raised) != (nil == raised) {
+				t.Fatal("raised:", raised, "want:", c.raised)
+			}
+		})
+	}
+}
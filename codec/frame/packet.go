@@ -24,26 +24,50 @@ import (
 	"github.com/mijingduI/go-netty/utils"
 )
 
-// PacketCodec create packet codec
+// packetShrinkReads is the count of consecutive small packets to shrink the read buffer grown by a large packet.
+const packetShrinkReads = 16
+
+// PacketCodec create packet codec, the read buffer grown by a large packet is shrunk to readBuffSize
+// after the following packets are much smaller than it.
 func PacketCodec(readBuffSize int) codec.Codec {
-	return &packetCodec{readBuffer: bytes.NewBuffer(make([]byte, 0, readBuffSize))}
+	if readBuffSize < bytes.MinRead {
+		readBuffSize = bytes.MinRead
+	}
+	return &packetCodec{readBuffer: bytes.NewBuffer(make([]byte, 0, readBuffSize)), readBuffSize: readBuffSize}
 }
 
 type packetCodec struct {
-	readBuffer *bytes.Buffer
+	readBuffer   *bytes.Buffer
+	readBuffSize int
+	smallReads   int // the count of consecutive packets less than a quarter of the grown buffer
 }
 
 func (packetCodec) CodecName() string {
 	return "packet-codec"
 }
 
-func (p packetCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+func (p *packetCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
 	// reset read buffer
 	p.readBuffer.Reset()
 	// read packet
-	utils.AssertLong(p.readBuffer.ReadFrom(utils.MustToReader(message)))
+	n := utils.AssertLong(p.readBuffer.ReadFrom(utils.MustToReader(message)))
 	// post packet
 	ctx.HandleRead(p.readBuffer)
+	// compact the grown buffer, the buffer is kept until the small packets dominate to avoid thrashing.
+	p.compact(int(n))
+}
+
+// compact the read buffer to the initial size after packetShrinkReads small packets.
+func (p *packetCodec) compact(n int) {
+	if size := p.readBuffer.Cap(); size <= 4*p.readBuffSize || n > size/4 {
+		p.smallReads = 0
+		return
+	}
+
+	if p.smallReads++; p.smallReads >= packetShrinkReads {
+		p.readBuffer = bytes.NewBuffer(make([]byte, 0, p.readBuffSize))
+		p.smallReads = 0
+	}
 }
 
 func (packetCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
@@ -55,3 +55,35 @@ func TestPacketCodec(t *testing.T) {
 		})
 	}
 }
+
+func TestPacketCodecCompaction(t *testing.T) {
+
+	codec := PacketCodec(1024).(*packetCodec)
+	ctx := MockHandlerContext{MockHandleRead: func(message netty.Message) {}}
+
+	codec.HandleRead(ctx, bytes.NewReader(make([]byte, 1<<20)))
+	if grown := codec.readBuffer.Cap(); grown < 1<<20 {
+		t.Fatal("unexpected buffer size:", grown)
+	}
+
+	// the buffer is kept until the small packets dominate.
+	for i := 0; i < packetShrinkReads-1; i++ {
+		codec.HandleRead(ctx, []byte("small"))
+	}
+	if codec.readBuffer.Cap() < 1<<20 {
+		t.Fatal("the buffer shrinks too early:", codec.readBuffer.Cap())
+	}
+
+	codec.HandleRead(ctx, []byte("small"))
+	if size := codec.readBuffer.Cap(); size > 4*1024 {
+		t.Fatal("the buffer is not shrunk:", size)
+	}
+
+	// the small packets fit the initial buffer.
+	for i := 0; i < packetShrinkReads*2; i++ {
+		codec.HandleRead(ctx, []byte("small"))
+	}
+	if size := codec.readBuffer.Cap(); 1024 != size {
+		t.Fatal("the buffer is reallocated:", size)
+	}
+}
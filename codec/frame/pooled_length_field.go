@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+// PooledLengthFieldCodec create a codec of 4 bytes length field + payload, the payload is read
+// into a *pbytes.Buffer borrowed from pool, the handler which consumes the buffer must Release it,
+// the buffer reached the tail of pipeline is released automatically, the outbound frames are encoded by
+// PooledLengthFieldPrepender.
+//
+// The frames larger than the max size class of pbytes.DefaultPool (64KiB) are not pooled,
+// the byteOrder defaults to binary.BigEndian if nil.
+func PooledLengthFieldCodec(byteOrder binary.ByteOrder, maxFrameLength int) codec.Codec {
+	utils.AssertIf(maxFrameLength <= 0, "maxFrameLength must be a positive integer")
+	byteOrder = byteOrderOf(byteOrder)
+	return &pooledLengthFieldCodec{
+		byteOrder:       byteOrder,
+		maxFrameLength:  maxFrameLength,
+		OutboundHandler: PooledLengthFieldPrepender(byteOrder, 4, 0, false),
+	}
+}
+
+type pooledLengthFieldCodec struct {
+	byteOrder      binary.ByteOrder
+	maxFrameLength int
+
+	// default encoder
+	netty.OutboundHandler
+}
+
+func (*pooledLengthFieldCodec) CodecName() string {
+	return "pooled-length-field-codec"
+}
+
+func (l *pooledLengthFieldCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	// unwrap to reader
+	reader := utils.MustToReader(message)
+
+	// read the length field into a pooled buffer to avoid allocation.
+	headerBuffer := pbytes.Get(4)
+	defer pbytes.Put(headerBuffer)
+
+	lengthFieldBuff := (*headerBuffer)[:4]
+	// the arguments of AssertIf are checked explicitly to avoid allocation.
+	if n, err := io.ReadFull(reader, lengthFieldBuff); n != len(lengthFieldBuff) || nil != err {
+		utils.Assert(fmt.Errorf("read header fail, headerLength: %d, read: %d, error: %w", len(lengthFieldBuff), n, err))
+	}
+
+	// check before borrowing the buffer.
+	frameLength := int(l.byteOrder.Uint32(lengthFieldBuff))
+	if frameLength > l.maxFrameLength {
+		err := fmt.Errorf("%w: frameLength(%d) > maxFrameLength(%d)", ErrTooLongFrame, frameLength, l.maxFrameLength)
+		triggerDecodeError(ctx, err)
+		utils.Assert(err)
+	}
+
+	frameBuffer := pbytes.NewBuffer(frameLength)
+	if n, err := io.ReadFull(reader, frameBuffer.Bytes()); n != frameLength || nil != err {
+		frameBuffer.Release()
+		utils.Assert(fmt.Errorf("read frame fail, frameLength: %d, read: %d, error: %w", frameLength, n, err))
+	}
+
+	ctx.HandleRead(frameBuffer)
+}
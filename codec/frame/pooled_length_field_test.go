@@ -0,0 +1,177 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+func TestPooledLengthFieldCodec(t *testing.T) {
+
+	var cases = []struct {
+		byteOrder binary.ByteOrder
+		output    []byte
+	}{
+		{byteOrder: binary.LittleEndian, output: []byte("123456789")},
+		{byteOrder: binary.BigEndian, output: []byte("123456789")},
+		{byteOrder: binary.BigEndian, output: bytes.Repeat([]byte("1"), 100000)},
+		{byteOrder: binary.BigEndian, output: []byte{}},
+	}
+
+	for _, c := range cases {
+		codec := PooledLengthFieldCodec(c.byteOrder, 1024*1024)
+
+		var input []byte
+		var received *pbytes.Buffer
+		ctx := MockHandlerContext{
+			MockHandleRead: func(message netty.Message) {
+				received = message.(*pbytes.Buffer)
+				if dst := utils.MustToBytes(message); !bytes.Equal(dst, c.output) {
+					t.Fatalf("%v != %v", dst, c.output)
+				}
+			},
+			MockHandleWrite: func(message netty.Message) {
+				input = utils.MustToBytes(message)
+			},
+		}
+
+		codec.HandleWrite(ctx, c.output)
+		codec.HandleRead(ctx, input)
+
+		// the buffer is owned by the handler.
+		if 1 != received.RefCnt() {
+			t.Fatal("unexpected reference count:", received.RefCnt())
+		}
+		if !received.Release() {
+			t.Fatal("buffer should be deallocated")
+		}
+	}
+}
+
+func TestPooledLengthFieldCodecMaxLength(t *testing.T) {
+
+	codec := PooledLengthFieldCodec(binary.BigEndian, 16)
+
+	var input []byte
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			t.Fatal("frame too large should not be decoded")
+		},
+		MockHandleWrite: func(message netty.Message) {
+			input = utils.MustToBytes(message)
+		},
+	}
+
+	codec.HandleWrite(ctx, bytes.Repeat([]byte("1"), 17))
+
+	defer func() {
+		if nil == recover() {
+			t.Fatal("expect panic of too large frame")
+		}
+	}()
+	codec.HandleRead(ctx, input)
+}
+
+func TestPooledLengthFieldPrepender(t *testing.T) {
+
+	body := pbytes.NewBuffer(3)
+	copy(body.Bytes(), "abc")
+	body.Retain()
+
+	var frame *pbytes.Buffer
+	ctx := MockHandlerContext{
+		MockHandleWrite: func(message netty.Message) {
+			frame = message.(*pbytes.Buffer)
+		},
+	}
+	PooledLengthFieldPrepender(binary.LittleEndian, 2, 1, true).HandleWrite(ctx, body)
+
+	if want := []byte{6, 0, 'a', 'b', 'c'}; !bytes.Equal(want, frame.Bytes()) {
+		t.Fatalf("%v != %v", frame.Bytes(), want)
+	}
+
+	// the body is released once copied, and the frame is owned by the next handler.
+	if 1 != body.RefCnt() || 1 != frame.RefCnt() {
+		t.Fatal("unexpected reference count:", body.RefCnt(), frame.RefCnt())
+	}
+	body.Release()
+	frame.Release()
+}
+
+func benchmarkLengthFieldCodec(b *testing.B, codec netty.CodecHandler) {
+	frame := append([]byte{0, 0, 4, 0}, bytes.Repeat([]byte("1"), 1024)...)
+	reader := bytes.NewReader(frame)
+
+	var ctx netty.InboundContext = MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			if 1024 != len(utils.MustToBytes(message)) {
+				b.Fatal("unexpected frame")
+			}
+			if rc, ok := message.(utils.ReferenceCounted); ok {
+				rc.Release()
+			}
+		},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(frame)
+		codec.HandleRead(ctx, reader)
+	}
+}
+
+func BenchmarkLengthFieldCodec(b *testing.B) {
+	benchmarkLengthFieldCodec(b, LengthFieldCodec(binary.BigEndian, 4096, 0, 4, 0, 4))
+}
+
+func BenchmarkPooledLengthFieldCodec(b *testing.B) {
+	benchmarkLengthFieldCodec(b, PooledLengthFieldCodec(binary.BigEndian, 4096))
+}
+
+func benchmarkLengthFieldPrepender(b *testing.B, prepender netty.OutboundHandler) {
+	var payload netty.Message = bytes.Repeat([]byte("1"), 1024)
+
+	// release the frame after written like the head of pipeline.
+	var ctx netty.OutboundContext = MockHandlerContext{
+		MockHandleWrite: func(message netty.Message) {
+			if rc, ok := message.(utils.ReferenceCounted); ok {
+				rc.Release()
+			}
+		},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		prepender.HandleWrite(ctx, payload)
+	}
+}
+
+func BenchmarkLengthFieldPrepender(b *testing.B) {
+	benchmarkLengthFieldPrepender(b, LengthFieldPrepender(binary.BigEndian, 4, 0, false))
+}
+
+func BenchmarkPooledLengthFieldPrepender(b *testing.B) {
+	benchmarkLengthFieldPrepender(b, PooledLengthFieldPrepender(binary.BigEndian, 4, 0, false))
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrTooLongFrame is wrapped by the error of a frame exceeds the max frame length.
+var ErrTooLongFrame = errors.New("frame too long")
+
+// ErrCorruptedFrame is wrapped by the error of a malformed frame.
+var ErrCorruptedFrame = errors.New("corrupted frame")
+
+// DecoderErrorStrategy defines how the frame decoders recover from a too long or malformed frame
+type DecoderErrorStrategy int
+
+const (
+	// FailFast raises the error immediately without consuming the rest of the frame, the exception
+	// handlers decide whether to close the channel, it is the default.
+	FailFast DecoderErrorStrategy = iota
+	// CloseOnError closes the channel with the error.
+	CloseOnError
+	// DiscardAndContinue discards the bad frame to the next frame boundary and decodes the next one,
+	// the channel is closed if the boundary is unknown, e.g. a negative length field.
+	DiscardAndContinue
+)
+
+// DecoderOption to configure the frame codecs
+type DecoderOption func(options *decoderOptions)
+
+// WithErrorStrategy to set the DecoderErrorStrategy
+func WithErrorStrategy(strategy DecoderErrorStrategy) DecoderOption {
+	return func(options *decoderOptions) {
+		options.strategy = strategy
+	}
+}
+
+// WithByteOrder to set the byte order of the numeric fields, default to binary.BigEndian, the network byte order.
+func WithByteOrder(byteOrder binary.ByteOrder) DecoderOption {
+	return func(options *decoderOptions) {
+		options.byteOrder = byteOrderOf(byteOrder)
+	}
+}
+
+type decoderOptions struct {
+	strategy  DecoderErrorStrategy
+	byteOrder binary.ByteOrder
+}
+
+func newDecoderOptions(option ...DecoderOption) decoderOptions {
+	var options = decoderOptions{byteOrder: binary.BigEndian}
+	for _, op := range option {
+		op(&options)
+	}
+	return options
+}
+
+// byteOrderOf returns the byte order, binary.BigEndian if nil.
+func byteOrderOf(byteOrder binary.ByteOrder) binary.ByteOrder {
+	if nil == byteOrder {
+		return binary.BigEndian
+	}
+	return byteOrder
+}
+
+// fail to handle the error by the strategy, discard skips the rest of the bad frame, nil if the boundary is unknown.
+func (o decoderOptions) fail(ctx netty.InboundContext, err error, discard func()) {
+	triggerDecodeError(ctx, err)
+
+	switch o.strategy {
+	case DiscardAndContinue:
+		if nil != discard {
+			discard()
+			return
+		}
+		ctx.Close(err)
+	case CloseOnError:
+		ctx.Close(err)
+	default:
+		utils.Assert(err)
+	}
+}
+
+// decodeErrorCategory returns the category of the decode error of frame codecs.
+func decodeErrorCategory(err error) netty.DecodeErrorCategory {
+	switch {
+	case errors.Is(err, ErrTooLongFrame):
+		return netty.DecodeErrorTooLong
+	case errors.Is(err, ErrCorruptedFrame), errors.Is(err, ErrHeaderMismatch):
+		return netty.DecodeErrorMalformed
+	case errors.Is(err, ErrChecksumMismatch):
+		return netty.DecodeErrorChecksumMismatch
+	case errors.Is(err, ErrDecryptFailed):
+		return netty.DecodeErrorDecryptFailed
+	default:
+		return netty.DecodeErrorOther
+	}
+}
+
+// triggerDecodeError to trigger the DecodeErrorEvent before the error is handled.
+func triggerDecodeError(ctx netty.InboundContext, err error) {
+	ctx.Trigger(netty.DecodeErrorEvent{Category: decodeErrorCategory(err), Err: err})
+}
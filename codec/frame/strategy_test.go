@@ -0,0 +1,149 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frame
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// decodeAll to decode the frames of input by the codec, returns the decoded frames, the close reason & the raised error.
+func decodeAll(codec codec.Codec, input []byte, frames int) (decoded []string, closed error, raised error) {
+	reader := bytes.NewReader(input)
+	ctx := MockHandlerContext{
+		MockHandleRead: func(message netty.Message) {
+			decoded = append(decoded, string(utils.MustToBytes(message)))
+		},
+		MockClose: func(err error) {
+			closed = err
+		},
+	}
+
+	defer func() {
+		raised, _ = recover().(error)
+	}()
+
+	for i := 0; i < frames && nil == closed; i++ {
+		codec.HandleRead(ctx, reader)
+	}
+	return
+}
+
+func TestDecoderErrorStrategy(t *testing.T) {
+
+	type codecFactory func(option ...DecoderOption) codec.Codec
+
+	delimiter := func(option ...DecoderOption) codec.Codec {
+		return DelimiterCodec(4, "\r\n", true, option...)
+	}
+
+	lengthField := func(option ...DecoderOption) codec.Codec {
+		return LengthFieldCodec(binary.BigEndian, 8, 0, 1, 0, 1, option...)
+	}
+
+	// the length field is adjusted to negative.
+	corrupted := func(option ...DecoderOption) codec.Codec {
+		return LengthFieldCodec(binary.BigEndian, 8, 0, 1, -8, 1, option...)
+	}
+
+	var cases = []struct {
+		name     string
+		codec    codecFactory
+		input    []byte
+		strategy DecoderErrorStrategy
+		decoded  []string
+		closed   error
+		raised   error
+	}{
+		{name: "delimiter", codec: delimiter, input: []byte("too long\r\nok\r\n"), strategy: FailFast, raised: ErrTooLongFrame},
+		{name: "delimiter", codec: delimiter, input: []byte("too long\r\nok\r\n"), strategy: CloseOnError, closed: ErrTooLongFrame},
+		{name: "delimiter", codec: delimiter, input: []byte("too long\r\nok\r\n"), strategy: DiscardAndContinue, decoded: []string{"ok"}},
+		{name: "delimiter", codec: delimiter, input: []byte("long\r\r\nok\r\n"), strategy: DiscardAndContinue, decoded: []string{"ok"}},
+		{name: "length-field", codec: lengthField, input: []byte("\x0atoo long!!\x02ok"), strategy: FailFast, raised: ErrTooLongFrame},
+		{name: "length-field", codec: lengthField, input: []byte("\x0atoo long!!\x02ok"), strategy: CloseOnError, closed: ErrTooLongFrame},
+		{name: "length-field", codec: lengthField, input: []byte("\x0atoo long!!\x02ok"), strategy: DiscardAndContinue, decoded: []string{"ok"}},
+		{name: "corrupted", codec: corrupted, input: []byte("\x02ok\x02ok"), strategy: DiscardAndContinue, closed: ErrCorruptedFrame},
+	}
+
+	for index, c := range cases {
+		t.Run(fmt.Sprint(c.name, "#", index), func(t *testing.T) {
+			decoded, closed, raised := decodeAll(c.codec(WithErrorStrategy(c.strategy)), c.input, 2)
+
+			if !errors.Is(closed, c.closed) || (nil == c.closed) != (nil == closed) {
+				t.Fatal("unexpected close reason:", closed)
+			}
+
+			if !errors.Is(raised, c.raised) || (nil == c.raised) != (nil == raised) {
+				t.Fatal("unexpected raised error:", raised)
+			}
+
+			if fmt.Sprint(c.decoded) != fmt.Sprint(decoded) {
+				t.Fatal(decoded, "!=", c.decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorEvent(t *testing.T) {
+
+	var cases = []struct {
+		name     string
+		codec    codec.Codec
+		input    []byte
+		category netty.DecodeErrorCategory
+	}{
+		{name: "delimiter", codec: DelimiterCodec(4, "\r\n", true), input: []byte("too long\r\n"), category: netty.DecodeErrorTooLong},
+		{name: "length-field", codec: LengthFieldCodec(binary.BigEndian, 8, 0, 1, -8, 1), input: []byte("\x02ok"), category: netty.DecodeErrorMalformed},
+		{name: "varint", codec: VarintLengthFieldCodec(4), input: []byte("\x0atoo long!!"), category: netty.DecodeErrorTooLong},
+		{name: "pooled", codec: PooledLengthFieldCodec(binary.BigEndian, 4), input: []byte("\x00\x00\x00\x0atoo long!!"), category: netty.DecodeErrorTooLong},
+		{name: "checksum", codec: ChecksumCodec(CRC32C, false), input: []byte("go-netty\x00\x00\x00\x00"), category: netty.DecodeErrorChecksumMismatch},
+		{name: "header", codec: HeaderCodec([]byte("GN"), true), input: []byte("XXok"), category: netty.DecodeErrorMalformed},
+		{name: "aes-gcm", codec: AESGCMCodec(make([]byte, 16), CounterNonce()), input: make([]byte, 32), category: netty.DecodeErrorDecryptFailed},
+	}
+
+	for _, c := range cases {
+		var events []netty.DecodeErrorEvent
+		ctx := MockHandlerContext{
+			MockHandleRead: func(message netty.Message) {
+				t.Fatal(c.name, "bad frame should not be decoded")
+			},
+			MockTrigger: func(event netty.Event) {
+				events = append(events, event.(netty.DecodeErrorEvent))
+			},
+		}
+
+		func() {
+			defer func() {
+				if err, _ := recover().(error); nil == err {
+					t.Fatal(c.name, "expect decode error")
+				}
+			}()
+			c.codec.HandleRead(ctx, bytes.NewReader(c.input))
+		}()
+
+		if 1 != len(events) || c.category != events[0].Category || nil == events[0].Err {
+			t.Fatalf("%s: unexpected events: %v", c.name, events)
+		}
+	}
+}
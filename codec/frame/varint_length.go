@@ -18,6 +18,7 @@ package frame
 
 import (
 	"encoding/binary"
+	"fmt"
 	"github.com/mijingduI/go-netty"
 	"github.com/mijingduI/go-netty/codec"
 	"github.com/mijingduI/go-netty/utils"
@@ -46,8 +47,11 @@ func (v *varintLengthFieldCodec) HandleRead(ctx netty.InboundContext, message ne
 
 	frameLength, err := binary.ReadUvarint(utils.NewByteReader(reader))
 	utils.Assert(err)
-	utils.AssertIf(frameLength > uint64(v.maxFrameLength),
-		"frame length too large, frameLength(%d) > maxFrameLength(%d)", frameLength, v.maxFrameLength)
+	if frameLength > uint64(v.maxFrameLength) {
+		err = fmt.Errorf("%w: frameLength(%d) > maxFrameLength(%d)", ErrTooLongFrame, frameLength, v.maxFrameLength)
+		triggerDecodeError(ctx, err)
+		utils.Assert(err)
+	}
 
 	ctx.HandleRead(io.LimitReader(reader, int64(frameLength)))
 }
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrMalformedChunk is wrapped by the error of a malformed chunked body.
+var ErrMalformedChunk = errors.New("xhttp: malformed chunk")
+
+// ErrTooLargeBody is wrapped by the error of a chunked body exceeds the max size.
+var ErrTooLargeBody = errors.New("xhttp: too large chunked body")
+
+// maxLineLength limits the chunk-size line and the trailer section.
+const maxLineLength = 4096
+
+// ChunkedBody defines a body of chunked transfer encoding
+type ChunkedBody struct {
+	Body []byte
+	// Trailer fields after the last chunk, nil if there is no trailer.
+	Trailer http.Header
+}
+
+// ChunkedCodec create a codec of chunked transfer encoding, the inbound chunks are reassembled into
+// a *ChunkedBody, and maxBodySize limits the size of reassembled body, the chunk extensions are ignored.
+// the outbound []byte, *ChunkedBody or io.Reader is written as chunks of chunkSize at most,
+// the chunks of io.Reader are written as soon as they are read, so the body could be streamed.
+func ChunkedCodec(maxBodySize, chunkSize int) codec.Codec {
+	utils.AssertIf(maxBodySize <= 0, "maxBodySize must be a positive integer")
+	utils.AssertIf(chunkSize <= 0, "chunkSize must be a positive integer")
+	return &chunkedCodec{maxBodySize: maxBodySize, chunkSize: chunkSize}
+}
+
+type chunkedCodec struct {
+	maxBodySize int
+	chunkSize   int
+}
+
+func (*chunkedCodec) CodecName() string {
+	return "chunked-codec"
+}
+
+func (c *chunkedCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	reader := utils.MustToReader(message)
+
+	var body []byte
+	for {
+		line := readLine(reader, maxLineLength)
+
+		// chunk-size [ chunk-ext ]
+		if index := strings.IndexByte(line, ';'); index >= 0 {
+			line = line[:index]
+		}
+
+		size, err := strconv.ParseUint(strings.TrimSpace(line), 16, 63)
+		if nil != err {
+			utils.Assert(fmt.Errorf("%w: invalid chunk size: %q", ErrMalformedChunk, line))
+		}
+
+		if 0 == size {
+			break
+		}
+
+		if size > uint64(c.maxBodySize-len(body)) {
+			utils.Assert(fmt.Errorf("%w: body size(%d) > maxBodySize(%d)", ErrTooLargeBody, uint64(len(body))+size, c.maxBodySize))
+		}
+
+		offset := len(body)
+		body = append(body, make([]byte, size)...)
+		utils.AssertLength(io.ReadFull(reader, body[offset:]))
+
+		if crlf := readLine(reader, maxLineLength); "" != crlf {
+			utils.Assert(fmt.Errorf("%w: expect CRLF after chunk data, got: %q", ErrMalformedChunk, crlf))
+		}
+	}
+
+	ctx.HandleRead(&ChunkedBody{Body: body, Trailer: readTrailer(reader)})
+}
+
+func (c *chunkedCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	switch r := message.(type) {
+	case []byte:
+		ctx.HandleWrite(c.chunks(r, nil))
+	case *ChunkedBody:
+		ctx.HandleWrite(c.chunks(r.Body, r.Trailer))
+	case io.Reader:
+		for {
+			data := make([]byte, c.chunkSize)
+			n, err := io.ReadFull(r, data)
+			if n > 0 {
+				ctx.HandleWrite(chunk(data[:n]))
+			}
+
+			if io.EOF == err || io.ErrUnexpectedEOF == err {
+				break
+			}
+			utils.Assert(err)
+		}
+		ctx.HandleWrite(lastChunk(nil))
+	default:
+		ctx.HandleWrite(message)
+	}
+}
+
+// chunks to split the body into chunks, followed by the last chunk and trailer.
+func (c *chunkedCodec) chunks(body []byte, trailer http.Header) [][]byte {
+
+	var buffers [][]byte
+	for len(body) > 0 {
+		n := c.chunkSize
+		if n > len(body) {
+			n = len(body)
+		}
+		buffers = append(buffers, chunk(body[:n])...)
+		body = body[n:]
+	}
+	return append(buffers, lastChunk(trailer)...)
+}
+
+// chunk to encode the data as a chunk.
+func chunk(data []byte) [][]byte {
+	return [][]byte{[]byte(strconv.FormatInt(int64(len(data)), 16) + "\r\n"), data, []byte("\r\n")}
+}
+
+// lastChunk to encode the last chunk with the trailer.
+func lastChunk(trailer http.Header) [][]byte {
+	last := bytes.NewBufferString("0\r\n")
+	utils.Assert(trailer.Write(last))
+	last.WriteString("\r\n")
+	return [][]byte{last.Bytes()}
+}
+
+// readLine to read a line ends with LF, the line ending is stripped.
+func readLine(reader io.Reader, maxLength int) string {
+
+	line := make([]byte, 0, 16)
+	tempBuff := make([]byte, 1)
+	for len(line) < maxLength {
+		if 0 == utils.AssertLength(reader.Read(tempBuff)) {
+			continue
+		}
+
+		if '\n' == tempBuff[0] {
+			return strings.TrimSuffix(string(line), "\r")
+		}
+		line = append(line, tempBuff[0])
+	}
+
+	utils.Assert(fmt.Errorf("%w: line length(%d) > maxLength(%d)", ErrMalformedChunk, len(line), maxLength))
+	return ""
+}
+
+// readTrailer to read the trailer fields until an empty line.
+func readTrailer(reader io.Reader) http.Header {
+
+	var trailer http.Header
+	for remain := maxLineLength; ; {
+		line := readLine(reader, remain)
+		if "" == line {
+			return trailer
+		}
+		remain -= len(line)
+
+		index := strings.IndexByte(line, ':')
+		if index <= 0 {
+			utils.Assert(fmt.Errorf("%w: invalid trailer field: %q", ErrMalformedChunk, line))
+		}
+
+		if nil == trailer {
+			trailer = make(http.Header)
+		}
+		trailer.Add(textproto.TrimString(line[:index]), textproto.TrimString(line[index+1:]))
+	}
+}
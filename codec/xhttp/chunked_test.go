@@ -0,0 +1,147 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http/httputil"
+	"strings"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// codecContext records the messages passed on by the codec.
+type codecContext struct {
+	netty.HandlerContext
+	messages []netty.Message
+}
+
+func (c *codecContext) HandleRead(message netty.Message) {
+	c.messages = append(c.messages, message)
+}
+
+func (c *codecContext) HandleWrite(message netty.Message) {
+	c.messages = append(c.messages, message)
+}
+
+// written to join the written buffers.
+func (c *codecContext) written() []byte {
+	var buffer bytes.Buffer
+	for _, message := range c.messages {
+		for _, b := range message.([][]byte) {
+			buffer.Write(b)
+		}
+	}
+	return buffer.Bytes()
+}
+
+func TestChunkedCodecDecode(t *testing.T) {
+
+	input := "4;name=value\r\nWiki\r\n" +
+		"5\r\npedia\r\n" +
+		"E\r\n in\r\n\r\nchunks.\r\n" +
+		"0\r\n" +
+		"Expires: Wed, 21 Oct 2015 07:28:00 GMT\r\n" +
+		"x-checksum: abc\r\n" +
+		"\r\n" +
+		// the next body without trailer.
+		"3\r\nend\r\n0\r\n\r\n"
+
+	reader := strings.NewReader(input)
+	ctx := &codecContext{}
+	codec := ChunkedCodec(64, 16)
+	codec.HandleRead(ctx, reader)
+	codec.HandleRead(ctx, reader)
+
+	if 2 != len(ctx.messages) {
+		t.Fatal("unexpected bodies:", len(ctx.messages))
+	}
+
+	first := ctx.messages[0].(*ChunkedBody)
+	if "Wikipedia in\r\n\r\nchunks." != string(first.Body) {
+		t.Fatalf("unexpected body: %q", first.Body)
+	}
+	if "abc" != first.Trailer.Get("X-Checksum") || "Wed, 21 Oct 2015 07:28:00 GMT" != first.Trailer.Get("Expires") {
+		t.Fatal("unexpected trailer:", first.Trailer)
+	}
+
+	second := ctx.messages[1].(*ChunkedBody)
+	if "end" != string(second.Body) || nil != second.Trailer {
+		t.Fatal("unexpected body:", second)
+	}
+
+	var cases = []struct {
+		input string
+		err   error
+	}{
+		{input: "41\r\n" + strings.Repeat("x", 0x41) + "\r\n0\r\n\r\n", err: ErrTooLargeBody},
+		{input: "20\r\n" + strings.Repeat("x", 0x20) + "\r\n21\r\n", err: ErrTooLargeBody},
+		{input: "xyz\r\n", err: ErrMalformedChunk},
+		{input: "3\r\nabcd\r\n", err: ErrMalformedChunk},
+		{input: "0\r\nbad trailer\r\n\r\n", err: ErrMalformedChunk},
+		{input: strings.Repeat("0", maxLineLength+1), err: ErrMalformedChunk},
+	}
+
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, c.err) {
+					t.Fatalf("%q: expect %v, got: %v", c.input, c.err, err)
+				}
+			}()
+			codec.HandleRead(&codecContext{}, strings.NewReader(c.input))
+		}()
+	}
+}
+
+func TestChunkedCodecEncode(t *testing.T) {
+
+	body := []byte("hello go-netty, hello chunked transfer encoding")
+	codec := ChunkedCodec(1024, 8)
+
+	// a body with trailer.
+	ctx := &codecContext{}
+	codec.HandleWrite(ctx, &ChunkedBody{Body: body, Trailer: map[string][]string{"X-Checksum": {"abc"}}})
+
+	decoded := &codecContext{}
+	codec.HandleRead(decoded, bytes.NewReader(ctx.written()))
+	if result := decoded.messages[0].(*ChunkedBody); !bytes.Equal(body, result.Body) || "abc" != result.Trailer.Get("X-Checksum") {
+		t.Fatal("unexpected body:", string(result.Body), result.Trailer)
+	}
+
+	// a streamed body is written chunk by chunk, compatible with net/http.
+	ctx = &codecContext{}
+	codec.HandleWrite(ctx, bytes.NewReader(body))
+	if want := (len(body)+7)/8 + 1; want != len(ctx.messages) {
+		t.Fatal("written chunks:", len(ctx.messages), "want:", want)
+	}
+
+	if data := utils.AssertBytes(ioutil.ReadAll(httputil.NewChunkedReader(bytes.NewReader(ctx.written())))); !bytes.Equal(body, data) {
+		t.Fatal("unexpected body:", string(data))
+	}
+
+	// an empty body.
+	ctx = &codecContext{}
+	codec.HandleWrite(ctx, []byte{})
+	if "0\r\n\r\n" != string(ctx.written()) {
+		t.Fatalf("unexpected empty body: %q", ctx.written())
+	}
+}
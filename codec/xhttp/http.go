@@ -17,22 +17,83 @@
 package xhttp
 
 import (
+	"errors"
 	"fmt"
 	"github.com/mijingduI/go-netty/utils"
+	"io"
 	"net/http"
 
 	"github.com/mijingduI/go-netty"
 	"github.com/mijingduI/go-netty/codec"
 )
 
+// ErrHeaderTooLarge is raised if the header of inbound message exceeds the max header size before it is terminated,
+// it is the 431 Request Header Fields Too Large of server codec.
+var ErrHeaderTooLarge = errors.New("xhttp: header too large")
+
+// DefaultMaxHeaderSize is the max header size of ClientCodec & ServerCodec.
+const DefaultMaxHeaderSize = http.DefaultMaxHeaderBytes
+
 // ClientCodec create a http client codec
 func ClientCodec() codec.Codec {
-	return codec.Combine("http-client-codec", new(responseCodec), new(requestCodec))
+	return ClientCodecWith(DefaultMaxHeaderSize)
+}
+
+// ClientCodecWith create a http client codec, the response header is limited to maxHeaderSize bytes
+// including the status line.
+func ClientCodecWith(maxHeaderSize int) codec.Codec {
+	utils.AssertIf(maxHeaderSize <= 0, "maxHeaderSize must be a positive integer")
+	return codec.Combine("http-client-codec", &responseCodec{maxHeaderSize: maxHeaderSize}, new(requestCodec))
 }
 
 // ServerCodec create a http server codec
 func ServerCodec() codec.Codec {
-	return codec.Combine("http-server-codec", new(requestCodec), new(responseCodec))
+	return ServerCodecWith(DefaultMaxHeaderSize)
+}
+
+// ServerCodecWith create a http server codec, the request header is limited to maxHeaderSize bytes
+// including the request line.
+func ServerCodecWith(maxHeaderSize int) codec.Codec {
+	utils.AssertIf(maxHeaderSize <= 0, "maxHeaderSize must be a positive integer")
+	return codec.Combine("http-server-codec", &requestCodec{maxHeaderSize: maxHeaderSize}, new(responseCodec))
+}
+
+// headerLimitReader limits the bytes read before the header is parsed, the bytes read ahead by
+// bufio.Reader are counted, so the body in the same read could be cut, it is read after unlimit.
+type headerLimitReader struct {
+	reader    io.Reader
+	remaining int // -1 if unlimited
+}
+
+func newHeaderLimitReader(reader io.Reader, maxHeaderSize int) *headerLimitReader {
+	if maxHeaderSize <= 0 {
+		maxHeaderSize = DefaultMaxHeaderSize
+	}
+	return &headerLimitReader{reader: reader, remaining: maxHeaderSize}
+}
+
+func (l *headerLimitReader) Read(p []byte) (int, error) {
+	switch {
+	case l.remaining < 0:
+		return l.reader.Read(p)
+	case 0 == l.remaining:
+		return 0, ErrHeaderTooLarge
+	case len(p) > l.remaining:
+		p = p[:l.remaining]
+	}
+
+	n, err := l.reader.Read(p)
+	l.remaining -= n
+	return n, err
+}
+
+// check the error of parsing header, and remove the limit for the body.
+func (l *headerLimitReader) check(err error) {
+	if nil != err && 0 == l.remaining {
+		utils.Assert(fmt.Errorf("%w: %v", ErrHeaderTooLarge, err))
+	}
+	utils.Assert(err)
+	l.remaining = -1
 }
 
 // Handler to convert http.Handler to codec.Codec
@@ -18,13 +18,16 @@ package xhttp
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
 )
 
 func TestServerCodec(t *testing.T) {
@@ -91,3 +94,59 @@ func TestServerCodec(t *testing.T) {
 	wg.Wait()
 	bootstrap.Shutdown()
 }
+
+// endlessHeader is a header line never terminated, it counts the bytes read.
+type endlessHeader struct {
+	read int
+}
+
+func (e *endlessHeader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	e.read += len(p)
+	return len(p), nil
+}
+
+func TestCodecMaxHeaderSize(t *testing.T) {
+
+	var cases = []struct {
+		name  string
+		codec codec.Codec
+	}{
+		{name: "server", codec: ServerCodecWith(1024)},
+		{name: "client", codec: ClientCodecWith(1024)},
+	}
+
+	for _, c := range cases {
+		header := &endlessHeader{}
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrHeaderTooLarge) {
+					t.Fatal(c.name, "unexpected error:", err)
+				}
+			}()
+			c.codec.HandleRead(&codecContext{}, header)
+		}()
+
+		if header.read > 1024 {
+			t.Fatal(c.name, "read too many bytes:", header.read)
+		}
+	}
+
+	// the body is not limited.
+	body := strings.Repeat("go-netty", 1024)
+	ctx := &codecContext{}
+	ServerCodecWith(1024).HandleRead(ctx, strings.NewReader("POST /test HTTP/1.1\r\nHost: 127.0.0.1\r\nContent-Length: 8192\r\n\r\n"+body))
+
+	request := ctx.messages[0].(*http.Request)
+	if data, err := ioutil.ReadAll(request.Body); nil != err || body != string(data) {
+		t.Fatal("unexpected body length:", len(data), err)
+	}
+
+	ctx = &codecContext{}
+	ClientCodecWith(1024).HandleRead(ctx, strings.NewReader("HTTP/1.1 200 OK\r\nContent-Length: 8\r\n\r\ngo-netty"))
+	if response := ctx.messages[0].(*http.Response); http.StatusOK != response.StatusCode {
+		t.Fatal("unexpected status:", response.StatusCode)
+	}
+}
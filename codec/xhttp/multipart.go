@@ -0,0 +1,169 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrTooManyParts is wrapped by the error of a multipart body exceeds the max parts.
+var ErrTooManyParts = errors.New("xhttp: too many multipart parts")
+
+// ErrTooLargePart is wrapped by the error of a multipart part exceeds the max part size.
+var ErrTooLargePart = errors.New("xhttp: too large multipart part")
+
+// DefaultMultipartChunkSize is the chunk size of MultipartDecoder if it is not specified.
+const DefaultMultipartChunkSize = 32 * 1024
+
+// MultipartOptions defines the limits of MultipartDecoder
+type MultipartOptions struct {
+	// MaxParts limits the number of parts of a body, 0 means unlimited.
+	MaxParts int `json:"maxParts"`
+	// MaxPartSize limits the size of each part body, 0 means unlimited.
+	MaxPartSize int64 `json:"maxPartSize"`
+	// ChunkSize is the max size of each MultipartChunk, DefaultMultipartChunkSize if it is not positive.
+	ChunkSize int `json:"chunkSize"`
+}
+
+// MultipartPart is passed on before the body of each part.
+type MultipartPart struct {
+	Request  *http.Request
+	Index    int
+	Header   textproto.MIMEHeader
+	FormName string
+	FileName string
+}
+
+// MultipartChunk is a piece of the part body, the last chunk of a part could be empty.
+type MultipartChunk struct {
+	Part *MultipartPart
+	Data []byte
+	Last bool
+}
+
+// MultipartEnd is passed on after the last part of the body.
+type MultipartEnd struct {
+	Request *http.Request
+	Parts   int
+}
+
+// MultipartDecoder create a decoder of multipart/form-data requests, the body of *http.Request is parsed as
+// it is received, each part is passed on as a *MultipartPart followed by the *MultipartChunk of its body,
+// and a *MultipartEnd at last, so the uploaded files are never buffered in whole.
+// the other messages are passed on untouched.
+func MultipartDecoder(options MultipartOptions) codec.Codec {
+	utils.AssertIf(options.MaxParts < 0, "maxParts must be a non-negative integer")
+	utils.AssertIf(options.MaxPartSize < 0, "maxPartSize must be a non-negative integer")
+	if options.ChunkSize <= 0 {
+		options.ChunkSize = DefaultMultipartChunkSize
+	}
+	return &multipartDecoder{options: options}
+}
+
+type multipartDecoder struct {
+	options MultipartOptions
+}
+
+func (*multipartDecoder) CodecName() string {
+	return "multipart-decoder"
+}
+
+func (d *multipartDecoder) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	request, ok := message.(*http.Request)
+	if !ok {
+		ctx.HandleRead(message)
+		return
+	}
+
+	boundary, ok := multipartBoundary(request)
+	if !ok {
+		ctx.HandleRead(message)
+		return
+	}
+
+	// the boundary spanning reads is detected by multipart.Reader.
+	reader := multipart.NewReader(request.Body, boundary)
+	var parts int
+	for {
+		p, err := reader.NextPart()
+		if io.EOF == err {
+			break
+		}
+		utils.Assert(err)
+
+		if d.options.MaxParts > 0 && parts >= d.options.MaxParts {
+			utils.Assert(fmt.Errorf("%w: parts > maxParts(%d)", ErrTooManyParts, d.options.MaxParts))
+		}
+
+		part := &MultipartPart{Request: request, Index: parts, Header: p.Header, FormName: p.FormName(), FileName: p.FileName()}
+		ctx.HandleRead(part)
+		d.stream(ctx, part, p)
+		parts++
+	}
+
+	ctx.HandleRead(&MultipartEnd{Request: request, Parts: parts})
+}
+
+// stream to pass on the part body as chunks.
+func (d *multipartDecoder) stream(ctx netty.InboundContext, part *MultipartPart, reader io.Reader) {
+
+	var size int64
+	for {
+		data := make([]byte, d.options.ChunkSize)
+		n, err := io.ReadFull(reader, data)
+		last := io.EOF == err || io.ErrUnexpectedEOF == err
+		if !last {
+			utils.Assert(err)
+		}
+
+		if size += int64(n); d.options.MaxPartSize > 0 && size > d.options.MaxPartSize {
+			utils.Assert(fmt.Errorf("%w: part #%d size > maxPartSize(%d)", ErrTooLargePart, part.Index, d.options.MaxPartSize))
+		}
+
+		ctx.HandleRead(&MultipartChunk{Part: part, Data: data[:n], Last: last})
+		if last {
+			return
+		}
+	}
+}
+
+func (*multipartDecoder) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	ctx.HandleWrite(message)
+}
+
+// multipartBoundary returns the boundary of a multipart/form-data request.
+func multipartBoundary(request *http.Request) (string, bool) {
+	mediaType, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if nil != err || !strings.EqualFold("multipart/form-data", mediaType) || nil == request.Body {
+		return "", false
+	}
+
+	boundary, ok := params["boundary"]
+	return boundary, ok && "" != boundary
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// segmentReader returns the segments in separate reads.
+type segmentReader struct {
+	segments [][]byte
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	if 0 == len(s.segments) {
+		return 0, io.EOF
+	}
+	n := copy(p, s.segments[0])
+	if s.segments[0] = s.segments[0][n:]; 0 == len(s.segments[0]) {
+		s.segments = s.segments[1:]
+	}
+	return n, nil
+}
+
+// multipartRequest create a multipart/form-data request, the body is split in the middle of the boundary after the file.
+func multipartRequest(t *testing.T, file []byte) *http.Request {
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("title", "go-netty")
+	fw, err := writer.CreateFormFile("upload", "netty.bin")
+	if nil != err {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write(file)
+	_ = writer.WriteField("comment", "done")
+	_ = writer.Close()
+
+	data := body.Bytes()
+	offset := bytes.Index(data, file)
+	split := offset + len(file) + len("\r\n--") + 3
+	segments := [][]byte{data[:offset+100], data[offset+100 : split], data[split:]}
+
+	request, err := http.NewRequest(http.MethodPost, "/upload", &segmentReader{segments: segments})
+	if nil != err {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func TestMultipartDecoder(t *testing.T) {
+
+	file := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	ctx := &codecContext{}
+	MultipartDecoder(MultipartOptions{MaxParts: 3, MaxPartSize: 2048, ChunkSize: 256}).HandleRead(ctx, multipartRequest(t, file))
+
+	var parts []*MultipartPart
+	bodies := map[string]*bytes.Buffer{}
+	var chunks int
+	for _, message := range ctx.messages {
+		switch m := message.(type) {
+		case *MultipartPart:
+			parts = append(parts, m)
+			bodies[m.FormName] = &bytes.Buffer{}
+		case *MultipartChunk:
+			if m.Part != parts[len(parts)-1] {
+				t.Fatal("chunk of unexpected part:", m.Part.FormName)
+			}
+			if len(m.Data) > 256 {
+				t.Fatal("too large chunk:", len(m.Data))
+			}
+			bodies[m.Part.FormName].Write(m.Data)
+			if "upload" == m.Part.FormName {
+				chunks++
+			}
+		case *MultipartEnd:
+			if 3 != m.Parts {
+				t.Fatal("unexpected parts:", m.Parts)
+			}
+		default:
+			t.Fatalf("unexpected message: %T", message)
+		}
+	}
+
+	if _, ok := ctx.messages[len(ctx.messages)-1].(*MultipartEnd); !ok || 3 != len(parts) {
+		t.Fatal("unexpected parts:", len(parts))
+	}
+	if "netty.bin" != parts[1].FileName || "" != parts[0].FileName {
+		t.Fatal("unexpected file name:", parts[1].FileName)
+	}
+	if !bytes.Equal(file, bodies["upload"].Bytes()) || chunks < 4 {
+		t.Fatal("unexpected file of", chunks, "chunks")
+	}
+	if "go-netty" != bodies["title"].String() || "done" != bodies["comment"].String() {
+		t.Fatal("unexpected fields:", bodies["title"], bodies["comment"])
+	}
+
+	// non-multipart request is passed on.
+	plain, _ := http.NewRequest(http.MethodPost, "/upload", strings.NewReader("plain"))
+	ctx = &codecContext{}
+	MultipartDecoder(MultipartOptions{}).HandleRead(ctx, plain)
+	if 1 != len(ctx.messages) || plain != ctx.messages[0] {
+		t.Fatal("unexpected messages:", ctx.messages)
+	}
+}
+
+func TestMultipartDecoderLimits(t *testing.T) {
+
+	var cases = []struct {
+		options MultipartOptions
+		err     error
+	}{
+		{options: MultipartOptions{MaxParts: 2}, err: ErrTooManyParts},
+		{options: MultipartOptions{MaxPartSize: 1023}, err: ErrTooLargePart},
+	}
+
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, c.err) {
+					t.Fatalf("expect %v, got: %v", c.err, err)
+				}
+			}()
+			MultipartDecoder(c.options).HandleRead(&codecContext{}, multipartRequest(t, bytes.Repeat([]byte("x"), 1024)))
+		}()
+	}
+}
@@ -27,15 +27,22 @@ import (
 )
 
 type requestCodec struct {
+	maxHeaderSize int
 }
 
-func (*requestCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+func (c *requestCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
 
 	switch r := message.(type) {
 	case io.Reader:
-		request, err := http.ReadRequest(bufio.NewReader(r))
-		utils.Assert(err)
+		limiter := newHeaderLimitReader(r, c.maxHeaderSize)
+		reader := bufio.NewReader(limiter)
+		request, err := http.ReadRequest(reader)
+		limiter.check(err)
 		if request != nil {
+			// the bytes after an upgrade request belong to the new protocol.
+			if isUpgrade(request) {
+				request = withUpgradeReader(request, reader, r)
+			}
 			ctx.HandleRead(request)
 		}
 	default:
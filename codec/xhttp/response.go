@@ -27,14 +27,15 @@ import (
 )
 
 type responseCodec struct {
+	maxHeaderSize int
 }
 
-func (*responseCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+func (c *responseCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
 
-	reader := utils.MustToReader(message)
+	limiter := newHeaderLimitReader(utils.MustToReader(message), c.maxHeaderSize)
 
-	response, err := http.ReadResponse(bufio.NewReader(reader), nil)
-	utils.Assert(err)
+	response, err := http.ReadResponse(bufio.NewReader(limiter), nil)
+	limiter.check(err)
 	ctx.HandleRead(response)
 }
 
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// websocketGUID is appended to Sec-WebSocket-Key to compute Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketOptions to upgrade the http requests to websocket
+type WebSocketOptions struct {
+	// Subprotocols supported by the server in order of preference, the most preferred one
+	// requested by the client is selected, no subprotocol is selected if empty.
+	Subprotocols []string
+	// Extensions to negotiate the extensions offered by the client, returns the accepted ones,
+	// the reserved bits of frames are allowed if any extension is accepted, nil to accept none.
+	Extensions func(offers []string) []string
+	// CheckOrigin returns false to reject the request with 403, nil to accept any origin.
+	CheckOrigin func(request *http.Request) bool
+	// MaxFrameSize of the inbound frames, default to 64KB.
+	MaxFrameSize int
+	// MaxMessageSize enables WebSocketMessageCodec after upgraded if > 0, the next handlers read
+	// TextMessage or BinaryMessage reassembled within the size instead of the data frames.
+	MaxMessageSize int
+}
+
+// WebSocketUpgradeEvent is triggered after the channel is upgraded to websocket.
+type WebSocketUpgradeEvent struct {
+	Request     *http.Request
+	Subprotocol string
+	Extensions  []string
+}
+
+// WebSocketUpgrader create a handler to upgrade the websocket requests decoded by ServerCodec, the other
+// requests are passed on. the handler writes the 101 response, replaces the http codec with WebSocketCodec,
+// removes itself, triggers WebSocketUpgradeEvent and replays the bytes read after the request,
+// so the next handlers read *WebSocketFrame after upgraded. an invalid upgrade request is responded
+// with an error status, and the channel is closed after the response is flushed.
+func WebSocketUpgrader(options WebSocketOptions) netty.InboundHandler {
+	if 0 == options.MaxFrameSize {
+		options.MaxFrameSize = 64 * 1024
+	}
+	utils.AssertIf(options.MaxFrameSize < 0, "MaxFrameSize must be a positive integer")
+	return &websocketUpgrader{options: options}
+}
+
+type websocketUpgrader struct {
+	options WebSocketOptions
+}
+
+func (w *websocketUpgrader) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	request, ok := message.(*http.Request)
+	if !ok || !isUpgrade(request) || !headerContains(request.Header, "Upgrade", "websocket") {
+		ctx.HandleRead(message)
+		return
+	}
+
+	header := make(http.Header)
+	status, err := w.validate(request)
+	if http.StatusUpgradeRequired == status {
+		header.Set("Sec-WebSocket-Version", "13")
+	}
+
+	if nil != err {
+		header.Set("Connection", "close")
+		reason := err.Error()
+		_ = ctx.Channel().WriteAndClose(&http.Response{
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			StatusCode:    status,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(reason)),
+			ContentLength: int64(len(reason)),
+		})
+		return
+	}
+
+	event := WebSocketUpgradeEvent{Request: request, Subprotocol: w.subprotocol(request)}
+	if nil != w.options.Extensions {
+		event.Extensions = w.options.Extensions(headerValues(request.Header, "Sec-WebSocket-Extensions"))
+	}
+
+	header.Set("Upgrade", "websocket")
+	header.Set("Connection", "Upgrade")
+	header.Set("Sec-WebSocket-Accept", acceptKey(request.Header.Get("Sec-WebSocket-Key")))
+	if "" != event.Subprotocol {
+		header.Set("Sec-WebSocket-Protocol", event.Subprotocol)
+	}
+	if len(event.Extensions) > 0 {
+		header.Set("Sec-WebSocket-Extensions", strings.Join(event.Extensions, ", "))
+	}
+
+	ctx.Write(&http.Response{ProtoMajor: 1, ProtoMinor: 1, StatusCode: http.StatusSwitchingProtocols, Header: header})
+
+	// rewire the pipeline to websocket, the codec takes the place of http codec, or the upgrader if not found.
+	pipeline := ctx.Channel().Pipeline()
+	position := pipeline.IndexOf(func(handler netty.Handler) bool {
+		return handler == netty.Handler(w)
+	})
+	if codecPosition := pipeline.IndexOf(func(handler netty.Handler) bool {
+		c, ok := handler.(codec.Codec)
+		return ok && "http-server-codec" == c.CodecName()
+	}); -1 != codecPosition && codecPosition < position {
+		pipeline.RemoveHandler(position)
+		position = codecPosition
+	}
+
+	pipeline.AddHandler(position, newWebSocketCodec(false, w.options.MaxFrameSize, len(event.Extensions) > 0))
+	pipeline.RemoveHandler(position)
+	if w.options.MaxMessageSize > 0 {
+		pipeline.AddHandler(position, WebSocketMessageCodec(w.options.MaxMessageSize))
+	}
+
+	ctx.Trigger(event)
+
+	// replay the buffered frames, the last one may be completed by the stream.
+	if upgrade, ok := request.Context().Value(upgradeReaderKey{}).(*upgradeReader); ok {
+		prev := pipeline.ContextAt(position - 1).(netty.InboundContext)
+		for upgrade.buffered.Len() > 0 {
+			prev.HandleRead(io.MultiReader(upgrade.buffered, upgrade.stream))
+		}
+	}
+}
+
+// validate the upgrade request, returns the status to respond if failed.
+func (w *websocketUpgrader) validate(request *http.Request) (int, error) {
+
+	if http.MethodGet != request.Method || !request.ProtoAtLeast(1, 1) {
+		return http.StatusBadRequest, fmt.Errorf("unexpected request: %s %s", request.Method, request.Proto)
+	}
+
+	if version := request.Header.Get("Sec-WebSocket-Version"); "13" != version {
+		return http.StatusUpgradeRequired, fmt.Errorf("unsupported version: %s", version)
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(request.Header.Get("Sec-WebSocket-Key")); nil != err || 16 != len(key) {
+		return http.StatusBadRequest, fmt.Errorf("invalid Sec-WebSocket-Key: %s", request.Header.Get("Sec-WebSocket-Key"))
+	}
+
+	if nil != w.options.CheckOrigin && !w.options.CheckOrigin(request) {
+		return http.StatusForbidden, fmt.Errorf("origin not allowed: %s", request.Header.Get("Origin"))
+	}
+	return http.StatusSwitchingProtocols, nil
+}
+
+// subprotocol to select the most preferred subprotocol requested by the client.
+func (w *websocketUpgrader) subprotocol(request *http.Request) string {
+	requested := headerValues(request.Header, "Sec-WebSocket-Protocol")
+	for _, supported := range w.options.Subprotocols {
+		for _, protocol := range requested {
+			if supported == protocol {
+				return protocol
+			}
+		}
+	}
+	return ""
+}
+
+// acceptKey to compute Sec-WebSocket-Accept of the key.
+func acceptKey(key string) string {
+	digest := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// isUpgrade returns true if the request asks for a protocol upgrade.
+func isUpgrade(request *http.Request) bool {
+	return headerContains(request.Header, "Connection", "upgrade")
+}
+
+// headerContains returns true if the comma separated values of the header contain the token.
+func headerContains(header http.Header, key, token string) bool {
+	for _, value := range headerValues(header, key) {
+		if strings.EqualFold(value, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerValues to split the comma separated values of the header.
+func headerValues(header http.Header, key string) []string {
+	var values []string
+	for _, line := range header.Values(key) {
+		for _, value := range strings.Split(line, ",") {
+			if value = strings.TrimSpace(value); "" != value {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+type upgradeReaderKey struct{}
+
+// upgradeReader holds the bytes buffered after the upgrade request and the stream of channel.
+type upgradeReader struct {
+	buffered *bytes.Reader
+	stream   io.Reader
+}
+
+// withUpgradeReader to attach the bytes buffered by the reader after the request.
+func withUpgradeReader(request *http.Request, reader *bufio.Reader, stream io.Reader) *http.Request {
+	buffered, _ := reader.Peek(reader.Buffered())
+	upgrade := &upgradeReader{buffered: bytes.NewReader(buffered), stream: stream}
+	return request.WithContext(context.WithValue(request.Context(), upgradeReaderKey{}, upgrade))
+}
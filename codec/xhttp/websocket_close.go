@@ -0,0 +1,151 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/mijingduI/go-netty"
+)
+
+// websocket close codes of RFC 6455
+const (
+	CloseNormalClosure      uint16 = 1000
+	CloseGoingAway          uint16 = 1001
+	CloseProtocolError      uint16 = 1002
+	CloseUnsupportedData    uint16 = 1003
+	CloseNoStatusReceived   uint16 = 1005 // the close frame has no code, it is never sent.
+	CloseAbnormalClosure    uint16 = 1006 // the connection is lost without a close frame, it is never sent.
+	CloseInvalidPayload     uint16 = 1007
+	ClosePolicyViolation    uint16 = 1008
+	CloseMessageTooBig      uint16 = 1009
+	CloseMandatoryExtension uint16 = 1010
+	CloseInternalError      uint16 = 1011
+)
+
+// WebSocketCloseError is the close cause of the websocket channel closed by WebSocketCloseHandler
+type WebSocketCloseError struct {
+	Code   uint16
+	Reason string
+	// Clean is true if the close frame is received, false for CloseAbnormalClosure.
+	Clean bool
+}
+
+func (e *WebSocketCloseError) Error() string {
+	if "" == e.Reason {
+		return fmt.Sprintf("xhttp: websocket closed: %d", e.Code)
+	}
+	return fmt.Sprintf("xhttp: websocket closed: %d %s", e.Code, e.Reason)
+}
+
+// WebSocketCloseEvent is triggered by WebSocketCloseHandler when the websocket is closed by the peer or lost.
+type WebSocketCloseEvent struct {
+	*WebSocketCloseError
+}
+
+// WebSocketCloseFrame create a close frame of the code and reason, the reason is truncated to fit the control frame.
+func WebSocketCloseFrame(code uint16, reason string) *WebSocketFrame {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, code)
+	return &WebSocketFrame{Fin: true, Opcode: OpClose, Payload: append(payload, reason...)}
+}
+
+// WebSocketCloseHandler create a handler to finish the closing handshake of websocket, add it after WebSocketCodec.
+// the close frame of the peer is replied and WebSocketCloseEvent is triggered, then the channel is closed
+// with *WebSocketCloseError as the cause, the reply is skipped if the close frame is sent by this side first.
+// if the channel is closed without a close frame received, WebSocketCloseEvent of CloseAbnormalClosure is
+// triggered before the inactive event, so the handlers could decide whether to reconnect by the code.
+// create a new one for each channel.
+func WebSocketCloseHandler() netty.ChannelHandler {
+	return &websocketCloseHandler{}
+}
+
+type websocketCloseHandler struct {
+	sent     int32 // a close frame is sent
+	received int32 // a close frame is received
+}
+
+func (*websocketCloseHandler) HandleActive(ctx netty.ActiveContext) {
+	ctx.HandleActive()
+}
+
+func (w *websocketCloseHandler) HandleRead(ctx netty.InboundContext, message netty.Message) {
+	frame, ok := message.(*WebSocketFrame)
+	if !ok || OpClose != frame.Opcode {
+		ctx.HandleRead(message)
+		return
+	}
+
+	atomic.StoreInt32(&w.received, 1)
+	cause := parseCloseFrame(frame.Payload)
+
+	// echo the code to finish the closing handshake.
+	if atomic.CompareAndSwapInt32(&w.sent, 0, 1) {
+		if CloseNoStatusReceived == cause.Code {
+			ctx.Write(&WebSocketFrame{Fin: true, Opcode: OpClose})
+		} else {
+			ctx.Write(WebSocketCloseFrame(cause.Code, ""))
+		}
+	}
+
+	ctx.Trigger(WebSocketCloseEvent{WebSocketCloseError: cause})
+	ctx.Close(cause)
+}
+
+func (w *websocketCloseHandler) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+	if frame, ok := message.(*WebSocketFrame); ok && OpClose == frame.Opcode {
+		atomic.StoreInt32(&w.sent, 1)
+	}
+	ctx.HandleWrite(message)
+}
+
+func (*websocketCloseHandler) HandleException(ctx netty.ExceptionContext, ex netty.Exception) {
+	ctx.HandleException(ex)
+}
+
+func (w *websocketCloseHandler) HandleInactive(ctx netty.InactiveContext, ex netty.Exception) {
+	if 0 == atomic.LoadInt32(&w.received) {
+		reason := ""
+		if nil != ex {
+			reason = ex.Error()
+		}
+		ctx.Trigger(WebSocketCloseEvent{WebSocketCloseError: &WebSocketCloseError{Code: CloseAbnormalClosure, Reason: reason}})
+	}
+	ctx.HandleInactive(ex)
+}
+
+// parseCloseFrame to parse the code and reason of close frame, the malformed payload is reported as CloseProtocolError.
+func parseCloseFrame(payload []byte) *WebSocketCloseError {
+	switch {
+	case 0 == len(payload):
+		return &WebSocketCloseError{Code: CloseNoStatusReceived, Clean: true}
+	case 1 == len(payload):
+		return &WebSocketCloseError{Code: CloseProtocolError, Reason: "malformed close frame", Clean: true}
+	}
+
+	code, reason := binary.BigEndian.Uint16(payload), payload[2:]
+	if !utf8.Valid(reason) {
+		return &WebSocketCloseError{Code: CloseInvalidPayload, Reason: "invalid utf-8 reason", Clean: true}
+	}
+	return &WebSocketCloseError{Code: code, Reason: string(reason), Clean: true}
+}
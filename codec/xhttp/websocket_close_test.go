@@ -0,0 +1,114 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/mijingduI/go-netty"
+)
+
+// closeContext records the writes, events and close cause of the handler.
+type closeContext struct {
+	netty.HandlerContext
+	written []netty.Message
+	events  []netty.Event
+	cause   error
+}
+
+func (c *closeContext) HandleRead(message netty.Message)  {}
+func (c *closeContext) HandleWrite(message netty.Message) { c.written = append(c.written, message) }
+func (c *closeContext) HandleInactive(ex netty.Exception) {}
+func (c *closeContext) Write(message netty.Message)       { c.written = append(c.written, message) }
+func (c *closeContext) Trigger(event netty.Event)         { c.events = append(c.events, event) }
+func (c *closeContext) Close(err error)                   { c.cause = err }
+
+func TestWebSocketCloseHandler(t *testing.T) {
+
+	var cases = []struct {
+		frame  *WebSocketFrame
+		code   uint16
+		reason string
+		echo   []byte
+	}{
+		{frame: WebSocketCloseFrame(CloseNormalClosure, "bye"), code: CloseNormalClosure, reason: "bye", echo: []byte{0x03, 0xE8}},
+		{frame: WebSocketCloseFrame(CloseGoingAway, ""), code: CloseGoingAway, echo: []byte{0x03, 0xE9}},
+		{frame: WebSocketCloseFrame(4000, "app"), code: 4000, reason: "app", echo: []byte{0x0F, 0xA0}},
+		{frame: &WebSocketFrame{Fin: true, Opcode: OpClose}, code: CloseNoStatusReceived, echo: []byte{}},
+		{frame: &WebSocketFrame{Fin: true, Opcode: OpClose, Payload: []byte{0x03}}, code: CloseProtocolError, reason: "malformed close frame", echo: []byte{0x03, 0xEA}},
+		{frame: &WebSocketFrame{Fin: true, Opcode: OpClose, Payload: []byte{0x03, 0xE8, 0xFF}}, code: CloseInvalidPayload, reason: "invalid utf-8 reason", echo: []byte{0x03, 0xEF}},
+	}
+
+	for _, c := range cases {
+		ctx := &closeContext{}
+		handler := WebSocketCloseHandler()
+		handler.HandleRead(ctx, c.frame)
+
+		var cause *WebSocketCloseError
+		if !errors.As(ctx.cause, &cause) || c.code != cause.Code || c.reason != cause.Reason || !cause.Clean {
+			t.Fatalf("unexpected close cause: %+v, want: %d %q", ctx.cause, c.code, c.reason)
+		}
+
+		if 1 != len(ctx.events) || ctx.events[0].(WebSocketCloseEvent).WebSocketCloseError != cause {
+			t.Fatalf("unexpected events: %+v", ctx.events)
+		}
+
+		if 1 != len(ctx.written) || OpClose != ctx.written[0].(*WebSocketFrame).Opcode || string(c.echo) != string(ctx.written[0].(*WebSocketFrame).Payload) {
+			t.Fatalf("unexpected echo: %+v", ctx.written)
+		}
+
+		// no abnormal closure after the close frame.
+		handler.HandleInactive(ctx, ctx.cause)
+		if 1 != len(ctx.events) {
+			t.Fatalf("unexpected events: %+v", ctx.events)
+		}
+	}
+}
+
+func TestWebSocketCloseHandlerInitiated(t *testing.T) {
+
+	ctx := &closeContext{}
+	handler := WebSocketCloseHandler()
+
+	// the reply of the close sent by this side is not echoed.
+	handler.HandleWrite(ctx, WebSocketCloseFrame(CloseNormalClosure, "done"))
+	handler.HandleRead(ctx, WebSocketCloseFrame(CloseNormalClosure, ""))
+	if 1 != len(ctx.written) {
+		t.Fatalf("unexpected writes: %+v", ctx.written)
+	}
+
+	// the data frames are passed on.
+	handler.HandleRead(ctx, &WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte("hello")})
+	if 1 != len(ctx.events) {
+		t.Fatalf("unexpected events: %+v", ctx.events)
+	}
+}
+
+func TestWebSocketCloseHandlerAbnormal(t *testing.T) {
+
+	ctx := &closeContext{}
+	WebSocketCloseHandler().HandleInactive(ctx, io.EOF)
+
+	if 1 != len(ctx.events) {
+		t.Fatalf("unexpected events: %+v", ctx.events)
+	}
+	if event := ctx.events[0].(WebSocketCloseEvent); CloseAbnormalClosure != event.Code || event.Clean || io.EOF.Error() != event.Reason {
+		t.Fatalf("unexpected event: %+v", event.WebSocketCloseError)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrWebSocketFrame is wrapped by the error of a malformed or too long websocket frame.
+var ErrWebSocketFrame = errors.New("xhttp: bad websocket frame")
+
+// websocket opcodes of RFC 6455
+const (
+	OpContinuation byte = 0x0
+	OpText         byte = 0x1
+	OpBinary       byte = 0x2
+	OpClose        byte = 0x8
+	OpPing         byte = 0x9
+	OpPong         byte = 0xA
+)
+
+// WebSocketFrame defines a websocket frame, the payload is unmasked.
+type WebSocketFrame struct {
+	Fin     bool
+	Rsv     byte // the reserved bits RSV1-3 used by the extensions, 0x4 for RSV1.
+	Opcode  byte
+	Payload []byte
+}
+
+// IsControl returns true if the frame is a close, ping or pong frame.
+func (f *WebSocketFrame) IsControl() bool {
+	return f.Opcode&0x8 != 0
+}
+
+// WebSocketCodec create a websocket frame codec, the inbound frames are decoded to *WebSocketFrame,
+// the outbound *WebSocketFrame, []byte or BinaryMessage as a binary frame, string or TextMessage as a text frame are encoded,
+// the client masks the outbound frames, and the server requires the inbound frames masked.
+func WebSocketCodec(client bool, maxFrameSize int) codec.Codec {
+	return newWebSocketCodec(client, maxFrameSize, false)
+}
+
+func newWebSocketCodec(client bool, maxFrameSize int, extended bool) codec.Codec {
+	utils.AssertIf(maxFrameSize <= 0, "maxFrameSize must be a positive integer")
+	return &websocketCodec{client: client, maxFrameSize: maxFrameSize, extended: extended}
+}
+
+type websocketCodec struct {
+	client       bool
+	maxFrameSize int
+	extended     bool // the reserved bits are negotiated by extensions.
+}
+
+func (*websocketCodec) CodecName() string {
+	return "websocket-codec"
+}
+
+func (w *websocketCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+	frame, err := readWebSocketFrame(utils.MustToReader(message), !w.client, w.maxFrameSize, w.extended)
+	utils.Assert(err)
+	ctx.HandleRead(frame)
+}
+
+func (w *websocketCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	switch r := message.(type) {
+	case *WebSocketFrame:
+		ctx.HandleWrite(appendWebSocketFrame(nil, r, w.client))
+	case []byte:
+		ctx.HandleWrite(appendWebSocketFrame(nil, &WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: r}, w.client))
+	case BinaryMessage:
+		ctx.HandleWrite(appendWebSocketFrame(nil, &WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: r}, w.client))
+	case string:
+		ctx.HandleWrite(appendWebSocketFrame(nil, &WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte(r)}, w.client))
+	case TextMessage:
+		ctx.HandleWrite(appendWebSocketFrame(nil, &WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte(r)}, w.client))
+	default:
+		ctx.HandleWrite(message)
+	}
+}
+
+// readWebSocketFrame to read a frame from the reader, masked to require the payload masked.
+func readWebSocketFrame(reader io.Reader, masked bool, maxFrameSize int, extended bool) (*WebSocketFrame, error) {
+
+	var header [14]byte
+	if _, err := io.ReadFull(reader, header[:2]); nil != err {
+		return nil, err
+	}
+
+	frame := &WebSocketFrame{Fin: header[0]&0x80 != 0, Rsv: header[0] >> 4 & 0x7, Opcode: header[0] & 0xF}
+
+	if 0 != frame.Rsv && !extended {
+		return nil, fmt.Errorf("%w: reserved bits without extension: %#x", ErrWebSocketFrame, frame.Rsv)
+	}
+
+	switch frame.Opcode {
+	case OpContinuation, OpText, OpBinary, OpClose, OpPing, OpPong:
+	default:
+		return nil, fmt.Errorf("%w: unknown opcode: %#x", ErrWebSocketFrame, frame.Opcode)
+	}
+
+	if mask := header[1]&0x80 != 0; mask != masked {
+		return nil, fmt.Errorf("%w: mask: %v, want: %v", ErrWebSocketFrame, mask, masked)
+	}
+
+	var length = uint64(header[1] & 0x7F)
+	var extra = 0
+	switch length {
+	case 126:
+		extra = 2
+	case 127:
+		extra = 8
+	}
+
+	if masked {
+		extra += 4
+	}
+
+	if _, err := io.ReadFull(reader, header[2:2+extra]); nil != err {
+		return nil, err
+	}
+
+	switch length {
+	case 126:
+		length = uint64(binary.BigEndian.Uint16(header[2:]))
+	case 127:
+		length = binary.BigEndian.Uint64(header[2:])
+	}
+
+	if frame.IsControl() && (length > 125 || !frame.Fin) {
+		return nil, fmt.Errorf("%w: fragmented or too long control frame: %d", ErrWebSocketFrame, length)
+	}
+
+	if length > uint64(maxFrameSize) {
+		return nil, fmt.Errorf("%w: frame size(%d) > maxFrameSize(%d)", ErrWebSocketFrame, length, maxFrameSize)
+	}
+
+	frame.Payload = make([]byte, length)
+	if _, err := io.ReadFull(reader, frame.Payload); nil != err {
+		return nil, err
+	}
+
+	if masked {
+		maskBytes(header[extra-2:extra+2], frame.Payload)
+	}
+	return frame, nil
+}
+
+// appendWebSocketFrame to encode the frame to the buffer, a random masking key is used if masked.
+func appendWebSocketFrame(buffer []byte, frame *WebSocketFrame, masked bool) []byte {
+
+	var b0 = frame.Rsv<<4 | frame.Opcode
+	if frame.Fin {
+		b0 |= 0x80
+	}
+
+	var mask byte
+	if masked {
+		mask = 0x80
+	}
+
+	switch length := len(frame.Payload); {
+	case length <= 125:
+		buffer = append(buffer, b0, mask|byte(length))
+	case length <= 0xFFFF:
+		buffer = append(buffer, b0, mask|126, byte(length>>8), byte(length))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(length))
+		buffer = append(append(buffer, b0, mask|127), ext[:]...)
+	}
+
+	if !masked {
+		return append(buffer, frame.Payload...)
+	}
+
+	var key [4]byte
+	utils.AssertLength(rand.Read(key[:]))
+	buffer = append(buffer, key[:]...)
+
+	offset := len(buffer)
+	buffer = append(buffer, frame.Payload...)
+	maskBytes(key[:], buffer[offset:])
+	return buffer
+}
+
+// maskBytes to mask or unmask the payload with the key.
+func maskBytes(key []byte, payload []byte) {
+	for i := range payload {
+		payload[i] ^= key[i&3]
+	}
+}
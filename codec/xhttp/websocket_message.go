@@ -0,0 +1,119 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/mijingduI/go-netty"
+	"github.com/mijingduI/go-netty/codec"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// TextMessage defines a websocket message of text frames.
+type TextMessage string
+
+// BinaryMessage defines a websocket message of binary frames.
+type BinaryMessage []byte
+
+// WebSocketMessageCodec create a codec to reassemble the data frames decoded by WebSocketCodec to
+// TextMessage or BinaryMessage, the control frames are passed on as *WebSocketFrame between the fragments.
+// the outbound TextMessage & string are encoded as a text frame, BinaryMessage & []byte as a binary frame.
+// create a new one for each channel.
+func WebSocketMessageCodec(maxMessageSize int) codec.Codec {
+	utils.AssertIf(maxMessageSize <= 0, "maxMessageSize must be a positive integer")
+	return &websocketMessageCodec{maxMessageSize: maxMessageSize}
+}
+
+type websocketMessageCodec struct {
+	maxMessageSize int
+	opcode         byte // the opcode of the fragmented message, OpContinuation if none.
+	fragments      []byte
+}
+
+func (*websocketMessageCodec) CodecName() string {
+	return "websocket-message-codec"
+}
+
+func (w *websocketMessageCodec) HandleRead(ctx netty.InboundContext, message netty.Message) {
+
+	frame, ok := message.(*WebSocketFrame)
+	if !ok || frame.IsControl() {
+		ctx.HandleRead(message)
+		return
+	}
+
+	switch {
+	case OpContinuation == frame.Opcode && OpContinuation == w.opcode:
+		panic(fmt.Errorf("%w: continuation frame without message", ErrWebSocketFrame))
+	case OpContinuation != frame.Opcode && OpContinuation != w.opcode:
+		panic(fmt.Errorf("%w: new message before the fragmented one finished", ErrWebSocketFrame))
+	}
+
+	// a single-frame message.
+	if frame.Fin && OpContinuation == w.opcode {
+		ctx.HandleRead(w.message(frame.Opcode, frame.Payload))
+		return
+	}
+
+	if len(w.fragments)+len(frame.Payload) > w.maxMessageSize {
+		panic(fmt.Errorf("%w: message size > maxMessageSize(%d)", ErrWebSocketFrame, w.maxMessageSize))
+	}
+
+	if OpContinuation != frame.Opcode {
+		w.opcode = frame.Opcode
+	}
+	w.fragments = append(w.fragments, frame.Payload...)
+
+	if frame.Fin {
+		opcode, payload := w.opcode, w.fragments
+		w.opcode, w.fragments = OpContinuation, nil
+		ctx.HandleRead(w.message(opcode, payload))
+	}
+}
+
+// message to wrap the payload of opcode, the text must be valid UTF-8.
+func (w *websocketMessageCodec) message(opcode byte, payload []byte) netty.Message {
+	if len(payload) > w.maxMessageSize {
+		panic(fmt.Errorf("%w: message size(%d) > maxMessageSize(%d)", ErrWebSocketFrame, len(payload), w.maxMessageSize))
+	}
+
+	if OpText == opcode {
+		if !utf8.Valid(payload) {
+			panic(fmt.Errorf("%w: invalid utf-8 text", ErrWebSocketFrame))
+		}
+		return TextMessage(payload)
+	}
+	return BinaryMessage(payload)
+}
+
+func (w *websocketMessageCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
+
+	switch m := message.(type) {
+	case TextMessage:
+		ctx.HandleWrite(&WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte(m)})
+	case string:
+		ctx.HandleWrite(&WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte(m)})
+	case BinaryMessage:
+		ctx.HandleWrite(&WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: m})
+	case []byte:
+		ctx.HandleWrite(&WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: m})
+	default:
+		ctx.HandleWrite(message)
+	}
+}
@@ -0,0 +1,431 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xhttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty"
+)
+
+func TestWebSocketFrame(t *testing.T) {
+
+	for _, size := range []int{0, 1, 125, 126, 0xFFFF, 0x10000} {
+		frame := &WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: bytes.Repeat([]byte{'x'}, size)}
+		for _, masked := range []bool{true, false} {
+			decoded, err := readWebSocketFrame(bytes.NewReader(appendWebSocketFrame(nil, frame, masked)), masked, 0x10000, false)
+			if nil != err {
+				t.Fatal(size, masked, err)
+			}
+			if !decoded.Fin || OpBinary != decoded.Opcode || !bytes.Equal(frame.Payload, decoded.Payload) {
+				t.Fatal("unexpected frame of size:", size)
+			}
+		}
+	}
+
+	var cases = []struct {
+		frame  *WebSocketFrame
+		masked bool
+	}{
+		// too long
+		{frame: &WebSocketFrame{Fin: true, Opcode: OpText, Payload: make([]byte, 1025)}, masked: true},
+		// unmasked frame from client
+		{frame: &WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte("hello")}, masked: false},
+		// fragmented control frame
+		{frame: &WebSocketFrame{Opcode: OpPing}, masked: true},
+		// reserved bits without extension
+		{frame: &WebSocketFrame{Fin: true, Rsv: 0x4, Opcode: OpText}, masked: true},
+		// unknown opcode
+		{frame: &WebSocketFrame{Fin: true, Opcode: 0x3}, masked: true},
+	}
+
+	for _, c := range cases {
+		if _, err := readWebSocketFrame(bytes.NewReader(appendWebSocketFrame(nil, c.frame, c.masked)), true, 1024, false); !errors.Is(err, ErrWebSocketFrame) {
+			t.Fatalf("%+v: expect ErrWebSocketFrame, got: %v", c.frame, err)
+		}
+	}
+}
+
+func TestWebSocketUpgrader(t *testing.T) {
+
+	upgraded := make(chan WebSocketUpgradeEvent, 1)
+
+	httpMux := http.NewServeMux()
+	httpMux.HandleFunc("/test", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("hello go-netty"))
+	})
+
+	var childInitializer = func(channel netty.Channel) {
+		channel.Pipeline().
+			AddLast(ServerCodec()).
+			AddLast(WebSocketUpgrader(WebSocketOptions{
+				Subprotocols: []string{"superchat", "chat"},
+				Extensions: func(offers []string) []string {
+					return []string{"x-test"}
+				},
+			})).
+			AddLast(netty.EventHandlerFunc(func(ctx netty.EventContext, event netty.Event) {
+				if e, ok := event.(WebSocketUpgradeEvent); ok {
+					upgraded <- e
+				}
+			})).
+			AddLast(netty.InboundHandlerFunc(func(ctx netty.InboundContext, message netty.Message) {
+				frame, ok := message.(*WebSocketFrame)
+				if !ok {
+					ctx.HandleRead(message)
+					return
+				}
+
+				switch frame.Opcode {
+				case OpPing:
+					ctx.Write(&WebSocketFrame{Fin: true, Opcode: OpPong, Payload: frame.Payload})
+				case OpClose:
+					_ = ctx.Channel().WriteAndClose(&WebSocketFrame{Fin: true, Opcode: OpClose, Payload: frame.Payload})
+				default:
+					ctx.Write(frame)
+				}
+			})).
+			AddLast(Handler(httpMux)).
+			AddLast(netty.ExceptionHandlerFunc(func(ctx netty.ExceptionContext, ex netty.Exception) {
+				ctx.Close(ex)
+			}))
+	}
+
+	bootstrap := netty.NewBootstrap(netty.WithChildInitializer(childInitializer))
+	defer bootstrap.Shutdown()
+	bootstrap.Listen("127.0.0.1:9550").Async(func(err error) {})
+
+	var conn net.Conn
+	var err error
+	for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+		if conn, err = net.Dial("tcp", "127.0.0.1:9550"); nil == err {
+			break
+		}
+	}
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Second * 2))
+
+	// the frame sent along with the request is replayed after upgraded.
+	request := "GET /chat HTTP/1.1\r\n" +
+		"Host: 127.0.0.1\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Protocol: chat, superchat\r\n" +
+		"Sec-WebSocket-Extensions: x-test\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	early := appendWebSocketFrame([]byte(request), &WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte("early")}, true)
+	if _, err := conn.Write(early); nil != err {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if http.StatusSwitchingProtocols != response.StatusCode ||
+		"s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" != response.Header.Get("Sec-WebSocket-Accept") ||
+		"superchat" != response.Header.Get("Sec-WebSocket-Protocol") ||
+		"x-test" != response.Header.Get("Sec-WebSocket-Extensions") {
+		t.Fatal("unexpected response:", response.Status, response.Header)
+	}
+
+	select {
+	case e := <-upgraded:
+		if "superchat" != e.Subprotocol || "/chat" != e.Request.URL.Path {
+			t.Fatal("unexpected upgrade event:", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("upgrade event not triggered")
+	}
+
+	var exchanges = []struct {
+		frame *WebSocketFrame
+		want  *WebSocketFrame
+	}{
+		{want: &WebSocketFrame{Fin: true, Opcode: OpText, Payload: []byte("early")}},
+		{
+			frame: &WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: bytes.Repeat([]byte{1}, 1000)},
+			want:  &WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: bytes.Repeat([]byte{1}, 1000)},
+		},
+		{
+			frame: &WebSocketFrame{Fin: true, Opcode: OpPing, Payload: []byte("ping")},
+			want:  &WebSocketFrame{Fin: true, Opcode: OpPong, Payload: []byte("ping")},
+		},
+		{
+			frame: &WebSocketFrame{Fin: true, Opcode: OpClose, Payload: []byte{0x03, 0xE8}},
+			want:  &WebSocketFrame{Fin: true, Opcode: OpClose, Payload: []byte{0x03, 0xE8}},
+		},
+	}
+
+	for _, e := range exchanges {
+		if nil != e.frame {
+			if _, err := conn.Write(appendWebSocketFrame(nil, e.frame, true)); nil != err {
+				t.Fatal(err)
+			}
+		}
+
+		frame, err := readWebSocketFrame(reader, false, 1024, false)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if frame.Opcode != e.want.Opcode || !bytes.Equal(frame.Payload, e.want.Payload) {
+			t.Fatalf("frame: %#x %q, want: %#x %q", frame.Opcode, frame.Payload, e.want.Opcode, e.want.Payload)
+		}
+	}
+
+	// the plain http requests are still served.
+	plain, err := net.Dial("tcp", "127.0.0.1:9550")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+
+	if _, err := plain.Write([]byte("GET /test HTTP/1.1\r\nHost: 127.0.0.1\r\n\r\n")); nil != err {
+		t.Fatal(err)
+	}
+	if response, err := http.ReadResponse(bufio.NewReader(plain), nil); nil != err {
+		t.Fatal(err)
+	} else if body, _ := ioutil.ReadAll(response.Body); "hello go-netty" != string(body) {
+		t.Fatal("unexpected body:", string(body))
+	}
+}
+
+func TestWebSocketUpgraderRejected(t *testing.T) {
+
+	var childInitializer = func(channel netty.Channel) {
+		channel.Pipeline().
+			AddLast(ServerCodec()).
+			AddLast(WebSocketUpgrader(WebSocketOptions{
+				CheckOrigin: func(request *http.Request) bool {
+					return "http://example.org" == request.Header.Get("Origin")
+				},
+			})).
+			AddLast(netty.ExceptionHandlerFunc(func(ctx netty.ExceptionContext, ex netty.Exception) {
+				ctx.Close(ex)
+			}))
+	}
+
+	bootstrap := netty.NewBootstrap(netty.WithChildInitializer(childInitializer))
+	defer bootstrap.Shutdown()
+	bootstrap.Listen("127.0.0.1:9551").Async(func(err error) {})
+
+	var cases = []struct {
+		header string
+		status int
+	}{
+		{header: "Sec-WebSocket-Version: 8\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n", status: http.StatusUpgradeRequired},
+		{header: "Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: c2hvcnQ=\r\n", status: http.StatusBadRequest},
+		{header: "Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nOrigin: http://evil.org\r\n", status: http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		var conn net.Conn
+		var err error
+		for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+			if conn, err = net.Dial("tcp", "127.0.0.1:9551"); nil == err {
+				break
+			}
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+		_ = conn.SetDeadline(time.Now().Add(time.Second * 2))
+
+		request := "GET /chat HTTP/1.1\r\nHost: 127.0.0.1\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" + c.header + "\r\n"
+		if _, err := conn.Write([]byte(request)); nil != err {
+			t.Fatal(err)
+		}
+
+		reader := bufio.NewReader(conn)
+		response, err := http.ReadResponse(reader, nil)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if c.status != response.StatusCode {
+			t.Fatal("status:", response.Status, "want:", c.status)
+		}
+		if http.StatusUpgradeRequired == c.status && "13" != response.Header.Get("Sec-WebSocket-Version") {
+			t.Fatal("supported version is not responded")
+		}
+		_, _ = ioutil.ReadAll(response.Body)
+
+		// closed by the server.
+		if _, err := reader.ReadByte(); io.EOF != err {
+			t.Fatal("connection is not closed:", err)
+		}
+		_ = conn.Close()
+	}
+}
+
+func TestWebSocketMessageCodec(t *testing.T) {
+
+	ctx := &codecContext{}
+	codec := WebSocketMessageCodec(16)
+
+	// the control frame is passed on between the fragments.
+	for _, frame := range []*WebSocketFrame{
+		{Fin: false, Opcode: OpText, Payload: []byte("hello, ")},
+		{Fin: true, Opcode: OpPing, Payload: []byte("ping")},
+		{Fin: true, Opcode: OpContinuation, Payload: []byte("world")},
+		{Fin: true, Opcode: OpBinary, Payload: []byte{0xFF, 0x00}},
+		{Fin: true, Opcode: OpText, Payload: []byte{}},
+	} {
+		codec.HandleRead(ctx, frame)
+	}
+
+	if 4 != len(ctx.messages) {
+		t.Fatal("unexpected messages:", ctx.messages)
+	}
+	if frame, ok := ctx.messages[0].(*WebSocketFrame); !ok || OpPing != frame.Opcode {
+		t.Fatalf("unexpected control frame: %#v", ctx.messages[0])
+	}
+	if text, ok := ctx.messages[1].(TextMessage); !ok || "hello, world" != text {
+		t.Fatalf("unexpected text: %#v", ctx.messages[1])
+	}
+	if binary, ok := ctx.messages[2].(BinaryMessage); !ok || !bytes.Equal([]byte{0xFF, 0x00}, binary) {
+		t.Fatalf("unexpected binary: %#v", ctx.messages[2])
+	}
+	if text, ok := ctx.messages[3].(TextMessage); !ok || "" != text {
+		t.Fatalf("unexpected empty text: %#v", ctx.messages[3])
+	}
+
+	var invalids = [][]*WebSocketFrame{
+		{{Fin: true, Opcode: OpContinuation, Payload: []byte("orphan")}},
+		{{Fin: false, Opcode: OpText, Payload: []byte("a")}, {Fin: true, Opcode: OpBinary, Payload: []byte("b")}},
+		{{Fin: false, Opcode: OpBinary, Payload: bytes.Repeat([]byte{1}, 10)}, {Fin: true, Opcode: OpContinuation, Payload: bytes.Repeat([]byte{1}, 10)}},
+		{{Fin: true, Opcode: OpText, Payload: []byte{0xFF}}},
+	}
+
+	for _, frames := range invalids {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrWebSocketFrame) {
+					t.Fatal("unexpected error:", err)
+				}
+			}()
+			codec := WebSocketMessageCodec(16)
+			for _, frame := range frames {
+				codec.HandleRead(&codecContext{}, frame)
+			}
+		}()
+	}
+
+	// the outbound messages are mapped to frames.
+	var writes = []struct {
+		message netty.Message
+		opcode  byte
+	}{
+		{message: TextMessage("text"), opcode: OpText},
+		{message: "string", opcode: OpText},
+		{message: BinaryMessage("binary"), opcode: OpBinary},
+		{message: []byte("bytes"), opcode: OpBinary},
+	}
+
+	for _, w := range writes {
+		ctx := &codecContext{}
+		codec.HandleWrite(ctx, w.message)
+		if frame, ok := ctx.messages[0].(*WebSocketFrame); !ok || w.opcode != frame.Opcode || !frame.Fin {
+			t.Fatalf("%#v: unexpected frame: %#v", w.message, ctx.messages[0])
+		}
+	}
+}
+
+func TestWebSocketUpgraderMessages(t *testing.T) {
+
+	var childInitializer = func(channel netty.Channel) {
+		channel.Pipeline().
+			AddLast(ServerCodec()).
+			AddLast(WebSocketUpgrader(WebSocketOptions{MaxMessageSize: 1024})).
+			AddLast(netty.InboundHandlerFunc(func(ctx netty.InboundContext, message netty.Message) {
+				// echo the message of the same type.
+				switch m := message.(type) {
+				case TextMessage:
+					ctx.Write(TextMessage("text: " + m))
+				case BinaryMessage:
+					ctx.Write(BinaryMessage(append([]byte("binary: "), m...)))
+				}
+			})).
+			AddLast(netty.ExceptionHandlerFunc(func(ctx netty.ExceptionContext, ex netty.Exception) {
+				ctx.Close(ex)
+			}))
+	}
+
+	bootstrap := netty.NewBootstrap(netty.WithChildInitializer(childInitializer))
+	defer bootstrap.Shutdown()
+	bootstrap.Listen("127.0.0.1:9554").Async(func(err error) {})
+
+	var conn net.Conn
+	var err error
+	for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+		if conn, err = net.Dial("tcp", "127.0.0.1:9554"); nil == err {
+			break
+		}
+	}
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Second * 2))
+
+	request := "GET /chat HTTP/1.1\r\nHost: 127.0.0.1\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(request)); nil != err {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	if response, err := http.ReadResponse(reader, nil); nil != err {
+		t.Fatal(err)
+	} else if http.StatusSwitchingProtocols != response.StatusCode {
+		t.Fatal("unexpected response:", response.Status)
+	}
+
+	var frames []byte
+	frames = appendWebSocketFrame(frames, &WebSocketFrame{Fin: false, Opcode: OpText, Payload: []byte("hello, ")}, true)
+	frames = appendWebSocketFrame(frames, &WebSocketFrame{Fin: true, Opcode: OpContinuation, Payload: []byte("world")}, true)
+	frames = appendWebSocketFrame(frames, &WebSocketFrame{Fin: true, Opcode: OpBinary, Payload: []byte{0x01, 0x02}}, true)
+	if _, err := conn.Write(frames); nil != err {
+		t.Fatal(err)
+	}
+
+	for _, want := range []*WebSocketFrame{
+		{Opcode: OpText, Payload: []byte("text: hello, world")},
+		{Opcode: OpBinary, Payload: []byte("binary: \x01\x02")},
+	} {
+		frame, err := readWebSocketFrame(reader, false, 1024, false)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if want.Opcode != frame.Opcode || !bytes.Equal(want.Payload, frame.Payload) {
+			t.Fatalf("frame: %#x %q, want: %#x %q", frame.Opcode, frame.Payload, want.Opcode, want.Payload)
+		}
+	}
+}
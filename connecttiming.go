@@ -0,0 +1,83 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// ConnectTimingAttribute holds the latest ConnectTiming of the channel set by ConnectTimingHandler.
+const ConnectTimingAttribute AttributeKey = "netty.connect-timing"
+
+// ConnectTiming is the timing of connection establishment of a client channel.
+type ConnectTiming struct {
+	// ConnectTiming is the timing reported by the transport, zero if it is not a transport.TimingTransport.
+	transport.ConnectTiming
+	// FirstByte is the time from the channel became active to the first inbound message, zero until received.
+	FirstByte time.Duration `json:"firstByte"`
+}
+
+// ConnectedEvent is triggered by ConnectTimingHandler when the channel becomes active.
+type ConnectedEvent struct {
+	ConnectTiming
+}
+
+// FirstByteEvent is triggered by ConnectTimingHandler when the first inbound message is received.
+type FirstByteEvent struct {
+	ConnectTiming
+}
+
+// ConnectTimingHandler create a handler to report the timing of connection establishment, e.g. to attribute the
+// connect latency to the resolve, the dial or the tls handshake. a ConnectedEvent is triggered when the channel
+// becomes active, and a FirstByteEvent with FirstByte at the first inbound message, the ConnectTimingAttribute
+// is updated before each event. add it to the client pipeline, and create a new one for each channel.
+func ConnectTimingHandler() ChannelInboundHandler {
+	return &connectTimingHandler{}
+}
+
+type connectTimingHandler struct {
+	timing   ConnectTiming
+	active   time.Time
+	received bool
+}
+
+func (c *connectTimingHandler) HandleActive(ctx ActiveContext) {
+	if t, ok := ctx.Channel().Transport().(transport.TimingTransport); ok {
+		c.timing.ConnectTiming = t.ConnectTiming()
+	}
+	c.active = time.Now()
+
+	ctx.Channel().SetAttribute(ConnectTimingAttribute, c.timing)
+	ctx.Trigger(ConnectedEvent{ConnectTiming: c.timing})
+	ctx.HandleActive()
+}
+
+func (c *connectTimingHandler) HandleRead(ctx InboundContext, message Message) {
+	if !c.received {
+		c.received = true
+		c.timing.FirstByte = time.Since(c.active)
+		ctx.Channel().SetAttribute(ConnectTimingAttribute, c.timing)
+		ctx.Trigger(FirstByteEvent{ConnectTiming: c.timing})
+	}
+	ctx.HandleRead(message)
+}
+
+func (c *connectTimingHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	ctx.HandleInactive(ex)
+}
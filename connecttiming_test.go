@@ -0,0 +1,100 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport/tcp"
+)
+
+func TestConnectTimingHandler(t *testing.T) {
+
+	cert, pool := newTestCertificate("go-netty")
+
+	events := make(chan Event, 4)
+	bs := NewBootstrap(
+		WithChildInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(discardHandler{}, ActiveHandlerFunc(func(ctx ActiveContext) {
+				ctx.Write([]byte("hello"))
+			}))
+		}),
+		WithClientInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(ConnectTimingHandler(), discardHandler{}, EventHandlerFunc(func(ctx EventContext, event Event) {
+				events <- event
+			}))
+		}),
+	)
+	defer bs.Shutdown()
+
+	bs.Listen("127.0.0.1:9562", tcp.WithOptions(&tcp.Options{
+		Timeout: time.Second,
+		TLS:     &tls.Config{Certificates: []tls.Certificate{cert}},
+	})).Async(func(err error) {})
+
+	start := time.Now()
+	ch, err := connectRetry(bs, "tcp://localhost:9562", tcp.WithOptions(&tcp.Options{
+		Timeout: time.Second,
+		TLS:     &tls.Config{RootCAs: pool},
+	}))
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer ch.Close(nil)
+
+	next := func() Event {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("event is not triggered")
+			return nil
+		}
+	}
+
+	connected, ok := next().(ConnectedEvent)
+	if !ok {
+		t.Fatal("expect ConnectedEvent")
+	}
+	timing := connected.ConnectTiming
+	if !timing.TLS || timing.Resolve <= 0 || timing.Connect <= 0 || timing.Handshake <= 0 || 0 != timing.FirstByte {
+		t.Fatalf("unexpected timing: %+v", timing)
+	}
+	if timing.Start.Before(start) || timing.Start.Add(timing.Elapsed()).After(time.Now()) {
+		t.Fatalf("unexpected timing: %+v", timing)
+	}
+
+	firstByte, ok := next().(FirstByteEvent)
+	if !ok || firstByte.FirstByte <= 0 || firstByte.Handshake != timing.Handshake {
+		t.Fatalf("unexpected first byte event: %+v", firstByte)
+	}
+	if ch.Attribute(ConnectTimingAttribute) != firstByte.ConnectTiming {
+		t.Fatal("unexpected timing attribute:", ch.Attribute(ConnectTimingAttribute))
+	}
+
+	// no tls phase without tls.
+	pt, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", ConnectTimingHandler(), discardHandler{}, EventHandlerFunc(func(ctx EventContext, event Event) {
+		events <- event
+	}))
+	defer pt.Close(nil)
+	defer peer.Close()
+	if connected, ok := next().(ConnectedEvent); !ok || connected.TLS || 0 != connected.Handshake {
+		t.Fatalf("unexpected timing: %+v", connected)
+	}
+}
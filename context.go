@@ -16,6 +16,8 @@
 
 package netty
 
+import "sync/atomic"
+
 type (
 	// HandlerContext defines a base handler context
 	HandlerContext interface {
@@ -67,7 +69,7 @@ type (
 
 // handlerContext impl HandlerContext
 type handlerContext struct {
-	pipeline       Pipeline
+	pipeline       *pipeline
 	handler        Handler
 	prev           *handlerContext
 	next           *handlerContext
@@ -77,9 +79,12 @@ type handlerContext struct {
 	cast2Exception ExceptionHandler
 	cast2Inactive  InactiveHandler
 	cast2Event     EventHandler
+	cast2Cleanup   CleanupHandler
+	stats          *handlerStats // nil if the pipeline is not profiled.
+	writing        int32         // > 0 while the HandleWrite of handler is running in the outbound traversal.
 }
 
-func newHandlerContext(p Pipeline, handler Handler, prev, next *handlerContext) *handlerContext {
+func newHandlerContext(p *pipeline, handler Handler, prev, next *handlerContext) *handlerContext {
 	hc := &handlerContext{
 		pipeline: p,
 		handler:  handler,
@@ -93,9 +98,35 @@ func newHandlerContext(p Pipeline, handler Handler, prev, next *handlerContext)
 	hc.cast2Exception, _ = handler.(ExceptionHandler)
 	hc.cast2Inactive, _ = handler.(InactiveHandler)
 	hc.cast2Event, _ = handler.(EventHandler)
+	hc.cast2Cleanup, _ = handler.(CleanupHandler)
+	if nil != p.profiler {
+		hc.stats = p.profiler.statsOf(handler)
+	}
+	if r, ok := handler.(*panicContextHandler); ok {
+		p.recovery = r
+	}
 	return hc
 }
 
+// invoke the method of handler by fn, with the profiling and the context of panic if enabled.
+func (hc *handlerContext) invoke(method string, message interface{}, fn func(ctx dispatchContext)) {
+	if r := hc.pipeline.recovery; nil != r {
+		defer r.recover(hc, method, message)
+	}
+
+	ctx, pc := hc.enter()
+	fn(ctx)
+	hc.exit(pc, "HandleWrite" == method)
+}
+
+// invokeWrite call the HandleWrite of handler, the writes by the context in HandleWrite are nested in the
+// outbound traversal, which already holds the write token of pipeline.
+func (hc *handlerContext) invokeWrite(handler OutboundHandler, message Message) {
+	atomic.AddInt32(&hc.writing, 1)
+	defer atomic.AddInt32(&hc.writing, -1)
+	hc.invoke("HandleWrite", message, func(ctx dispatchContext) { handler.HandleWrite(ctx, message) })
+}
+
 func (hc *handlerContext) prevContext() *handlerContext {
 	return hc.prev
 }
@@ -112,6 +143,11 @@ func (hc *handlerContext) Write(message Message) {
 		}
 	}()
 
+	if 0 == atomic.LoadInt32(&hc.writing) {
+		hc.pipeline.lockWrite()
+		defer hc.pipeline.unlockWrite()
+	}
+
 	var next = hc
 
 	for {
@@ -120,7 +156,7 @@ func (hc *handlerContext) Write(message Message) {
 		}
 
 		if handler := next.cast2Outbound; nil != handler {
-			handler.HandleWrite(next, message)
+			next.invokeWrite(handler, message)
 			break
 		}
 	}
@@ -142,7 +178,7 @@ func (hc *handlerContext) Trigger(event Event) {
 		}
 
 		if handler := next.cast2Event; nil != handler {
-			handler.HandleEvent(next, event)
+			next.invoke("HandleEvent", event, func(ctx dispatchContext) { handler.HandleEvent(ctx, event) })
 			break
 		}
 	}
@@ -156,6 +192,11 @@ func (hc *handlerContext) Channel() Channel {
 	return hc.pipeline.Channel()
 }
 
+// priorityWrite returns true if the outbound traversal is a priority write.
+func (hc *handlerContext) priorityWrite() bool {
+	return hc.pipeline.priority
+}
+
 func (hc *handlerContext) Handler() Handler {
 	return hc.handler
 }
@@ -178,7 +219,7 @@ func (hc *handlerContext) HandleActive() {
 		}
 
 		if handler := next.cast2Active; nil != handler {
-			handler.HandleActive(next)
+			next.invoke("HandleActive", nil, func(ctx dispatchContext) { handler.HandleActive(ctx) })
 			break
 		}
 	}
@@ -193,7 +234,7 @@ func (hc *handlerContext) HandleRead(message Message) {
 		}
 
 		if handler := next.cast2Inbound; nil != handler {
-			handler.HandleRead(next, message)
+			next.invoke("HandleRead", message, func(ctx dispatchContext) { handler.HandleRead(ctx, message) })
 			break
 		}
 	}
@@ -208,7 +249,7 @@ func (hc *handlerContext) HandleWrite(message Message) {
 		}
 
 		if handler := prev.cast2Outbound; nil != handler {
-			handler.HandleWrite(prev, message)
+			prev.invokeWrite(handler, message)
 			break
 		}
 	}
@@ -223,7 +264,7 @@ func (hc *handlerContext) HandleException(ex Exception) {
 		}
 
 		if handler := next.cast2Exception; nil != handler {
-			handler.HandleException(next, ex)
+			next.invoke("HandleException", ex, func(ctx dispatchContext) { handler.HandleException(ctx, ex) })
 			break
 		}
 	}
@@ -238,7 +279,7 @@ func (hc *handlerContext) HandleInactive(ex Exception) {
 		}
 
 		if handler := next.cast2Inactive; nil != handler {
-			handler.HandleInactive(next, ex)
+			next.invoke("HandleInactive", ex, func(ctx dispatchContext) { handler.HandleInactive(ctx, ex) })
 			break
 		}
 	}
@@ -253,7 +294,7 @@ func (hc *handlerContext) HandleEvent(event Event) {
 		}
 
 		if handler := next.cast2Event; nil != handler {
-			handler.HandleEvent(next, event)
+			next.invoke("HandleEvent", event, func(ctx dispatchContext) { handler.HandleEvent(ctx, event) })
 			break
 		}
 	}
@@ -0,0 +1,323 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+var (
+	// ErrRequestTimeout is returned by ResponseFuture if the response is not received in time.
+	ErrRequestTimeout = errors.New("netty: request timeout")
+
+	// ErrChannelNotActive is returned by ResponseFuture if the request is sent before the channel is active.
+	ErrChannelNotActive = errors.New("netty: channel not active")
+
+	// ErrRequestQueueTimeout is returned by ResponseFuture if the queued request does not get a free slot in time.
+	ErrRequestQueueTimeout = errors.New("netty: request queue timeout")
+)
+
+// Correlator defines how the correlation id is carried by the messages of a protocol
+type Correlator interface {
+	// RequestID returns the correlation id of the outbound request, assign one to the message if necessary.
+	RequestID(request Message) interface{}
+
+	// ResponseID returns the correlation id of the inbound message, false if it is not a response.
+	ResponseID(response Message) (interface{}, bool)
+}
+
+// RequestResponseHandler matches the inbound responses to the outstanding requests by the correlation id,
+// the inbound messages which are not responses are passed to the next handler, and the responses
+// of unknown id (e.g. timed out) are dropped.
+type RequestResponseHandler interface {
+	ActiveHandler
+	InboundHandler
+	InactiveHandler
+
+	// Request write the request through the channel, the returned future is completed by the matched
+	// response, or failed if the request is not written or the response is not received in timeout.
+	Request(request Message) ResponseFuture
+
+	// Pending returns the count of outstanding requests.
+	Pending() int
+
+	// Queued returns the count of requests waiting for a free slot of MaxInFlight.
+	Queued() int
+}
+
+// RequestOptions defines the options of RequestResponseHandler
+type RequestOptions struct {
+	// Timeout of waiting for the response after the request is sent, <= 0 to wait forever.
+	Timeout time.Duration `json:"timeout"`
+	// MaxInFlight limits the outstanding requests, the requests beyond it are queued in FIFO order
+	// and sent when the responses free the slots, <= 0 for unlimited.
+	MaxInFlight int `json:"maxInFlight"`
+	// QueueTimeout of waiting for a free slot, <= 0 to wait forever.
+	QueueTimeout time.Duration `json:"queueTimeout"`
+}
+
+// ResponseFuture defines the result of a request
+type ResponseFuture interface {
+	Future
+
+	// Response blocks until completed, returns the response message and the error.
+	Response() (Message, error)
+}
+
+// NewRequestResponseHandler create a RequestResponseHandler for a channel, timeout <= 0 to wait forever.
+func NewRequestResponseHandler(correlator Correlator, timeout time.Duration) RequestResponseHandler {
+	return NewRequestResponseHandlerWith(correlator, RequestOptions{Timeout: timeout})
+}
+
+// NewRequestResponseHandlerWith create a RequestResponseHandler with the options, e.g. to limit the in-flight requests.
+func NewRequestResponseHandlerWith(correlator Correlator, options RequestOptions) RequestResponseHandler {
+	utils.AssertIf(nil == correlator, "correlator is required")
+	return &requestResponseHandler{
+		correlator:   correlator,
+		timeout:      options.Timeout,
+		maxInFlight:  options.MaxInFlight,
+		queueTimeout: options.QueueTimeout,
+		pending:      make(map[interface{}]*responseFuture),
+	}
+}
+
+type requestResponseHandler struct {
+	correlator   Correlator
+	timeout      time.Duration
+	maxInFlight  int
+	queueTimeout time.Duration
+	mutex        sync.Mutex
+	channel      Channel
+	clock        Clock
+	closeErr     error
+	pending      map[interface{}]*responseFuture
+	queued       []*queuedRequest
+}
+
+// queuedRequest is a request waiting for a free slot.
+type queuedRequest struct {
+	request Message
+	future  *responseFuture
+}
+
+func (r *requestResponseHandler) HandleActive(ctx ActiveContext) {
+	r.mutex.Lock()
+	r.channel = ctx.Channel()
+	r.clock = ClockOf(ctx.Channel())
+	r.mutex.Unlock()
+	ctx.HandleActive()
+}
+
+func (r *requestResponseHandler) Request(request Message) ResponseFuture {
+	future := &responseFuture{promise: newPromise()}
+	future.self = future
+
+	r.mutex.Lock()
+	ch, closeErr := r.channel, r.closeErr
+	if nil == ch || nil != closeErr {
+		r.mutex.Unlock()
+		if nil == closeErr {
+			closeErr = ErrChannelNotActive
+		}
+		future.complete(closeErr)
+		return future
+	}
+
+	// wait for a free slot.
+	if r.maxInFlight > 0 && len(r.pending) >= r.maxInFlight {
+		queued := &queuedRequest{request: request, future: future}
+		r.queued = append(r.queued, queued)
+		if r.queueTimeout > 0 {
+			future.timer = r.clock.AfterFunc(r.queueTimeout, func() {
+				if r.dequeue(queued) {
+					future.complete(ErrRequestQueueTimeout)
+				}
+			})
+		}
+		r.mutex.Unlock()
+		return future
+	}
+
+	id, err := r.registerLocked(request, future)
+	r.mutex.Unlock()
+
+	if nil != err {
+		future.complete(err)
+		return future
+	}
+
+	r.send(ch, id, request, future)
+	return future
+}
+
+// registerLocked assign the correlation id to the request and start the timer of response.
+func (r *requestResponseHandler) registerLocked(request Message, future *responseFuture) (interface{}, error) {
+	id := r.correlator.RequestID(request)
+	if _, ok := r.pending[id]; ok {
+		return nil, fmt.Errorf("duplicate correlation id: %v", id)
+	}
+	r.pending[id] = future
+
+	if r.timeout > 0 {
+		future.timer = r.clock.AfterFunc(r.timeout, func() {
+			if r.remove(id, future) {
+				future.complete(fmt.Errorf("%w: %v", ErrRequestTimeout, id))
+				r.release()
+			}
+		})
+	}
+	return id, nil
+}
+
+// send the registered request, the future is failed if the request is not written.
+func (r *requestResponseHandler) send(ch Channel, id interface{}, request Message, future *responseFuture) {
+	if err := ch.Write(request); nil != err && r.remove(id, future) {
+		future.stop()
+		future.complete(err)
+		r.release()
+	}
+}
+
+// release send the queued requests while there are free slots.
+func (r *requestResponseHandler) release() {
+	for {
+		r.mutex.Lock()
+		if nil != r.closeErr || 0 == len(r.queued) || len(r.pending) >= r.maxInFlight {
+			r.mutex.Unlock()
+			return
+		}
+
+		queued := r.queued[0]
+		r.queued[0] = nil
+		r.queued = r.queued[1:]
+		queued.future.stop()
+		id, err := r.registerLocked(queued.request, queued.future)
+		ch := r.channel
+		r.mutex.Unlock()
+
+		if nil != err {
+			queued.future.complete(err)
+			continue
+		}
+		r.send(ch, id, queued.request, queued.future)
+	}
+}
+
+// dequeue remove the queued request, false if it is already sent.
+func (r *requestResponseHandler) dequeue(queued *queuedRequest) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for index, q := range r.queued {
+		if q == queued {
+			r.queued = append(r.queued[:index], r.queued[index+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (r *requestResponseHandler) Pending() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.pending)
+}
+
+func (r *requestResponseHandler) Queued() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.queued)
+}
+
+func (r *requestResponseHandler) HandleRead(ctx InboundContext, message Message) {
+	id, ok := r.correlator.ResponseID(message)
+	if !ok {
+		ctx.HandleRead(message)
+		return
+	}
+
+	r.mutex.Lock()
+	future, ok := r.pending[id]
+	if ok {
+		delete(r.pending, id)
+	}
+	r.mutex.Unlock()
+
+	if ok {
+		future.stop()
+		future.response = message
+		future.complete(nil)
+		r.release()
+	}
+}
+
+func (r *requestResponseHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	r.mutex.Lock()
+	r.closeErr = ex
+	if nil == r.closeErr {
+		r.closeErr = ErrChannelClosed
+	}
+	pending, queued := r.pending, r.queued
+	r.pending = make(map[interface{}]*responseFuture)
+	r.queued = nil
+	r.mutex.Unlock()
+
+	// fail the outstanding and queued requests.
+	for _, future := range pending {
+		future.stop()
+		future.complete(r.closeErr)
+	}
+	for _, q := range queued {
+		q.future.stop()
+		q.future.complete(r.closeErr)
+	}
+	ctx.HandleInactive(ex)
+}
+
+// remove the pending future of id, false if it is already completed.
+func (r *requestResponseHandler) remove(id interface{}, future *responseFuture) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if f, ok := r.pending[id]; ok && f == future {
+		delete(r.pending, id)
+		return true
+	}
+	return false
+}
+
+type responseFuture struct {
+	*promise
+	timer    Timer
+	response Message
+}
+
+func (f *responseFuture) Response() (Message, error) {
+	if err := f.Wait(); nil != err {
+		return nil, err
+	}
+	return f.response, nil
+}
+
+func (f *responseFuture) stop() {
+	if nil != f.timer {
+		f.timer.Stop()
+	}
+}
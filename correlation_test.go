@@ -0,0 +1,242 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRPC is a message of "id body" line
+type testRPC struct {
+	id   int64
+	body string
+}
+
+type testRPCCodec struct{}
+
+func (testRPCCodec) CodecName() string { return "test-rpc-codec" }
+
+func (testRPCCodec) HandleRead(ctx InboundContext, message Message) {
+	var rpc testRPC
+	if _, err := fmt.Sscanf(message.(string), "%d %s", &rpc.id, &rpc.body); nil != err {
+		panic(err)
+	}
+	ctx.HandleRead(&rpc)
+}
+
+func (testRPCCodec) HandleWrite(ctx OutboundContext, message Message) {
+	rpc := message.(*testRPC)
+	ctx.HandleWrite(fmt.Sprintf("%d %s", rpc.id, rpc.body))
+}
+
+type testCorrelator struct {
+	sequence int64
+}
+
+func (c *testCorrelator) RequestID(request Message) interface{} {
+	rpc := request.(*testRPC)
+	rpc.id = atomic.AddInt64(&c.sequence, 1)
+	return rpc.id
+}
+
+func (c *testCorrelator) ResponseID(response Message) (interface{}, bool) {
+	rpc, ok := response.(*testRPC)
+	if !ok || 0 == rpc.id {
+		return nil, false
+	}
+	return rpc.id, true
+}
+
+func TestRequestResponseHandler(t *testing.T) {
+
+	handler := NewRequestResponseHandler(&testCorrelator{}, time.Millisecond*200)
+	pushed := make(chan Message, 1)
+
+	ch, peer := pipeChannel(NewAsyncWriteChannel(64, true), "127.0.0.1:9527",
+		delimiterCodec{maxFrameLength: 1024, delimiter: []byte("\n"), stripDelimiter: true},
+		&textCodec{},
+		testRPCCodec{},
+		handler,
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			pushed <- message
+		}),
+	)
+	defer ch.Close(nil)
+
+	// the peer responds in random order, and ignores the requests of "timeout".
+	go func() {
+		var mutex sync.Mutex
+		scanner := bufio.NewScanner(peer)
+		for scanner.Scan() {
+			line := scanner.Text()
+			go func() {
+				var id int64
+				var body string
+				_, _ = fmt.Sscanf(line, "%d %s", &id, &body)
+				if "timeout" == body {
+					return
+				}
+
+				time.Sleep(time.Duration(rand.Intn(20)) * time.Millisecond)
+				mutex.Lock()
+				defer mutex.Unlock()
+				_, _ = fmt.Fprintf(peer, "%d echo-%s\n", id, body)
+			}()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf("request-%d", i)
+			response, err := handler.Request(&testRPC{body: body}).Response()
+			if nil != err {
+				t.Error(err)
+				return
+			}
+			if got := response.(*testRPC).body; "echo-"+body != got {
+				t.Errorf("mismatched response: %s, want: echo-%s", got, body)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// timeout eviction
+	start := time.Now()
+	if _, err := handler.Request(&testRPC{body: "timeout"}).Response(); !errors.Is(err, ErrRequestTimeout) {
+		t.Fatal("expect timeout, got:", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*200 {
+		t.Fatal("timeout too early:", elapsed)
+	}
+	if n := handler.Pending(); 0 != n {
+		t.Fatal("timed out request is not evicted:", n)
+	}
+
+	// the messages which are not responses are passed to the next handler.
+	_, _ = fmt.Fprintf(peer, "0 push\n")
+	select {
+	case message := <-pushed:
+		if "push" != message.(*testRPC).body {
+			t.Fatal("unexpected message:", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not passed")
+	}
+
+	// the outstanding requests fail on close.
+	future := handler.Request(&testRPC{body: "timeout"})
+	ch.Close(nil)
+	if err := future.Wait(); !errors.Is(err, ErrChannelClosed) {
+		t.Fatal("expect closed, got:", err)
+	}
+	if err := handler.Request(&testRPC{body: "closed"}).Wait(); !errors.Is(err, ErrChannelClosed) {
+		t.Fatal("expect closed, got:", err)
+	}
+}
+
+func TestRequestResponseHandlerMaxInFlight(t *testing.T) {
+
+	handler := NewRequestResponseHandlerWith(&testCorrelator{}, RequestOptions{
+		Timeout:      time.Second,
+		MaxInFlight:  2,
+		QueueTimeout: time.Millisecond * 200,
+	})
+
+	ch, peer := pipeChannel(NewAsyncWriteChannel(64, true), "127.0.0.1:9527",
+		delimiterCodec{maxFrameLength: 1024, delimiter: []byte("\n"), stripDelimiter: true},
+		&textCodec{},
+		testRPCCodec{},
+		handler,
+	)
+	defer ch.Close(nil)
+
+	// the peer responds on demand.
+	requests := make(chan int64, 16)
+	go func() {
+		scanner := bufio.NewScanner(peer)
+		for scanner.Scan() {
+			var id int64
+			var body string
+			_, _ = fmt.Sscanf(scanner.Text(), "%d %s", &id, &body)
+			requests <- id
+		}
+	}()
+
+	received := func() int64 {
+		select {
+		case id := <-requests:
+			return id
+		case <-time.After(time.Second):
+			t.Fatal("request is not sent")
+			return 0
+		}
+	}
+
+	var futures []ResponseFuture
+	for i := 0; i < 5; i++ {
+		futures = append(futures, handler.Request(&testRPC{body: fmt.Sprintf("request-%d", i)}))
+	}
+
+	// only 2 requests are sent.
+	first, second := received(), received()
+	select {
+	case id := <-requests:
+		t.Fatal("request beyond the limit is sent:", id)
+	case <-time.After(time.Millisecond * 50):
+	}
+	if 2 != handler.Pending() || 3 != handler.Queued() {
+		t.Fatal("unexpected pending:", handler.Pending(), "queued:", handler.Queued())
+	}
+
+	// the queued requests are sent in order as the responses free the slots.
+	for _, id := range []int64{first, second} {
+		_, _ = fmt.Fprintf(peer, "%d echo\n", id)
+		if next := received(); next <= second {
+			t.Fatal("unexpected request:", next)
+		}
+	}
+	for i, future := range futures[:2] {
+		if _, err := future.Response(); nil != err {
+			t.Fatal("request", i, err)
+		}
+	}
+
+	// the last queued request times out waiting for a free slot.
+	if err := futures[4].Wait(); !errors.Is(err, ErrRequestQueueTimeout) {
+		t.Fatal("expect queue timeout, got:", err)
+	}
+	if 0 != handler.Queued() {
+		t.Fatal("timed out request is not dequeued:", handler.Queued())
+	}
+
+	// queued requests fail on close.
+	queued := handler.Request(&testRPC{body: "queued"})
+	ch.Close(nil)
+	if err := queued.Wait(); !errors.Is(err, ErrChannelClosed) {
+		t.Fatal("expect closed, got:", err)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrNotUnixSocket is returned by PeerCredentials if the channel is not over a unix domain socket.
+var ErrNotUnixSocket = errors.New("netty: not a unix socket")
+
+// errCredentialsNotSupported is returned by PeerCredentials on the platforms without SO_PEERCRED or LOCAL_PEERCRED.
+var errCredentialsNotSupported = errors.New("netty: peer credentials not supported on this platform")
+
+// Ucred defines the credentials of the peer process
+type Ucred struct {
+	Pid int32
+	Uid uint32
+	Gid uint32
+}
+
+// PeerCredentials returns the credentials of the peer process of a unix socket channel, it is
+// retrieved by SO_PEERCRED on linux, and LOCAL_PEERCRED on darwin & freebsd, the pid is not
+// available on the old freebsd and reported as 0.
+func PeerCredentials(ch Channel) (Ucred, error) {
+	conn, _ := RawConn(ch)
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return Ucred{}, fmt.Errorf("%w: %T", ErrNotUnixSocket, conn)
+	}
+
+	rawConn, err := unixConn.SyscallConn()
+	if nil != err {
+		return Ucred{}, err
+	}
+
+	var cred Ucred
+	var credErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		cred, credErr = peerCredentials(fd)
+	}); nil != err {
+		return Ucred{}, err
+	}
+	return cred, credErr
+}
@@ -0,0 +1,68 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	solLocal      = 0
+	localPeerCred = 1
+	localPeerPid  = 2 // darwin only
+)
+
+// xucred is the credentials of LOCAL_PEERCRED, the pid of freebsd is at the start of the
+// union following the groups, which is aligned as a pointer.
+type xucred struct {
+	version uint32
+	uid     uint32
+	ngroups int16
+	groups  [16]uint32
+	pid     uintptr
+}
+
+// peerCredentials to get the credentials of peer by LOCAL_PEERCRED.
+func peerCredentials(fd uintptr) (Ucred, error) {
+	var cred xucred
+	size := uint32(unsafe.Sizeof(cred))
+	if _, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, solLocal, localPeerCred,
+		uintptr(unsafe.Pointer(&cred)), uintptr(unsafe.Pointer(&size)), 0); 0 != errno {
+		return Ucred{}, errno
+	}
+
+	ucred := Ucred{Uid: cred.uid}
+	if cred.ngroups > 0 {
+		ucred.Gid = cred.groups[0]
+	}
+
+	if "darwin" == runtime.GOOS {
+		pid, err := syscall.GetsockoptInt(int(fd), solLocal, localPeerPid)
+		if nil != err {
+			return Ucred{}, err
+		}
+		ucred.Pid = int32(pid)
+	} else if size >= uint32(unsafe.Offsetof(cred.pid)+4) {
+		ucred.Pid = *(*int32)(unsafe.Pointer(&cred.pid))
+	}
+	return ucred, nil
+}
@@ -0,0 +1,28 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import "syscall"
+
+// peerCredentials to get the credentials of peer by SO_PEERCRED.
+func peerCredentials(fd uintptr) (Ucred, error) {
+	cred, err := syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if nil != err {
+		return Ucred{}, err
+	}
+	return Ucred{Pid: cred.Pid, Uid: cred.Uid, Gid: cred.Gid}, nil
+}
@@ -0,0 +1,79 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestPeerCredentials(t *testing.T) {
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: filepath.Join(t.TempDir(), "netty.sock"), Net: "unix"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	client, err := net.DialUnix("unix", nil, listener.Addr().(*net.UnixAddr))
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn, err := listener.AcceptUnix()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	pl := NewPipeline().AddLast(discardHandler{})
+	ch := NewChannel()(testChannelID(), context.Background(), pl, transport.NewTransport(conn, 0, 0), AsyncExecutor())
+	pl.ServeChannel(ch)
+	defer ch.Close(nil)
+
+	cred, err := PeerCredentials(ch)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if uint32(os.Geteuid()) != cred.Uid || uint32(os.Getegid()) != cred.Gid {
+		t.Fatalf("unexpected credentials: %+v, euid: %d, egid: %d", cred, os.Geteuid(), os.Getegid())
+	}
+
+	if "freebsd" != runtime.GOOS && int32(os.Getpid()) != cred.Pid {
+		t.Fatalf("unexpected pid: %d, want: %d", cred.Pid, os.Getpid())
+	}
+
+	// the channel over net.Pipe is not a unix socket.
+	pipe, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+	defer pipe.Close(nil)
+	defer peer.Close()
+
+	if _, err := PeerCredentials(pipe); !errors.Is(err, ErrNotUnixSocket) {
+		t.Fatal("unexpected error:", err)
+	}
+}
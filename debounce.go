@@ -0,0 +1,180 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// DefaultDebounceMaxKeys is the default max number of pending keys of DebounceHandler.
+const DefaultDebounceMaxKeys = 1024
+
+// DebounceOptions for DebounceHandlerWith
+type DebounceOptions struct {
+	// KeyOf returns the key of the outbound message, the messages of the same key supersede each other.
+	KeyOf func(message Message) string `json:"-"`
+	// Window is the quiet period after the latest message of a key before it is written.
+	Window time.Duration `json:"window"`
+	// MaxKeys bounds the pending keys, the oldest pending message is written at once to make room
+	// for a new key, DefaultDebounceMaxKeys if zero.
+	MaxKeys int `json:"maxKeys"`
+}
+
+// DebounceHandler create a handler to coalesce the bursts of outbound messages of the same key, the latest
+// message of a key is written after window elapsed without a newer one, create a new one for each channel.
+func DebounceHandler(keyOf func(message Message) string, window time.Duration) ChannelHandler {
+	return DebounceHandlerWith(DebounceOptions{KeyOf: keyOf, Window: window})
+}
+
+// DebounceHandlerWith create a DebounceHandler with options, the superseded messages are released if
+// reference counted, and the pending messages are dropped after the channel is inactive.
+func DebounceHandlerWith(options DebounceOptions) ChannelHandler {
+	utils.AssertIf(nil == options.KeyOf, "keyOf is required")
+	utils.AssertIf(options.Window <= 0, "window must be a positive duration")
+	utils.AssertIf(options.MaxKeys < 0, "maxKeys must be a positive integer")
+	if 0 == options.MaxKeys {
+		options.MaxKeys = DefaultDebounceMaxKeys
+	}
+	return &debounceHandler{options: options, pending: make(map[string]*debounced), order: list.New()}
+}
+
+// debounced is the pending message of a key.
+type debounced struct {
+	key     string
+	message Message
+	due     time.Time
+	timer   Timer
+	element *list.Element
+}
+
+type debounceHandler struct {
+	options DebounceOptions
+	mutex   sync.Mutex
+	clock   Clock
+	closed  bool
+	pending map[string]*debounced
+	order   *list.List // the pending keys, the oldest first.
+}
+
+func (d *debounceHandler) HandleActive(ctx ActiveContext) {
+	ctx.HandleActive()
+}
+
+func (d *debounceHandler) HandleRead(ctx InboundContext, message Message) {
+	ctx.HandleRead(message)
+}
+
+func (d *debounceHandler) HandleWrite(ctx OutboundContext, message Message) {
+	key := d.options.KeyOf(message)
+
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		releaseMessage(message)
+		return
+	}
+
+	if nil == d.clock {
+		d.clock = ClockOf(ctx.Channel())
+	}
+	due := d.clock.Now().Add(d.options.Window)
+
+	// supersede the pending message of the key.
+	if p, ok := d.pending[key]; ok {
+		superseded := p.message
+		p.message, p.due = message, due
+		p.timer.Reset(d.options.Window)
+		d.order.MoveToBack(p.element)
+		d.mutex.Unlock()
+		releaseMessage(superseded)
+		return
+	}
+
+	// make room for the new key.
+	var evicted *debounced
+	if d.order.Len() >= d.options.MaxKeys {
+		evicted = d.removeLocked(d.order.Front().Value.(*debounced))
+	}
+
+	p := &debounced{key: key, message: message, due: due}
+	p.element = d.order.PushBack(p)
+	p.timer = d.clock.AfterFunc(d.options.Window, func() { d.expire(ctx, p) })
+	d.pending[key] = p
+	d.mutex.Unlock()
+
+	if nil != evicted {
+		// the evicted message is held by the handler, so it is released if failed.
+		defer func() {
+			if e := recover(); nil != e {
+				releaseMessage(evicted.message)
+				panic(e)
+			}
+		}()
+		ctx.HandleWrite(evicted.message)
+	}
+}
+
+func (d *debounceHandler) HandleException(ctx ExceptionContext, ex Exception) {
+	ctx.HandleException(ex)
+}
+
+func (d *debounceHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	d.mutex.Lock()
+	d.closed = true
+	var dropped []Message
+	for _, p := range d.pending {
+		d.removeLocked(p)
+		dropped = append(dropped, p.message)
+	}
+	d.mutex.Unlock()
+
+	for _, message := range dropped {
+		releaseMessage(message)
+	}
+	ctx.HandleInactive(ex)
+}
+
+// removeLocked the pending message and stop its timer.
+func (d *debounceHandler) removeLocked(p *debounced) *debounced {
+	p.timer.Stop()
+	d.order.Remove(p.element)
+	delete(d.pending, p.key)
+	return p
+}
+
+// expire write the pending message if it is not superseded within the window.
+func (d *debounceHandler) expire(ctx OutboundContext, p *debounced) {
+	d.mutex.Lock()
+	// written, dropped or rescheduled by a newer message.
+	if d.pending[p.key] != p || d.clock.Now().Before(p.due) {
+		d.mutex.Unlock()
+		return
+	}
+	d.removeLocked(p)
+	message := p.message
+	d.mutex.Unlock()
+
+	// the failed message is held by the handler, so it is released here.
+	if err := writeSerialized(ctx, message); nil != err {
+		releaseMessage(message)
+		ctx.Channel().Pipeline().FireChannelException(AsException(err))
+	}
+}
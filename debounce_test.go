@@ -0,0 +1,120 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// keyOfUpdate returns the key of "key=value"
+func keyOfUpdate(message Message) string {
+	return string(bytes.SplitN(message.([]byte), []byte("="), 2)[0])
+}
+
+func TestDebounceHandler(t *testing.T) {
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{}, DebounceHandler(keyOfUpdate, time.Second))
+	defer peer.Close()
+	defer ch.Close(nil)
+	ch.SetAttribute(ClockAttribute, clock)
+	messages := readPeer(peer)
+
+	// rapid updates of a key within the window.
+	for i := 0; i < 5; i++ {
+		if err := ch.Write([]byte("x=" + string(rune('0'+i)))); nil != err {
+			t.Fatal(err)
+		}
+		clock.Advance(500 * time.Millisecond)
+	}
+
+	if err := ch.Write([]byte("y=0")); nil != err {
+		t.Fatal(err)
+	}
+
+	select {
+	case m := <-messages:
+		t.Fatal("written before the window elapsed:", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// only the latest of x is written after the quiet window.
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case m := <-messages:
+		if "x=4" != m {
+			t.Fatal("unexpected message:", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("latest message is not written")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case m := <-messages:
+		if "y=0" != m {
+			t.Fatal("unexpected message:", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message of y is not written")
+	}
+
+	if 0 != clock.Timers() {
+		t.Fatal("timers left:", clock.Timers())
+	}
+}
+
+func TestDebounceHandlerMaxKeys(t *testing.T) {
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	handler := DebounceHandlerWith(DebounceOptions{KeyOf: keyOfUpdate, Window: time.Second, MaxKeys: 2})
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{}, handler)
+	defer peer.Close()
+	ch.SetAttribute(ClockAttribute, clock)
+	messages := readPeer(peer)
+
+	for _, m := range []string{"a=1", "b=1", "a=2", "c=1"} {
+		if err := ch.Write([]byte(m)); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	// the oldest pending key b is written at once to make room for c.
+	select {
+	case m := <-messages:
+		if "b=1" != m {
+			t.Fatal("unexpected message:", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("oldest key is not evicted")
+	}
+
+	if n := len(handler.(*debounceHandler).pending); 2 != n {
+		t.Fatal("unexpected pending keys:", n)
+	}
+
+	// the pending messages are dropped after closed.
+	ch.Close(nil)
+	<-ch.Context().Done()
+	for deadline := time.Now().Add(time.Second); 0 != clock.Timers(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timers left:", clock.Timers())
+		}
+	}
+}
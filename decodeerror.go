@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DecodeErrorCategory defines the category of decode errors, it is shared by the codecs.
+type DecodeErrorCategory int
+
+const (
+	// DecodeErrorOther is the decode error not categorized.
+	DecodeErrorOther DecodeErrorCategory = iota
+	// DecodeErrorTooLong the frame exceeds the max frame length.
+	DecodeErrorTooLong
+	// DecodeErrorMalformed the frame is malformed, e.g. a negative length field or a mismatched header.
+	DecodeErrorMalformed
+	// DecodeErrorChecksumMismatch the checksum of frame is mismatched.
+	DecodeErrorChecksumMismatch
+	// DecodeErrorDecryptFailed the frame failed to be decrypted or authenticated.
+	DecodeErrorDecryptFailed
+
+	decodeErrorCategories
+)
+
+var decodeErrorNames = [decodeErrorCategories]string{"other", "too-long", "malformed", "checksum-mismatch", "decrypt-failed"}
+
+func (c DecodeErrorCategory) String() string {
+	if c >= 0 && c < decodeErrorCategories {
+		return decodeErrorNames[c]
+	}
+	return fmt.Sprintf("DecodeErrorCategory(%d)", int(c))
+}
+
+// DecodeErrorEvent is triggered by the codecs when an inbound frame failed to decode,
+// before the error is raised or the channel is closed by the error strategy of codec.
+type DecodeErrorEvent struct {
+	Category DecodeErrorCategory
+	Err      error
+}
+
+// DecodeErrorCounters defines the count of decode errors by category.
+type DecodeErrorCounters map[DecodeErrorCategory]int64
+
+// DecodeErrorMetrics aggregates the DecodeErrorEvents counted by DecodeErrorMetricsHandler.
+type DecodeErrorMetrics interface {
+	// Global returns the counters of all channels, including the closed ones.
+	Global() DecodeErrorCounters
+
+	// Channel returns the counters of an active channel, nil if the channel has no decode error,
+	// the counters of channel are removed when it is inactive.
+	Channel(id int64) DecodeErrorCounters
+
+	// Reset the counters.
+	Reset()
+
+	record(id int64, category DecodeErrorCategory)
+	remove(id int64)
+}
+
+// NewDecodeErrorMetrics create an empty DecodeErrorMetrics
+func NewDecodeErrorMetrics() DecodeErrorMetrics {
+	return &decodeErrorMetrics{channels: make(map[int64]*decodeErrorCounts)}
+}
+
+type decodeErrorCounts [decodeErrorCategories]int64
+
+func (c *decodeErrorCounts) counters() DecodeErrorCounters {
+	counters := make(DecodeErrorCounters)
+	for category, n := range c {
+		if n > 0 {
+			counters[DecodeErrorCategory(category)] = n
+		}
+	}
+	return counters
+}
+
+type decodeErrorMetrics struct {
+	mutex    sync.Mutex
+	global   decodeErrorCounts
+	channels map[int64]*decodeErrorCounts
+}
+
+func (m *decodeErrorMetrics) Global() DecodeErrorCounters {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.global.counters()
+}
+
+func (m *decodeErrorMetrics) Channel(id int64) DecodeErrorCounters {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if counts, ok := m.channels[id]; ok {
+		return counts.counters()
+	}
+	return nil
+}
+
+func (m *decodeErrorMetrics) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.global = decodeErrorCounts{}
+	for _, counts := range m.channels {
+		*counts = decodeErrorCounts{}
+	}
+}
+
+func (m *decodeErrorMetrics) record(id int64, category DecodeErrorCategory) {
+	if category < 0 || category >= decodeErrorCategories {
+		category = DecodeErrorOther
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	counts, ok := m.channels[id]
+	if !ok {
+		counts = new(decodeErrorCounts)
+		m.channels[id] = counts
+	}
+	counts[category]++
+	m.global[category]++
+}
+
+func (m *decodeErrorMetrics) remove(id int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.channels, id)
+}
+
+// DecodeErrorMetricsHandler counts the DecodeErrorEvents of channel into the metrics, it should be placed
+// after the codecs, the event is passed on to the next handlers, the handler could be shared by channels.
+func DecodeErrorMetricsHandler(metrics DecodeErrorMetrics) Handler {
+	return &decodeErrorMetricsHandler{metrics: metrics}
+}
+
+type decodeErrorMetricsHandler struct {
+	metrics DecodeErrorMetrics
+}
+
+func (h *decodeErrorMetricsHandler) HandleEvent(ctx EventContext, event Event) {
+	if e, ok := event.(DecodeErrorEvent); ok {
+		h.metrics.record(ctx.Channel().ID(), e.Category)
+	}
+	ctx.HandleEvent(event)
+}
+
+func (h *decodeErrorMetricsHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	h.metrics.remove(ctx.Channel().ID())
+	ctx.HandleInactive(ex)
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeErrorMetricsHandler(t *testing.T) {
+
+	metrics := NewDecodeErrorMetrics()
+	passed := make(chan Event, 8)
+
+	// triggers the decode error events like a codec.
+	newChannel := func() (Channel, func(categories ...DecodeErrorCategory)) {
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+			discardHandler{},
+			DecodeErrorMetricsHandler(metrics),
+			EventHandlerFunc(func(ctx EventContext, event Event) {
+				passed <- event
+			}),
+		)
+		t.Cleanup(func() { _ = peer.Close() })
+
+		return ch, func(categories ...DecodeErrorCategory) {
+			for _, category := range categories {
+				ch.Trigger(DecodeErrorEvent{Category: category, Err: errors.New(category.String())})
+				select {
+				case <-passed:
+				case <-time.After(time.Second):
+					t.Fatal("event is not passed on")
+				}
+			}
+		}
+	}
+
+	alice, triggerAlice := newChannel()
+	bob, triggerBob := newChannel()
+
+	triggerAlice(DecodeErrorTooLong, DecodeErrorTooLong, DecodeErrorMalformed)
+	triggerBob(DecodeErrorChecksumMismatch, DecodeErrorDecryptFailed, DecodeErrorOther, DecodeErrorTooLong)
+
+	var cases = []struct {
+		name     string
+		counters DecodeErrorCounters
+		want     DecodeErrorCounters
+	}{
+		{name: "alice", counters: metrics.Channel(alice.ID()), want: DecodeErrorCounters{DecodeErrorTooLong: 2, DecodeErrorMalformed: 1}},
+		{name: "bob", counters: metrics.Channel(bob.ID()), want: DecodeErrorCounters{
+			DecodeErrorChecksumMismatch: 1, DecodeErrorDecryptFailed: 1, DecodeErrorOther: 1, DecodeErrorTooLong: 1,
+		}},
+		{name: "global", counters: metrics.Global(), want: DecodeErrorCounters{
+			DecodeErrorTooLong: 3, DecodeErrorMalformed: 1, DecodeErrorChecksumMismatch: 1, DecodeErrorDecryptFailed: 1, DecodeErrorOther: 1,
+		}},
+	}
+
+	for _, c := range cases {
+		if len(c.want) != len(c.counters) {
+			t.Fatal(c.name, "unexpected counters:", c.counters)
+		}
+		for category, n := range c.want {
+			if n != c.counters[category] {
+				t.Fatalf("%s: %s = %d, want: %d", c.name, category, c.counters[category], n)
+			}
+		}
+	}
+
+	// the counters of channel are removed after closed, the global counters are kept.
+	alice.Close(nil)
+	if nil != metrics.Channel(alice.ID()) {
+		t.Fatal("counters of closed channel are not removed")
+	}
+	if 3 != metrics.Global()[DecodeErrorTooLong] {
+		t.Fatal("unexpected global counters:", metrics.Global())
+	}
+
+	metrics.Reset()
+	if 0 != len(metrics.Global()) || 0 != len(metrics.Channel(bob.ID())) {
+		t.Fatal("counters are not reset")
+	}
+	bob.Close(nil)
+}
+
+func TestDecodeErrorCategoryString(t *testing.T) {
+	if "checksum-mismatch" != DecodeErrorChecksumMismatch.String() || "DecodeErrorCategory(42)" != DecodeErrorCategory(42).String() {
+		t.Fatal("unexpected names:", DecodeErrorChecksumMismatch, DecodeErrorCategory(42))
+	}
+}
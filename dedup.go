@@ -0,0 +1,55 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// DedupHandler create a handler to drop the duplicate messages, the ids of the latest window messages
+// are tracked in a LRU set, so a duplicate is dropped if its id is seen within the window, the id of
+// a duplicate is marked as recently seen again. the dropped message is released if reference counted.
+func DedupHandler(idOf func(message Message) string, window int) InboundHandler {
+	utils.AssertIf(window <= 0, "window must be a positive integer")
+	return &dedupHandler{idOf: idOf, seen: newLRUCache(window)}
+}
+
+type dedupHandler struct {
+	mutex sync.Mutex
+	idOf  func(message Message) string
+	seen  *lruCache
+}
+
+func (d *dedupHandler) HandleRead(ctx InboundContext, message Message) {
+	id := d.idOf(message)
+
+	d.mutex.Lock()
+	_, duplicated := d.seen.Get(id)
+	if !duplicated {
+		d.seen.Put(id, struct{}{})
+	}
+	d.mutex.Unlock()
+
+	if duplicated {
+		releaseMessage(message)
+		return
+	}
+
+	ctx.HandleRead(message)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupHandler(t *testing.T) {
+
+	received := make(chan string, 16)
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		byteFrameHandler{},
+		DedupHandler(func(message Message) string { return string(message.([]byte)) }, 3),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			received <- string(message.([]byte))
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	// the duplicate a is dropped within the window, b & a are forwarded again after evicted.
+	if _, err := peer.Write([]byte("abacdba")); nil != err {
+		t.Fatal(err)
+	}
+
+	var forwarded string
+	for _, want := range "abcdba" {
+		select {
+		case id := <-received:
+			forwarded += id
+		case <-time.After(time.Second):
+			t.Fatal("forwarded:", forwarded, "want:", string(want))
+		}
+	}
+
+	if "abcdba" != forwarded {
+		t.Fatal("forwarded:", forwarded)
+	}
+
+	select {
+	case id := <-received:
+		t.Fatal("unexpected message:", id)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
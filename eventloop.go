@@ -0,0 +1,348 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// errPollerNotSupported is returned if the readiness poller is not supported on the platform.
+var errPollerNotSupported = errors.New("netty: poller not supported on this platform")
+
+// EventLoopGroup defines a fixed number of event loops which serve the reads & writes of many channels, each loop
+// waits for the readiness of the registered connections, a channel is dispatched to the workers of group
+// only when its connection is readable, or writable for the pending async writes,
+// so the idle channels do not hold a goroutine each.
+// set it by WithExecutor, the plain tcp transports on linux are served by the loops, the others are
+// served by a goroutine each as the AsyncExecutor.
+type EventLoopGroup interface {
+	Executor
+
+	// Size returns the number of event loops, 0 if the platform is not supported.
+	Size() int
+
+	// Close the event loops, the registered channels are switched to a goroutine each.
+	Close() error
+}
+
+// EventLoopOptions to create an EventLoopGroup
+type EventLoopOptions struct {
+	// Size of event loops.
+	Size int
+	// Workers to preallocate, default to Size.
+	Workers int
+	// Disabled to serve the channels by a goroutine each, the workers are still reused.
+	Disabled bool
+}
+
+// NewEventLoopGroup create an EventLoopGroup with size loops, the same number of worker goroutines are
+// preallocated and reused by the dispatched channels, a new goroutine is created if all workers are busy.
+func NewEventLoopGroup(size int) EventLoopGroup {
+	return NewEventLoopGroupWith(EventLoopOptions{Size: size})
+}
+
+// NewEventLoopGroupWith create an EventLoopGroup with the options, the group falls back to
+// the goroutine-per-channel model if disabled or the platform is not supported.
+func NewEventLoopGroupWith(options EventLoopOptions) EventLoopGroup {
+	utils.AssertIf(options.Size <= 0, "size must be a positive integer")
+	utils.AssertIf(options.Workers < 0, "workers must be a non-negative integer")
+
+	if 0 == options.Workers {
+		options.Workers = options.Size
+	}
+
+	g := &eventLoopGroup{tasks: make(chan Action), done: make(chan struct{})}
+	for i := 0; i < options.Size && !options.Disabled; i++ {
+		p, err := newPoller(g.dispatch)
+		if nil != err {
+			// fallback to the goroutine-per-channel model.
+			break
+		}
+		g.loops = append(g.loops, p)
+	}
+
+	for i := 0; i < options.Workers; i++ {
+		g.workers.Add(1)
+		go g.worker()
+	}
+	return g
+}
+
+type eventLoopGroup struct {
+	loops   []poller
+	next    uint32
+	tasks   chan Action
+	workers sync.WaitGroup
+	once    sync.Once
+	done    chan struct{}
+}
+
+func (g *eventLoopGroup) Exec(action Action) {
+	select {
+	case g.tasks <- action:
+	default:
+		go action()
+	}
+}
+
+func (g *eventLoopGroup) worker() {
+	defer g.workers.Done()
+	for {
+		select {
+		case action := <-g.tasks:
+			action()
+		case <-g.done:
+			return
+		}
+	}
+}
+
+func (g *eventLoopGroup) Size() int {
+	return len(g.loops)
+}
+
+func (g *eventLoopGroup) Close() error {
+	g.once.Do(func() {
+		for _, loop := range g.loops {
+			loop.close()
+		}
+		close(g.done)
+	})
+	g.workers.Wait()
+	return nil
+}
+
+// register the channel to an event loop, false if the transport could not be polled.
+func (g *eventLoopGroup) register(c *channel) bool {
+	if 0 == len(g.loops) {
+		return false
+	}
+
+	buffered, ok := c.transport.(transport.BufferedTransport)
+	if !ok {
+		return false
+	}
+
+	// the tls connection buffers the decrypted bytes, so the readiness of socket is not applicable.
+	conn, ok := c.transport.RawTransport().(*net.TCPConn)
+	if !ok {
+		return false
+	}
+
+	rawConn, err := conn.SyscallConn()
+	if nil != err {
+		return false
+	}
+
+	r := &registration{
+		rawConn:  rawConn,
+		channel:  c,
+		buffered: buffered,
+		exec:     g.Exec,
+	}
+
+	select {
+	case <-g.done:
+		return false
+	default:
+	}
+
+	loop := g.loops[atomic.AddUint32(&g.next, 1)%uint32(len(g.loops))]
+	r.poller = loop
+	c.registration.Store(r)
+
+	// the registration may be removed by closing.
+	r.mutex.Lock()
+	if !r.closed {
+		err = loop.add(r)
+	}
+	r.mutex.Unlock()
+	return nil == err
+}
+
+// dispatch the ready channel to the workers, the reading and writing are dispatched separately.
+func (g *eventLoopGroup) dispatch(r *registration, readable, writable bool) {
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return
+	}
+
+	read := readable && !r.reading
+	write := writable && r.writing
+	r.reading = r.reading || read
+	r.writing = r.writing && !write
+
+	// the readiness is reported once, wait for the one still interested.
+	err := r.arm()
+	r.mutex.Unlock()
+
+	if nil != err {
+		g.Exec(func() { r.channel.Close(err) })
+		return
+	}
+
+	if read {
+		g.Exec(r.read)
+	}
+	if write {
+		g.Exec(r.channel.writeOnce)
+	}
+}
+
+// poller defines the readiness poller of an event loop.
+type poller interface {
+	// add the registration and wait for readable once.
+	add(r *registration) error
+	// rearm to wait for readable or writable once again, at least one of them is true.
+	rearm(r *registration, readable, writable bool) error
+	// remove the registration.
+	remove(r *registration) error
+	// close the poller, the registrations left are switched to a goroutine each before the poller is released.
+	close()
+}
+
+// registration of a channel in the event loop.
+type registration struct {
+	mutex    sync.Mutex
+	poller   poller
+	rawConn  syscall.RawConn
+	fd       int
+	channel  *channel
+	buffered transport.BufferedTransport
+	exec     func(action Action) // to dispatch the reading again
+	reading  bool
+	writing  bool
+	closed   bool
+	switched bool
+}
+
+// arm to wait for the readiness not yet dispatched, the reading is not waited until it is done.
+func (r *registration) arm() error {
+	if r.reading && !r.writing {
+		return nil
+	}
+	return r.poller.rearm(r, !r.reading, r.writing)
+}
+
+// waitWritable to dispatch the async writing once the connection is writable, false if not registered.
+func (r *registration) waitWritable() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return false
+	}
+
+	r.writing = true
+	if err := r.arm(); nil != err {
+		r.writing = false
+		return false
+	}
+	return true
+}
+
+// read the channel until the buffered bytes are consumed, then wait for readable again, the reading is
+// dispatched again after ChannelOptions.MaxReadsPerLoop reads, so a busy channel does not hold the worker.
+func (r *registration) read() {
+	c := r.channel
+	for reads := 1; ; reads++ {
+		// continued by Resume.
+		if c.gate.park(r.read) {
+			return
+		}
+
+		select {
+		case <-c.ctx.Done():
+			r.readDone()
+			return
+		default:
+			c.invokeMethod(c.fireRead)
+		}
+
+		if 0 == r.buffered.Buffered() && 0 == c.reader.buffered() {
+			break
+		}
+
+		// the buffered bytes are not waited by the poller, continue by another dispatch.
+		if reads == c.maxReads {
+			r.exec(r.read)
+			return
+		}
+	}
+	r.readDone()
+}
+
+// readDone to rearm the registration, or switch to a goroutine if it is closed by the loop.
+func (r *registration) readDone() {
+	r.mutex.Lock()
+	r.reading = false
+	if r.closed {
+		switched := r.switched
+		r.switched = false
+		r.mutex.Unlock()
+		if switched {
+			go r.channel.readForever()
+		}
+		return
+	}
+	err := r.arm()
+	r.mutex.Unlock()
+
+	if nil != err {
+		r.channel.Close(err)
+	}
+}
+
+// remove the registration before the connection is closed.
+func (r *registration) remove() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.closed {
+		r.closed = true
+		_ = r.poller.remove(r)
+	}
+	r.switched = false
+}
+
+// fallback to serve the channel by a goroutine since the loop is closed.
+func (r *registration) fallback() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return
+	}
+
+	r.closed = true
+	if r.writing {
+		r.writing = false
+		go r.channel.writeOnce()
+	}
+
+	if r.reading {
+		// switched after the current read.
+		r.switched = true
+		return
+	}
+	go r.channel.readForever()
+}
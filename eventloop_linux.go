@@ -0,0 +1,172 @@
+//go:build linux
+// +build linux
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"net"
+	"sync"
+	"syscall"
+)
+
+// epollEvents to wait for readable once, the peer closing is reported as readable.
+const epollEvents = syscall.EPOLLIN | syscall.EPOLLRDHUP | syscall.EPOLLONESHOT
+
+// epollReadable & epollWritable are the events dispatched as readable or writable, the errors are both.
+const (
+	epollReadable = syscall.EPOLLIN | syscall.EPOLLRDHUP | syscall.EPOLLHUP | syscall.EPOLLERR
+	epollWritable = syscall.EPOLLOUT | syscall.EPOLLHUP | syscall.EPOLLERR
+)
+
+// epoller is the poller of epoll.
+type epoller struct {
+	epfd          int
+	wake          [2]int
+	mutex         sync.Mutex
+	registrations map[int]*registration
+	dispatch      func(r *registration, readable, writable bool)
+	done          chan struct{}
+	closed        bool
+}
+
+func newPoller(dispatch func(r *registration, readable, writable bool)) (poller, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if nil != err {
+		return nil, err
+	}
+
+	// the pipe to wake up the loop on closing.
+	e := &epoller{epfd: epfd, registrations: make(map[int]*registration), dispatch: dispatch, done: make(chan struct{})}
+	if err = syscall.Pipe2(e.wake[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC); nil != err {
+		_ = syscall.Close(epfd)
+		return nil, err
+	}
+
+	if err = syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, e.wake[0], &syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(e.wake[0])}); nil != err {
+		e.release()
+		return nil, err
+	}
+
+	go e.loop()
+	return e, nil
+}
+
+func (e *epoller) loop() {
+	defer close(e.done)
+
+	events := make([]syscall.EpollEvent, 128)
+	for {
+		n, err := syscall.EpollWait(e.epfd, events, -1)
+		if nil != err {
+			if syscall.EINTR == err {
+				continue
+			}
+			return
+		}
+
+		for i := 0; i < n; i++ {
+			fd := int(events[i].Fd)
+			if fd == e.wake[0] {
+				return
+			}
+
+			e.mutex.Lock()
+			r := e.registrations[fd]
+			e.mutex.Unlock()
+
+			if nil != r {
+				e.dispatch(r, 0 != events[i].Events&epollReadable, 0 != events[i].Events&epollWritable)
+			}
+		}
+	}
+}
+
+// control the fd of registration, the connection is never closed during the control.
+func (e *epoller) control(r *registration, op int, events uint32) error {
+	var ctlErr error
+	if err := r.rawConn.Control(func(fd uintptr) {
+		r.fd = int(fd)
+		ctlErr = syscall.EpollCtl(e.epfd, op, r.fd, &syscall.EpollEvent{Events: events, Fd: int32(fd)})
+	}); nil != err {
+		return err
+	}
+	return ctlErr
+}
+
+func (e *epoller) add(r *registration) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return net.ErrClosed
+	}
+
+	// hold the lock, so the registration is found by the loop once the fd is added.
+	if err := e.control(r, syscall.EPOLL_CTL_ADD, epollEvents); nil != err {
+		return err
+	}
+	e.registrations[r.fd] = r
+	return nil
+}
+
+func (e *epoller) rearm(r *registration, readable, writable bool) error {
+	var events uint32 = syscall.EPOLLONESHOT
+	if readable {
+		events |= syscall.EPOLLIN | syscall.EPOLLRDHUP
+	}
+	if writable {
+		events |= syscall.EPOLLOUT
+	}
+	return e.control(r, syscall.EPOLL_CTL_MOD, events)
+}
+
+func (e *epoller) remove(r *registration) error {
+	e.mutex.Lock()
+	if e.registrations[r.fd] == r {
+		delete(e.registrations, r.fd)
+	}
+	e.mutex.Unlock()
+	return e.control(r, syscall.EPOLL_CTL_DEL, 0)
+}
+
+func (e *epoller) close() {
+	e.mutex.Lock()
+	if e.closed {
+		e.mutex.Unlock()
+		return
+	}
+	e.closed = true
+	registrations := e.registrations
+	e.registrations = map[int]*registration{}
+	e.mutex.Unlock()
+
+	_, _ = syscall.Write(e.wake[1], []byte{0})
+	<-e.done
+
+	for _, r := range registrations {
+		r.fallback()
+	}
+	e.release()
+}
+
+func (e *epoller) release() {
+	_ = syscall.Close(e.wake[0])
+	_ = syscall.Close(e.wake[1])
+	_ = syscall.Close(e.epfd)
+}
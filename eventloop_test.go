@@ -0,0 +1,432 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport/tcp"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// loopEchoHandler write the inbound bytes back.
+type loopEchoHandler struct{}
+
+func (loopEchoHandler) HandleRead(ctx InboundContext, message Message) {
+	buffer := make([]byte, 1024)
+	n := utils.AssertLength(utils.MustToReader(message).Read(buffer))
+	ctx.Write(buffer[:n])
+}
+
+// echoServer listen the address with the executor, returns the counter of active channels.
+func echoServer(executor Executor, address string, option ...Option) (Bootstrap, *int32) {
+	var active int32
+	bs := NewBootstrap(append([]Option{WithExecutor(executor), WithChildInitializer(func(ch Channel) {
+		ch.Pipeline().AddLast(ActiveHandlerFunc(func(ctx ActiveContext) {
+			atomic.AddInt32(&active, 1)
+			ctx.HandleActive()
+		}), loopEchoHandler{}, ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		}))
+	})}, option...)...)
+	bs.Listen(address).Async(func(err error) {})
+	return bs, &active
+}
+
+// dialEcho to connect the echo server n times, and wait for the channels activated.
+func dialEcho(t testing.TB, address string, n int, active *int32) []net.Conn {
+	conns := make([]net.Conn, 0, n)
+	for i := 0; i < n; i++ {
+		var conn net.Conn
+		var err error
+		for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+			if conn, err = net.Dial("tcp", address); nil == err {
+				break
+			}
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+
+	for deadline := time.Now().Add(time.Second * 2); atomic.LoadInt32(active) < int32(n); time.Sleep(time.Millisecond * 10) {
+		if time.Now().After(deadline) {
+			t.Fatal("active channels:", atomic.LoadInt32(active), "want:", n)
+		}
+	}
+	return conns
+}
+
+func echo(conn net.Conn, message string) error {
+	if _, err := conn.Write([]byte(message)); nil != err {
+		return err
+	}
+
+	buffer := make([]byte, len(message))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(conn, buffer); nil != err {
+		return err
+	}
+	if message != string(buffer) {
+		return fmt.Errorf("echo: %s, want: %s", buffer, message)
+	}
+	return nil
+}
+
+func TestEventLoopGroup(t *testing.T) {
+
+	group := NewEventLoopGroup(2)
+	if 0 == group.Size() {
+		_ = group.Close()
+		t.Skip("event loop is not supported")
+	}
+
+	bs, active := echoServer(group, "127.0.0.1:9543")
+	defer bs.Shutdown()
+
+	const connections = 200
+	before := runtime.NumGoroutine()
+	conns := dialEcho(t, "127.0.0.1:9543", connections, active)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	// the idle channels do not hold goroutines.
+	if delta := runtime.NumGoroutine() - before; delta > connections/10 {
+		t.Fatal("goroutines increased:", delta)
+	}
+
+	for i, conn := range conns {
+		if err := echo(conn, fmt.Sprint("hello#", i)); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	// partial frames and more bytes than a read.
+	if err := echo(conns[0], string(make([]byte, 4096))); nil != err {
+		t.Fatal(err)
+	}
+
+	// the registered channels are switched to a goroutine each after closed.
+	if err := group.Close(); nil != err {
+		t.Fatal(err)
+	}
+
+	for i, conn := range conns[:10] {
+		if err := echo(conn, fmt.Sprint("again#", i)); nil != err {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEventLoopGroupClosing(t *testing.T) {
+
+	group := NewEventLoopGroup(1)
+	defer group.Close()
+
+	inactive := make(chan struct{}, 8)
+	bs := NewBootstrap(WithExecutor(group), WithChildInitializer(func(ch Channel) {
+		ch.Pipeline().AddLast(loopEchoHandler{}, ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		}), InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+			inactive <- struct{}{}
+		}))
+	}))
+	defer bs.Shutdown()
+	bs.Listen("127.0.0.1:9544").Async(func(err error) {})
+
+	for i := 0; i < 4; i++ {
+		var conn net.Conn
+		var err error
+		for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+			if conn, err = net.Dial("tcp", "127.0.0.1:9544"); nil == err {
+				break
+			}
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if err := echo(conn, "ping"); nil != err {
+			t.Fatal(err)
+		}
+
+		// the peer closing is dispatched as readable.
+		_ = conn.Close()
+		select {
+		case <-inactive:
+		case <-time.After(time.Second):
+			t.Fatal("channel is not closed with the peer")
+		}
+	}
+}
+
+func TestEventLoopGroupIdleConnections(t *testing.T) {
+
+	group := NewEventLoopGroupWith(EventLoopOptions{Size: 2, Workers: 4})
+	defer group.Close()
+	if 0 == group.Size() {
+		t.Skip("event loop is not supported")
+	}
+
+	// reply the remote address, so the dispatched channel is verified.
+	var active int32
+	bs := NewBootstrap(WithExecutor(group), WithChildInitializer(func(ch Channel) {
+		ch.Pipeline().AddLast(ActiveHandlerFunc(func(ctx ActiveContext) {
+			atomic.AddInt32(&active, 1)
+			ctx.HandleActive()
+		}), InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			_, _ = utils.MustToReader(message).Read(make([]byte, 64))
+			ctx.Write([]byte(fmt.Sprintf("%-32s", ctx.Channel().RemoteAddr())))
+		}), ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		}))
+	}))
+	defer bs.Shutdown()
+	bs.Listen("127.0.0.1:9547").Async(func(err error) {})
+
+	const connections = 1000
+	before := runtime.NumGoroutine()
+	conns := dialEcho(t, "127.0.0.1:9547", connections, &active)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	if delta := runtime.NumGoroutine() - before; delta > 16 {
+		t.Fatal("goroutines increased:", delta)
+	}
+
+	// wake up a few channels at the same time, the others keep idle.
+	for round := 0; round < 5; round++ {
+		wakes := conns[round*100 : round*100+20]
+		for _, conn := range wakes {
+			if _, err := conn.Write([]byte("ping")); nil != err {
+				t.Fatal(err)
+			}
+		}
+
+		for _, conn := range wakes {
+			buffer := make([]byte, 32)
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			if _, err := io.ReadFull(conn, buffer); nil != err {
+				t.Fatal(err)
+			}
+			if remote := strings.TrimSpace(string(buffer)); conn.LocalAddr().String() != remote {
+				t.Fatal("dispatched to:", remote, "want:", conn.LocalAddr())
+			}
+		}
+
+		if delta := runtime.NumGoroutine() - before; delta > 16 {
+			t.Fatal("goroutines increased:", delta)
+		}
+	}
+}
+
+// goroutineID parse the id of the current goroutine from the header of its stack, e.g. "goroutine 18 [running]:".
+func goroutineID() int64 {
+	var buffer [64]byte
+	header := buffer[len("goroutine "):runtime.Stack(buffer[:], false)]
+	for i, c := range header {
+		if ' ' == c {
+			header = header[:i]
+			break
+		}
+	}
+	id, _ := strconv.ParseInt(string(header), 10, 64)
+	return id
+}
+
+func TestEventLoopGroupMaxReadsPerLoop(t *testing.T) {
+
+	group := NewEventLoopGroupWith(EventLoopOptions{Size: 1, Workers: 1})
+	defer group.Close()
+	if 0 == group.Size() {
+		t.Skip("event loop is not supported")
+	}
+
+	const frames, maxReads = 64, 4
+
+	// the busy channel reads a frame of 4 bytes per read, the goroutine of each read is recorded.
+	var active int32
+	readers := make(chan int64, frames)
+	idle := make(chan struct{}, 1)
+	bs := NewBootstrap(WithExecutor(group), WithChannel(NewChannelWith(ChannelOptions{MaxReadsPerLoop: maxReads})),
+		WithChildInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(ActiveHandlerFunc(func(ctx ActiveContext) {
+				atomic.AddInt32(&active, 1)
+				ctx.HandleActive()
+			}), InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				frame := make([]byte, 4)
+				utils.AssertLength(io.ReadFull(utils.MustToReader(message), frame))
+				if "ping" == string(frame) {
+					idle <- struct{}{}
+					return
+				}
+				readers <- goroutineID()
+				time.Sleep(time.Millisecond)
+			}))
+		}))
+	defer bs.Shutdown()
+	// the frames are buffered by the transport, they are not waited by the poller.
+	bs.Listen("127.0.0.1:9565", tcp.WithOptions(&tcp.Options{Timeout: time.Second, ReadBufferSize: 4096})).Async(func(err error) {})
+
+	conns := dialEcho(t, "127.0.0.1:9565", 2, &active)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	if _, err := conns[0].Write([]byte(strings.Repeat("busy", frames))); nil != err {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 10)
+	if _, err := conns[1].Write([]byte("ping")); nil != err {
+		t.Fatal(err)
+	}
+
+	// the idle channel is served while the busy channel is reading.
+	select {
+	case <-idle:
+	case <-time.After(time.Second):
+		t.Fatal("idle channel is starved")
+	}
+	if len(readers) == frames {
+		t.Fatal("idle channel is served after the busy channel")
+	}
+
+	// the reading is dispatched again after max reads.
+	var last int64
+	var run int
+	for i := 0; i < frames; i++ {
+		select {
+		case id := <-readers:
+			if id != last {
+				last, run = id, 0
+			}
+			if run++; run > maxReads {
+				t.Fatalf("read #%d: %d reads in a dispatch, want: %d", i, run, maxReads)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("busy channel is not reading:", i)
+		}
+	}
+}
+
+func TestEventLoopGroupWritable(t *testing.T) {
+
+	group := NewEventLoopGroup(1)
+	defer group.Close()
+	if 0 == group.Size() {
+		t.Skip("event loop is not supported")
+	}
+
+	// the async writes are dispatched when the connection is writable.
+	bs, active := echoServer(group, "127.0.0.1:9548", WithChannel(NewAsyncWriteChannel(16, true)))
+	defer bs.Shutdown()
+
+	conns := dialEcho(t, "127.0.0.1:9548", 1, active)
+	defer conns[0].Close()
+
+	for i := 0; i < 10; i++ {
+		if err := echo(conns[0], fmt.Sprint("hello#", i)); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	// the socket buffers are filled before the peer reads.
+	message := strings.Repeat("x", 1000)
+	go func() {
+		for i := 0; i < 4096; i++ {
+			if _, err := conns[0].Write([]byte(message)); nil != err {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(time.Millisecond * 100)
+	_ = conns[0].SetReadDeadline(time.Now().Add(time.Second * 5))
+	if n, err := io.CopyN(io.Discard, conns[0], int64(len(message)*4096)); nil != err {
+		t.Fatal(n, err)
+	}
+}
+
+func TestEventLoopGroupDisabled(t *testing.T) {
+
+	group := NewEventLoopGroupWith(EventLoopOptions{Size: 2, Disabled: true})
+	defer group.Close()
+	if 0 != group.Size() {
+		t.Fatal("disabled group with loops:", group.Size())
+	}
+
+	bs, active := echoServer(group, "127.0.0.1:9549")
+	defer bs.Shutdown()
+
+	conns := dialEcho(t, "127.0.0.1:9549", 4, active)
+	for i, conn := range conns {
+		if err := echo(conn, fmt.Sprint("hello#", i)); nil != err {
+			t.Fatal(err)
+		}
+		_ = conn.Close()
+	}
+}
+
+func benchmarkEcho(b *testing.B, executor Executor, address string) {
+	bs, active := echoServer(executor, address)
+	defer bs.Shutdown()
+
+	conns := dialEcho(b, address, 64, active)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		conn := conns[int(atomic.AddInt32(active, 1))%len(conns)]
+		for pb.Next() {
+			if err := echo(conn, "hello"); nil != err {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkEchoGoroutinePerChannel(b *testing.B) {
+	benchmarkEcho(b, AsyncExecutor(), "127.0.0.1:9545")
+}
+
+func BenchmarkEchoEventLoopGroup(b *testing.B) {
+	group := NewEventLoopGroup(runtime.GOMAXPROCS(0))
+	defer group.Close()
+	benchmarkEcho(b, group, "127.0.0.1:9546")
+}
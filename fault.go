@@ -0,0 +1,211 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// FaultOptions defines the probabilities of faults injected by FaultInjectionHandler, each in [0, 1].
+type FaultOptions struct {
+	// Drop the message.
+	Drop float64
+	// Duplicate the message.
+	Duplicate float64
+	// Reorder to hold the message and pass it on after the next one.
+	Reorder float64
+	// Corrupt to flip a random bit of the message.
+	Corrupt float64
+	// Inbound & Outbound are the directions to inject faults.
+	Inbound, Outbound bool
+	// Seed of the random faults, the faults of each direction are reproducible with the same seed and messages.
+	Seed int64
+}
+
+// FaultInjector defines a handler to inject faults
+type FaultInjector interface {
+	ChannelHandler
+
+	// Remove the injector from the pipeline, the held messages are passed on.
+	Remove()
+}
+
+// FaultInjectionHandler create a handler to simulate an unreliable link, the messages of the directions are
+// dropped, duplicated, reordered or corrupted randomly, the readers are drained to bytes, so add it after
+// the frame decoders to inject faults to frames, the corrupted message is a copy. create a new one for each channel.
+func FaultInjectionHandler(options FaultOptions) FaultInjector {
+	for _, p := range []float64{options.Drop, options.Duplicate, options.Reorder, options.Corrupt} {
+		utils.AssertIf(p < 0 || p > 1, "probability must be in [0, 1]: %v", p)
+	}
+
+	return &faultInjector{
+		options:  options,
+		inbound:  faultState{random: rand.New(rand.NewSource(options.Seed))},
+		outbound: faultState{random: rand.New(rand.NewSource(options.Seed + 1))},
+	}
+}
+
+// faultState is the random source and the held message of a direction.
+type faultState struct {
+	random  *rand.Rand
+	held    Message
+	holding bool
+	pass    func(message Message)
+}
+
+type faultInjector struct {
+	mutex    sync.Mutex
+	options  FaultOptions
+	inbound  faultState
+	outbound faultState
+	pipeline Pipeline
+	removed  bool
+}
+
+func (f *faultInjector) HandleActive(ctx ActiveContext) {
+	f.mutex.Lock()
+	f.pipeline = ctx.Channel().Pipeline()
+	f.mutex.Unlock()
+	ctx.HandleActive()
+}
+
+func (f *faultInjector) HandleRead(ctx InboundContext, message Message) {
+	if !f.options.Inbound {
+		ctx.HandleRead(message)
+		return
+	}
+	f.inject(&f.inbound, message, ctx.HandleRead)
+}
+
+func (f *faultInjector) HandleWrite(ctx OutboundContext, message Message) {
+	if !f.options.Outbound {
+		ctx.HandleWrite(message)
+		return
+	}
+	f.inject(&f.outbound, message, ctx.HandleWrite)
+}
+
+func (f *faultInjector) HandleException(ctx ExceptionContext, ex Exception) {
+	ctx.HandleException(ex)
+}
+
+func (f *faultInjector) HandleInactive(ctx InactiveContext, ex Exception) {
+	f.mutex.Lock()
+	for _, s := range []*faultState{&f.inbound, &f.outbound} {
+		if s.holding {
+			releaseMessage(s.held)
+			s.held, s.holding = nil, false
+		}
+	}
+	f.mutex.Unlock()
+	ctx.HandleInactive(ex)
+}
+
+func (f *faultInjector) Remove() {
+	f.mutex.Lock()
+	if f.removed || nil == f.pipeline {
+		f.mutex.Unlock()
+		return
+	}
+	f.removed = true
+
+	if index := f.pipeline.IndexOf(func(handler Handler) bool { return handler == Handler(f) }); index > 0 {
+		f.pipeline.RemoveHandler(index)
+	}
+
+	var held []*faultState
+	for _, s := range []*faultState{&f.inbound, &f.outbound} {
+		if s.holding {
+			held = append(held, &faultState{held: s.held, pass: s.pass})
+			s.held, s.holding = nil, false
+		}
+	}
+	f.mutex.Unlock()
+
+	for _, s := range held {
+		s.pass(s.held)
+	}
+}
+
+// inject the faults to the message, the faults are decided in order of drop, corrupt, duplicate & reorder.
+func (f *faultInjector) inject(s *faultState, message Message, pass func(message Message)) {
+
+	// the stream is read before returned to the read loop.
+	message = detachMessage(message)
+
+	f.mutex.Lock()
+	if f.removed {
+		f.mutex.Unlock()
+		pass(message)
+		return
+	}
+
+	drop := s.random.Float64() < f.options.Drop
+	corrupt := s.random.Float64() < f.options.Corrupt
+	duplicate := s.random.Float64() < f.options.Duplicate
+	reorder := s.random.Float64() < f.options.Reorder
+	position := s.random.Int63()
+
+	if drop {
+		f.mutex.Unlock()
+		releaseMessage(message)
+		return
+	}
+
+	if corrupt {
+		if data := utils.MustToBytes(message); len(data) > 0 {
+			corrupted := append([]byte(nil), data...)
+			corrupted[position%int64(len(corrupted))] ^= 1 << uint(position%8)
+			releaseMessage(message)
+			message = corrupted
+		}
+	}
+
+	// pass on the held message after this one.
+	var messages = []Message{message}
+	if duplicate {
+		messages = append(messages, copyMessage(message))
+	}
+
+	if s.holding {
+		messages = append(messages, s.held)
+		s.held, s.holding = nil, false
+	} else if reorder {
+		s.held, s.holding, s.pass = messages[len(messages)-1], true, pass
+		messages = messages[:len(messages)-1]
+	}
+	f.mutex.Unlock()
+
+	for _, m := range messages {
+		pass(m)
+	}
+}
+
+// copyMessage returns a copy of the bytes, the other messages are shared, the reference counted one
+// is retained for the copy, since each written message is released once.
+func copyMessage(message Message) Message {
+	switch m := message.(type) {
+	case []byte:
+		return append([]byte(nil), m...)
+	case utils.ReferenceCounted:
+		m.Retain()
+	}
+	return message
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+// injectFaults pass the bytes through a FaultInjectionHandler one byte per frame, and returns the received bytes.
+func injectFaults(t *testing.T, options FaultOptions, input []byte) []byte {
+
+	received := make(chan byte, len(input)*2)
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		byteFrameHandler{},
+		FaultInjectionHandler(options),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			received <- message.([]byte)[0]
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	if _, err := peer.Write(input); nil != err {
+		t.Fatal(err)
+	}
+
+	var output []byte
+	for {
+		select {
+		case b := <-received:
+			output = append(output, b)
+		case <-time.After(time.Millisecond * 100):
+			return output
+		}
+	}
+}
+
+func TestFaultInjectionHandler(t *testing.T) {
+
+	input := []byte("abcdefghijklmnopqrstuvwxyz0123456789")
+	options := FaultOptions{Drop: 0.1, Duplicate: 0.1, Reorder: 0.1, Corrupt: 0.1, Inbound: true, Seed: 42}
+
+	// the same seed produces the same faults.
+	output := injectFaults(t, options, input)
+	if again := injectFaults(t, options, input); !bytes.Equal(output, again) {
+		t.Fatalf("faults are not reproducible: %q, %q", output, again)
+	}
+	if bytes.Equal(input, output) {
+		t.Fatal("no fault injected")
+	}
+
+	options.Seed = 7
+	if other := injectFaults(t, options, input); bytes.Equal(output, other) {
+		t.Fatal("the faults are independent of seed:", string(other))
+	}
+
+	var cases = []struct {
+		options FaultOptions
+		want    string
+	}{
+		{options: FaultOptions{Inbound: true}, want: "abcdef"},
+		{options: FaultOptions{Drop: 1, Outbound: true}, want: "abcdef"},
+		{options: FaultOptions{Drop: 1, Inbound: true}, want: ""},
+		{options: FaultOptions{Duplicate: 1, Inbound: true}, want: "aabbccddeeff"},
+		{options: FaultOptions{Reorder: 1, Inbound: true}, want: "badcfe"},
+	}
+
+	for _, c := range cases {
+		if output := injectFaults(t, c.options, []byte("abcdef")); c.want != string(output) {
+			t.Fatalf("%+v: %q, want: %q", c.options, output, c.want)
+		}
+	}
+
+	// one bit of each byte is flipped.
+	corrupted := injectFaults(t, FaultOptions{Corrupt: 1, Inbound: true, Seed: 1}, []byte("abcdef"))
+	if 6 != len(corrupted) {
+		t.Fatalf("unexpected output: %q", corrupted)
+	}
+	for i, b := range corrupted {
+		if diff := b ^ "abcdef"[i]; 0 == diff || 0 != diff&(diff-1) {
+			t.Fatalf("byte #%d is not corrupted by one bit: %q", i, corrupted)
+		}
+	}
+}
+
+func TestFaultInjectionHandlerRemove(t *testing.T) {
+
+	injector := FaultInjectionHandler(FaultOptions{Reorder: 1, Outbound: true})
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{}, injector)
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	read := func(want string) {
+		buffer := make([]byte, len(want))
+		_ = peer.SetReadDeadline(time.Now().Add(time.Second))
+		for n := 0; n < len(buffer); {
+			size, err := peer.Read(buffer[n:])
+			if nil != err {
+				t.Fatal(err)
+			}
+			n += size
+		}
+		if want != string(buffer) {
+			t.Fatalf("received: %q, want: %q", buffer, want)
+		}
+	}
+
+	for _, message := range []string{"1", "2", "3"} {
+		go func(message string) { _ = ch.Write([]byte(message)) }(message)
+		time.Sleep(time.Millisecond * 20)
+	}
+	read("21")
+
+	// the held message is passed on after removed.
+	go injector.Remove()
+	read("3")
+
+	if -1 != ch.Pipeline().IndexOf(func(handler Handler) bool { return handler == Handler(injector) }) {
+		t.Fatal("injector is not removed")
+	}
+
+	go func() { _ = ch.Write([]byte("45")) }()
+	read("45")
+}
+
+func TestFaultInjectionHandlerDuplicateBuffer(t *testing.T) {
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{},
+		FaultInjectionHandler(FaultOptions{Duplicate: 1, Outbound: true}))
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	buffer := pbytes.NewBuffer(5)
+	copy(buffer.Bytes(), "hello")
+	// hold a reference to check the release.
+	buffer.Retain()
+
+	go func() { _ = ch.Write(buffer) }()
+
+	received := make([]byte, 10)
+	_ = peer.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(peer, received); nil != err {
+		t.Fatal(err)
+	}
+	if "hellohello" != string(received) {
+		t.Fatalf("received: %q", received)
+	}
+
+	// the duplicate is retained, so the buffer is released once for each write.
+	for deadline := time.Now().Add(time.Second); 1 != buffer.RefCnt() && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if 1 != buffer.RefCnt() {
+		t.Fatal("unexpected reference count:", buffer.RefCnt())
+	}
+	buffer.Release()
+}
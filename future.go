@@ -0,0 +1,97 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import "sync"
+
+// Future defines the result of an asynchronous operation
+type Future interface {
+	// Done returns a channel that is closed when the operation is completed.
+	Done() <-chan struct{}
+
+	// Err returns the error of the completed operation, nil if succeeded or not yet completed.
+	Err() error
+
+	// Wait blocks until the operation is completed, returns the error of it.
+	Wait() error
+
+	// AddListener registers a function called when the operation is completed,
+	// it is called immediately if the operation is already completed.
+	AddListener(fn func(Future))
+}
+
+// promise impl Future
+type promise struct {
+	mutex     sync.Mutex
+	done      chan struct{}
+	err       error
+	completed bool
+	listeners []func(Future)
+	self      Future // the outer Future to be passed to listeners
+}
+
+func newPromise() *promise {
+	p := &promise{done: make(chan struct{})}
+	p.self = p
+	return p
+}
+
+func (p *promise) Done() <-chan struct{} {
+	return p.done
+}
+
+func (p *promise) Err() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.err
+}
+
+func (p *promise) Wait() error {
+	<-p.done
+	return p.Err()
+}
+
+func (p *promise) AddListener(fn func(Future)) {
+	p.mutex.Lock()
+	if !p.completed {
+		p.listeners = append(p.listeners, fn)
+		p.mutex.Unlock()
+		return
+	}
+	p.mutex.Unlock()
+	fn(p.self)
+}
+
+// complete the promise with the error, false if it is already completed.
+func (p *promise) complete(err error) bool {
+	p.mutex.Lock()
+	if p.completed {
+		p.mutex.Unlock()
+		return false
+	}
+	p.completed = true
+	p.err = err
+	listeners := p.listeners
+	p.listeners = nil
+	close(p.done)
+	p.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(p.self)
+	}
+	return true
+}
@@ -1,3 +1,22 @@
 module github.com/mijingduI/go-netty
 
 go 1.18
+
+require (
+	github.com/golang/snappy v0.0.4
+	github.com/pierrec/lz4/v4 v4.1.18
+	github.com/xtaci/kcp-go/v5 v5.6.2
+	golang.org/x/text v0.15.0
+)
+
+require (
+	github.com/klauspost/cpuid/v2 v2.0.14 // indirect
+	github.com/klauspost/reedsolomon v1.10.0 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/templexxx/cpu v0.0.9 // indirect
+	github.com/templexxx/xorsimd v0.4.1 // indirect
+	github.com/tjfoc/gmsm v1.4.1 // indirect
+	golang.org/x/crypto v0.23.0 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+)
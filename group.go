@@ -0,0 +1,263 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrGroupWriteFailed is wrapped by the error of GroupFuture if any channel failed to write.
+var ErrGroupWriteFailed = errors.New("netty: group write failed")
+
+// SelectStrategy defines how ChannelGroup selects a channel
+type SelectStrategy int
+
+const (
+	// RoundRobin selects the channels in turn.
+	RoundRobin SelectStrategy = iota
+	// WeightedRoundRobin selects the channels in proportion to their weights, smoothly interleaved.
+	WeightedRoundRobin
+)
+
+// ChannelGroup defines a set of channels, the closed channels are removed automatically.
+type ChannelGroup interface {
+	// Add a channel with weight 1, false if the channel is already added or inactive.
+	Add(ch Channel) bool
+
+	// AddWeighted add a channel with the weight for WeightedRoundRobin.
+	AddWeighted(ch Channel, weight int) bool
+
+	// Remove the channel from group.
+	Remove(ch Channel) bool
+
+	// Len of active channels.
+	Len() int
+
+	// Channels returns a snapshot of the active channels.
+	Channels() []Channel
+
+	// Broadcast write the message to all channels.
+	Broadcast(message Message)
+
+	// WriteAndFlush write the message to all channels, the result of each channel is reported
+	// by the returned future, a failed channel does not abort the writing of others.
+	WriteAndFlush(message Message) GroupFuture
+
+	// Select a channel with the strategy, nil if the group is empty.
+	Select(strategy SelectStrategy) Channel
+}
+
+// NewChannelGroup create an empty ChannelGroup
+func NewChannelGroup() ChannelGroup {
+	return &channelGroup{members: make(map[int64]*groupMember)}
+}
+
+type groupMember struct {
+	channel       Channel
+	weight        int
+	currentWeight int
+	removed       chan struct{}
+}
+
+type channelGroup struct {
+	mutex   sync.Mutex
+	members map[int64]*groupMember
+	ordered []*groupMember
+	next    int
+}
+
+func (g *channelGroup) Add(ch Channel) bool {
+	return g.AddWeighted(ch, 1)
+}
+
+func (g *channelGroup) AddWeighted(ch Channel, weight int) bool {
+	utils.AssertIf(weight <= 0, "weight must be a positive integer")
+
+	if !ch.IsActive() {
+		return false
+	}
+
+	member := &groupMember{channel: ch, weight: weight, removed: make(chan struct{})}
+
+	g.mutex.Lock()
+	if _, ok := g.members[ch.ID()]; ok {
+		g.mutex.Unlock()
+		return false
+	}
+	g.members[ch.ID()] = member
+	g.ordered = append(g.ordered, member)
+	g.mutex.Unlock()
+
+	// prune the channel when it is closed.
+	go func() {
+		select {
+		case <-ch.Context().Done():
+			g.Remove(ch)
+		case <-member.removed:
+		}
+	}()
+	return true
+}
+
+func (g *channelGroup) Remove(ch Channel) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.removeLocked(ch.ID())
+}
+
+func (g *channelGroup) removeLocked(id int64) bool {
+	member, ok := g.members[id]
+	if !ok {
+		return false
+	}
+
+	delete(g.members, id)
+	for index, m := range g.ordered {
+		if m == member {
+			g.ordered = append(g.ordered[:index], g.ordered[index+1:]...)
+			break
+		}
+	}
+	close(member.removed)
+	return true
+}
+
+// pruneLocked remove the inactive channels which are not yet removed by the watcher.
+func (g *channelGroup) pruneLocked() {
+	for i := 0; i < len(g.ordered); {
+		if ch := g.ordered[i].channel; !ch.IsActive() {
+			g.removeLocked(ch.ID())
+			continue
+		}
+		i++
+	}
+}
+
+func (g *channelGroup) Len() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.pruneLocked()
+	return len(g.ordered)
+}
+
+func (g *channelGroup) Channels() []Channel {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.pruneLocked()
+
+	channels := make([]Channel, 0, len(g.ordered))
+	for _, m := range g.ordered {
+		channels = append(channels, m.channel)
+	}
+	return channels
+}
+
+func (g *channelGroup) Broadcast(message Message) {
+	for _, ch := range g.Channels() {
+		_ = ch.Write(message)
+	}
+}
+
+func (g *channelGroup) WriteAndFlush(message Message) GroupFuture {
+	channels := g.Channels()
+	future := &groupFuture{promise: newPromise(), failed: make(map[Channel]error)}
+	future.self = future
+
+	for _, ch := range channels {
+		if err := ch.Write(message); nil != err {
+			future.failed[ch] = err
+			// prune the dead channel.
+			if !ch.IsActive() {
+				g.Remove(ch)
+			}
+		} else {
+			future.succeeded = append(future.succeeded, ch)
+		}
+	}
+
+	if n := len(future.failed); n > 0 {
+		future.complete(fmt.Errorf("%w: %d of %d channels", ErrGroupWriteFailed, n, len(channels)))
+	} else {
+		future.complete(nil)
+	}
+	return future
+}
+
+func (g *channelGroup) Select(strategy SelectStrategy) Channel {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.pruneLocked()
+
+	if 0 == len(g.ordered) {
+		return nil
+	}
+
+	switch strategy {
+	case RoundRobin:
+		g.next %= len(g.ordered)
+		member := g.ordered[g.next]
+		g.next++
+		return member.channel
+	case WeightedRoundRobin:
+		// smooth weighted round-robin
+		var total int
+		var best *groupMember
+		for _, m := range g.ordered {
+			m.currentWeight += m.weight
+			total += m.weight
+			if nil == best || m.currentWeight > best.currentWeight {
+				best = m
+			}
+		}
+		best.currentWeight -= total
+		return best.channel
+	default:
+		utils.Assert(fmt.Errorf("unrecognized strategy: %d", strategy))
+		return nil
+	}
+}
+
+// GroupFuture defines the result of writing to a ChannelGroup
+type GroupFuture interface {
+	Future
+
+	// Succeeded returns the channels written successfully.
+	Succeeded() []Channel
+
+	// Failed returns the failed channels with the causes.
+	Failed() map[Channel]error
+}
+
+type groupFuture struct {
+	*promise
+	succeeded []Channel
+	failed    map[Channel]error
+}
+
+func (g *groupFuture) Succeeded() []Channel {
+	<-g.Done()
+	return g.succeeded
+}
+
+func (g *groupFuture) Failed() map[Channel]error {
+	<-g.Done()
+	return g.failed
+}
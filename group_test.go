@@ -0,0 +1,203 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestChannelGroupSelect(t *testing.T) {
+
+	group := NewChannelGroup()
+
+	var channels []Channel
+	for i, weight := range []int{5, 1, 1} {
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+		defer peer.Close()
+		defer ch.Close(nil)
+		if !group.AddWeighted(ch, weight) {
+			t.Fatal("add channel failed:", i)
+		}
+		channels = append(channels, ch)
+	}
+
+	if group.Add(channels[0]) {
+		t.Fatal("duplicate channel added")
+	}
+
+	counts := map[int64]int{}
+	for i := 0; i < 70; i++ {
+		counts[group.Select(WeightedRoundRobin).ID()]++
+	}
+
+	for i, want := range []int{50, 10, 10} {
+		if counts[channels[i].ID()] != want {
+			t.Fatalf("weighted selection of #%d: %d, want: %d", i, counts[channels[i].ID()], want)
+		}
+	}
+
+	// smooth: the heavy channel is not selected 5 times in a row.
+	var sequence []int64
+	for i := 0; i < 7; i++ {
+		sequence = append(sequence, group.Select(WeightedRoundRobin).ID())
+	}
+	if sequence[0] == sequence[1] && sequence[1] == sequence[2] && sequence[2] == sequence[3] && sequence[3] == sequence[4] {
+		t.Fatal("weighted selection is not interleaved:", sequence)
+	}
+
+	counts = map[int64]int{}
+	for i := 0; i < 30; i++ {
+		counts[group.Select(RoundRobin).ID()]++
+	}
+
+	for i := range channels {
+		if counts[channels[i].ID()] != 10 {
+			t.Fatalf("round-robin selection of #%d: %d, want: 10", i, counts[channels[i].ID()])
+		}
+	}
+}
+
+func TestChannelGroupPrune(t *testing.T) {
+
+	group := NewChannelGroup()
+	if nil != group.Select(RoundRobin) {
+		t.Fatal("empty group should select nothing")
+	}
+
+	ch1, peer1 := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+	ch2, peer2 := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+	defer peer1.Close()
+	defer peer2.Close()
+	defer ch2.Close(nil)
+
+	group.Add(ch1)
+	group.Add(ch2)
+
+	ch1.Close(nil)
+
+	// closed channel is pruned.
+	if 1 != group.Len() {
+		t.Fatal("closed channel not pruned:", group.Len())
+	}
+
+	for i := 0; i < 4; i++ {
+		if ch := group.Select(RoundRobin); ch != ch2 {
+			t.Fatal("closed channel selected")
+		}
+	}
+
+	// received by the peer
+	peerRead := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 5)
+		n, _ := peer2.Read(buf)
+		peerRead <- buf[:n]
+	}()
+
+	group.Broadcast([]byte("hello"))
+
+	select {
+	case data := <-peerRead:
+		if "hello" != string(data) {
+			t.Fatal("unexpected broadcast data:", string(data))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("broadcast message not received")
+	}
+
+	if !group.Remove(ch2) || 0 != group.Len() {
+		t.Fatal("remove channel failed")
+	}
+}
+
+func TestChannelGroupWriteAndFlush(t *testing.T) {
+
+	group := NewChannelGroup()
+	errWrite := errors.New("write failed")
+
+	var healthy []Channel
+	for i := 0; i < 3; i++ {
+		ch, peer := pipeChannel(NewAsyncWriteChannel(16, false), "127.0.0.1:9527", discardHandler{})
+		go io.Copy(ioutil.Discard, peer)
+		defer peer.Close()
+		defer ch.Close(nil)
+		group.Add(ch)
+		healthy = append(healthy, ch)
+	}
+
+	// the channel failed to encode.
+	failing, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{},
+		OutboundHandlerFunc(func(ctx OutboundContext, message Message) {
+			panic(errWrite)
+		}),
+		ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {}),
+	)
+	defer peer.Close()
+	defer failing.Close(nil)
+	group.Add(failing)
+
+	// the channel died while writing.
+	dead, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{},
+		OutboundHandlerFunc(func(ctx OutboundContext, message Message) {
+			ctx.Close(errWrite)
+			panic(errWrite)
+		}),
+	)
+	defer peer.Close()
+	group.Add(dead)
+
+	future := group.WriteAndFlush([]byte("hello"))
+
+	select {
+	case <-future.Done():
+	case <-time.After(time.Second):
+		t.Fatal("future not completed")
+	}
+
+	if err := future.Wait(); !errors.Is(err, ErrGroupWriteFailed) {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if n := len(future.Succeeded()); 3 != n {
+		t.Fatal("unexpected succeeded channels:", n)
+	}
+
+	failed := future.Failed()
+	if 2 != len(failed) || !errors.Is(failed[failing], errWrite) || !errors.Is(failed[dead], errWrite) {
+		t.Fatal("unexpected failed channels:", failed)
+	}
+
+	// the dead channel is pruned, the failing one is still active.
+	if 4 != group.Len() {
+		t.Fatal("unexpected group size:", group.Len())
+	}
+
+	var notified int
+	future.AddListener(func(f Future) {
+		if f != future {
+			t.Fatal("unexpected future")
+		}
+		notified++
+	})
+	if 1 != notified {
+		t.Fatal("listener not notified")
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/mijingduI/go-netty/utils"
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
 )
 
 type (
@@ -85,6 +86,13 @@ type (
 	EventHandler interface {
 		HandleEvent(ctx EventContext, event Event)
 	}
+
+	// CleanupHandler defines a handler to release the resources of channel, HandleCleanup is called
+	// exactly once after the channel is closed and the inactive event is fired, the handlers are called
+	// from tail to head, so the handler is cleaned up before the handlers it depends on.
+	CleanupHandler interface {
+		HandleCleanup(ch Channel, ex Exception)
+	}
 )
 
 // CodecHandler defines an codec handler
@@ -156,17 +164,86 @@ type EventHandlerFunc func(ctx EventContext, event Event)
 // HandleEvent to impl EventHandler
 func (fn EventHandlerFunc) HandleEvent(ctx EventContext, event Event) { fn(ctx, event) }
 
+// CleanupHandlerFunc impl CleanupHandler
+type CleanupHandlerFunc func(ch Channel, ex Exception)
+
+// HandleCleanup to impl CleanupHandler
+func (fn CleanupHandlerFunc) HandleCleanup(ch Channel, ex Exception) { fn(ch, ex) }
+
+// stringWriter is implemented by the channel to write the string without allocation.
+type stringWriter interface {
+	writeString(s string) (int, error)
+}
+
+// priorityWriter is implemented by the channel to queue the bytes ahead of the normal writes.
+type priorityWriter interface {
+	writePriority(p [][]byte) (int64, error)
+}
+
+// priorityContext is implemented by the context of head handler to tell the priority writes.
+type priorityContext interface {
+	priorityWrite() bool
+}
+
+// streamBufferSize is the size of chunks to write the streamed messages.
+const streamBufferSize = 32 * 1024
+
+// channelWriter to write the bytes to the channel directly.
+type channelWriter struct {
+	channel Channel
+}
+
+func (w channelWriter) Write(p []byte) (int, error) {
+	return w.channel.Write1(p)
+}
+
 type headHandler struct{}
 
-func (headHandler) HandleWrite(ctx OutboundContext, message Message) {
+func (h headHandler) HandleWrite(ctx OutboundContext, message Message) {
+	h.write(ctx, message)
+
+	// the reference counted message is written or copied to the write queue, so it is released after written,
+	// the failed one is left to the caller, e.g. the RetryHandler to write it again.
+	if rc, ok := message.(utils.ReferenceCounted); ok {
+		rc.Release()
+	}
+}
+
+// write the message to the channel, it panics with the failure.
+func (headHandler) write(ctx OutboundContext, message Message) {
+	if pc, ok := ctx.(priorityContext); ok && pc.priorityWrite() {
+		if w, ok := ctx.Channel().(priorityWriter); ok {
+			if m, ok := message.([][]byte); ok {
+				utils.AssertLong(w.writePriority(m))
+			} else {
+				utils.AssertLong(w.writePriority([][]byte{utils.MustToBytes(message)}))
+			}
+			return
+		}
+	}
 
 	switch m := message.(type) {
 	case []byte:
 		utils.AssertLength(ctx.Channel().Write1(m))
+	case string:
+		if w, ok := ctx.Channel().(stringWriter); ok {
+			utils.AssertLength(w.writeString(m))
+		} else {
+			utils.AssertLength(ctx.Channel().Write1([]byte(m)))
+		}
 	case [][]byte:
 		utils.AssertLong(ctx.Channel().Writev(m))
 	case *bytes.Buffer:
 		utils.AssertLength(ctx.Channel().Write1(m.Bytes()))
+	case *pbytes.Buffer:
+		utils.AssertLength(ctx.Channel().Write1(m.Bytes()))
+	case []io.Reader:
+		// stream the readers to the channel without buffering the whole message.
+		reader := utils.MultiReader(m...)
+		defer reader.Close()
+		buffer := pbytes.Get(streamBufferSize)
+		defer pbytes.Put(buffer)
+		utils.AssertLong(io.CopyBuffer(channelWriter{ctx.Channel()}, reader, (*buffer)[:streamBufferSize]))
 	case io.WriterTo:
 		data := utils.AssertBytes(utils.StealBytes(m))
 		utils.AssertLength(ctx.Channel().Write1(data))
@@ -178,7 +255,17 @@ func (headHandler) HandleWrite(ctx OutboundContext, message Message) {
 	}
 }
 
-type tailHandler struct{}
+type tailHandler struct {
+	unhandled UnhandledHandler
+}
+
+func (t tailHandler) HandleRead(ctx InboundContext, message Message) {
+	// release the buffer even if the handler panics.
+	if rc, ok := message.(utils.ReferenceCounted); ok {
+		defer rc.Release()
+	}
+	t.unhandled(ctx.Channel(), message)
+}
 
 func (tailHandler) HandleException(ctx ExceptionContext, ex Exception) {
 	// The final closing operation will be provided when the user registered handler is not processing.
@@ -186,7 +273,7 @@ func (tailHandler) HandleException(ctx ExceptionContext, ex Exception) {
 		"An HandleException() event was fired, and it reached at the tail of the pipeline.",
 		"It usually means the last handler in the pipeline did not handle the exception.",
 		"We will close the channel, If you don't want to close the channel please add HandleException() to the pipeline.\n",
-		"Exception throw on ", ctx.Channel().RemoteAddr(), "\n",
+		"Exception throw on ", describeChannel(ctx.Channel()), "\n",
 		ex,
 	)
 	ctx.Channel().Close(ex)
@@ -221,7 +308,8 @@ type readIdleHandler struct {
 	mutex        sync.RWMutex
 	idleTime     time.Duration
 	lastReadTime time.Time
-	readTimer    *time.Timer
+	readTimer    Timer
+	clock        Clock
 	handlerCtx   HandlerContext
 }
 
@@ -241,8 +329,9 @@ func (r *readIdleHandler) HandleActive(ctx ActiveContext) {
 	// cache context.
 	r.withLock(func() {
 		r.handlerCtx = ctx
-		r.lastReadTime = time.Now()
-		r.readTimer = time.AfterFunc(r.idleTime, r.onReadTimeout)
+		r.clock = ClockOf(ctx.Channel())
+		r.lastReadTime = r.clock.Now()
+		r.readTimer = r.clock.AfterFunc(r.idleTime, r.onReadTimeout)
 	})
 	// post the active event.
 	ctx.HandleActive()
@@ -252,10 +341,9 @@ func (r *readIdleHandler) HandleRead(ctx InboundContext, message Message) {
 	ctx.HandleRead(message)
 
 	r.withLock(func() {
-		// update last read time.
-		r.lastReadTime = time.Now()
-		// reset timer.
+		// update last read time & reset timer.
 		if r.readTimer != nil {
+			r.lastReadTime = r.clock.Now()
 			r.readTimer.Reset(r.idleTime)
 		}
 	})
@@ -282,8 +370,8 @@ func (r *readIdleHandler) onReadTimeout() {
 
 	r.withReadLock(func() {
 		// check if the idle time expires.
-		expired = time.Since(r.lastReadTime) >= r.idleTime
 		ctx = r.handlerCtx
+		expired = nil != ctx && r.clock.Now().Sub(r.lastReadTime) >= r.idleTime
 	})
 
 	if expired && ctx != nil {
@@ -314,7 +402,8 @@ type writeIdleHandler struct {
 	mutex         sync.RWMutex
 	idleTime      time.Duration
 	lastWriteTime time.Time
-	writeTimer    *time.Timer
+	writeTimer    Timer
+	clock         Clock
 	handlerCtx    HandlerContext
 }
 
@@ -335,8 +424,9 @@ func (w *writeIdleHandler) HandleActive(ctx ActiveContext) {
 	// cache context
 	w.withLock(func() {
 		w.handlerCtx = ctx
-		w.lastWriteTime = time.Now()
-		w.writeTimer = time.AfterFunc(w.idleTime, w.onWriteTimeout)
+		w.clock = ClockOf(ctx.Channel())
+		w.lastWriteTime = w.clock.Now()
+		w.writeTimer = w.clock.AfterFunc(w.idleTime, w.onWriteTimeout)
 	})
 
 	// post the active event.
@@ -347,9 +437,9 @@ func (w *writeIdleHandler) HandleWrite(ctx OutboundContext, message Message) {
 
 	// update last write time.
 	w.withLock(func() {
-		w.lastWriteTime = time.Now()
-		// reset timer.
+		// update last write time & reset timer.
 		if w.writeTimer != nil {
+			w.lastWriteTime = w.clock.Now()
 			w.writeTimer.Reset(w.idleTime)
 		}
 	})
@@ -381,8 +471,8 @@ func (w *writeIdleHandler) onWriteTimeout() {
 
 	w.withReadLock(func() {
 		// check if the idle time expires.
-		expired = time.Since(w.lastWriteTime) >= w.idleTime
 		ctx = w.handlerCtx
+		expired = nil != ctx && w.clock.Now().Sub(w.lastWriteTime) >= w.idleTime
 	})
 
 	// check if the idle time expires
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrHandshakeFailed is wrapped by the close reason of a failed handshake.
+var ErrHandshakeFailed = errors.New("netty: handshake failed")
+
+// HandshakeCompleteEvent is triggered when the handshake is completed successfully.
+type HandshakeCompleteEvent struct{}
+
+// Handshaker defines the state machine of a handshake protocol.
+type Handshaker interface {
+	// Start is called at channel activation, the returned message is sent to the peer if not nil.
+	Start(ch Channel) Message
+
+	// Handshake to process the buffered inbound bytes, returns the number of consumed bytes,
+	// the response to send if not nil, and whether the handshake is completed, returns 0 consumed
+	// bytes to wait for more inbound bytes, a non-nil error fails the handshake.
+	Handshake(ch Channel, data []byte) (consumed int, response Message, done bool, err error)
+}
+
+// HandshakeHandler to run the handshaker before the application traffic, the inbound bytes are
+// buffered until the handshake is completed, then the handler removes itself from the pipeline,
+// fires HandshakeCompleteEvent, and replays the unconsumed bytes to the next handlers. the channel
+// is closed with ErrHandshakeFailed if the handshake failed or the buffered bytes exceed maxBuffered.
+// the handler holds the state of handshake, so create a new one for each channel.
+func HandshakeHandler(handshaker Handshaker, maxBuffered int) ChannelInboundHandler {
+	utils.AssertIf(maxBuffered <= 0, "maxBuffered must be a positive integer")
+	return &handshakeHandler{handshaker: handshaker, maxBuffered: maxBuffered}
+}
+
+type handshakeHandler struct {
+	handshaker  Handshaker
+	maxBuffered int
+	buffer      []byte
+}
+
+func (h *handshakeHandler) HandleActive(ctx ActiveContext) {
+	if message := h.handshaker.Start(ctx.Channel()); nil != message {
+		ctx.Write(message)
+	}
+	ctx.HandleActive()
+}
+
+func (h *handshakeHandler) HandleRead(ctx InboundContext, message Message) {
+
+	switch r := message.(type) {
+	case []byte:
+		h.buffer = append(h.buffer, r...)
+	case io.Reader:
+		// read the available bytes only, the stream is never drained.
+		buffer := make([]byte, h.maxBuffered-len(h.buffer)+1)
+		h.buffer = append(h.buffer, buffer[:utils.AssertLength(r.Read(buffer))]...)
+	default:
+		h.buffer = append(h.buffer, utils.MustToBytes(message)...)
+	}
+
+	for {
+		consumed, response, done, err := h.handshaker.Handshake(ctx.Channel(), h.buffer)
+		if nil != err {
+			ctx.Close(fmt.Errorf("%w: %v", ErrHandshakeFailed, err))
+			return
+		}
+
+		if nil != response {
+			ctx.Write(response)
+		}
+
+		h.buffer = h.buffer[consumed:]
+
+		if done {
+			h.complete(ctx, message)
+			return
+		}
+
+		if 0 == consumed {
+			break
+		}
+	}
+
+	if len(h.buffer) > h.maxBuffered {
+		ctx.Close(fmt.Errorf("%w: buffered %d bytes exceeds %d", ErrHandshakeFailed, len(h.buffer), h.maxBuffered))
+	}
+}
+
+func (h *handshakeHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	h.buffer = nil
+	ctx.HandleInactive(ex)
+}
+
+// complete to remove the handler and replay the unconsumed bytes.
+func (h *handshakeHandler) complete(ctx InboundContext, message Message) {
+
+	pipeline := ctx.Channel().Pipeline()
+	pipeline.RemoveHandler(pipeline.IndexOf(func(handler Handler) bool {
+		return handler == Handler(h)
+	}))
+
+	ctx.Trigger(HandshakeCompleteEvent{})
+
+	leftover := h.buffer
+	h.buffer = nil
+	if 0 == len(leftover) {
+		return
+	}
+
+	// push back to the channel if possible, so that the next handlers could read them as a stream.
+	if r, ok := message.(*peekReader); ok {
+		r.unread(leftover)
+	} else {
+		ctx.HandleRead(leftover)
+	}
+}
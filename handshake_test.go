@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// challengeHandshaker expects the peer to answer the challenge reversed.
+type challengeHandshaker struct {
+	challenge string
+}
+
+func (c *challengeHandshaker) Start(ch Channel) Message {
+	return []byte(c.challenge + "\n")
+}
+
+func (c *challengeHandshaker) Handshake(ch Channel, data []byte) (int, Message, bool, error) {
+	index := bytes.IndexByte(data, '\n')
+	if index < 0 {
+		return 0, nil, false, nil
+	}
+
+	answer := []byte(c.challenge)
+	for i, j := 0, len(answer)-1; i < j; i, j = i+1, j-1 {
+		answer[i], answer[j] = answer[j], answer[i]
+	}
+
+	if !bytes.Equal(answer, data[:index]) {
+		return 0, nil, false, errors.New("wrong answer")
+	}
+	return index + 1, []byte("ok\n"), true, nil
+}
+
+func TestHandshakeHandler(t *testing.T) {
+
+	var cases = []struct {
+		answer string
+		passed bool
+	}{
+		{answer: "4321", passed: true},
+		{answer: "1234", passed: false},
+	}
+
+	for _, c := range cases {
+		completed := make(chan struct{}, 1)
+		received := make(chan string, 1)
+		inactive := make(chan Exception, 1)
+
+		ch, peer := pipeChannel(NewAsyncWriteChannel(8, true), "127.0.0.1:9527",
+			HandshakeHandler(&challengeHandshaker{challenge: "1234"}, 64),
+			EventHandlerFunc(func(ctx EventContext, event Event) {
+				if _, ok := event.(HandshakeCompleteEvent); ok {
+					completed <- struct{}{}
+				}
+			}),
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				buffer := make([]byte, 64)
+				received <- string(buffer[:utils.AssertLength(utils.MustToReader(message).Read(buffer))])
+			}),
+			InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+				inactive <- ex
+			}),
+		)
+
+		challenge := make([]byte, 5)
+		if _, err := io.ReadFull(peer, challenge); nil != err || "1234\n" != string(challenge) {
+			t.Fatal("unexpected challenge:", string(challenge), err)
+		}
+
+		// the application bytes follow the answer in the same packet.
+		if _, err := peer.Write([]byte(c.answer + "\nhello")); nil != err {
+			t.Fatal(err)
+		}
+
+		if !c.passed {
+			select {
+			case ex := <-inactive:
+				if !errors.Is(ex, ErrHandshakeFailed) {
+					t.Fatal("unexpected close reason:", ex)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("failed handshake is not closed")
+			}
+			_ = peer.Close()
+			continue
+		}
+
+		ok := make([]byte, 3)
+		if _, err := io.ReadFull(peer, ok); nil != err || "ok\n" != string(ok) {
+			t.Fatal("unexpected response:", string(ok), err)
+		}
+
+		select {
+		case <-completed:
+		case <-time.After(time.Second):
+			t.Fatal("handshake is not completed")
+		}
+
+		select {
+		case message := <-received:
+			if "hello" != message {
+				t.Fatal("unexpected replayed bytes:", message)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("buffered bytes are not replayed")
+		}
+
+		if -1 != ch.Pipeline().IndexOf(func(handler Handler) bool {
+			_, ok := handler.(*handshakeHandler)
+			return ok
+		}) {
+			t.Fatal("handshake handler is not removed")
+		}
+
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// RealIPAttribute overrides the remote ip of channel, the value could be a net.IP or
+// an X-Forwarded-For style string, the first address of the list is used.
+const RealIPAttribute AttributeKey = "netty.real-ip"
+
+// ErrIPRejected is returned when the remote ip is rejected by IPFilterHandler.
+var ErrIPRejected = errors.New("netty: ip rejected")
+
+// AnyAddress matches any ipv4 or ipv6 address in IPRule.
+const AnyAddress = "*"
+
+// IPRule defines an allow or deny rule of IPFilterHandler
+type IPRule struct {
+	// CIDR could be an ip, a CIDR or AnyAddress.
+	CIDR string `json:"cidr"`
+	// Allow the matched ip or not.
+	Allow bool `json:"allow"`
+}
+
+// IPFilterHandler check the remote ip of channel against the rules at channel activation,
+// the first matched rule decides whether the channel is allowed, the channel is denied
+// if there is no matched rule, add a rule of AnyAddress at the end to change the default policy.
+func IPFilterHandler(rules []IPRule) ActiveHandler {
+	filter := &ipFilterHandler{rules: make([]ipRule, 0, len(rules))}
+	for _, rule := range rules {
+		r := ipRule{allow: rule.Allow}
+		if AnyAddress != rule.CIDR {
+			ipNet, err := parseCIDR(rule.CIDR)
+			utils.Assert(err)
+			r.cidr = ipNet
+		}
+		filter.rules = append(filter.rules, r)
+	}
+	return filter
+}
+
+type ipRule struct {
+	cidr  *net.IPNet // nil to match any address
+	allow bool
+}
+
+type ipFilterHandler struct {
+	rules []ipRule
+}
+
+func (f *ipFilterHandler) HandleActive(ctx ActiveContext) {
+	ip := realIP(ctx.Channel())
+	if !f.allowed(ip) {
+		ctx.Close(fmt.Errorf("%w: %s", ErrIPRejected, ip))
+		return
+	}
+
+	ctx.HandleActive()
+}
+
+func (f *ipFilterHandler) allowed(ip net.IP) bool {
+	if nil == ip {
+		return false
+	}
+
+	for _, rule := range f.rules {
+		if nil == rule.cidr || rule.cidr.Contains(ip) {
+			return rule.allow
+		}
+	}
+
+	// default deny
+	return false
+}
+
+// realIP to get the remote ip of channel, RealIPAttribute takes precedence over the transport address.
+func realIP(ch Channel) net.IP {
+	switch v := ch.Attribute(RealIPAttribute).(type) {
+	case net.IP:
+		return v
+	case string:
+		// X-Forwarded-For: client, proxy1, proxy2
+		if index := strings.IndexByte(v, ','); index >= 0 {
+			v = v[:index]
+		}
+		return net.ParseIP(strings.TrimSpace(v))
+	}
+	return remoteIP(ch.Transport().RemoteAddr())
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestIPFilterRules(t *testing.T) {
+
+	filter := IPFilterHandler([]IPRule{
+		{CIDR: "192.168.1.100", Allow: false},
+		{CIDR: "192.168.1.0/24", Allow: true},
+		{CIDR: "2001:db8::/32", Allow: true},
+		{CIDR: "2001:db9::1", Allow: true},
+	}).(*ipFilterHandler)
+
+	var cases = []struct {
+		ip    string
+		allow bool
+	}{
+		{ip: "192.168.1.1", allow: true},
+		{ip: "192.168.1.100", allow: false},
+		{ip: "192.168.2.1", allow: false},
+		{ip: "2001:db8::1", allow: true},
+		{ip: "2001:db8:ffff::1", allow: true},
+		{ip: "2001:db9::1", allow: true},
+		{ip: "2001:db9::2", allow: false},
+		{ip: "::ffff:192.168.1.1", allow: true},
+	}
+
+	for _, c := range cases {
+		if allowed := filter.allowed(net.ParseIP(c.ip)); allowed != c.allow {
+			t.Fatalf("%s: allowed = %v, want: %v", c.ip, allowed, c.allow)
+		}
+	}
+
+	// default policy
+	allowAll := IPFilterHandler([]IPRule{{CIDR: "10.0.0.0/8", Allow: false}, {CIDR: AnyAddress, Allow: true}}).(*ipFilterHandler)
+	if !allowAll.allowed(net.ParseIP("172.16.0.1")) || !allowAll.allowed(net.ParseIP("::1")) {
+		t.Fatal("default allow policy not applied")
+	}
+	if allowAll.allowed(net.ParseIP("10.0.0.1")) {
+		t.Fatal("deny rule not applied")
+	}
+}
+
+func TestIPFilterHandler(t *testing.T) {
+
+	rules := []IPRule{{CIDR: "127.0.0.0/8", Allow: true}, {CIDR: "::1", Allow: true}}
+
+	var cases = []struct {
+		remote  string
+		realIP  interface{}
+		allowed bool
+	}{
+		{remote: "127.0.0.1:9527", allowed: true},
+		{remote: "[::1]:9527", allowed: true},
+		{remote: "192.168.1.1:9527", allowed: false},
+		{remote: "127.0.0.1:9527", realIP: "192.168.1.1, 127.0.0.1", allowed: false},
+		{remote: "192.168.1.1:9527", realIP: net.ParseIP("127.0.0.2"), allowed: true},
+	}
+
+	for _, c := range cases {
+		activated := make(chan struct{}, 1)
+		inactive := make(chan Exception, 1)
+
+		ch, peer := pipeChannel(NewChannel(), c.remote,
+			ActiveHandlerFunc(func(ctx ActiveContext) {
+				if nil != c.realIP {
+					ctx.Channel().SetAttribute(RealIPAttribute, c.realIP)
+				}
+				ctx.HandleActive()
+			}),
+			IPFilterHandler(rules),
+			ActiveHandlerFunc(func(ctx ActiveContext) {
+				activated <- struct{}{}
+			}),
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				_, _ = utils.MustToReader(message).Read(make([]byte, 16))
+			}),
+			InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+				inactive <- ex
+			}),
+		)
+
+		if c.allowed {
+			select {
+			case <-activated:
+			case <-time.After(time.Second):
+				t.Fatal(c.remote, "should be allowed")
+			}
+			if !ch.IsActive() {
+				t.Fatal(c.remote, "should be active")
+			}
+			ch.Close(nil)
+		} else {
+			select {
+			case ex := <-inactive:
+				if !errors.Is(ex, ErrIPRejected) {
+					t.Fatal("unexpected close reason:", ex)
+				}
+			case <-time.After(time.Second):
+				t.Fatal(c.remote, "should be rejected")
+			}
+			if len(activated) > 0 {
+				t.Fatal(c.remote, "should not be activated")
+			}
+		}
+		_ = peer.Close()
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"io"
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// KeyedExecutor executes the actions of the same key sequentially in the order of submission, and the actions
+// of different keys in parallel, the keys are hashed to a fixed number of workers, e.g. to process the messages
+// of a user or session like an actor, it could be shared by the channels.
+type KeyedExecutor interface {
+	// ExecKey queue the action to the worker of the key, it blocks if the queue of worker is full,
+	// false if the executor is shut down.
+	ExecKey(key string, action Action) bool
+
+	// Shutdown the workers after the queued actions are executed.
+	Shutdown()
+}
+
+// NewKeyedExecutor create a KeyedExecutor of workers, each worker queues up to queueSize actions.
+func NewKeyedExecutor(workers, queueSize int) KeyedExecutor {
+	utils.AssertIf(workers <= 0, "workers must be a positive integer")
+	utils.AssertIf(queueSize <= 0, "queueSize must be a positive integer")
+
+	e := &keyedExecutor{queues: make([]chan Action, workers), done: make(chan struct{})}
+	for index := range e.queues {
+		e.queues[index] = make(chan Action, queueSize)
+		e.workers.Add(1)
+		go e.work(e.queues[index])
+	}
+	return e
+}
+
+type keyedExecutor struct {
+	queues  []chan Action
+	once    sync.Once
+	done    chan struct{}
+	workers sync.WaitGroup
+}
+
+func (e *keyedExecutor) ExecKey(key string, action Action) bool {
+	select {
+	case <-e.done:
+		return false
+	default:
+	}
+
+	select {
+	case e.queues[e.workerOf(key)] <- action:
+		return true
+	case <-e.done:
+		return false
+	}
+}
+
+func (e *keyedExecutor) Shutdown() {
+	e.once.Do(func() { close(e.done) })
+	e.workers.Wait()
+}
+
+// workerOf the key by the fnv-1a hash.
+func (e *keyedExecutor) workerOf(key string) int {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return int(hash % uint32(len(e.queues)))
+}
+
+// work execute the queued actions until shut down.
+func (e *keyedExecutor) work(queue chan Action) {
+	defer e.workers.Done()
+	for {
+		select {
+		case action := <-queue:
+			action()
+		case <-e.done:
+			for {
+				select {
+				case action := <-queue:
+					action()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// KeyedInboundHandler create a handler to process the inbound messages by the executor, the messages of the same
+// key returned by keyOf are passed to the next handlers in order, and the others in parallel, the io.Reader
+// messages are read into bytes, so add it after a frame decoder. the inactive event is passed on directly
+// without waiting for the queued messages, and the messages are dropped after the executor is shut down.
+func KeyedInboundHandler(executor KeyedExecutor, keyOf func(Message) string) InboundHandler {
+	utils.AssertIf(nil == executor, "executor is required")
+	utils.AssertIf(nil == keyOf, "keyOf is required")
+	return &keyedInboundHandler{executor: executor, keyOf: keyOf}
+}
+
+type keyedInboundHandler struct {
+	executor KeyedExecutor
+	keyOf    func(Message) string
+}
+
+func (k *keyedInboundHandler) HandleRead(ctx InboundContext, message Message) {
+
+	// the reader may be reused by the decoder.
+	if _, ok := message.(io.Reader); ok {
+		message = utils.MustToBytes(message)
+	}
+
+	if !k.executor.ExecKey(k.keyOf(message), func() { k.deliver(ctx, message) }) {
+		releaseMessage(message)
+	}
+}
+
+// deliver the message to the next handler, the exception is captured out of the read loop.
+func (k *keyedInboundHandler) deliver(ctx InboundContext, message Message) {
+	defer func() {
+		if err := recover(); nil != err {
+			ctx.Channel().Pipeline().FireChannelException(AsException(err))
+		}
+	}()
+	ctx.HandleRead(message)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedExecutor(t *testing.T) {
+
+	executor := NewKeyedExecutor(4, 16)
+	defer executor.Shutdown()
+
+	// two keys of different workers.
+	keyA, keyB := "a", "b"
+	for index := 0; executor.(*keyedExecutor).workerOf(keyA) == executor.(*keyedExecutor).workerOf(keyB); index++ {
+		keyB = fmt.Sprint("b", index)
+	}
+
+	gate := make(chan struct{})
+	var mutex sync.Mutex
+	var executed []string
+	record := func(name string) Action {
+		return func() {
+			mutex.Lock()
+			executed = append(executed, name)
+			mutex.Unlock()
+		}
+	}
+
+	// the worker of a is blocked, b is not affected.
+	executor.ExecKey(keyA, func() { <-gate })
+	for i := 0; i < 3; i++ {
+		executor.ExecKey(keyA, record(fmt.Sprint("a", i)))
+	}
+
+	done := make(chan struct{})
+	executor.ExecKey(keyB, record("b"))
+	executor.ExecKey(keyB, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("key b is blocked by key a")
+	}
+
+	close(gate)
+	executor.Shutdown()
+
+	if got := strings.Join(executed, ","); "b,a0,a1,a2" != got {
+		t.Fatal("unexpected execution order:", got)
+	}
+
+	if executor.ExecKey(keyA, func() {}) {
+		t.Fatal("executed after shutdown")
+	}
+}
+
+func TestKeyedInboundHandler(t *testing.T) {
+
+	executor := NewKeyedExecutor(4, 16)
+	defer executor.Shutdown()
+
+	var mutex sync.Mutex
+	received := make(map[string][]string)
+	var wg sync.WaitGroup
+	wg.Add(30)
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		delimiterCodec{maxFrameLength: 1024, delimiter: []byte("\n"), stripDelimiter: true},
+		&textCodec{},
+		KeyedInboundHandler(executor, func(message Message) string {
+			return message.(string)[:1]
+		}),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			defer wg.Done()
+			line := message.(string)
+			mutex.Lock()
+			received[line[:1]] = append(received[line[:1]], line[1:])
+			mutex.Unlock()
+		}),
+	)
+	defer ch.Close(nil)
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			for _, key := range []string{"a", "b", "c"} {
+				_, _ = fmt.Fprintf(peer, "%s%d\n", key, i)
+			}
+		}
+	}()
+	wg.Wait()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if got := strings.Join(received[key], ","); "0,1,2,3,4,5,6,7,8,9" != got {
+			t.Fatal("messages of", key, "are out of order:", got)
+		}
+	}
+}
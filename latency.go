@@ -0,0 +1,200 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// LatencyDirection defines the directions of messages delayed by LatencyHandler
+type LatencyDirection int
+
+const (
+	// DelayInbound delays the inbound messages.
+	DelayInbound LatencyDirection = 1 << iota
+	// DelayOutbound delays the outbound messages.
+	DelayOutbound
+	// DelayBoth delays the messages of both directions.
+	DelayBoth = DelayInbound | DelayOutbound
+)
+
+// LatencyHandler delays the messages of direction by base plus a random jitter in [0, jitter) for chaos testing,
+// the messages are delivered by a timer goroutine in order, so the delay never blocks the read loop or the writer,
+// the delay of a message is extended if the previous one is not delivered yet. the readers are drained to bytes,
+// so add it after the frame decoders, and create a new one for each channel.
+func LatencyHandler(base, jitter time.Duration, direction LatencyDirection) ChannelHandler {
+	utils.AssertIf(base < 0 || jitter < 0, "invalid latency: %s + %s", base, jitter)
+	utils.AssertIf(0 == direction&DelayBoth, "invalid direction: %d", direction)
+	return &latencyHandler{
+		base:      base,
+		jitter:    jitter,
+		direction: direction,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type delayedMessage struct {
+	message Message
+	due     time.Time
+	deliver func(message Message)
+}
+
+// delayQueue delivers the messages one by one at the due time.
+type delayQueue struct {
+	messages []delayedMessage
+	last     time.Time
+	running  bool
+}
+
+type latencyHandler struct {
+	mutex     sync.Mutex
+	base      time.Duration
+	jitter    time.Duration
+	direction LatencyDirection
+	random    *rand.Rand
+	inbound   delayQueue
+	outbound  delayQueue
+}
+
+func (l *latencyHandler) HandleActive(ctx ActiveContext) {
+	ctx.HandleActive()
+}
+
+func (l *latencyHandler) HandleRead(ctx InboundContext, message Message) {
+	if 0 == l.direction&DelayInbound {
+		ctx.HandleRead(message)
+		return
+	}
+
+	l.delay(ctx, &l.inbound, message, func(message Message) {
+		ctx.HandleRead(message)
+	})
+}
+
+func (l *latencyHandler) HandleWrite(ctx OutboundContext, message Message) {
+	if 0 == l.direction&DelayOutbound {
+		ctx.HandleWrite(message)
+		return
+	}
+
+	// the delayed message is written out of the outbound traversal, it is serialized with the writes of channel.
+	l.delay(ctx, &l.outbound, message, func(message Message) {
+		// the failed message is held by the handler, so it is released here.
+		if err := writeSerialized(ctx, message); nil != err {
+			releaseMessage(message)
+			panic(err)
+		}
+	})
+}
+
+func (l *latencyHandler) HandleException(ctx ExceptionContext, ex Exception) {
+	ctx.HandleException(ex)
+}
+
+func (l *latencyHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	ctx.HandleInactive(ex)
+}
+
+// delay the message in the queue, a goroutine is started to deliver the messages if not running.
+func (l *latencyHandler) delay(ctx HandlerContext, queue *delayQueue, message Message, deliver func(message Message)) {
+
+	// the stream is read before returned to the read loop.
+	message = detachMessage(message)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delay := l.base
+	if l.jitter > 0 {
+		delay += time.Duration(l.random.Int63n(int64(l.jitter)))
+	}
+
+	// keep the order of messages.
+	due := ClockOf(ctx.Channel()).Now().Add(delay)
+	if due.Before(queue.last) {
+		due = queue.last
+	}
+	queue.last = due
+	queue.messages = append(queue.messages, delayedMessage{message: message, due: due, deliver: deliver})
+
+	if !queue.running {
+		queue.running = true
+		go l.run(ctx, queue)
+	}
+}
+
+// run to deliver the messages of queue until it is empty, the messages left are released after closed.
+func (l *latencyHandler) run(ctx HandlerContext, queue *delayQueue) {
+
+	clock := ClockOf(ctx.Channel())
+	timer := clock.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		l.mutex.Lock()
+		if 0 == len(queue.messages) {
+			queue.running = false
+			l.mutex.Unlock()
+			return
+		}
+		next := queue.messages[0]
+		l.mutex.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(next.due.Sub(clock.Now()))
+
+		select {
+		case <-ctx.Channel().Context().Done():
+			l.mutex.Lock()
+			messages := queue.messages
+			queue.messages, queue.running = nil, false
+			l.mutex.Unlock()
+
+			for _, m := range messages {
+				releaseMessage(m.message)
+			}
+			return
+		case <-timer.C():
+		}
+
+		l.mutex.Lock()
+		queue.messages = queue.messages[1:]
+		l.mutex.Unlock()
+
+		l.deliver(ctx, next)
+	}
+}
+
+// deliver the message with the failure passed to the exception handlers.
+func (l *latencyHandler) deliver(ctx HandlerContext, m delayedMessage) {
+	defer func() {
+		if err := recover(); nil != err {
+			ctx.Channel().Pipeline().FireChannelException(AsException(err))
+		}
+	}()
+
+	m.deliver(m.message)
+}
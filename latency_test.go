@@ -0,0 +1,103 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHandler(t *testing.T) {
+
+	const base, jitter = 30 * time.Millisecond, 20 * time.Millisecond
+
+	type arrival struct {
+		message string
+		at      time.Time
+	}
+
+	received := make(chan arrival, 16)
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		byteFrameHandler{},
+		LatencyHandler(base, jitter, DelayBoth),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			received <- arrival{message: string(message.([]byte)), at: time.Now()}
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	// the inbound messages are delayed in order.
+	start := time.Now()
+	if _, err := peer.Write([]byte("abcdefghij")); nil != err {
+		t.Fatal(err)
+	}
+
+	for _, want := range "abcdefghij" {
+		select {
+		case a := <-received:
+			if string(want) != a.message {
+				t.Fatal("message:", a.message, "want:", string(want))
+			}
+			if delay := a.at.Sub(start); delay < base || delay > base+jitter+50*time.Millisecond {
+				t.Fatal("unexpected delay:", delay)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message is not delivered")
+		}
+	}
+
+	// the outbound messages are delayed without blocking the writer.
+	start = time.Now()
+	for _, message := range []string{"1", "2", "3"} {
+		if err := ch.Write([]byte(message)); nil != err {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= base {
+		t.Fatal("writer is blocked:", elapsed)
+	}
+
+	buffer := make([]byte, 3)
+	for i := 0; i < len(buffer); i++ {
+		_ = peer.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := peer.Read(buffer[i : i+1]); nil != err {
+			t.Fatal(err)
+		}
+		if delay := time.Since(start); delay < base {
+			t.Fatal("outbound message is not delayed:", delay)
+		}
+	}
+	if "123" != string(buffer) {
+		t.Fatalf("unexpected order: %q", buffer)
+	}
+}
+
+func TestLatencyHandlerClose(t *testing.T) {
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{}, LatencyHandler(time.Millisecond*20, 0, DelayOutbound))
+	defer peer.Close()
+
+	// the pending messages are dropped after closed.
+	_ = ch.Write([]byte("hello"))
+	ch.Close(nil)
+
+	_ = peer.SetReadDeadline(time.Now().Add(time.Millisecond * 100))
+	if n, err := peer.Read(make([]byte, 8)); nil == err {
+		t.Fatal("unexpected message:", n)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import "container/list"
+
+// lruCache is a bounded least-recently-used cache, it is not safe for concurrent use.
+type lruCache struct {
+	capacity int
+	items    map[interface{}]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		items:    make(map[interface{}]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// Get the value of key and mark it as recently used.
+func (c *lruCache) Get(key interface{}) (interface{}, bool) {
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// Put the value of key, the least recently used one will be evicted if the cache is full.
+func (c *lruCache) Put(key, value interface{}) {
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value.(*lruEntry).value = value
+		return
+	}
+
+	if c.order.Len() >= c.capacity {
+		if oldest := c.order.Back(); nil != oldest {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+}
+
+// Remove the key from cache.
+func (c *lruCache) Remove(key interface{}) {
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+// Len of the cache.
+func (c *lruCache) Len() int {
+	return c.order.Len()
+}
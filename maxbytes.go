@@ -0,0 +1,115 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrMaxBytesExceeded is wrapped by the close cause of a channel sent more bytes than the limit of MaxBytesHandler.
+var ErrMaxBytesExceeded = errors.New("netty: max bytes exceeded")
+
+// MaxBytesOptions for MaxBytesHandlerWith
+type MaxBytesOptions struct {
+	// Limit of the inbound bytes over the lifetime of channel.
+	Limit int64 `json:"limit"`
+	// Warning is written to the peer before the channel is closed if not nil, it is best-effort,
+	// e.g. it could be dropped by a full write queue.
+	Warning Message `json:"-"`
+}
+
+// MaxBytesHandler create a handler to close the channel once it received more than limit bytes,
+// add it as the first handler to count the raw bytes, and create a new one for each channel.
+func MaxBytesHandler(limit int64) InboundHandler {
+	return MaxBytesHandlerWith(MaxBytesOptions{Limit: limit})
+}
+
+// MaxBytesHandlerWith create a MaxBytesHandler with options, the bytes up to the limit are passed on,
+// the exceeded bytes are dropped and the channel is closed with ErrMaxBytesExceeded.
+func MaxBytesHandlerWith(options MaxBytesOptions) InboundHandler {
+	utils.AssertIf(options.Limit <= 0, "limit must be a positive integer")
+	return &maxBytesHandler{options: options}
+}
+
+type maxBytesHandler struct {
+	options  MaxBytesOptions
+	received int64
+	exceeded bool
+	source   io.Reader       // the stream of channel
+	reader   *maxBytesReader // the reader of source
+}
+
+func (m *maxBytesHandler) HandleRead(ctx InboundContext, message Message) {
+	switch r := message.(type) {
+	case io.Reader:
+		// the stream of channel is fired for each read, so the reader is reused.
+		if r != m.source {
+			m.source, m.reader = r, &maxBytesReader{reader: r, handler: m}
+		}
+		m.reader.ctx = ctx
+		ctx.HandleRead(m.reader)
+	default:
+		if size := len(utils.MustToBytes(message)); m.count(ctx, size) < size {
+			releaseMessage(message)
+			return
+		}
+		ctx.HandleRead(message)
+	}
+}
+
+// count the received bytes, returns how many of them are allowed, the channel is closed if the limit is exceeded.
+func (m *maxBytesHandler) count(ctx InboundContext, n int) int {
+	if m.exceeded {
+		return 0
+	}
+
+	if remaining := m.options.Limit - m.received; int64(n) > remaining {
+		m.exceeded = true
+		m.received = m.options.Limit
+		if nil != m.options.Warning {
+			ctx.Write(m.options.Warning)
+		}
+		ctx.Close(fmt.Errorf("%w: received %d bytes > limit(%d)", ErrMaxBytesExceeded, m.options.Limit-remaining+int64(n), m.options.Limit))
+		return int(remaining)
+	}
+
+	m.received += int64(n)
+	return n
+}
+
+// maxBytesReader counts the bytes read from the stream
+type maxBytesReader struct {
+	reader  io.Reader
+	handler *maxBytesHandler
+	ctx     InboundContext
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	if r.handler.exceeded {
+		return 0, ErrMaxBytesExceeded
+	}
+
+	n, err := r.reader.Read(p)
+	if allowed := r.handler.count(r.ctx, n); allowed < n {
+		return allowed, nil
+	}
+	return n, err
+}
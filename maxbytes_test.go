@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxBytesHandler(t *testing.T) {
+
+	received := make(chan string, 8)
+	inactive := make(chan Exception, 1)
+
+	_, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		MaxBytesHandlerWith(MaxBytesOptions{Limit: 10, Warning: []byte("bye")}),
+		delimiterCodec{maxFrameLength: 1024, delimiter: []byte("\n"), stripDelimiter: true},
+		&textCodec{},
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			received <- message.(string)
+		}),
+		InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+			inactive <- ex
+		}),
+	)
+	defer peer.Close()
+	written := readPeer(peer)
+
+	// 6 + 4 bytes are allowed, the channel is closed at the 11th byte.
+	_, _ = peer.Write([]byte("hello\n"))
+	_, _ = peer.Write([]byte("abc\nxyz\n"))
+
+	for _, want := range []string{"hello", "abc"} {
+		select {
+		case got := <-received:
+			if want != got {
+				t.Fatal("unexpected message:", got, "want:", want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message is not received:", want)
+		}
+	}
+
+	select {
+	case ex := <-inactive:
+		if !errors.Is(ex, ErrMaxBytesExceeded) {
+			t.Fatal("unexpected close cause:", ex)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel is not closed")
+	}
+
+	if warning := <-written; "bye" != warning {
+		t.Fatal("unexpected warning:", warning)
+	}
+	if 0 != len(received) {
+		t.Fatal("exceeded bytes are passed on:", <-received)
+	}
+}
@@ -0,0 +1,511 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrStreamClosed is returned when writing to a closed stream of MultiplexHandler.
+var ErrStreamClosed = errors.New("netty: stream closed")
+
+// ErrStreamWindowExceeded is the cause of closing the connection if the peer sent more data than the window.
+var ErrStreamWindowExceeded = errors.New("netty: stream window exceeded")
+
+// ErrStreamRefused is returned when opening a stream over a draining connection of MultiplexHandler.
+var ErrStreamRefused = errors.New("netty: stream refused")
+
+// frame types of MultiplexHandler
+const (
+	muxData   byte = 0
+	muxClose  byte = 1
+	muxWindow byte = 2
+	muxGoAway byte = 3
+)
+
+// muxHeaderSize is the size of stream id and frame type.
+const muxHeaderSize = 5
+
+// MultiplexOptions defines the options of MultiplexHandler
+type MultiplexOptions struct {
+	// Client side opens the streams of odd ids, the server side opens the even ones.
+	Client bool
+	// Window is the bytes of a stream could be sent before acknowledged by the peer, default 64KB.
+	Window int
+	// MaxFrameSize is the max payload size of a frame, default 16KB.
+	MaxFrameSize int
+	// Initializer to initialize the pipeline of streams, both opened locally and by the peer.
+	Initializer ChannelInitializer
+	// ChannelFactory to create the stream channels, NewChannel() if nil.
+	ChannelFactory ChannelFactory
+}
+
+// Multiplexer runs many sub-channels over one connection.
+type Multiplexer interface {
+	ChannelInboundHandler
+
+	// OpenStream open a new stream to the peer, the stream channel is initialized by the Initializer.
+	OpenStream() (Channel, error)
+
+	// Streams returns the number of active streams.
+	Streams() int
+
+	// Drain send a goaway to the peer and refuse the new streams of both sides, the open streams
+	// are not affected, the connection is closed after all of them are closed, or ctx is done,
+	// ctx.Err() is returned then. it is useful for the rolling restart of servers.
+	Drain(ctx context.Context) error
+}
+
+// MultiplexHandler demultiplex the inbound frames into the stream channels and multiplex the writes of
+// them back to the connection, each stream is a Channel with its own pipeline, the writes of a stream are
+// blocked if the window is exhausted until the peer consumed the data, so a slow stream does not block others.
+// the frame is [stream id: 4 bytes | type: 1 byte | payload], so the handler must be placed after a frame codec,
+// and it should be the last handler of the connection. create a new one for each channel.
+func MultiplexHandler(options MultiplexOptions) Multiplexer {
+	utils.AssertIf(nil == options.Initializer, "initializer is required")
+
+	if options.Window <= 0 {
+		options.Window = 64 * 1024
+	}
+	if options.MaxFrameSize <= 0 {
+		options.MaxFrameSize = 16 * 1024
+	}
+	if nil == options.ChannelFactory {
+		options.ChannelFactory = NewChannel()
+	}
+
+	m := &multiplexer{options: options, streams: make(map[uint32]*muxStream), nextID: 2}
+	if options.Client {
+		m.nextID = 1
+	}
+	return m
+}
+
+type multiplexer struct {
+	options MultiplexOptions
+	mutex   sync.Mutex
+	parent  Channel
+	streams map[uint32]*muxStream
+	nextID  uint32
+	closed  bool
+	// lastAccepted is the last stream id opened by the peer, it is sent by the goaway.
+	lastAccepted uint32
+	// draining is set by Drain, and goingAway is set by the goaway of peer.
+	draining  bool
+	goingAway bool
+	drained   chan struct{}
+}
+
+func (m *multiplexer) HandleActive(ctx ActiveContext) {
+	m.mutex.Lock()
+	m.parent = ctx.Channel()
+	m.mutex.Unlock()
+	ctx.HandleActive()
+}
+
+func (m *multiplexer) HandleRead(ctx InboundContext, message Message) {
+	frame := utils.MustToBytes(message)
+	utils.AssertIf(len(frame) < muxHeaderSize, "invalid frame: %d bytes", len(frame))
+
+	id := binary.BigEndian.Uint32(frame)
+	payload := frame[muxHeaderSize:]
+
+	switch frame[4] {
+	case muxData:
+		if stream := m.acceptStream(id); nil != stream {
+			if !stream.receive(payload) {
+				ctx.Close(fmt.Errorf("%w: stream %d", ErrStreamWindowExceeded, id))
+			}
+		}
+	case muxClose:
+		if stream := m.stream(id); nil != stream {
+			stream.remoteClose()
+		}
+	case muxWindow:
+		utils.AssertIf(len(payload) < 4, "invalid window update of stream %d", id)
+		if stream := m.stream(id); nil != stream {
+			stream.updateWindow(int(binary.BigEndian.Uint32(payload)))
+		}
+	case muxGoAway:
+		utils.AssertIf(len(payload) < 4, "invalid goaway")
+		m.goAway(binary.BigEndian.Uint32(payload))
+	default:
+		utils.Assert(fmt.Errorf("unrecognized frame type: %d", frame[4]))
+	}
+}
+
+func (m *multiplexer) HandleInactive(ctx InactiveContext, ex Exception) {
+	m.mutex.Lock()
+	m.closed = true
+	streams := make([]*muxStream, 0, len(m.streams))
+	for _, stream := range m.streams {
+		streams = append(streams, stream)
+	}
+	m.streams = map[uint32]*muxStream{}
+	m.checkDrainedLocked()
+	m.mutex.Unlock()
+
+	for _, stream := range streams {
+		stream.remoteClose()
+	}
+
+	ctx.HandleInactive(ex)
+}
+
+func (m *multiplexer) OpenStream() (Channel, error) {
+	m.mutex.Lock()
+	if m.closed || nil == m.parent {
+		m.mutex.Unlock()
+		return nil, ErrChannelClosed
+	}
+	if m.draining || m.goingAway {
+		m.mutex.Unlock()
+		return nil, ErrStreamRefused
+	}
+	id := m.nextID
+	m.nextID += 2
+	stream := m.newStreamLocked(id)
+	m.mutex.Unlock()
+
+	return m.serveStream(stream), nil
+}
+
+func (m *multiplexer) Streams() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.streams)
+}
+
+func (m *multiplexer) Drain(ctx context.Context) error {
+	m.mutex.Lock()
+	if m.closed || nil == m.parent {
+		m.mutex.Unlock()
+		return ErrChannelClosed
+	}
+
+	first := !m.draining
+	if first {
+		m.draining = true
+		m.drained = make(chan struct{})
+		m.checkDrainedLocked()
+	}
+	drained, lastID := m.drained, m.lastAccepted
+	m.mutex.Unlock()
+
+	var payload [4]byte
+	binary.BigEndian.PutUint32(payload[:], lastID)
+	if first {
+		_ = m.writeFrame(0, muxGoAway, payload[:])
+	}
+
+	select {
+	case <-drained:
+		if first {
+			// the goaway is sent again to close the connection after the frames of streams are flushed.
+			return m.parent.WriteAndClose(m.newFrame(0, muxGoAway, payload[:]))
+		}
+		return nil
+	case <-ctx.Done():
+		m.parent.Close(ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// goAway to refuse the new streams after the goaway of peer, the streams opened locally after
+// the last one accepted by the peer are closed.
+func (m *multiplexer) goAway(lastID uint32) {
+	m.mutex.Lock()
+	m.goingAway = true
+	var refused []*muxStream
+	for id, stream := range m.streams {
+		if m.options.Client == (1 == id%2) && id > lastID {
+			refused = append(refused, stream)
+		}
+	}
+	m.mutex.Unlock()
+
+	for _, stream := range refused {
+		stream.remoteClose()
+	}
+}
+
+// checkDrainedLocked to notify Drain if all streams are closed.
+func (m *multiplexer) checkDrainedLocked() {
+	if m.draining && 0 == len(m.streams) {
+		select {
+		case <-m.drained:
+		default:
+			close(m.drained)
+		}
+	}
+}
+
+func (m *multiplexer) stream(id uint32) *muxStream {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.streams[id]
+}
+
+// acceptStream to get the stream of id, a new stream is created if it is opened by the peer,
+// nil if the stream is closed already.
+func (m *multiplexer) acceptStream(id uint32) *muxStream {
+	m.mutex.Lock()
+	if stream, ok := m.streams[id]; ok {
+		m.mutex.Unlock()
+		return stream
+	}
+
+	// the local opened stream is closed, or unknown id.
+	if m.closed || m.options.Client == (1 == id%2) || id == 0 || id <= m.lastAccepted {
+		m.mutex.Unlock()
+		return nil
+	}
+
+	// refuse the streams opened by the peer before it received the goaway.
+	if m.draining {
+		m.mutex.Unlock()
+		_ = m.writeFrame(id, muxClose, nil)
+		return nil
+	}
+
+	m.lastAccepted = id
+	stream := m.newStreamLocked(id)
+	m.mutex.Unlock()
+
+	// serve the stream asynchronously, so that the handlers of stream do not block the connection.
+	go m.serveStream(stream)
+	return stream
+}
+
+func (m *multiplexer) newStreamLocked(id uint32) *muxStream {
+	stream := &muxStream{mux: m, id: id, sendWindow: m.options.Window}
+	stream.cond = sync.NewCond(&stream.mutex)
+	m.streams[id] = stream
+	return stream
+}
+
+func (m *multiplexer) serveStream(stream *muxStream) Channel {
+	// derived from the id of connection and the stream id.
+	id := m.parent.ID()<<32 | int64(stream.id)
+
+	pl := NewPipeline()
+	ch := m.options.ChannelFactory(id, m.parent.Context(), pl, stream, AsyncExecutor())
+	m.options.Initializer(ch)
+	pl.ServeChannel(ch)
+	return ch
+}
+
+func (m *multiplexer) remove(id uint32) {
+	m.mutex.Lock()
+	delete(m.streams, id)
+	m.checkDrainedLocked()
+	m.mutex.Unlock()
+}
+
+// writeFrame to write a frame to the connection.
+func (m *multiplexer) writeFrame(id uint32, kind byte, payload []byte) error {
+	return m.parent.Write(m.newFrame(id, kind, payload))
+}
+
+func (m *multiplexer) newFrame(id uint32, kind byte, payload []byte) []byte {
+	frame := make([]byte, muxHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame, id)
+	frame[4] = kind
+	copy(frame[muxHeaderSize:], payload)
+	return frame
+}
+
+// muxStream is the transport of a stream channel.
+type muxStream struct {
+	mux          *multiplexer
+	id           uint32
+	mutex        sync.Mutex
+	cond         *sync.Cond
+	inbound      bytes.Buffer
+	sendWindow   int
+	consumed     int
+	closed       bool
+	remoteClosed bool
+}
+
+// receive the data from peer, false if the window is exceeded.
+func (s *muxStream) receive(data []byte) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed || s.remoteClosed {
+		return true
+	}
+
+	if s.inbound.Len()+s.consumed+len(data) > s.mux.options.Window {
+		return false
+	}
+
+	s.inbound.Write(data)
+	s.cond.Broadcast()
+	return true
+}
+
+func (s *muxStream) remoteClose() {
+	s.mutex.Lock()
+	s.remoteClosed = true
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+func (s *muxStream) updateWindow(n int) {
+	s.mutex.Lock()
+	s.sendWindow += n
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+func (s *muxStream) Read(p []byte) (int, error) {
+	s.mutex.Lock()
+	for 0 == s.inbound.Len() && !s.closed && !s.remoteClosed {
+		s.cond.Wait()
+	}
+
+	if 0 == s.inbound.Len() {
+		s.mutex.Unlock()
+		if s.closed {
+			return 0, net.ErrClosed
+		}
+		return 0, io.EOF
+	}
+
+	n, _ := s.inbound.Read(p)
+
+	// acknowledge the consumed bytes when half of the window is consumed.
+	var update int
+	if s.consumed += n; s.consumed >= s.mux.options.Window/2 {
+		update, s.consumed = s.consumed, 0
+	}
+	s.mutex.Unlock()
+
+	if update > 0 {
+		var payload [4]byte
+		binary.BigEndian.PutUint32(payload[:], uint32(update))
+		_ = s.mux.writeFrame(s.id, muxWindow, payload[:])
+	}
+	return n, nil
+}
+
+func (s *muxStream) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		s.mutex.Lock()
+		for s.sendWindow <= 0 && !s.closed && !s.remoteClosed {
+			s.cond.Wait()
+		}
+
+		if s.closed || s.remoteClosed {
+			s.mutex.Unlock()
+			return written, ErrStreamClosed
+		}
+
+		n := len(p)
+		if n > s.sendWindow {
+			n = s.sendWindow
+		}
+		if n > s.mux.options.MaxFrameSize {
+			n = s.mux.options.MaxFrameSize
+		}
+		s.sendWindow -= n
+		s.mutex.Unlock()
+
+		if err := s.mux.writeFrame(s.id, muxData, p[:n]); nil != err {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func (s *muxStream) Writev(buffs transport.Buffers) (int64, error) {
+	var written int64
+	for _, buff := range buffs.Buffers {
+		n, err := s.Write(buff)
+		written += int64(n)
+		if nil != err {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (s *muxStream) Flush() error {
+	return nil
+}
+
+func (s *muxStream) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	remoteClosed := s.remoteClosed
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+
+	s.mux.remove(s.id)
+	if !remoteClosed {
+		return s.mux.writeFrame(s.id, muxClose, nil)
+	}
+	return nil
+}
+
+func (s *muxStream) LocalAddr() net.Addr {
+	return s.mux.parent.Transport().LocalAddr()
+}
+
+func (s *muxStream) RemoteAddr() net.Addr {
+	return s.mux.parent.Transport().RemoteAddr()
+}
+
+// SetDeadline is not supported by stream.
+func (s *muxStream) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (s *muxStream) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (s *muxStream) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// RawTransport returns the transport of the connection.
+func (s *muxStream) RawTransport() interface{} {
+	return s.mux.parent.Transport()
+}
+
+var _ transport.Transport = (*muxStream)(nil)
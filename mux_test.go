@@ -0,0 +1,456 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// lengthFrameCodec prefixes the frames with 4 bytes length.
+type lengthFrameCodec struct{}
+
+func (lengthFrameCodec) HandleRead(ctx InboundContext, message Message) {
+	reader := utils.MustToReader(message)
+	var header [4]byte
+	utils.AssertLength(io.ReadFull(reader, header[:]))
+	frame := make([]byte, binary.BigEndian.Uint32(header[:]))
+	utils.AssertLength(io.ReadFull(reader, frame))
+	ctx.HandleRead(frame)
+}
+
+func (lengthFrameCodec) HandleWrite(ctx OutboundContext, message Message) {
+	payload := utils.MustToBytes(message)
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	ctx.HandleWrite(frame)
+}
+
+// muxChannels serve the server and client multiplexer over net.Pipe.
+func muxChannels(server, client Multiplexer) (Channel, Channel) {
+	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:9527")
+	serverConn, clientConn := net.Pipe()
+
+	serve := func(conn net.Conn, mux Multiplexer) Channel {
+		pl := NewPipeline()
+		pl.AddLast(lengthFrameCodec{}, mux, ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		}))
+		t := transport.NewTransport(addrConn{Conn: conn, local: addr, remote: addr}, 0, 0)
+		ch := NewChannel()(testChannelID(), context.Background(), pl, t, AsyncExecutor())
+		pl.ServeChannel(ch)
+		return ch
+	}
+
+	var serverCh Channel
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serverCh = serve(serverConn, server)
+	}()
+	clientCh := serve(clientConn, client)
+	wg.Wait()
+	return serverCh, clientCh
+}
+
+// streamHandler deliver the inbound bytes of stream to the channel, and close the stream at the end.
+func streamHandler(received chan<- []byte) Handler {
+	return &struct {
+		InboundHandlerFunc
+		ExceptionHandlerFunc
+	}{
+		InboundHandlerFunc: func(ctx InboundContext, message Message) {
+			buffer := make([]byte, 64)
+			n := utils.AssertLength(utils.MustToReader(message).Read(buffer))
+			received <- buffer[:n]
+		},
+		ExceptionHandlerFunc: func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		},
+	}
+}
+
+func TestMultiplexHandler(t *testing.T) {
+
+	// the server echo the data of each stream.
+	server := MultiplexHandler(MultiplexOptions{Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			buffer := make([]byte, 64)
+			n := utils.AssertLength(utils.MustToReader(message).Read(buffer))
+			ctx.Write(bytes.ToUpper(buffer[:n]))
+		}), ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		}))
+	}})
+
+	var received = make(chan []byte, 4)
+	client := MultiplexHandler(MultiplexOptions{Client: true, Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(streamHandler(received))
+	}})
+
+	serverCh, clientCh := muxChannels(server, client)
+	defer serverCh.Close(nil)
+	defer clientCh.Close(nil)
+
+	streams := make([]Channel, 2)
+	for i := range streams {
+		stream, err := client.OpenStream()
+		if nil != err {
+			t.Fatal(err)
+		}
+		streams[i] = stream
+	}
+
+	if streams[0].ID() == streams[1].ID() {
+		t.Fatal("streams share the same id")
+	}
+
+	for i, message := range []string{"hello", "world"} {
+		if err := streams[i].Write([]byte(message)); nil != err {
+			t.Fatal(err)
+		}
+
+		select {
+		case data := <-received:
+			if want := bytes.ToUpper([]byte(message)); !bytes.Equal(want, data) {
+				t.Fatalf("stream %d received: %q, want: %q", i, data, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("stream", i, "not echoed")
+		}
+	}
+
+	if 2 != server.Streams() || 2 != client.Streams() {
+		t.Fatal("unexpected streams:", server.Streams(), client.Streams())
+	}
+
+	// close one stream, the other is not affected.
+	streams[0].Close(nil)
+	for deadline := time.Now().Add(time.Second); 1 != server.Streams(); {
+		if time.Now().After(deadline) {
+			t.Fatal("stream is not closed at server side:", server.Streams())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if err := streams[1].Write([]byte("again")); nil != err {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-received:
+		if "AGAIN" != string(data) {
+			t.Fatal("unexpected data:", string(data))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream not echoed after the other closed")
+	}
+
+	// all streams are closed with the connection.
+	clientCh.Close(nil)
+	select {
+	case <-streams[1].Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("stream is not closed with the connection")
+	}
+
+	if _, err := client.OpenStream(); nil == err {
+		t.Fatal("expect error of opening stream over closed connection")
+	}
+}
+
+func TestMultiplexFlowControl(t *testing.T) {
+
+	gate := make(chan struct{})
+	received := make(chan []byte, 64)
+	var accepted int32
+
+	server := MultiplexHandler(MultiplexOptions{Window: 8, Initializer: func(ch Channel) {
+		// the first stream is blocked until the gate is opened.
+		if 1 == atomic.AddInt32(&accepted, 1) {
+			ch.Pipeline().AddLast(ActiveHandlerFunc(func(ctx ActiveContext) {
+				ctx.HandleActive()
+				<-gate
+			}))
+		}
+		ch.Pipeline().AddLast(streamHandler(received))
+	}})
+	client := MultiplexHandler(MultiplexOptions{Client: true, Window: 8, Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(discardHandler{})
+	}})
+
+	serverCh, clientCh := muxChannels(server, client)
+	defer serverCh.Close(nil)
+	defer clientCh.Close(nil)
+
+	slow, err := client.OpenStream()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		written <- slow.Write(bytes.Repeat([]byte{'s'}, 32))
+	}()
+
+	select {
+	case err := <-written:
+		t.Fatal("write is not blocked by the window:", err)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	// the other stream is not blocked.
+	fast, err := client.OpenStream()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := fast.Write([]byte("fast")); nil != err {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-received:
+		if "fast" != string(data) {
+			t.Fatal("unexpected data:", string(data))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream is blocked by the other")
+	}
+
+	close(gate)
+	select {
+	case err := <-written:
+		if nil != err {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("write is not resumed by window update")
+	}
+
+	var total int
+	for total < 32 {
+		select {
+		case data := <-received:
+			if !bytes.Equal(bytes.Repeat([]byte{'s'}, len(data)), data) {
+				t.Fatal("unexpected data:", string(data))
+			}
+			total += len(data)
+		case <-time.After(time.Second):
+			t.Fatal("received:", total)
+		}
+	}
+}
+
+func TestMultiplexDrain(t *testing.T) {
+
+	// the server replies after the gate is opened.
+	gate := make(chan struct{})
+	server := MultiplexHandler(MultiplexOptions{Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			buffer := make([]byte, 64)
+			n := utils.AssertLength(utils.MustToReader(message).Read(buffer))
+			<-gate
+			ctx.Channel().WriteAndClose(bytes.ToUpper(buffer[:n]))
+		}), ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		}))
+	}})
+
+	received := make(chan []byte, 4)
+	client := MultiplexHandler(MultiplexOptions{Client: true, Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(streamHandler(received))
+	}})
+
+	serverCh, clientCh := muxChannels(server, client)
+	defer serverCh.Close(nil)
+	defer clientCh.Close(nil)
+
+	stream, err := client.OpenStream()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := stream.Write([]byte("in-flight")); nil != err {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second); 1 != server.Streams(); {
+		if time.Now().After(deadline) {
+			t.Fatal("stream is not accepted")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	drained := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		drained <- server.Drain(ctx)
+	}()
+
+	// the new streams of both sides are refused once the goaway is received.
+	for deadline := time.Now().Add(time.Second); ; {
+		_, err := client.OpenStream()
+		if ErrStreamRefused == err {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("new stream is not refused:", err)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if _, err := server.OpenStream(); ErrStreamRefused != err {
+		t.Fatal("expect stream refused, got:", err)
+	}
+
+	select {
+	case err := <-drained:
+		t.Fatal("drained with an open stream:", err)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	// the in-flight stream completes, then the connection is closed.
+	close(gate)
+	select {
+	case data := <-received:
+		if "IN-FLIGHT" != string(data) {
+			t.Fatal("unexpected data:", string(data))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("in-flight stream is not completed")
+	}
+
+	select {
+	case err := <-drained:
+		if nil != err {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("not drained")
+	}
+
+	select {
+	case <-clientCh.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection is not closed after drained")
+	}
+}
+
+func TestMultiplexDrainTimeout(t *testing.T) {
+
+	server := MultiplexHandler(MultiplexOptions{Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(discardHandler{})
+	}})
+	client := MultiplexHandler(MultiplexOptions{Client: true, Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(discardHandler{})
+	}})
+
+	serverCh, clientCh := muxChannels(server, client)
+	defer serverCh.Close(nil)
+	defer clientCh.Close(nil)
+
+	// the stream is never closed.
+	stream, err := client.OpenStream()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := stream.Write([]byte("idle")); nil != err {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second); 1 != server.Streams(); {
+		if time.Now().After(deadline) {
+			t.Fatal("stream is not accepted")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if err := server.Drain(ctx); context.DeadlineExceeded != err {
+		t.Fatal("expect deadline exceeded, got:", err)
+	}
+
+	select {
+	case <-stream.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("stream is not closed with the connection")
+	}
+}
+
+func TestMultiplexStreamPause(t *testing.T) {
+
+	var received = make(chan []byte, 4)
+	var streams = make(chan Channel, 1)
+	server := MultiplexHandler(MultiplexOptions{Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(streamHandler(received))
+		streams <- ch
+	}})
+	client := MultiplexHandler(MultiplexOptions{Client: true, Initializer: func(ch Channel) {
+		ch.Pipeline().AddLast(discardHandler{})
+	}})
+
+	serverCh, clientCh := muxChannels(server, client)
+	defer serverCh.Close(nil)
+	defer clientCh.Close(nil)
+
+	stream, err := client.OpenStream()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	expect := func(want string) {
+		select {
+		case data := <-received:
+			if want != string(data) {
+				t.Fatalf("received: %q, want: %q", data, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message is not received:", want)
+		}
+	}
+
+	if err := stream.Write([]byte("before")); nil != err {
+		t.Fatal(err)
+	}
+	expect("before")
+
+	serverStream := <-streams
+	serverStream.Pause()
+
+	if err := stream.Write([]byte("paused")); nil != err {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-received:
+		t.Fatalf("stream read while paused: %q", data)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	serverStream.Resume()
+	expect("paused")
+}
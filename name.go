@@ -0,0 +1,49 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+// NameAttribute holds the logical name of the channel, it is set by WithName before the channel is initialized,
+// and could be overridden by the ChannelInitializer.
+const NameAttribute AttributeKey = "netty.name"
+
+// NameOf returns the name of the channel, empty if it is not named.
+func NameOf(ch Channel) string {
+	name, _ := ch.Attribute(NameAttribute).(string)
+	return name
+}
+
+// LogFields returns the fields describing the channel for the structured loggers and the labels of metrics,
+// the name is included if the channel is named.
+func LogFields(ch Channel) map[string]interface{} {
+	fields := map[string]interface{}{
+		"id":     ch.ID(),
+		"local":  ch.LocalAddr(),
+		"remote": ch.RemoteAddr(),
+	}
+	if name := NameOf(ch); "" != name {
+		fields["name"] = name
+	}
+	return fields
+}
+
+// describeChannel to log the channel, e.g. "public-ws 127.0.0.1:9527".
+func describeChannel(ch Channel) string {
+	if name := NameOf(ch); "" != name {
+		return name + " " + ch.RemoteAddr()
+	}
+	return ch.RemoteAddr()
+}
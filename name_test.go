@@ -0,0 +1,99 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport/tcp"
+)
+
+func TestBootstrapName(t *testing.T) {
+
+	names := make(chan string, 1)
+	bs := NewBootstrap(WithName("internal-rpc"), WithChildInitializer(func(ch Channel) {
+		names <- NameOf(ch)
+		ch.Pipeline().AddLast(discardHandler{})
+	}), WithClientInitializer(func(ch Channel) {
+		// override the name of bootstrap.
+		ch.SetAttribute(NameAttribute, "rpc-client")
+		ch.Pipeline().AddLast(discardHandler{})
+	}))
+	defer bs.Shutdown()
+
+	bs.Listen("127.0.0.1:9564", tcp.WithOptions(&tcp.Options{Timeout: time.Second})).Async(func(err error) {})
+
+	ch, err := connectRetry(bs, "tcp://127.0.0.1:9564", tcp.WithOptions(&tcp.Options{Timeout: time.Second}))
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer ch.Close(nil)
+
+	select {
+	case name := <-names:
+		if "internal-rpc" != name {
+			t.Fatal("unexpected name of child channel:", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("child channel not initialized")
+	}
+
+	if "rpc-client" != ch.Attribute(NameAttribute) {
+		t.Fatal("unexpected name of client channel:", ch.Attribute(NameAttribute))
+	}
+
+	fields := LogFields(ch)
+	if "rpc-client" != fields["name"] || ch.ID() != fields["id"] || ch.RemoteAddr() != fields["remote"] {
+		t.Fatal("unexpected fields:", fields)
+	}
+}
+
+func TestNameInLogs(t *testing.T) {
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+	defer peer.Close()
+	defer ch.Close(nil)
+
+	if _, ok := LogFields(ch)["name"]; ok || "" != NameOf(ch) {
+		t.Fatal("unnamed channel has a name")
+	}
+
+	ch.SetAttribute(NameAttribute, "public-ws")
+	if "public-ws" != LogFields(ch)["name"] {
+		t.Fatal("name not in fields:", LogFields(ch))
+	}
+
+	r, w, err := os.Pipe()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	WarnUnhandled()(ch, []byte("abc"))
+	os.Stderr = stderr
+	_ = w.Close()
+
+	line, _ := bufio.NewReader(r).ReadString('\n')
+	if !strings.Contains(line, "public-ws 127.0.0.1:9527") {
+		t.Fatal("name not in log:", line)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
 )
 
 type (
@@ -43,6 +44,9 @@ type (
 		// CloseAll close the all channels
 		CloseAll(err error)
 	}
+	// AcceptFilter to filter the accepted transport before serving it,
+	// the transport will be closed if a non-nil error is returned.
+	AcceptFilter func(t transport.Transport) error
 
 	// bootstrapOptions
 	bootstrapOptions struct {
@@ -56,6 +60,10 @@ type (
 		channelIDFactory  ChannelIDFactory
 		executor          Executor
 		holder            ChannelHolder
+		acceptFilters     []AcceptFilter
+		protocolInits     map[string]ChannelInitializer
+		acceptBackoff     [2]time.Duration // initial & max delay
+		name              string
 	}
 )
 
@@ -132,3 +140,38 @@ func WithChannelHolder(holder ChannelHolder) Option {
 		options.holder = holder
 	}
 }
+
+// WithAcceptFilter to filter the accepted transports before serving them
+func WithAcceptFilter(filters ...AcceptFilter) Option {
+	return func(options *bootstrapOptions) {
+		options.acceptFilters = append(options.acceptFilters, filters...)
+	}
+}
+
+// WithProtocolInitializer to set the server side ChannelInitializer used instead of the child one
+// when the protocol is negotiated by tls ALPN, e.g. "h2"
+func WithProtocolInitializer(protocol string, initializer ChannelInitializer) Option {
+	return func(options *bootstrapOptions) {
+		if nil == options.protocolInits {
+			options.protocolInits = make(map[string]ChannelInitializer)
+		}
+		options.protocolInits[protocol] = initializer
+	}
+}
+
+// WithAcceptBackoff to pause the accept loop on temporary errors, the delay starts from initial
+// and doubles on each consecutive error up to max, default: 5ms ~ 1s.
+func WithAcceptBackoff(initial, max time.Duration) Option {
+	return func(options *bootstrapOptions) {
+		utils.AssertIf(initial <= 0 || max < initial, "invalid accept backoff: %s ~ %s", initial, max)
+		options.acceptBackoff = [2]time.Duration{initial, max}
+	}
+}
+
+// WithName to tag the channels of bootstrap with a logical name, e.g. "public-ws", the name is held by
+// NameAttribute of the channels, and included in the LogFields and the logs of go-netty.
+func WithName(name string) Option {
+	return func(options *bootstrapOptions) {
+		options.name = name
+	}
+}
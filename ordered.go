@@ -0,0 +1,111 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrTooManyPendingResponses is raised when the out-of-order responses exceed the limit of OrderedResponseHandler.
+var ErrTooManyPendingResponses = errors.New("netty: too many pending responses")
+
+// PipelinedRequest is the inbound request tagged with the sequence by OrderedResponseHandler
+type PipelinedRequest struct {
+	Sequence uint64
+	Message  Message
+}
+
+// Respond wrap the response of this request to write.
+func (r PipelinedRequest) Respond(response Message) PipelinedResponse {
+	return PipelinedResponse{Sequence: r.Sequence, Message: response}
+}
+
+// PipelinedResponse is the outbound response of the PipelinedRequest with the same sequence
+type PipelinedResponse struct {
+	Sequence uint64
+	Message  Message
+}
+
+// OrderedResponseHandler tags the inbound requests with sequence as PipelinedRequest, and writes the
+// PipelinedResponse strictly in the order of requests, the early completed responses are buffered,
+// the channel is closed with ErrTooManyPendingResponses if more than maxPending responses are buffered.
+// The other outbound messages are written directly.
+func OrderedResponseHandler(maxPending int) CodecHandler {
+	utils.AssertIf(maxPending <= 0, "maxPending must be a positive integer")
+	return &orderedResponseHandler{maxPending: maxPending, pending: make(map[uint64]Message)}
+}
+
+type orderedResponseHandler struct {
+	maxPending int
+	sequence   uint64 // sequence of next request
+	mutex      sync.Mutex
+	next       uint64 // sequence of next response to write
+	pending    map[uint64]Message
+}
+
+func (*orderedResponseHandler) CodecName() string {
+	return "ordered-response-handler"
+}
+
+func (o *orderedResponseHandler) HandleRead(ctx InboundContext, message Message) {
+	// the read loop is the only writer of sequence.
+	request := PipelinedRequest{Sequence: o.sequence, Message: message}
+	o.sequence++
+	ctx.HandleRead(request)
+}
+
+func (o *orderedResponseHandler) HandleWrite(ctx OutboundContext, message Message) {
+	response, ok := message.(PipelinedResponse)
+	if !ok {
+		ctx.HandleWrite(message)
+		return
+	}
+
+	// the responses are written with lock held to keep the order.
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	switch {
+	case response.Sequence < o.next:
+		utils.Assert(fmt.Errorf("response of sequence %d is already written", response.Sequence))
+	case response.Sequence > o.next:
+		if _, ok := o.pending[response.Sequence]; ok {
+			utils.Assert(fmt.Errorf("duplicate response of sequence %d", response.Sequence))
+		}
+		if len(o.pending) >= o.maxPending {
+			ctx.Close(fmt.Errorf("%w: %d", ErrTooManyPendingResponses, len(o.pending)+1))
+			return
+		}
+		o.pending[response.Sequence] = response.Message
+		return
+	}
+
+	// write the response and the buffered successors.
+	ctx.HandleWrite(response.Message)
+	for o.next++; len(o.pending) > 0; o.next++ {
+		next, ok := o.pending[o.next]
+		if !ok {
+			break
+		}
+		delete(o.pending, o.next)
+		ctx.HandleWrite(next)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOrderedResponseHandler(t *testing.T) {
+
+	const requests = 10
+	ch, peer := pipeChannel(NewAsyncWriteChannel(64, true), "127.0.0.1:9527",
+		delimiterCodec{maxFrameLength: 1024, delimiter: []byte("\n"), stripDelimiter: true},
+		&textCodec{},
+		OrderedResponseHandler(requests),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			request := message.(PipelinedRequest)
+			// the later requests complete earlier.
+			go func() {
+				time.Sleep(time.Duration(requests-request.Sequence) * time.Millisecond * 5)
+				_ = ctx.Channel().Write(request.Respond(fmt.Sprintf("response-%s", request.Message)))
+			}()
+		}),
+	)
+	defer ch.Close(nil)
+
+	go func() {
+		for i := 0; i < requests; i++ {
+			_, _ = fmt.Fprintf(peer, "%d\n", i)
+		}
+	}()
+
+	scanner := bufio.NewScanner(peer)
+	for i := 0; i < requests; i++ {
+		if !scanner.Scan() {
+			t.Fatal("read response failed:", scanner.Err())
+		}
+		if want := fmt.Sprintf("response-%d", i); want != scanner.Text() {
+			t.Fatalf("unexpected response: %s, want: %s", scanner.Text(), want)
+		}
+	}
+}
+
+func TestOrderedResponseHandlerOverflow(t *testing.T) {
+
+	inactive := make(chan Exception, 1)
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		discardHandler{},
+		OrderedResponseHandler(2),
+		InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+			inactive <- ex
+		}),
+	)
+	defer peer.Close()
+
+	// the response of sequence 0 is never completed.
+	for sequence := uint64(1); sequence <= 3; sequence++ {
+		_ = ch.Write(PipelinedResponse{Sequence: sequence, Message: []byte("response")})
+	}
+
+	select {
+	case ex := <-inactive:
+		if !errors.Is(ex, ErrTooManyPendingResponses) {
+			t.Fatal("unexpected close cause:", ex)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel is not closed")
+	}
+}
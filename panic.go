@@ -0,0 +1,102 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// PanicError is the exception of a panic recovered from a handler of the pipeline which PanicContextHandler
+// is added to, it carries the context of the panic, the error of panic could be checked by errors.Is & errors.As.
+type PanicError struct {
+	// ChannelID & RemoteAddr of the channel.
+	ChannelID  int64
+	RemoteAddr string
+	// Handler is the name of the panicked handler, see HandlerProfile.Name.
+	Handler string
+	// Method of the handler, e.g. HandleRead or HandleWrite.
+	Method string
+	// Message or event being processed, it is redacted by the hook of PanicContextHandler.
+	Message Message
+	// Value of the panic.
+	Value interface{}
+	// Stack of the panicked goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("netty: panic in %s.%s of channel %d from %s: %v, message: %s",
+		e.Handler, e.Method, e.ChannelID, e.RemoteAddr, e.Value, describeMessage(e.Message))
+}
+
+// Unwrap returns the error of panic, nil if the panic is not an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// describeMessage to log the message without consuming a stream.
+func describeMessage(message interface{}) string {
+	switch message.(type) {
+	case nil:
+		return "<nil>"
+	case interface{ Bytes() []byte }:
+	case io.Reader:
+		return fmt.Sprintf("%T", message)
+	}
+	return snippetOf(message)
+}
+
+// PanicContextHandler enriches the panics of all handlers in the pipeline it is added to with the
+// context as PanicError, which is routed to HandleException as the generic recovery does, add it
+// once at the first, redact could replace the sensitive message before it is carried by the error,
+// the message is carried as is if redact is nil. it could be shared by the channels.
+func PanicContextHandler(redact func(message Message) Message) ActiveHandler {
+	return &panicContextHandler{redact: redact}
+}
+
+type panicContextHandler struct {
+	redact func(message Message) Message
+}
+
+func (h *panicContextHandler) HandleActive(ctx ActiveContext) {
+	ctx.HandleActive()
+}
+
+// recover the panic of the handler of hc, and panic again with the context, the panic is wrapped once
+// by the innermost handler, so the outer handlers pass it on as is.
+func (h *panicContextHandler) recover(hc *handlerContext, method string, message interface{}) {
+	v := recover()
+	if nil == v {
+		return
+	}
+
+	if _, ok := v.(*PanicError); !ok {
+		if nil != message && nil != h.redact {
+			message = h.redact(message)
+		}
+
+		pe := &PanicError{Handler: handlerName(hc.handler), Method: method, Message: message, Value: v, Stack: debug.Stack()}
+		if ch := hc.Channel(); nil != ch {
+			pe.ChannelID, pe.RemoteAddr = ch.ID(), ch.RemoteAddr()
+		}
+		v = pe
+	}
+	panic(v)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// panicHandler panics while processing the inbound messages.
+type panicHandler struct{}
+
+func (panicHandler) HandleRead(ctx InboundContext, message Message) {
+	panic(errors.New("bad message"))
+}
+
+func TestPanicContextHandler(t *testing.T) {
+
+	exceptions := make(chan Exception, 1)
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		PanicContextHandler(func(message Message) Message {
+			if m, ok := message.([]byte); ok && bytes.HasPrefix(m, []byte("password")) {
+				return "<redacted>"
+			}
+			return message
+		}),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			buffer := make([]byte, 64)
+			ctx.HandleRead(buffer[:utils.AssertLength(utils.MustToReader(message).Read(buffer))])
+		}),
+		panicHandler{},
+		ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			exceptions <- ex
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	for _, c := range []struct {
+		data    string
+		message Message
+	}{
+		{data: "hello", message: []byte("hello")},
+		{data: "password=123", message: "<redacted>"},
+	} {
+		if _, err := peer.Write([]byte(c.data)); nil != err {
+			t.Fatal(err)
+		}
+
+		var ex Exception
+		select {
+		case ex = <-exceptions:
+		case <-time.After(time.Second):
+			t.Fatal("exception is not caught")
+		}
+
+		var pe *PanicError
+		if !errors.As(ex, &pe) {
+			t.Fatalf("unexpected exception: %T %v", ex, ex)
+		}
+
+		if pe.ChannelID != ch.ID() || "127.0.0.1:9527" != pe.RemoteAddr || "netty.panicHandler" != pe.Handler || "HandleRead" != pe.Method {
+			t.Fatalf("unexpected context: %+v", pe)
+		}
+
+		if fmt.Sprint(c.message) != fmt.Sprint(pe.Message) || "bad message" != pe.Unwrap().Error() || 0 == len(pe.Stack) {
+			t.Fatalf("unexpected panic: %+v", pe)
+		}
+
+		if !strings.Contains(ex.Error(), "netty.panicHandler.HandleRead") {
+			t.Fatal("unexpected message:", ex.Error())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// pauseGate suspends the reading of channel, see Channel.Pause.
+type pauseGate struct {
+	mutex   sync.Mutex
+	paused  int32
+	resumed chan struct{} // closed by resume
+	parked  func()        // the reading of event loop parked until resumed
+}
+
+func (g *pauseGate) isPaused() bool {
+	return 1 == atomic.LoadInt32(&g.paused)
+}
+
+// wait until resumed or done, returns false if done.
+func (g *pauseGate) wait(done <-chan struct{}) bool {
+	for g.isPaused() {
+		g.mutex.Lock()
+		resumed := g.resumed
+		g.mutex.Unlock()
+		if nil == resumed {
+			continue
+		}
+
+		select {
+		case <-resumed:
+		case <-done:
+			return false
+		}
+	}
+	return true
+}
+
+// park the reading of event loop if paused, it is continued by resume.
+func (g *pauseGate) park(read func()) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.isPaused() {
+		g.parked = read
+		return true
+	}
+	return false
+}
+
+// pausedReader reads the transport unless the channel is paused.
+type pausedReader struct {
+	reader io.Reader
+	gate   *pauseGate
+	done   <-chan struct{}
+}
+
+func (r *pausedReader) Read(p []byte) (int, error) {
+	if !r.gate.wait(r.done) {
+		return 0, net.ErrClosed
+	}
+
+	// the read in progress is not interrupted by pause, the deadlines of transport are left as is,
+	// so the bytes read across the pause are held until resumed.
+	n, err := r.reader.Read(p)
+	if !r.gate.wait(r.done) {
+		return 0, net.ErrClosed
+	}
+	return n, err
+}
+
+// Pause the reading of inbound stream and the async writing.
+func (c *channel) Pause() {
+	c.gate.mutex.Lock()
+	defer c.gate.mutex.Unlock()
+	if !c.IsActive() || c.gate.isPaused() {
+		return
+	}
+
+	c.gate.resumed = make(chan struct{})
+	atomic.StoreInt32(&c.gate.paused, 1)
+}
+
+// Resume the reading and the async writing paused by Pause.
+func (c *channel) Resume() {
+	c.gate.mutex.Lock()
+	if !c.gate.isPaused() {
+		c.gate.mutex.Unlock()
+		return
+	}
+
+	atomic.StoreInt32(&c.gate.paused, 0)
+	close(c.gate.resumed)
+	c.gate.resumed = nil
+	parked := c.gate.parked
+	c.gate.parked = nil
+	c.gate.mutex.Unlock()
+
+	if nil != parked {
+		c.executor.Exec(parked)
+	}
+
+	// flush the writes queued while paused.
+	if nil != c.writeQueue && c.queued() {
+		c.startWriter()
+	}
+}
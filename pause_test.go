@@ -0,0 +1,126 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestChannelPause(t *testing.T) {
+
+	received := make(chan string, 4)
+	ch, peer := pipeChannel(NewChannelWith(ChannelOptions{WriteQueueSize: 16}), "127.0.0.1:9527",
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			buffer := make([]byte, 64)
+			received <- string(buffer[:utils.AssertLength(utils.MustToReader(message).Read(buffer))])
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+	written := readPeer(peer)
+
+	expect := func(messages <-chan string, want string) {
+		select {
+		case m := <-messages:
+			if want != m {
+				t.Fatal("unexpected message:", m, "want:", want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message is not received:", want)
+		}
+	}
+
+	if _, err := peer.Write([]byte("before")); nil != err {
+		t.Fatal(err)
+	}
+	expect(received, "before")
+
+	ch.Pause()
+
+	// the peer is blocked since the pipe is not read.
+	go func() { _, _ = peer.Write([]byte("paused")) }()
+	if err := ch.Write([]byte("queued")); nil != err {
+		t.Fatal(err)
+	}
+
+	select {
+	case m := <-received:
+		t.Fatal("inbound message is delivered while paused:", m)
+	case m := <-written:
+		t.Fatal("outbound message is written while paused:", m)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	if !ch.IsActive() {
+		t.Fatal("paused channel is closed")
+	}
+
+	ch.Resume()
+	expect(received, "paused")
+	expect(written, "queued")
+
+	// resumed cleanly.
+	if _, err := peer.Write([]byte("after")); nil != err {
+		t.Fatal(err)
+	}
+	expect(received, "after")
+}
+
+func TestEventLoopChannelPause(t *testing.T) {
+
+	group := NewEventLoopGroup(1)
+	defer group.Close()
+
+	var active int32
+	channels := make(chan Channel, 1)
+	bs, _ := echoServer(group, "127.0.0.1:9560", WithChildInitializer(func(ch Channel) {
+		atomic.AddInt32(&active, 1)
+		channels <- ch
+		ch.Pipeline().AddLast(loopEchoHandler{})
+	}))
+	defer bs.Shutdown()
+
+	conn := dialEcho(t, "127.0.0.1:9560", 1, &active)[0]
+	defer conn.Close()
+	ch := <-channels
+
+	if err := echo(conn, "ping"); nil != err {
+		t.Fatal(err)
+	}
+
+	ch.Pause()
+	if _, err := conn.Write([]byte("held")); nil != err {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Millisecond * 100))
+	if n, err := conn.Read(make([]byte, 4)); nil == err {
+		t.Fatal("echoed while paused:", n)
+	}
+
+	ch.Resume()
+	buffer := make([]byte, 4)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(conn, buffer); nil != err || "held" != string(buffer) {
+		t.Fatal("unexpected echo after resumed:", string(buffer), err)
+	}
+}
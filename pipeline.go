@@ -18,6 +18,7 @@ package netty
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/mijingduI/go-netty/utils"
 )
@@ -34,6 +35,9 @@ type Pipeline interface {
 	// AddHandler add handlers in position.
 	AddHandler(position int, handlers ...Handler) Pipeline
 
+	// RemoveHandler remove the handler in position.
+	RemoveHandler(position int) Pipeline
+
 	// IndexOf find fist index of handler.
 	IndexOf(func(Handler) bool) int
 
@@ -58,14 +62,28 @@ type Pipeline interface {
 	FireChannelException(ex Exception)
 	FireChannelInactive(ex Exception)
 	FireChannelEvent(event Event)
+
+	// FireChannelCleanup call the CleanupHandlers from tail to head, it takes effect only once, the inactive
+	// event is fired from head to tail before it, a panic of handler does not stop the cleanup of the others.
+	FireChannelCleanup(ex Exception)
 }
 
 // NewPipeline create a pipeline.
 func NewPipeline() Pipeline {
+	return newPipelineWith(defaultUnhandled)
+}
+
+// newPipelineWith create a pipeline with the UnhandledHandler at tail.
+func newPipelineWith(unhandled UnhandledHandler) Pipeline {
+	return newProfiledPipeline(unhandled, nil)
+}
+
+// newProfiledPipeline create a pipeline profiled by the profiler if not nil.
+func newProfiledPipeline(unhandled UnhandledHandler, profiler *handlerProfiler) Pipeline {
 
-	p := &pipeline{}
+	p := &pipeline{profiler: profiler, writing: make(chan struct{}, 1)}
 	p.head = newHandlerContext(p, headHandler{}, nil, nil)
-	p.tail = newHandlerContext(p, tailHandler{}, nil, nil)
+	p.tail = newHandlerContext(p, tailHandler{unhandled: unhandled}, nil, nil)
 
 	p.head.next = p.tail
 	p.tail.prev = p.head
@@ -77,10 +95,15 @@ func NewPipeline() Pipeline {
 
 // pipeline to implement Pipeline
 type pipeline struct {
-	head    *handlerContext
-	tail    *handlerContext
-	channel Channel
-	size    int
+	head     *handlerContext
+	tail     *handlerContext
+	channel  Channel
+	size     int
+	cleanup  sync.Once
+	profiler *handlerProfiler
+	recovery *panicContextHandler
+	writing  chan struct{} // the token of outbound traversal
+	priority bool          // the outbound traversal is a priority write, protected by the token
 }
 
 // AddFirst to add handlers at head
@@ -135,6 +158,24 @@ func (p *pipeline) AddHandler(position int, handlers ...Handler) Pipeline {
 	return p
 }
 
+// RemoveHandler to remove the handler in position, the head & tail could not be removed.
+func (p *pipeline) RemoveHandler(position int) Pipeline {
+
+	// checking position.
+	utils.AssertIf(position <= 0 || position >= p.size-1, "invalid position: %d", position)
+
+	curNode := p.head
+	for i := 0; i < position; i++ {
+		curNode = curNode.next
+	}
+
+	// the removed context keeps its links, so that an in-flight event could be passed on.
+	curNode.prev.next = curNode.next
+	curNode.next.prev = curNode.prev
+	p.size--
+	return p
+}
+
 // IndexOf to find fist index of handler.
 func (p *pipeline) IndexOf(comp func(Handler) bool) int {
 
@@ -228,9 +269,73 @@ func (p *pipeline) FireChannelRead(message Message) {
 }
 
 func (p *pipeline) FireChannelWrite(message Message) {
+	p.lockWrite()
+	defer p.unlockWrite()
 	p.tail.HandleWrite(message)
 }
 
+// firePriorityWrite write the message through the pipeline, the head handler queues the bytes ahead of the normal writes.
+func (p *pipeline) firePriorityWrite(message Message) {
+	p.lockWrite()
+	defer p.unlockWrite()
+	p.priority = true
+	defer func() { p.priority = false }()
+	p.tail.HandleWrite(message)
+}
+
+// lockWrite to serialize the outbound traversals, so the bytes of a message are never interleaved with
+// the others, it panics with the close cause if the channel is closed while waiting instead of deadlock,
+// e.g. the handler writes in HandleInactive when the channel is closed by an outbound handler.
+func (p *pipeline) lockWrite() {
+	select {
+	case p.writing <- struct{}{}:
+		return
+	default:
+	}
+
+	var done <-chan struct{}
+	if nil != p.channel {
+		done = p.channel.Context().Done()
+	}
+
+	select {
+	case p.writing <- struct{}{}:
+	case <-done:
+		if cause, ok := p.channel.Attribute(CloseCauseAttribute).(error); ok {
+			panic(cause)
+		}
+		panic(ErrChannelClosed)
+	}
+}
+
+func (p *pipeline) unlockWrite() {
+	<-p.writing
+}
+
+// serializeWrites run the writes out of the outbound traversal with the write token of pipeline held, so they are
+// serialized with the writes of channel, the failure of writes or the close of channel while waiting is returned.
+func serializeWrites(ctx HandlerContext, writes func() error) (err error) {
+	defer func() {
+		if e := recover(); nil != e {
+			err = AsException(e)
+		}
+	}()
+
+	if p, ok := ctx.Channel().Pipeline().(*pipeline); ok {
+		p.lockWrite()
+		defer p.unlockWrite()
+	}
+	return writes()
+}
+
+// writeSerialized write the message by the context out of the outbound traversal, e.g. the delayed writes.
+func writeSerialized(ctx OutboundContext, message Message) error {
+	return serializeWrites(ctx, func() error {
+		ctx.HandleWrite(message)
+		return nil
+	})
+}
+
 func (p *pipeline) FireChannelException(ex Exception) {
 	p.head.HandleException(ex)
 }
@@ -243,6 +348,24 @@ func (p *pipeline) FireChannelEvent(event Event) {
 	p.head.HandleEvent(event)
 }
 
+func (p *pipeline) FireChannelCleanup(ex Exception) {
+	p.cleanup.Do(func() {
+		for ctx := p.tail.prev; nil != ctx && ctx != p.head; ctx = ctx.prev {
+			if handler := ctx.cast2Cleanup; nil != handler {
+				cleanupHandler(handler, p.channel, ex)
+			}
+		}
+	})
+}
+
+// cleanupHandler to call the handler with the panic recovered.
+func cleanupHandler(handler CleanupHandler, ch Channel, ex Exception) {
+	defer func() {
+		_ = recover()
+	}()
+	handler.HandleCleanup(ch, ex)
+}
+
 // checkHandler to checking handlers
 func checkHandler(handlers ...Handler) {
 
@@ -254,6 +377,7 @@ func checkHandler(handlers ...Handler) {
 		case ExceptionHandler:
 		case InactiveHandler:
 		case EventHandler:
+		case CleanupHandler:
 		default:
 			utils.Assert(fmt.Errorf("unrecognized Handler: %d:%T", index, h))
 		}
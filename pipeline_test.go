@@ -16,7 +16,13 @@
 
 package netty
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
 
 type oneHandler struct{}
 
@@ -104,6 +110,92 @@ func TestPipeline(t *testing.T) {
 		}
 	}
 
+	pl.RemoveHandler(3)
+	if _, ok := pl.ContextAt(3).Handler().(fourHandler); !ok || 6 != pl.Size() {
+		t.Fatal("threeHandler is not removed")
+	}
+
+	if 0 != pl.IndexOf(func(handler Handler) bool {
+		_, ok := handler.(headHandler)
+		return ok
+	}) {
+		t.Fatal("headHandler should not be moved")
+	}
+}
+
+// cleanupRecorder records the inactive & cleanup events of the handler.
+type cleanupRecorder struct {
+	name    string
+	events  chan<- string
+	forward bool
+}
+
+func (h cleanupRecorder) HandleInactive(ctx InactiveContext, ex Exception) {
+	h.events <- "inactive:" + h.name
+	if h.forward {
+		ctx.HandleInactive(ex)
+	}
+}
+
+func (h cleanupRecorder) HandleCleanup(ch Channel, ex Exception) {
+	h.events <- "cleanup:" + h.name
+	if "c" == h.name {
+		panic("cleanup failed")
+	}
+}
+
+func TestPipelineCleanup(t *testing.T) {
+
+	for round := 0; round < 10; round++ {
+		events := make(chan string, 16)
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+			discardHandler{},
+			cleanupRecorder{name: "a", events: events, forward: true},
+			// the inactive event is not passed to c.
+			cleanupRecorder{name: "b", events: events},
+			cleanupRecorder{name: "c", events: events},
+			ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {}),
+		)
+
+		// close races with the exception and the peer closing.
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				ch.Close(errors.New("closed"))
+			}()
+			go func() {
+				defer wg.Done()
+				ch.Pipeline().FireChannelException(errors.New("exception"))
+			}()
+			go func() {
+				defer wg.Done()
+				_ = peer.Close()
+			}()
+		}
+		wg.Wait()
+
+		var recorded []string
+		for len(recorded) < 5 {
+			select {
+			case event := <-events:
+				recorded = append(recorded, event)
+			case <-time.After(time.Second):
+				t.Fatal("missing events:", recorded)
+			}
+		}
+
+		select {
+		case event := <-events:
+			t.Fatal("unexpected event:", event)
+		case <-time.After(time.Millisecond * 20):
+		}
+
+		if want := "[inactive:a inactive:b cleanup:c cleanup:b cleanup:a]"; want != fmt.Sprint(recorded) {
+			t.Fatal("recorded:", recorded, "want:", want)
+		}
+	}
 }
 
 func BenchmarkPipeline(b *testing.B) {
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrPoolClosed is returned when acquiring from a closed ChannelPool.
+var ErrPoolClosed = errors.New("netty: channel pool closed")
+
+// ChannelPoolOptions defines the options of ChannelPool
+type ChannelPoolOptions struct {
+	// MaxIdle is the max number of idle channels, the released channels beyond it are closed.
+	MaxIdle int
+	// MaxIdleTime closes the channels idle beyond it by a background evictor if > 0.
+	MaxIdleTime time.Duration
+	// EvictInterval is the interval of evictor, defaults to MaxIdleTime / 2.
+	EvictInterval time.Duration
+	// HealthCheck validates the idle channel before handing out, the broken one is closed and replaced.
+	HealthCheck func(ch Channel) bool
+}
+
+// ChannelPool defines a pool of client channels
+type ChannelPool interface {
+	// Acquire an idle channel, or dial a new one if there is no valid idle channel.
+	Acquire() (Channel, error)
+
+	// Release the channel to the pool.
+	Release(ch Channel)
+
+	// Idle returns the number of idle channels.
+	Idle() int
+
+	// Close the pool and the idle channels, the evictor is stopped before returned.
+	Close()
+}
+
+// NewChannelPool create a ChannelPool dials by the function, e.g. Bootstrap.Connect
+func NewChannelPool(dial func() (Channel, error), options ChannelPoolOptions) ChannelPool {
+	utils.AssertIf(options.MaxIdle <= 0, "MaxIdle must be a positive integer")
+
+	p := &channelPool{dial: dial, options: options, now: time.Now, stop: make(chan struct{})}
+	if options.MaxIdleTime > 0 {
+		interval := options.EvictInterval
+		if interval <= 0 {
+			interval = options.MaxIdleTime / 2
+		}
+		p.wg.Add(1)
+		go p.evictLoop(interval)
+	}
+	return p
+}
+
+type idleChannel struct {
+	channel   Channel
+	idleSince time.Time
+}
+
+type channelPool struct {
+	mutex   sync.Mutex
+	dial    func() (Channel, error)
+	options ChannelPoolOptions
+	now     func() time.Time
+	idle    []idleChannel
+	closed  bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func (p *channelPool) Acquire() (Channel, error) {
+	for {
+		p.mutex.Lock()
+		if p.closed {
+			p.mutex.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		// the most recently used first.
+		n := len(p.idle)
+		if 0 == n {
+			p.mutex.Unlock()
+			return p.dial()
+		}
+		ch := p.idle[n-1].channel
+		p.idle = p.idle[:n-1]
+		p.mutex.Unlock()
+
+		if ch.IsActive() && (nil == p.options.HealthCheck || p.options.HealthCheck(ch)) {
+			return ch, nil
+		}
+		ch.Close(nil)
+	}
+}
+
+func (p *channelPool) Release(ch Channel) {
+	p.mutex.Lock()
+	if p.closed || !ch.IsActive() || len(p.idle) >= p.options.MaxIdle {
+		p.mutex.Unlock()
+		ch.Close(nil)
+		return
+	}
+	p.idle = append(p.idle, idleChannel{channel: ch, idleSince: p.now()})
+	p.mutex.Unlock()
+}
+
+func (p *channelPool) Idle() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.idle)
+}
+
+func (p *channelPool) Close() {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mutex.Unlock()
+
+	close(p.stop)
+	p.wg.Wait()
+
+	for _, c := range idle {
+		c.channel.Close(nil)
+	}
+}
+
+func (p *channelPool) evictLoop(interval time.Duration) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.evict()
+		}
+	}
+}
+
+// evict to close the channels idle beyond MaxIdleTime.
+func (p *channelPool) evict() {
+	p.mutex.Lock()
+	var expired []Channel
+	deadline := p.now().Add(-p.options.MaxIdleTime)
+
+	// the idle channels are ordered by idleSince.
+	for len(p.idle) > 0 && !p.idle[0].idleSince.After(deadline) {
+		expired = append(expired, p.idle[0].channel)
+		p.idle = p.idle[1:]
+	}
+	p.mutex.Unlock()
+
+	for _, ch := range expired {
+		ch.Close(nil)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pipeDialer dials channels over net.Pipe.
+type pipeDialer struct {
+	mutex sync.Mutex
+	peers []net.Conn
+	count int
+}
+
+func (d *pipeDialer) dial() (Channel, error) {
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+	d.mutex.Lock()
+	d.peers = append(d.peers, peer)
+	d.count++
+	d.mutex.Unlock()
+	return ch, nil
+}
+
+func (d *pipeDialer) close() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, peer := range d.peers {
+		_ = peer.Close()
+	}
+}
+
+func TestChannelPoolIdleEviction(t *testing.T) {
+
+	dialer := &pipeDialer{}
+	defer dialer.close()
+
+	pool := NewChannelPool(dialer.dial, ChannelPoolOptions{MaxIdle: 4, MaxIdleTime: time.Minute, EvictInterval: time.Hour})
+	defer pool.Close()
+
+	clock := time.Now()
+	pool.(*channelPool).now = func() time.Time { return clock }
+
+	first, _ := pool.Acquire()
+	second, _ := pool.Acquire()
+
+	pool.Release(first)
+	clock = clock.Add(time.Second * 30)
+	pool.Release(second)
+
+	clock = clock.Add(time.Second * 40)
+	pool.(*channelPool).evict()
+
+	if 1 != pool.Idle() || first.IsActive() || !second.IsActive() {
+		t.Fatal("the channel idle beyond MaxIdleTime is not evicted")
+	}
+
+	if ch, _ := pool.Acquire(); ch != second {
+		t.Fatal("unexpected channel acquired")
+	}
+}
+
+func TestChannelPoolHealthCheck(t *testing.T) {
+
+	dialer := &pipeDialer{}
+	defer dialer.close()
+
+	var broken Channel
+	pool := NewChannelPool(dialer.dial, ChannelPoolOptions{
+		MaxIdle:     4,
+		HealthCheck: func(ch Channel) bool { return ch != broken },
+	})
+	defer pool.Close()
+
+	broken, _ = pool.Acquire()
+	pool.Release(broken)
+
+	ch, err := pool.Acquire()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if ch == broken || broken.IsActive() || 2 != dialer.count {
+		t.Fatal("the broken channel is not replaced")
+	}
+
+	// the inactive channel is not pooled.
+	ch.Close(nil)
+	pool.Release(ch)
+	if 0 != pool.Idle() {
+		t.Fatal("inactive channel is pooled")
+	}
+}
+
+func TestChannelPoolClose(t *testing.T) {
+
+	dialer := &pipeDialer{}
+	defer dialer.close()
+
+	pool := NewChannelPool(dialer.dial, ChannelPoolOptions{MaxIdle: 1, MaxIdleTime: time.Millisecond * 10})
+
+	first, _ := pool.Acquire()
+	second, _ := pool.Acquire()
+	pool.Release(first)
+	pool.Release(second)
+
+	// beyond MaxIdle.
+	if second.IsActive() {
+		t.Fatal("the channel beyond MaxIdle is not closed")
+	}
+
+	pool.Close()
+
+	if first.IsActive() {
+		t.Fatal("idle channel is not closed")
+	}
+
+	if _, err := pool.Acquire(); ErrPoolClosed != err {
+		t.Fatal("expect pool closed, got:", err)
+	}
+}
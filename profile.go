@@ -0,0 +1,296 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// profileSamples is the number of recent samples kept for the percentiles of a HandlerTiming.
+const profileSamples = 1024
+
+// HandlerTiming defines the time spent in a handler, the time of the handlers called through its
+// context is excluded, the percentiles are computed from the recent samples.
+type HandlerTiming struct {
+	Count int64         `json:"count"`
+	Total time.Duration `json:"total"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+}
+
+// Mean time of a call.
+func (t HandlerTiming) Mean() time.Duration {
+	if 0 == t.Count {
+		return 0
+	}
+	return t.Total / time.Duration(t.Count)
+}
+
+// HandlerProfile defines the timings of a handler, the handlers of the same name are merged.
+type HandlerProfile struct {
+	// Name is the CodecName of codec, otherwise the type of handler, e.g. *netty.readIdleHandler.
+	Name string `json:"name"`
+	// Inbound is the time spent in the active, read, exception, inactive and event of handler.
+	Inbound HandlerTiming `json:"inbound"`
+	// Outbound is the time spent in the write of handler.
+	Outbound HandlerTiming `json:"outbound"`
+}
+
+// HandlerProfiler records the time spent in each handler of the pipelines created by it, to find
+// the bottleneck of pipeline, it could be enabled or disabled at runtime, only a flag is checked
+// for each call of handler if disabled, e.g. WithPipeline(profiler.NewPipeline).
+type HandlerProfiler interface {
+	// NewPipeline create a pipeline profiled by the profiler, it is a PipelineFactory.
+	NewPipeline() Pipeline
+
+	// SetEnabled to start or stop the profiling.
+	SetEnabled(enabled bool)
+
+	// Enabled returns true if the profiling is started.
+	Enabled() bool
+
+	// Profiles returns the profiles of handlers, the slowest handler of total time comes first.
+	Profiles() []HandlerProfile
+
+	// Reset the recorded timings.
+	Reset()
+}
+
+// NewHandlerProfiler create a HandlerProfiler, it is shared by the pipelines.
+func NewHandlerProfiler(enabled bool) HandlerProfiler {
+	p := &handlerProfiler{stats: make(map[string]*handlerStats)}
+	p.SetEnabled(enabled)
+	return p
+}
+
+type handlerProfiler struct {
+	enabled int32
+	mutex   sync.Mutex
+	stats   map[string]*handlerStats
+	names   []string
+}
+
+func (p *handlerProfiler) NewPipeline() Pipeline {
+	return newProfiledPipeline(defaultUnhandled, p)
+}
+
+func (p *handlerProfiler) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&p.enabled, v)
+}
+
+func (p *handlerProfiler) Enabled() bool {
+	return 1 == atomic.LoadInt32(&p.enabled)
+}
+
+func (p *handlerProfiler) Profiles() []HandlerProfile {
+	p.mutex.Lock()
+	profiles := make([]HandlerProfile, 0, len(p.names))
+	for _, name := range p.names {
+		profiles = append(profiles, p.stats[name].profile(name))
+	}
+	p.mutex.Unlock()
+
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].Inbound.Total+profiles[i].Outbound.Total > profiles[j].Inbound.Total+profiles[j].Outbound.Total
+	})
+	return profiles
+}
+
+func (p *handlerProfiler) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, stats := range p.stats {
+		stats.reset()
+	}
+}
+
+// statsOf the handler, it is cached by the context of handler.
+func (p *handlerProfiler) statsOf(handler Handler) *handlerStats {
+	name := handlerName(handler)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	stats, ok := p.stats[name]
+	if !ok {
+		stats = &handlerStats{profiler: p}
+		p.stats[name] = stats
+		p.names = append(p.names, name)
+	}
+	return stats
+}
+
+func handlerName(handler Handler) string {
+	switch h := handler.(type) {
+	case headHandler:
+		return "head"
+	case tailHandler:
+		return "tail"
+	case interface{ CodecName() string }:
+		return h.CodecName()
+	default:
+		return fmt.Sprintf("%T", handler)
+	}
+}
+
+// timingStats records the samples of a direction.
+type timingStats struct {
+	count   int64
+	total   time.Duration
+	samples []time.Duration
+	next    int
+}
+
+func (t *timingStats) record(elapsed time.Duration) {
+	t.count++
+	t.total += elapsed
+	if len(t.samples) < profileSamples {
+		t.samples = append(t.samples, elapsed)
+		return
+	}
+	t.samples[t.next] = elapsed
+	t.next = (t.next + 1) % profileSamples
+}
+
+func (t *timingStats) timing() HandlerTiming {
+	timing := HandlerTiming{Count: t.count, Total: t.total}
+	if 0 == len(t.samples) {
+		return timing
+	}
+
+	sorted := append([]time.Duration(nil), t.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+	timing.P50, timing.P90, timing.P99 = percentile(50), percentile(90), percentile(99)
+	return timing
+}
+
+type handlerStats struct {
+	profiler *handlerProfiler
+	mutex    sync.Mutex
+	inbound  timingStats
+	outbound timingStats
+}
+
+func (s *handlerStats) record(outbound bool, elapsed time.Duration) {
+	s.mutex.Lock()
+	if outbound {
+		s.outbound.record(elapsed)
+	} else {
+		s.inbound.record(elapsed)
+	}
+	s.mutex.Unlock()
+}
+
+func (s *handlerStats) profile(name string) HandlerProfile {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return HandlerProfile{Name: name, Inbound: s.inbound.timing(), Outbound: s.outbound.timing()}
+}
+
+func (s *handlerStats) reset() {
+	s.mutex.Lock()
+	s.inbound, s.outbound = timingStats{}, timingStats{}
+	s.mutex.Unlock()
+}
+
+// dispatchContext is the context passed to handlers, it is the handlerContext or the profiledContext.
+type dispatchContext interface {
+	ActiveContext
+	InboundContext
+	OutboundContext
+	ExceptionContext
+	InactiveContext
+	EventContext
+}
+
+// profiledContext measures a call of handler, the time of the handlers called through it is excluded.
+type profiledContext struct {
+	*handlerContext
+	start  time.Time
+	nested int64 // time.Duration
+}
+
+// enter to start the profiling of a call if enabled, pass the returned context to the handler.
+func (hc *handlerContext) enter() (dispatchContext, *profiledContext) {
+	if nil == hc.stats || !hc.stats.profiler.Enabled() {
+		return hc, nil
+	}
+	pc := &profiledContext{handlerContext: hc, start: time.Now()}
+	return pc, pc
+}
+
+// exit to record the call started by enter.
+func (hc *handlerContext) exit(pc *profiledContext, outbound bool) {
+	if nil != pc {
+		hc.stats.record(outbound, time.Since(pc.start)-time.Duration(atomic.LoadInt64(&pc.nested)))
+	}
+}
+
+func (pc *profiledContext) exclude(start time.Time) {
+	atomic.AddInt64(&pc.nested, int64(time.Since(start)))
+}
+
+func (pc *profiledContext) Write(message Message) {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.Write(message)
+}
+
+func (pc *profiledContext) Trigger(event Event) {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.Trigger(event)
+}
+
+func (pc *profiledContext) HandleActive() {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.HandleActive()
+}
+
+func (pc *profiledContext) HandleRead(message Message) {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.HandleRead(message)
+}
+
+func (pc *profiledContext) HandleWrite(message Message) {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.HandleWrite(message)
+}
+
+func (pc *profiledContext) HandleException(ex Exception) {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.HandleException(ex)
+}
+
+func (pc *profiledContext) HandleInactive(ex Exception) {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.HandleInactive(ex)
+}
+
+func (pc *profiledContext) HandleEvent(event Event) {
+	defer pc.exclude(time.Now())
+	pc.handlerContext.HandleEvent(event)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"testing"
+	"time"
+)
+
+// slowHandler delays the inbound messages.
+type slowHandler time.Duration
+
+func (s slowHandler) HandleRead(ctx InboundContext, message Message) {
+	time.Sleep(time.Duration(s))
+	ctx.HandleRead(message)
+}
+
+func TestHandlerProfiler(t *testing.T) {
+
+	profiler := NewHandlerProfiler(true)
+	received := make(chan Message, 16)
+
+	ch, peer := pipeChannelWith(profiler.NewPipeline(), NewChannel(), "127.0.0.1:9527",
+		byteFrameHandler{},
+		slowHandler(time.Millisecond*5),
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			received <- message
+		}),
+	)
+	defer ch.Close(nil)
+	defer peer.Close()
+	readPeer(peer)
+
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			if _, err := peer.Write([]byte{byte(i)}); nil != err {
+				t.Fatal(err)
+			}
+			select {
+			case <-received:
+			case <-time.After(time.Second):
+				t.Fatal("message is not received")
+			}
+		}
+	}
+
+	send(8)
+	if err := ch.Write([]byte("reply")); nil != err {
+		t.Fatal(err)
+	}
+
+	profile := func(name string) HandlerProfile {
+		for _, p := range profiler.Profiles() {
+			if p.Name == name {
+				return p
+			}
+		}
+		t.Fatal("no profile of", name)
+		return HandlerProfile{}
+	}
+
+	profiles := profiler.Profiles()
+	if "netty.slowHandler" != profiles[0].Name {
+		t.Fatalf("the slow handler does not dominate: %+v", profiles)
+	}
+
+	slow := profile("netty.slowHandler").Inbound
+	if 8 != slow.Count || slow.P50 < time.Millisecond*5 || slow.Mean() < time.Millisecond*5 {
+		t.Fatalf("unexpected timing of slow handler: %+v", slow)
+	}
+
+	// the time of the slow handler is excluded from the handler calls it.
+	if frame := profile("netty.byteFrameHandler").Inbound; 8 != frame.Count || frame.Total >= slow.Total/2 {
+		t.Fatalf("unexpected timing of frame handler: %+v", frame)
+	}
+
+	// the write is passed to the transport by head.
+	if head := profile("head").Outbound; 1 != head.Count {
+		t.Fatalf("unexpected timing of head: %+v", head)
+	}
+
+	// disabled at runtime.
+	profiler.SetEnabled(false)
+	send(2)
+	if n := profile("netty.slowHandler").Inbound.Count; 8 != n {
+		t.Fatal("profiled while disabled:", n)
+	}
+
+	profiler.Reset()
+	profiler.SetEnabled(true)
+	send(1)
+	if n := profile("netty.slowHandler").Inbound.Count; 1 != n {
+		t.Fatal("unexpected count after reset:", n)
+	}
+}
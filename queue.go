@@ -0,0 +1,167 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrInboundQueueFull is the close reason of channel if the inbound queue is full with CloseOnFull.
+var ErrInboundQueueFull = errors.New("netty: inbound queue is full")
+
+// OverflowPolicy defines the behavior of a full inbound queue
+type OverflowPolicy int
+
+const (
+	// BlockOnFull blocks the read loop until the queue has space.
+	BlockOnFull OverflowPolicy = iota
+	// DropOldest drops the oldest message in the queue.
+	DropOldest
+	// CloseOnFull closes the channel with ErrInboundQueueFull.
+	CloseOnFull
+)
+
+// InboundQueueHandler create a handler to decouple the read loop from the next handlers by a bounded queue,
+// the messages are processed in order by a dedicated goroutine, and the inactive event is passed on after
+// the queued messages are processed. the io.Reader messages are read into bytes, so add it after a frame
+// decoder, and create a new one for each channel.
+func InboundQueueHandler(size int, policy OverflowPolicy) ChannelInboundHandler {
+	utils.AssertIf(size <= 0, "size must be a positive integer")
+	return &inboundQueueHandler{
+		policy: policy,
+		queue:  make(chan queuedMessage, size),
+		done:   make(chan struct{}),
+	}
+}
+
+type queuedMessage struct {
+	ctx     InboundContext
+	message Message
+}
+
+type inboundQueueHandler struct {
+	policy   OverflowPolicy
+	queue    chan queuedMessage
+	once     sync.Once
+	done     chan struct{}
+	inactive InactiveContext
+	ex       Exception
+}
+
+func (q *inboundQueueHandler) HandleActive(ctx ActiveContext) {
+	go q.process()
+	ctx.HandleActive()
+}
+
+func (q *inboundQueueHandler) HandleRead(ctx InboundContext, message Message) {
+
+	message = detachMessage(message)
+	queued := queuedMessage{ctx: ctx, message: message}
+
+	switch q.policy {
+	case DropOldest:
+		for {
+			select {
+			case q.queue <- queued:
+				return
+			default:
+				select {
+				case oldest := <-q.queue:
+					releaseMessage(oldest.message)
+				default:
+				}
+			}
+		}
+	case CloseOnFull:
+		select {
+		case q.queue <- queued:
+		default:
+			releaseMessage(message)
+			ctx.Close(ErrInboundQueueFull)
+		}
+	default:
+		select {
+		case q.queue <- queued:
+		case <-ctx.Channel().Context().Done():
+			releaseMessage(message)
+		}
+	}
+}
+
+func (q *inboundQueueHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	q.once.Do(func() {
+		q.inactive, q.ex = ctx, ex
+		close(q.done)
+	})
+}
+
+// process the queued messages, then pass on the inactive event.
+func (q *inboundQueueHandler) process() {
+	for {
+		select {
+		case queued := <-q.queue:
+			q.deliver(queued)
+		case <-q.done:
+			for {
+				select {
+				case queued := <-q.queue:
+					q.deliver(queued)
+				default:
+					q.invoke(q.inactive, func() { q.inactive.HandleInactive(q.ex) })
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *inboundQueueHandler) deliver(queued queuedMessage) {
+	q.invoke(queued.ctx, func() { queued.ctx.HandleRead(queued.message) })
+}
+
+// invoke to capture the exception out of the read loop.
+func (q *inboundQueueHandler) invoke(ctx HandlerContext, fn func()) {
+	defer func() {
+		if err := recover(); nil != err {
+			ctx.Channel().Pipeline().FireChannelException(AsException(err))
+		}
+	}()
+	fn()
+}
+
+// detachMessage to read the io.Reader message into bytes before it is held, the reader may be reused by
+// the decoder, the utils.ReferenceCounted one is owned by the receiver, so it is held as is to be released.
+func detachMessage(message Message) Message {
+	if _, ok := message.(utils.ReferenceCounted); ok {
+		return message
+	}
+	if _, ok := message.(io.Reader); ok {
+		return utils.MustToBytes(message)
+	}
+	return message
+}
+
+// releaseMessage to release the dropped message if it is reference counted.
+func releaseMessage(message Message) {
+	if r, ok := message.(utils.ReferenceCounted); ok {
+		r.Release()
+	}
+}
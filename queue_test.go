@@ -0,0 +1,178 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+// byteFrameHandler decodes each inbound byte as a frame.
+type byteFrameHandler struct{}
+
+func (byteFrameHandler) HandleRead(ctx InboundContext, message Message) {
+	buffer := make([]byte, 1)
+	utils.AssertLength(utils.MustToReader(message).Read(buffer))
+	ctx.HandleRead(buffer)
+}
+
+func TestInboundQueueHandler(t *testing.T) {
+
+	var cases = []struct {
+		policy   OverflowPolicy
+		received string
+		closed   error
+	}{
+		{policy: BlockOnFull, received: "abcdef"},
+		{policy: DropOldest, received: "aef"},
+		{policy: CloseOnFull, received: "abc", closed: ErrInboundQueueFull},
+	}
+
+	for _, c := range cases {
+		started := make(chan struct{})
+		gate := make(chan struct{})
+		received := make(chan byte, 8)
+		inactive := make(chan Exception, 1)
+
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+			byteFrameHandler{},
+			InboundQueueHandler(2, c.policy),
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				if b := message.([]byte)[0]; 'a' == b {
+					close(started)
+					<-gate
+				}
+				received <- message.([]byte)[0]
+			}),
+			InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+				inactive <- ex
+			}),
+		)
+
+		// the processor is blocked by the first message.
+		if _, err := peer.Write([]byte("a")); nil != err {
+			t.Fatal(err)
+		}
+		<-started
+
+		written := make(chan struct{})
+		go func() {
+			_, _ = peer.Write([]byte("bcdef"))
+			close(written)
+		}()
+
+		select {
+		case <-written:
+			if BlockOnFull == c.policy {
+				t.Fatal("the reader is not blocked")
+			}
+		case <-time.After(time.Millisecond * 100):
+			if BlockOnFull != c.policy {
+				t.Fatal("the reader is blocked")
+			}
+		}
+
+		close(gate)
+
+		var bytes []byte
+		for len(bytes) < len(c.received) {
+			select {
+			case b := <-received:
+				bytes = append(bytes, b)
+			case <-time.After(time.Second):
+				t.Fatal("received:", string(bytes), "want:", c.received)
+			}
+		}
+
+		if c.received != string(bytes) {
+			t.Fatal("received:", string(bytes), "want:", c.received)
+		}
+
+		ch.Close(nil)
+		_ = peer.Close()
+
+		// the inactive event is passed on after the queued messages.
+		select {
+		case ex := <-inactive:
+			if nil != c.closed && !errors.Is(ex, c.closed) {
+				t.Fatal("unexpected close reason:", ex)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("inactive event is not passed on")
+		}
+
+		if 0 != len(received) {
+			t.Fatal("unexpected message after inactive:", <-received)
+		}
+	}
+}
+
+func TestInboundQueueHandlerReleasesBuffer(t *testing.T) {
+
+	var cases = map[string]func() Handler{
+		"queue":   func() Handler { return InboundQueueHandler(4, BlockOnFull) },
+		"latency": func() Handler { return LatencyHandler(time.Millisecond, 0, DelayInbound) },
+	}
+
+	for name, handler := range cases {
+		buffers := make(chan *pbytes.Buffer, 1)
+		received := make(chan Message, 1)
+
+		// decode each read as a pooled frame, and pass it to the tail after queued.
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				frame := pbytes.NewBuffer(1)
+				utils.AssertLength(utils.MustToReader(message).Read(frame.Bytes()))
+				// hold a reference to check the release.
+				frame.Retain()
+				buffers <- frame
+				ctx.HandleRead(frame)
+			}),
+			handler(),
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				received <- message
+				ctx.HandleRead(message)
+			}),
+		)
+
+		go func() { _, _ = peer.Write([]byte("x")) }()
+
+		frame := <-buffers
+		select {
+		case m := <-received:
+			if m != Message(frame) {
+				t.Fatalf("%s: the pooled frame is not passed on: %T", name, m)
+			}
+		case <-time.After(time.Second):
+			t.Fatal(name, "frame is not received")
+		}
+
+		// released by the tail.
+		for deadline := time.Now().Add(time.Second); 1 != frame.RefCnt(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal(name, "frame is not released:", frame.RefCnt())
+			}
+		}
+		frame.Release()
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
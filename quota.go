@@ -0,0 +1,197 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// QuotaExceededEvent is triggered by QuotaHandler when a message of the identity exceeds the quota.
+type QuotaExceededEvent struct {
+	// Identity of the channel.
+	Identity interface{}
+	// Size of the message in bytes.
+	Size int
+	// Delayed is true if the message is delayed, otherwise it is dropped.
+	Delayed bool
+}
+
+// QuotaOptions for QuotaHandler
+type QuotaOptions struct {
+	// IdentityKey is the attribute key of the identity set by the authentication, PeerIdentityAttribute if nil,
+	// the channels without identity are not limited.
+	IdentityKey interface{} `json:"-"`
+	// MessagesPerSecond limits the messages of each identity if > 0, MessageBurst defaults to MessagesPerSecond.
+	MessagesPerSecond float64 `json:"messages-per-second"`
+	MessageBurst      int     `json:"message-burst"`
+	// BytesPerMinute limits the bytes of each identity if > 0, the burst is BytesPerMinute.
+	BytesPerMinute int `json:"bytes-per-minute"`
+	// MaxDelay is the maximum time to delay an over-quota message, zero to drop it immediately,
+	// the read loop of channel is stalled while waiting.
+	MaxDelay time.Duration `json:"max-delay"`
+	// MaxTracked is the maximum number of identities to be tracked,
+	// the least recently seen identity will be evicted when it is exceeded.
+	MaxTracked int `json:"max-tracked"`
+}
+
+// QuotaHandler create a handler to limit the inbound messages of each identity, the quotas are shared
+// by the channels of the same identity, so share the handler by the channels. Add it after the frame
+// decoder to count the frames, a QuotaExceededEvent is triggered for the over-quota message.
+func QuotaHandler(options QuotaOptions) InboundHandler {
+	utils.AssertIf(options.MessagesPerSecond <= 0 && options.BytesPerMinute <= 0, "no quota is specified")
+	utils.AssertIf(options.MaxTracked <= 0, "maxTracked must be a positive integer")
+
+	if nil == options.IdentityKey {
+		options.IdentityKey = PeerIdentityAttribute
+	}
+
+	if options.MessageBurst <= 0 {
+		options.MessageBurst = int(options.MessagesPerSecond)
+		if options.MessageBurst <= 0 {
+			options.MessageBurst = 1
+		}
+	}
+
+	return &quotaHandler{options: options, quotas: newLRUCache(options.MaxTracked)}
+}
+
+// quota is the token buckets of an identity.
+type quota struct {
+	messages *tokenBucket
+	bytes    *tokenBucket
+}
+
+type quotaHandler struct {
+	options QuotaOptions
+	mutex   sync.Mutex
+	quotas  *lruCache // identity - *quota
+}
+
+func (q *quotaHandler) HandleRead(ctx InboundContext, message Message) {
+
+	identity := ctx.Channel().Attribute(q.options.IdentityKey)
+	if nil == identity {
+		ctx.HandleRead(message)
+		return
+	}
+
+	// the size of stream is unknown until it is read.
+	if _, ok := message.(io.Reader); ok && !isSized(message) {
+		message = utils.MustToBytes(message)
+	}
+	size := sizeOf(message)
+
+	clock := ClockOf(ctx.Channel())
+	wait, ok := q.reserve(identity, size, clock.Now())
+	if !ok {
+		releaseMessage(message)
+		ctx.Trigger(QuotaExceededEvent{Identity: identity, Size: size})
+		return
+	}
+
+	if wait > 0 {
+		ctx.Trigger(QuotaExceededEvent{Identity: identity, Size: size, Delayed: true})
+		select {
+		case <-clock.After(wait):
+		case <-ctx.Channel().Context().Done():
+			releaseMessage(message)
+			return
+		}
+	}
+	ctx.HandleRead(message)
+}
+
+// reserve the tokens of message, returns how long to wait, false if the message should be dropped.
+func (q *quotaHandler) reserve(identity interface{}, size int, now time.Time) (time.Duration, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	qt := q.quotaOf(identity, now)
+
+	// check both buckets before reserving, so a dropped message does not consume any quota.
+	var wait time.Duration
+	if nil != qt.messages {
+		wait = qt.messages.waitN(now, 1)
+	}
+	if nil != qt.bytes {
+		if w := qt.bytes.waitN(now, float64(size)); w > wait {
+			wait = w
+		}
+	}
+
+	if wait > q.options.MaxDelay {
+		return 0, false
+	}
+
+	if nil != qt.messages {
+		qt.messages.reserveN(now, 1, wait)
+	}
+	if nil != qt.bytes {
+		qt.bytes.reserveN(now, float64(size), wait)
+	}
+	return wait, true
+}
+
+func (q *quotaHandler) quotaOf(identity interface{}, now time.Time) *quota {
+	if qt, ok := q.quotas.Get(identity); ok {
+		return qt.(*quota)
+	}
+
+	qt := &quota{}
+	if q.options.MessagesPerSecond > 0 {
+		qt.messages = newTokenBucket(q.options.MessagesPerSecond, q.options.MessageBurst, now)
+	}
+	if q.options.BytesPerMinute > 0 {
+		qt.bytes = newTokenBucket(float64(q.options.BytesPerMinute)/60, q.options.BytesPerMinute, now)
+	}
+	q.quotas.Put(identity, qt)
+	return qt
+}
+
+// isSized to check whether the size of message is known without reading it.
+func isSized(message Message) bool {
+	switch message.(type) {
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		return true
+	}
+	return false
+}
+
+// sizeOf the message in bytes.
+func sizeOf(message Message) int {
+	switch m := message.(type) {
+	case []byte:
+		return len(m)
+	case string:
+		return len(m)
+	case [][]byte:
+		return int(utils.CountOf(m))
+	case *bytes.Buffer:
+		return m.Len()
+	case *bytes.Reader:
+		return m.Len()
+	case *strings.Reader:
+		return m.Len()
+	}
+	return 0
+}
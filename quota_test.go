@@ -0,0 +1,129 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// quotaChannel serve a channel of the identity with the quota handler, the inbound bytes are framed one byte per frame.
+func quotaChannel(identity string, quota InboundHandler, received, exceeded *int32) (Channel, net.Conn) {
+	return pipeChannel(NewChannel(), "127.0.0.1:9527",
+		ActiveHandlerFunc(func(ctx ActiveContext) {
+			ctx.Channel().SetAttribute(PeerIdentityAttribute, identity)
+			ctx.HandleActive()
+		}),
+		byteFrameHandler{},
+		quota,
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			atomic.AddInt32(received, 1)
+		}),
+		EventHandlerFunc(func(ctx EventContext, event Event) {
+			if e, ok := event.(QuotaExceededEvent); ok && identity == e.Identity && !e.Delayed {
+				atomic.AddInt32(exceeded, 1)
+			}
+		}),
+	)
+}
+
+func TestQuotaHandler(t *testing.T) {
+
+	var cases = []struct {
+		name    string
+		options QuotaOptions
+	}{
+		{name: "messages", options: QuotaOptions{MessagesPerSecond: 0.1, MessageBurst: 3, MaxTracked: 16}},
+		{name: "bytes", options: QuotaOptions{BytesPerMinute: 3, MaxTracked: 16}},
+	}
+
+	for _, c := range cases {
+		quota := QuotaHandler(c.options)
+
+		var aliceReceived, aliceExceeded, bobReceived, bobExceeded int32
+		alice, alicePeer := quotaChannel("alice", quota, &aliceReceived, &aliceExceeded)
+		bob, bobPeer := quotaChannel("bob", quota, &bobReceived, &bobExceeded)
+
+		// the channels of alice share the quota.
+		another, anotherPeer := quotaChannel("alice", quota, &aliceReceived, &aliceExceeded)
+
+		for _, peer := range []net.Conn{alicePeer, anotherPeer} {
+			if _, err := peer.Write([]byte("hello")); nil != err {
+				t.Fatal(c.name, err)
+			}
+		}
+		if _, err := bobPeer.Write([]byte("hi")); nil != err {
+			t.Fatal(c.name, err)
+		}
+
+		time.Sleep(time.Millisecond * 50)
+		if 3 != atomic.LoadInt32(&aliceReceived) || 7 != atomic.LoadInt32(&aliceExceeded) {
+			t.Fatal(c.name, "alice received:", aliceReceived, "exceeded:", aliceExceeded)
+		}
+		if 2 != atomic.LoadInt32(&bobReceived) || 0 != atomic.LoadInt32(&bobExceeded) {
+			t.Fatal(c.name, "bob received:", bobReceived, "exceeded:", bobExceeded)
+		}
+
+		for _, ch := range []Channel{alice, bob, another} {
+			ch.Close(nil)
+		}
+	}
+}
+
+func TestQuotaHandlerDelay(t *testing.T) {
+
+	quota := QuotaHandler(QuotaOptions{MessagesPerSecond: 20, MessageBurst: 1, MaxDelay: time.Second, MaxTracked: 16})
+
+	var received, exceeded int32
+	ch, peer := quotaChannel("alice", quota, &received, &exceeded)
+	defer ch.Close(nil)
+
+	start := time.Now()
+	if _, err := peer.Write([]byte("abc")); nil != err {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&received) < 3 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 2 messages are delayed 50ms each.
+	if elapsed := time.Since(start); 3 != atomic.LoadInt32(&received) || elapsed < 90*time.Millisecond {
+		t.Fatal("received:", received, "elapsed:", elapsed)
+	}
+	if 0 != atomic.LoadInt32(&exceeded) {
+		t.Fatal("delayed messages are dropped:", exceeded)
+	}
+}
+
+func TestQuotaHandlerEviction(t *testing.T) {
+
+	quota := QuotaHandler(QuotaOptions{MessagesPerSecond: 0.1, MessageBurst: 1, MaxTracked: 1}).(*quotaHandler)
+
+	now := time.Now()
+	for _, identity := range []string{"alice", "bob", "alice"} {
+		if _, ok := quota.reserve(identity, 1, now); !ok {
+			t.Fatal(identity, "is over quota")
+		}
+	}
+
+	if 1 != quota.quotas.Len() {
+		t.Fatal("unexpected tracked identities:", quota.quotas.Len())
+	}
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrConnectionRateLimited is returned when a remote ip opens connections too fast.
+var ErrConnectionRateLimited = errors.New("netty: connection rate limited")
+
+// RateLimitOptions for ConnectionRateLimiter
+type RateLimitOptions struct {
+	// Rate is the number of connections allowed per second for each remote ip.
+	Rate float64 `json:"rate"`
+	// Burst is the maximum number of connections allowed at once for each remote ip.
+	Burst int `json:"burst"`
+	// MaxDelay is the maximum time to wait for a connection exceeding the rate, zero to reject
+	// immediately. the connection is served after the delay without stalling the accept loop.
+	MaxDelay time.Duration `json:"max-delay"`
+	// MaxTracked is the maximum number of remote ips to be tracked,
+	// the least recently seen ip will be evicted when it is exceeded.
+	MaxTracked int `json:"max-tracked"`
+	// Whitelist of ips or CIDRs which are never limited.
+	Whitelist []string `json:"whitelist"`
+	// Clock of the limiter, SystemClock if nil, it is not a channel handler so ClockAttribute does not apply.
+	Clock Clock `json:"-"`
+}
+
+// ConnectionRateLimiter create an AcceptFilter to limit the rate of connections from the same remote ip.
+func ConnectionRateLimiter(options RateLimitOptions) AcceptFilter {
+	utils.AssertIf(options.Rate <= 0, "rate must be a positive number")
+	utils.AssertIf(options.Burst <= 0, "burst must be a positive integer")
+	utils.AssertIf(options.MaxTracked <= 0, "maxTracked must be a positive integer")
+
+	whitelist := make([]*net.IPNet, 0, len(options.Whitelist))
+	for _, s := range options.Whitelist {
+		ipNet, err := parseCIDR(s)
+		utils.Assert(err)
+		whitelist = append(whitelist, ipNet)
+	}
+
+	if nil == options.Clock {
+		options.Clock = SystemClock()
+	}
+
+	limiter := &connectionRateLimiter{
+		options:   options,
+		whitelist: whitelist,
+		buckets:   newLRUCache(options.MaxTracked),
+	}
+	return limiter.filter
+}
+
+type connectionRateLimiter struct {
+	options   RateLimitOptions
+	whitelist []*net.IPNet
+	mutex     sync.Mutex
+	buckets   *lruCache // ip - *tokenBucket
+}
+
+func (r *connectionRateLimiter) filter(t transport.Transport) error {
+
+	ip := remoteIP(t.RemoteAddr())
+	if nil == ip || matchCIDRs(r.whitelist, ip) {
+		return nil
+	}
+
+	r.mutex.Lock()
+	now := r.options.Clock.Now()
+	bucket := r.bucketOf(ip, now)
+	wait, ok := bucket.reserve(now, r.options.MaxDelay)
+	r.mutex.Unlock()
+
+	if !ok {
+		return ErrConnectionRateLimited
+	}
+
+	// delay the connection until the token is available.
+	if wait > 0 {
+		return acceptDelay(wait)
+	}
+	return nil
+}
+
+// acceptDelay is returned by the AcceptFilter to serve the transport after the delay, the listener waits
+// for it in another goroutine, so the connections of other ips are not stalled.
+type acceptDelay time.Duration
+
+func (d acceptDelay) Error() string {
+	return "netty: accept delayed " + time.Duration(d).String()
+}
+
+func (r *connectionRateLimiter) bucketOf(ip net.IP, now time.Time) *tokenBucket {
+	key := ip.String()
+	if bucket, ok := r.buckets.Get(key); ok {
+		return bucket.(*tokenBucket)
+	}
+
+	bucket := newTokenBucket(r.options.Rate, r.options.Burst, now)
+	r.buckets.Put(key, bucket)
+	return bucket
+}
+
+// tokenBucket is a simple token bucket, it is not safe for concurrent use.
+type tokenBucket struct {
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// refill the bucket with the tokens generated since last time.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// reserve n tokens, returns how long to wait until the tokens are available,
+// false if the wait time exceeds maxWait.
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	return b.reserveN(now, 1, maxWait)
+}
+
+// waitN returns how long to wait until n tokens are available without reserving them.
+func (b *tokenBucket) waitN(now time.Time, n float64) time.Duration {
+	b.refill(now)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) reserveN(now time.Time, n float64, maxWait time.Duration) (time.Duration, bool) {
+	b.refill(now)
+
+	if b.tokens >= n {
+		b.tokens -= n
+		return 0, true
+	}
+
+	wait := time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+	if wait > maxWait {
+		return 0, false
+	}
+
+	// reserve the future tokens.
+	b.tokens -= n
+	return wait, true
+}
+
+// remoteIP to get the ip of net.Addr, nil if it is not an ip address.
+func remoteIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	case nil:
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if nil != err {
+		host = addr.String()
+	}
+	return net.ParseIP(host)
+}
+
+// parseCIDR to parse an ip or CIDR, a single ip is treated as a full-length CIDR.
+func parseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if nil == ip {
+			return nil, &net.ParseError{Type: "IP address", Text: s}
+		}
+		if ip4 := ip.To4(); nil != ip4 {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	return ipNet, err
+}
+
+// matchCIDRs to check if ip is contained in any of the CIDRs.
+func matchCIDRs(cidrs []*net.IPNet, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
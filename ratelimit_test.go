@@ -0,0 +1,217 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func remoteTransport(address string) transport.Transport {
+	addr, err := net.ResolveTCPAddr("tcp", address)
+	if nil != err {
+		panic(err)
+	}
+	return transport.NewTransport(addrConn{local: addr, remote: addr}, 0, 0)
+}
+
+func TestConnectionRateLimiter(t *testing.T) {
+
+	filter := ConnectionRateLimiter(RateLimitOptions{
+		Rate:       1,
+		Burst:      3,
+		MaxTracked: 16,
+		Whitelist:  []string{"10.0.0.0/8"},
+	})
+
+	// rapid connects from one ip.
+	for i := 0; i < 3; i++ {
+		if err := filter(remoteTransport("192.168.1.1:10000")); nil != err {
+			t.Fatalf("connection #%d rejected: %v", i, err)
+		}
+	}
+
+	if err := filter(remoteTransport("192.168.1.1:10001")); ErrConnectionRateLimited != err {
+		t.Fatal("expect rate limited, got:", err)
+	}
+
+	// other ip is not affected.
+	if err := filter(remoteTransport("192.168.1.2:10000")); nil != err {
+		t.Fatal("unexpected rejection:", err)
+	}
+
+	// whitelisted ip is never limited.
+	for i := 0; i < 10; i++ {
+		if err := filter(remoteTransport("10.1.2.3:10000")); nil != err {
+			t.Fatal("whitelisted ip rejected:", err)
+		}
+	}
+}
+
+func TestConnectionRateLimiterDelay(t *testing.T) {
+
+	filter := ConnectionRateLimiter(RateLimitOptions{
+		Rate:       20,
+		Burst:      1,
+		MaxDelay:   time.Second,
+		MaxTracked: 16,
+	})
+
+	// the filter returns the delay at once, the connections are delayed 50ms each.
+	start := time.Now()
+	for i, want := range []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond} {
+		err := filter(remoteTransport("192.168.1.1:10000"))
+		if delay, _ := err.(acceptDelay); -10*time.Millisecond > time.Duration(delay)-want || time.Duration(delay) > want {
+			t.Fatalf("connection #%d: %v, want delay: %s", i, err, want)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatal("filter is blocked:", elapsed)
+	}
+}
+
+// queueAcceptor accepts the transports of queue.
+type queueAcceptor struct {
+	queue chan transport.Transport
+	once  sync.Once
+	done  chan struct{}
+}
+
+func (a *queueAcceptor) Accept() (transport.Transport, error) {
+	select {
+	case t := <-a.queue:
+		return t, nil
+	case <-a.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (a *queueAcceptor) Close() error {
+	a.once.Do(func() { close(a.done) })
+	return nil
+}
+
+type queueFactory struct {
+	acceptor *queueAcceptor
+}
+
+func (f *queueFactory) Schemes() transport.Schemes {
+	return transport.Schemes{"queue"}
+}
+
+func (f *queueFactory) Connect(options *transport.Options) (transport.Transport, error) {
+	return nil, net.ErrClosed
+}
+
+func (f *queueFactory) Listen(options *transport.Options) (transport.Acceptor, error) {
+	return f.acceptor, nil
+}
+
+func TestConnectionRateLimiterAcceptLoop(t *testing.T) {
+
+	acceptor := &queueAcceptor{queue: make(chan transport.Transport, 4), done: make(chan struct{})}
+	served := make(chan string, 4)
+	bs := NewBootstrap(
+		WithTransport(&queueFactory{acceptor: acceptor}),
+		WithAcceptFilter(ConnectionRateLimiter(RateLimitOptions{Rate: 5, Burst: 1, MaxDelay: time.Second, MaxTracked: 16})),
+		WithChildInitializer(func(ch Channel) {
+			served <- ch.RemoteAddr()
+			ch.Pipeline().AddLast(discardHandler{})
+		}),
+	)
+	defer bs.Shutdown()
+
+	bs.Listen("queue://127.0.0.1:9527").Async(func(err error) {})
+
+	accept := func(address string) {
+		addr, err := net.ResolveTCPAddr("tcp", address)
+		if nil != err {
+			t.Fatal(err)
+		}
+		local, remote := net.Pipe()
+		t.Cleanup(func() { _ = remote.Close() })
+		acceptor.queue <- transport.NewTransport(addrConn{Conn: local, local: addr, remote: addr}, 0, 0)
+	}
+
+	// the second connection of the abusive ip is delayed 200ms, the other ip is not stalled.
+	start := time.Now()
+	accept("192.168.1.1:10000")
+	accept("192.168.1.1:10001")
+	accept("192.168.1.2:10000")
+
+	var order []string
+	for len(order) < 3 {
+		select {
+		case remote := <-served:
+			order = append(order, remote)
+			if "192.168.1.2:10000" == remote && time.Since(start) > 100*time.Millisecond {
+				t.Fatal("other ip is stalled:", time.Since(start))
+			}
+		case <-time.After(time.Second):
+			t.Fatal("connections are not served:", order)
+		}
+	}
+
+	if "192.168.1.1:10001" != order[2] {
+		t.Fatal("delayed connection is not served last:", order)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatal("connection is not delayed:", elapsed)
+	}
+}
+
+func TestConnectionRateLimiterEviction(t *testing.T) {
+
+	limiter := ConnectionRateLimiter(RateLimitOptions{Rate: 1, Burst: 1, MaxTracked: 2})
+
+	for _, address := range []string{"192.168.1.1:1", "192.168.1.2:1", "192.168.1.3:1", "192.168.1.4:1"} {
+		if err := limiter(remoteTransport(address)); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	// the state of 192.168.1.1 was evicted, so it starts with a full bucket again.
+	if err := limiter(remoteTransport("192.168.1.1:2")); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+
+	cache := newLRUCache(2)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Get("a")
+	cache.Put("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("b should be evicted")
+	}
+
+	if v, ok := cache.Get("a"); !ok || 1 != v {
+		t.Fatal("unexpected value of a:", v)
+	}
+
+	if 2 != cache.Len() {
+		t.Fatal("unexpected length:", cache.Len())
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// recordHeaderSize is the size of chunk header: the unix nano time of 8 bytes and the length of 4 bytes.
+const recordHeaderSize = 12
+
+// RecordedChunk is a chunk of the inbound stream recorded by RecordHandler
+type RecordedChunk struct {
+	Time time.Time
+	Data []byte
+}
+
+// RecordHandler tees the inbound bytes of channel to w with the time of each read, the record is replayed by
+// ReplaySource, e.g. to reproduce a protocol issue in tests. add it as the first handler to record the raw bytes,
+// and create a new one for each channel.
+func RecordHandler(w io.Writer) InboundHandler {
+	utils.AssertIf(nil == w, "writer is required")
+	return &recordHandler{writer: w}
+}
+
+type recordHandler struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	header [recordHeaderSize]byte
+	source io.Reader     // the stream of channel
+	reader *recordReader // the reader of source
+}
+
+func (r *recordHandler) HandleRead(ctx InboundContext, message Message) {
+	switch m := message.(type) {
+	case io.Reader:
+		// the stream of channel is fired for each read, so the reader is reused.
+		if m != r.source {
+			r.source, r.reader = m, &recordReader{reader: m, recorder: r, clock: ClockOf(ctx.Channel())}
+		}
+		ctx.HandleRead(r.reader)
+	default:
+		r.record(ClockOf(ctx.Channel()).Now(), utils.MustToBytes(message))
+		ctx.HandleRead(message)
+	}
+}
+
+// record a chunk of the inbound bytes
+func (r *recordHandler) record(now time.Time, data []byte) {
+	if 0 == len(data) {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	binary.BigEndian.PutUint64(r.header[:8], uint64(now.UnixNano()))
+	binary.BigEndian.PutUint32(r.header[8:], uint32(len(data)))
+	utils.AssertLength(r.writer.Write(r.header[:]))
+	utils.AssertLength(r.writer.Write(data))
+}
+
+// recordReader records the bytes read from the stream
+type recordReader struct {
+	reader   io.Reader
+	recorder *recordHandler
+	clock    Clock
+}
+
+func (r *recordReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.recorder.record(r.clock.Now(), p[:n])
+	}
+	return n, err
+}
+
+// ReplaySource reads the chunks recorded by RecordHandler
+type ReplaySource struct {
+	reader *bufio.Reader
+	header [recordHeaderSize]byte
+	clock  Clock
+}
+
+// NewReplaySource create a ReplaySource of the record
+func NewReplaySource(r io.Reader) *ReplaySource {
+	return &ReplaySource{reader: bufio.NewReader(r), clock: SystemClock()}
+}
+
+// WithClock to use the clock to wait for the time of chunks when Replay, e.g. a FakeClock in tests.
+func (s *ReplaySource) WithClock(clock Clock) *ReplaySource {
+	s.clock = clock
+	return s
+}
+
+// Next returns the next recorded chunk, io.EOF if there is no more chunk.
+func (s *ReplaySource) Next() (RecordedChunk, error) {
+	if _, err := io.ReadFull(s.reader, s.header[:]); nil != err {
+		return RecordedChunk{}, err
+	}
+
+	chunk := RecordedChunk{
+		Time: time.Unix(0, int64(binary.BigEndian.Uint64(s.header[:8]))),
+		Data: make([]byte, binary.BigEndian.Uint32(s.header[8:])),
+	}
+	if _, err := io.ReadFull(s.reader, chunk.Data); nil != err {
+		if io.EOF == err {
+			err = io.ErrUnexpectedEOF
+		}
+		return RecordedChunk{}, err
+	}
+	return chunk, nil
+}
+
+// Replay write the recorded chunks to w, e.g. the peer of a channel, the intervals between the chunks are
+// preserved if realtime, or the chunks are written as fast as possible, returns nil at the end of record.
+func (s *ReplaySource) Replay(w io.Writer, realtime bool) error {
+	var last time.Time
+	for {
+		chunk, err := s.Next()
+		if io.EOF == err {
+			return nil
+		}
+		if nil != err {
+			return err
+		}
+
+		if realtime && !last.IsZero() {
+			if interval := chunk.Time.Sub(last); interval > 0 {
+				<-s.clock.After(interval)
+			}
+		}
+		last = chunk.Time
+
+		if _, err = w.Write(chunk.Data); nil != err {
+			return err
+		}
+	}
+}
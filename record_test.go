@@ -0,0 +1,108 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+
+	session := func(handlers ...Handler) (Channel, io.WriteCloser, <-chan string) {
+		received := make(chan string, 8)
+		handlers = append(handlers,
+			delimiterCodec{maxFrameLength: 1024, delimiter: []byte("\n"), stripDelimiter: true},
+			&textCodec{},
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				received <- message.(string)
+			}),
+		)
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", handlers...)
+		return ch, peer, received
+	}
+
+	collect := func(received <-chan string, n int) (messages []string) {
+		for i := 0; i < n; i++ {
+			select {
+			case message := <-received:
+				messages = append(messages, message)
+			case <-time.After(time.Second):
+				t.Fatal("messages not received:", messages)
+			}
+		}
+		return
+	}
+
+	// record
+	var record bytes.Buffer
+	ch, peer, received := session(RecordHandler(&record))
+	_, _ = peer.Write([]byte("hello\nwor"))
+	want := collect(received, 1)
+	time.Sleep(time.Millisecond * 100)
+	_, _ = peer.Write([]byte("ld\nbye\n"))
+	want = append(want, collect(received, 2)...)
+	ch.Close(nil)
+	_ = peer.Close()
+
+	// the chunks of the record
+	var stream []byte
+	source := NewReplaySource(bytes.NewReader(record.Bytes()))
+	for {
+		chunk, err := source.Next()
+		if io.EOF == err {
+			break
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+		stream = append(stream, chunk.Data...)
+	}
+	if "hello\nworld\nbye\n" != string(stream) {
+		t.Fatal("unexpected record:", string(stream))
+	}
+
+	// replay with the recorded timing
+	ch, peer, received = session()
+	defer ch.Close(nil)
+	start := time.Now()
+	go func() {
+		if err := NewReplaySource(bytes.NewReader(record.Bytes())).Replay(peer, true); nil != err {
+			t.Error(err)
+		}
+	}()
+
+	got := collect(received, 3)
+	if elapsed := time.Since(start); elapsed < time.Millisecond*80 {
+		t.Fatal("the timing is not preserved:", elapsed)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatal("unexpected replay:", got, "want:", want)
+		}
+	}
+
+	// truncated record
+	if _, err := NewReplaySource(bytes.NewReader(record.Bytes()[:record.Len()-1])).Next(); nil != err {
+		t.Fatal(err)
+	}
+	if err := NewReplaySource(bytes.NewReader(record.Bytes()[:record.Len()-1])).Replay(io.Discard, false); io.ErrUnexpectedEOF != err {
+		t.Fatal("expect unexpected eof, got:", err)
+	}
+}
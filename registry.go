@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownHandler is returned if no handler factory is registered for the name.
+var ErrUnknownHandler = errors.New("netty: unknown handler")
+
+// HandlerSpec defines a handler in the pipeline config, e.g. {"name": "idle", "params": {"timeout": "30s"}}
+type HandlerSpec struct {
+	// Name of the factory registered in HandlerRegistry.
+	Name string `json:"name"`
+	// Params passed to the factory as is, it may be empty.
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// HandlerFactory create a handler of the params, see UnmarshalParams to decode them.
+type HandlerFactory func(params json.RawMessage) (Handler, error)
+
+// HandlerRegistry maps the names to the handler factories, to build the pipelines from config
+// without recompiling, e.g. the config of a deployment.
+type HandlerRegistry interface {
+	// Register the factory of name, a later one replaces the former.
+	Register(name string, factory HandlerFactory) HandlerRegistry
+
+	// Registered returns the sorted names of registered factories.
+	Registered() []string
+
+	// Build the handlers of specs in order, the error tells the index and name of the bad spec.
+	Build(specs []HandlerSpec) ([]Handler, error)
+
+	// BuildPipeline returns the initializer to add the handlers of specs to each channel, the specs are built
+	// once to check the errors, then the handlers are created for each channel, as they may be stateful.
+	BuildPipeline(specs []HandlerSpec) (ChannelInitializer, error)
+}
+
+// NewHandlerRegistry create an empty HandlerRegistry
+func NewHandlerRegistry() HandlerRegistry {
+	return &handlerRegistry{factories: make(map[string]HandlerFactory)}
+}
+
+type handlerRegistry struct {
+	mutex     sync.RWMutex
+	factories map[string]HandlerFactory
+}
+
+func (r *handlerRegistry) Register(name string, factory HandlerFactory) HandlerRegistry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.factories[name] = factory
+	return r
+}
+
+func (r *handlerRegistry) Registered() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *handlerRegistry) Build(specs []HandlerSpec) ([]Handler, error) {
+	handlers := make([]Handler, 0, len(specs))
+	for index, spec := range specs {
+		r.mutex.RLock()
+		factory, ok := r.factories[spec.Name]
+		r.mutex.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("%w: #%d %s, registered: %v", ErrUnknownHandler, index, spec.Name, r.Registered())
+		}
+
+		handler, err := factory(spec.Params)
+		if nil != err {
+			return nil, fmt.Errorf("netty: handler #%d %s: %w", index, spec.Name, err)
+		}
+		if nil == handler {
+			return nil, fmt.Errorf("netty: handler #%d %s: nil handler", index, spec.Name)
+		}
+		handlers = append(handlers, handler)
+	}
+	return handlers, nil
+}
+
+func (r *handlerRegistry) BuildPipeline(specs []HandlerSpec) (ChannelInitializer, error) {
+	if _, err := r.Build(specs); nil != err {
+		return nil, err
+	}
+
+	return func(ch Channel) {
+		handlers, err := r.Build(specs)
+		if nil != err {
+			// the factory may be replaced after checked.
+			ch.Close(err)
+			return
+		}
+		ch.Pipeline().AddLast(handlers...)
+	}, nil
+}
+
+// UnmarshalParams decode the params of HandlerSpec into v strictly, the unknown fields are rejected,
+// and v is untouched if the params are empty.
+func UnmarshalParams(params json.RawMessage, v interface{}) error {
+	if 0 == len(bytes.TrimSpace(params)) || "null" == string(bytes.TrimSpace(params)) {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); nil != err {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
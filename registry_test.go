@@ -0,0 +1,130 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testHandlerRegistry(received chan<- string) HandlerRegistry {
+	return NewHandlerRegistry().
+		Register("delimiter", func(params json.RawMessage) (Handler, error) {
+			var p struct {
+				MaxFrameLength int    `json:"maxFrameLength"`
+				Delimiter      string `json:"delimiter"`
+			}
+			if err := UnmarshalParams(params, &p); nil != err {
+				return nil, err
+			}
+			if p.MaxFrameLength <= 0 || "" == p.Delimiter {
+				return nil, fmt.Errorf("invalid params: %+v", p)
+			}
+			return delimiterCodec{maxFrameLength: p.MaxFrameLength, delimiter: []byte(p.Delimiter), stripDelimiter: true}, nil
+		}).
+		Register("text", func(params json.RawMessage) (Handler, error) {
+			return &textCodec{}, nil
+		}).
+		Register("upper", func(params json.RawMessage) (Handler, error) {
+			return InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				ctx.HandleRead(strings.ToUpper(message.(string)))
+			}), nil
+		}).
+		Register("collect", func(params json.RawMessage) (Handler, error) {
+			return InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				received <- message.(string)
+			}), nil
+		})
+}
+
+func TestHandlerRegistry(t *testing.T) {
+
+	var specs []HandlerSpec
+	if err := json.Unmarshal([]byte(`[
+		{"name": "delimiter", "params": {"maxFrameLength": 1024, "delimiter": "\n"}},
+		{"name": "text"},
+		{"name": "upper"},
+		{"name": "collect"}
+	]`), &specs); nil != err {
+		t.Fatal(err)
+	}
+
+	received := make(chan string, 4)
+	registry := testHandlerRegistry(received)
+
+	initializer, err := registry.BuildPipeline(specs)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	bs := NewBootstrap(WithChildInitializer(initializer))
+	defer bs.Shutdown()
+	bs.Listen("127.0.0.1:9561").Async(func(err error) {})
+
+	var conn net.Conn
+	for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+		if conn, err = net.Dial("tcp", "127.0.0.1:9561"); nil == err {
+			break
+		}
+	}
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte("hello\nnetty\n"))
+	for _, want := range []string{"HELLO", "NETTY"} {
+		select {
+		case got := <-received:
+			if want != got {
+				t.Fatal("unexpected message:", got, "want:", want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message not received:", want)
+		}
+	}
+}
+
+func TestHandlerRegistryErrors(t *testing.T) {
+
+	registry := testHandlerRegistry(nil)
+
+	if _, err := registry.Build([]HandlerSpec{{Name: "text"}, {Name: "unknown"}}); !errors.Is(err, ErrUnknownHandler) || !strings.Contains(err.Error(), "#1 unknown") {
+		t.Fatal("expect unknown handler, got:", err)
+	}
+
+	var cases = []string{
+		`{"maxFrameLength": "1024", "delimiter": "\n"}`,
+		`{"maxFrameLength": 1024, "delimiter": "\n", "strip": true}`,
+		`{"maxFrameLength": 0}`,
+		``,
+	}
+	for _, params := range cases {
+		if _, err := registry.BuildPipeline([]HandlerSpec{{Name: "delimiter", Params: json.RawMessage(params)}}); nil == err || !strings.Contains(err.Error(), "#0 delimiter") {
+			t.Fatalf("%s: expect invalid params, got: %v", params, err)
+		}
+	}
+
+	if names := registry.Registered(); "collect,delimiter,text,upper" != strings.Join(names, ",") {
+		t.Fatal("unexpected registered:", names)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrTooManyUnacked is raised by the write of ReliableHandler if the unacknowledged messages reach MaxUnacked.
+var ErrTooManyUnacked = errors.New("netty: too many unacknowledged messages")
+
+// ErrSequenceLost is the cause of closing the channel if the messages of peer could not be delivered in order,
+// e.g. the peer lost its state, or the retransmit buffer of peer dropped them.
+var ErrSequenceLost = errors.New("netty: reliable sequence lost")
+
+// frame types of ReliableHandler
+const (
+	reliableData   byte = 0
+	reliableAck    byte = 1
+	reliableResume byte = 2
+)
+
+// reliableHeaderSize is the size of frame type and sequence.
+const reliableHeaderSize = 9
+
+// ReliableOptions defines the options of ReliableHandler
+type ReliableOptions struct {
+	// MaxUnacked is the max number of messages sent but not acknowledged by the peer, default to 1024.
+	MaxUnacked int `json:"maxUnacked"`
+}
+
+// ReliableHandler create a handler to deliver the messages in order and at most once over reconnects, each outbound
+// message is numbered by a sequence from 1 and kept until the peer acknowledged it. when the channel is active,
+// both sides send the last sequence they received, then only the unacknowledged messages after it are retransmitted
+// before the new ones, and the duplicates are dropped by sequence. the frame is [type: 1 byte | sequence: 8 bytes |
+// payload], so place it after a frame codec on both sides. the handler holds the sequences, so create a new one for
+// each logical connection, and add the same handler to the reconnected channels.
+func ReliableHandler(options ReliableOptions) ChannelHandler {
+	if 0 == options.MaxUnacked {
+		options.MaxUnacked = 1024
+	}
+	utils.AssertIf(options.MaxUnacked < 0, "maxUnacked must be a positive integer")
+	return &reliableHandler{options: options}
+}
+
+type reliableHandler struct {
+	options  ReliableOptions
+	mutex    sync.Mutex
+	ctx      OutboundContext // the context of active channel.
+	resumed  bool            // the resume of peer is received, so the messages are sent as written.
+	acked    uint64          // the last sequence acknowledged by the peer.
+	unacked  [][]byte        // the payloads of sequences after acked.
+	received uint64          // the last sequence received from the peer.
+}
+
+func (r *reliableHandler) HandleActive(ctx ActiveContext) {
+	r.mutex.Lock()
+	r.ctx, r.resumed = ctx.(OutboundContext), false
+	received := r.received
+	r.mutex.Unlock()
+
+	ctx.Write(reliableFrame(reliableResume, received))
+	ctx.HandleActive()
+}
+
+func (r *reliableHandler) HandleRead(ctx InboundContext, message Message) {
+	frame := utils.MustToBytes(message)
+	utils.AssertIf(len(frame) < reliableHeaderSize, "invalid frame: %d bytes", len(frame))
+
+	seq := binary.BigEndian.Uint64(frame[1:])
+	switch frame[0] {
+	case reliableData:
+		r.mutex.Lock()
+		expected := r.received + 1
+		if seq == expected {
+			r.received = seq
+		}
+		r.mutex.Unlock()
+
+		switch {
+		case seq < expected:
+			// duplicate
+			return
+		case seq > expected:
+			ctx.Close(fmt.Errorf("%w: expect sequence %d, got: %d", ErrSequenceLost, expected, seq))
+			return
+		}
+
+		ctx.Write(reliableFrame(reliableAck, seq))
+		ctx.HandleRead(frame[reliableHeaderSize:])
+	case reliableAck:
+		r.mutex.Lock()
+		err := r.ackLocked(seq)
+		r.mutex.Unlock()
+		utils.Assert(err)
+	case reliableResume:
+		if err := r.resume(ctx, seq); nil != err {
+			ctx.Close(err)
+		}
+	default:
+		utils.Assert(fmt.Errorf("invalid frame type: %d", frame[0]))
+	}
+}
+
+func (r *reliableHandler) HandleWrite(ctx OutboundContext, message Message) {
+	payload := append([]byte(nil), utils.MustToBytes(message)...)
+
+	// the writes are serialized with the retransmits by the write lock of pipeline, so the mutex is not held
+	// while writing, which blocks the acknowledgements of read loop otherwise.
+	r.mutex.Lock()
+	if unacked := len(r.unacked); unacked >= r.options.MaxUnacked {
+		r.mutex.Unlock()
+		utils.Assert(fmt.Errorf("%w: %d", ErrTooManyUnacked, unacked))
+	}
+
+	r.unacked = append(r.unacked, payload)
+	seq, resumed := r.acked+uint64(len(r.unacked)), r.resumed
+	r.mutex.Unlock()
+
+	// sent after the retransmits once resumed.
+	if resumed {
+		ctx.HandleWrite(dataFrame(seq, payload))
+	}
+}
+
+func (r *reliableHandler) HandleException(ctx ExceptionContext, ex Exception) {
+	ctx.HandleException(ex)
+}
+
+func (r *reliableHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	r.mutex.Lock()
+	// the reconnected channel may be active already.
+	if nil != r.ctx && r.ctx.Channel() == ctx.Channel() {
+		r.ctx, r.resumed = nil, false
+	}
+	r.mutex.Unlock()
+	ctx.HandleInactive(ex)
+}
+
+// ackLocked to drop the messages acknowledged by the peer.
+func (r *reliableHandler) ackLocked(seq uint64) error {
+	if last := r.acked + uint64(len(r.unacked)); seq > last {
+		return fmt.Errorf("invalid acknowledgement: %d > the last sequence: %d", seq, last)
+	}
+
+	if seq > r.acked {
+		n := seq - r.acked
+		for i := uint64(0); i < n; i++ {
+			r.unacked[i] = nil
+		}
+		r.unacked, r.acked = r.unacked[n:], seq
+	}
+	return nil
+}
+
+// resume to retransmit the messages after the last sequence received by the peer.
+func (r *reliableHandler) resume(ctx InboundContext, received uint64) error {
+	return serializeWrites(ctx, func() error { return r.retransmit(ctx.(OutboundContext), received) })
+}
+
+// retransmit the unacknowledged messages with the write token held.
+func (r *reliableHandler) retransmit(ctx OutboundContext, received uint64) error {
+	r.mutex.Lock()
+	if received < r.acked {
+		r.mutex.Unlock()
+		return fmt.Errorf("%w: the peer received %d, but %d is acknowledged", ErrSequenceLost, received, r.acked)
+	}
+	if err := r.ackLocked(received); nil != err {
+		r.mutex.Unlock()
+		return err
+	}
+
+	// the new writes are held by the write lock until the retransmits are written.
+	unacked := append([][]byte(nil), r.unacked...)
+	r.resumed = true
+	r.mutex.Unlock()
+
+	for index, payload := range unacked {
+		ctx.HandleWrite(dataFrame(received+uint64(index)+1, payload))
+	}
+	return nil
+}
+
+// reliableFrame to encode the frame without payload.
+func reliableFrame(kind byte, seq uint64) []byte {
+	frame := make([]byte, reliableHeaderSize)
+	frame[0] = kind
+	binary.BigEndian.PutUint64(frame[1:], seq)
+	return frame
+}
+
+// dataFrame to encode the data frame of payload.
+func dataFrame(seq uint64, payload []byte) [][]byte {
+	return [][]byte{reliableFrame(reliableData, seq), payload}
+}
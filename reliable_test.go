@@ -0,0 +1,156 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// dropSwitch drops the inbound frames while it is on, e.g. lost by the network.
+type dropSwitch struct {
+	on      int32
+	dropped int32
+}
+
+func (d *dropSwitch) HandleRead(ctx InboundContext, message Message) {
+	if 0 != atomic.LoadInt32(&d.on) {
+		atomic.AddInt32(&d.dropped, 1)
+		return
+	}
+	ctx.HandleRead(message)
+}
+
+// reliableChannels connect the sender and receiver over net.Pipe, the frames of each side could be dropped by the switch.
+func reliableChannels(sender, receiver ChannelHandler, senderDrop, receiverDrop *dropSwitch, received chan<- string) (Channel, Channel) {
+	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:9527")
+	senderConn, receiverConn := net.Pipe()
+
+	serve := func(conn net.Conn, handlers ...Handler) Channel {
+		pl := NewPipeline()
+		pl.AddLast(handlers...)
+		t := transport.NewTransport(addrConn{Conn: conn, local: addr, remote: addr}, 0, 0)
+		ch := NewAsyncWriteChannel(64, true)(testChannelID(), context.Background(), pl, t, AsyncExecutor())
+		pl.ServeChannel(ch)
+		return ch
+	}
+
+	return serve(senderConn, lengthFrameCodec{}, senderDrop, sender),
+		serve(receiverConn, lengthFrameCodec{}, receiverDrop, receiver, InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			received <- string(utils.MustToBytes(message))
+		}))
+}
+
+func TestReliableHandlerReconnect(t *testing.T) {
+
+	sender, receiver := ReliableHandler(ReliableOptions{}), ReliableHandler(ReliableOptions{})
+	senderDrop, receiverDrop := &dropSwitch{}, &dropSwitch{}
+	received := make(chan string, 32)
+
+	expect := func(from, to int) {
+		for i := from; i <= to; i++ {
+			select {
+			case m := <-received:
+				if want := fmt.Sprint("m", i); want != m {
+					t.Fatal("unexpected message:", m, "want:", want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("message is not received:", i)
+			}
+		}
+	}
+
+	write := func(ch Channel, from, to int) {
+		for i := from; i <= to; i++ {
+			if err := ch.Write([]byte(fmt.Sprint("m", i))); nil != err {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	a, b := reliableChannels(sender, receiver, senderDrop, receiverDrop, received)
+	write(a, 1, 5)
+	expect(1, 5)
+
+	// m6 ~ m8 are delivered, but the acknowledgements are lost.
+	atomic.StoreInt32(&senderDrop.on, 1)
+	write(a, 6, 8)
+	expect(6, 8)
+
+	// m9 & m10 are lost.
+	atomic.StoreInt32(&receiverDrop.on, 1)
+	write(a, 9, 10)
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&receiverDrop.dropped) < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("messages are not sent")
+		}
+	}
+
+	a.Close(nil)
+	b.Close(nil)
+	<-a.Context().Done()
+	<-b.Context().Done()
+
+	// reconnect, the new messages are sent after the retransmits.
+	atomic.StoreInt32(&senderDrop.on, 0)
+	atomic.StoreInt32(&receiverDrop.on, 0)
+	a, b = reliableChannels(sender, receiver, senderDrop, receiverDrop, received)
+	defer a.Close(nil)
+	defer b.Close(nil)
+	write(a, 11, 12)
+	expect(9, 12)
+
+	select {
+	case m := <-received:
+		t.Fatal("duplicate message:", m)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	r := sender.(*reliableHandler)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if 12 != r.acked || 0 != len(r.unacked) {
+		t.Fatal("unexpected acknowledgement:", r.acked, len(r.unacked))
+	}
+}
+
+func TestReliableHandlerMaxUnacked(t *testing.T) {
+
+	// the peer never resumes, so the messages are not acknowledged.
+	ch, peer := pipeChannel(NewAsyncWriteChannel(16, true), "127.0.0.1:9527", lengthFrameCodec{}, ReliableHandler(ReliableOptions{MaxUnacked: 2}))
+	defer ch.Close(nil)
+	defer peer.Close()
+	readPeer(peer)
+
+	for i := 0; i < 2; i++ {
+		if err := ch.Write([]byte("message")); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ch.Write([]byte("message")); !errors.Is(err, ErrTooManyUnacked) {
+		t.Fatal("expect too many unacked, got:", err)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// SequenceGapEvent is triggered by ReorderHandler when the missing messages in [From, To] are skipped.
+type SequenceGapEvent struct {
+	From, To uint64
+}
+
+// ReorderHandler create a handler to release the messages in the order of sequence, the sequence starts with
+// the first message, the out-of-order messages are buffered until the gap is filled, the gap is skipped with
+// a SequenceGapEvent if it is not filled in gapTimeout or more than maxBuffer messages are buffered, the late
+// messages of skipped or seen sequences are dropped, create a new one for each channel.
+func ReorderHandler(seqOf func(message Message) uint64, maxBuffer int, gapTimeout time.Duration) ChannelInboundHandler {
+	utils.AssertIf(maxBuffer <= 0, "maxBuffer must be a positive integer")
+	utils.AssertIf(gapTimeout <= 0, "gapTimeout must be a positive duration")
+	return &reorderHandler{
+		seqOf:      seqOf,
+		maxBuffer:  maxBuffer,
+		gapTimeout: gapTimeout,
+		buffered:   make(map[uint64]Message),
+	}
+}
+
+type reorderHandler struct {
+	mutex      sync.Mutex
+	seqOf      func(message Message) uint64
+	maxBuffer  int
+	gapTimeout time.Duration
+	started    bool
+	next       uint64
+	buffered   map[uint64]Message
+	gapTimer   Timer
+	watching   uint64
+	handlerCtx InboundContext
+}
+
+func (r *reorderHandler) HandleActive(ctx ActiveContext) {
+	ctx.HandleActive()
+}
+
+func (r *reorderHandler) HandleRead(ctx InboundContext, message Message) {
+	seq := r.seqOf(message)
+
+	// the messages are passed on with lock held to keep the order with the gap timer.
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.started {
+		r.started, r.next, r.handlerCtx = true, seq, ctx
+	}
+
+	switch {
+	case seq < r.next:
+		releaseMessage(message)
+	case seq == r.next:
+		r.next++
+		ctx.HandleRead(message)
+		r.releaseInOrder()
+	default:
+		if _, ok := r.buffered[seq]; ok {
+			releaseMessage(message)
+			return
+		}
+		r.buffered[seq] = message
+		if len(r.buffered) > r.maxBuffer {
+			r.skipGap()
+		}
+	}
+	r.watchGap()
+}
+
+func (r *reorderHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	r.mutex.Lock()
+	if nil != r.gapTimer {
+		r.gapTimer.Stop()
+		r.gapTimer = nil
+	}
+	for seq, message := range r.buffered {
+		delete(r.buffered, seq)
+		releaseMessage(message)
+	}
+	r.mutex.Unlock()
+
+	ctx.HandleInactive(ex)
+}
+
+// releaseInOrder to pass on the buffered messages following the next sequence.
+func (r *reorderHandler) releaseInOrder() {
+	for {
+		message, ok := r.buffered[r.next]
+		if !ok {
+			return
+		}
+		delete(r.buffered, r.next)
+		r.next++
+		r.handlerCtx.HandleRead(message)
+	}
+}
+
+// skipGap to skip the missing sequences before the first buffered message.
+func (r *reorderHandler) skipGap() {
+	first := r.next
+	for seq := range r.buffered {
+		if first == r.next || seq < first {
+			first = seq
+		}
+	}
+
+	gap := SequenceGapEvent{From: r.next, To: first - 1}
+	r.next = first
+	r.handlerCtx.Trigger(gap)
+	r.releaseInOrder()
+}
+
+// watchGap to start the gap timer if the gap is changed, it is stopped if there is no gap.
+func (r *reorderHandler) watchGap() {
+	if 0 == len(r.buffered) {
+		if nil != r.gapTimer {
+			r.gapTimer.Stop()
+			r.gapTimer = nil
+		}
+		return
+	}
+
+	if nil != r.gapTimer {
+		if r.watching == r.next {
+			return
+		}
+		r.gapTimer.Stop()
+	}
+
+	watching := r.next
+	r.watching = watching
+	r.gapTimer = ClockOf(r.handlerCtx.Channel()).AfterFunc(r.gapTimeout, func() {
+		defer func() {
+			if err := recover(); nil != err {
+				r.handlerCtx.Channel().Pipeline().FireChannelException(AsException(err))
+			}
+		}()
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		// the gap is filled or skipped already.
+		if watching != r.next || 0 == len(r.buffered) {
+			return
+		}
+
+		r.skipGap()
+		r.gapTimer = nil
+		r.watchGap()
+	})
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReorderHandler(t *testing.T) {
+
+	var cases = []struct {
+		name     string
+		input    string
+		released string
+		gaps     []SequenceGapEvent
+	}{
+		{name: "in-order", input: "abc", released: "abc"},
+		{name: "out-of-order", input: "acbedf", released: "abcdef"},
+		{name: "late", input: "abab", released: "ab"},
+		{name: "gap-timeout", input: "abd", released: "abd", gaps: []SequenceGapEvent{{From: 'c', To: 'c'}}},
+		{name: "max-buffer", input: "aefgb", released: "aefg", gaps: []SequenceGapEvent{{From: 'b', To: 'd'}}},
+	}
+
+	for _, c := range cases {
+		released := make(chan byte, 16)
+		gaps := make(chan SequenceGapEvent, 4)
+
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+			byteFrameHandler{},
+			ReorderHandler(func(message Message) uint64 { return uint64(message.([]byte)[0]) }, 2, time.Millisecond*50),
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				released <- message.([]byte)[0]
+			}),
+			EventHandlerFunc(func(ctx EventContext, event Event) {
+				if gap, ok := event.(SequenceGapEvent); ok {
+					gaps <- gap
+				}
+			}),
+		)
+
+		if _, err := peer.Write([]byte(c.input)); nil != err {
+			t.Fatal(err)
+		}
+
+		var output []byte
+		for len(output) < len(c.released) {
+			select {
+			case b := <-released:
+				output = append(output, b)
+			case <-time.After(time.Second):
+				t.Fatal(c.name, "released:", string(output), "want:", c.released)
+			}
+		}
+
+		if c.released != string(output) {
+			t.Fatal(c.name, "released:", string(output), "want:", c.released)
+		}
+
+		for _, want := range c.gaps {
+			if gap := <-gaps; want != gap {
+				t.Fatal(c.name, "unexpected gap:", gap, "want:", want)
+			}
+		}
+
+		select {
+		case b := <-released:
+			t.Fatal(c.name, "unexpected message:", string(b))
+		case <-time.After(time.Millisecond * 100):
+		}
+
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrAddressChanged is the cause of the channel closed by ResolveRefreshHandler when the remote address
+// is no longer resolved, it wraps ErrChannelClosed so the channel is closed gracefully.
+var ErrAddressChanged = fmt.Errorf("%w: resolved address changed", ErrChannelClosed)
+
+// AddressChangedEvent is triggered by ResolveRefreshHandler when the resolved addresses of host changed.
+type AddressChangedEvent struct {
+	Host      string
+	Addresses []transport.Addr
+	// Stale the remote address of channel is not in the resolved addresses.
+	Stale bool
+}
+
+// ResolveRefreshOptions defines the options of ResolveRefreshHandler
+type ResolveRefreshOptions struct {
+	// Host the host:port dialed by the channel.
+	Host string `json:"host"`
+	// Resolver resolve the host, transport.DefaultResolver if nil, the ttl reported by
+	// transport.TTLResolver takes the place of Interval.
+	Resolver transport.Resolver `json:"-"`
+	// Interval of re-resolving the host if the ttl is unknown, 30 seconds by default.
+	Interval time.Duration `json:"interval"`
+	// MinInterval bounds the short ttl, 1 second by default.
+	MinInterval time.Duration `json:"minInterval"`
+	// Reconnect close the channel with ErrAddressChanged when the remote address is stale,
+	// so that the reconnect logic of the application dials a fresh address.
+	Reconnect bool `json:"reconnect"`
+}
+
+// ResolveRefreshHandler periodically re-resolve the host of a long-lived client channel, and trigger
+// AddressChangedEvent if the addresses changed, create a new one for each channel.
+func ResolveRefreshHandler(options ResolveRefreshOptions) ChannelInboundHandler {
+	utils.AssertIf("" == options.Host, "host is required")
+	if nil == options.Resolver {
+		options.Resolver = transport.DefaultResolver
+	}
+	if options.Interval <= 0 {
+		options.Interval = 30 * time.Second
+	}
+	if options.MinInterval <= 0 {
+		options.MinInterval = time.Second
+	}
+	return &resolveRefreshHandler{options: options}
+}
+
+type resolveRefreshHandler struct {
+	options   ResolveRefreshOptions
+	mutex     sync.Mutex
+	ctx       HandlerContext
+	clock     Clock
+	timer     Timer
+	addresses []string
+}
+
+func (r *resolveRefreshHandler) HandleActive(ctx ActiveContext) {
+	r.mutex.Lock()
+	r.ctx = ctx
+	r.clock = ClockOf(ctx.Channel())
+	r.addresses = nil
+	r.timer = r.clock.AfterFunc(r.options.Interval, r.refresh)
+	r.mutex.Unlock()
+
+	ctx.HandleActive()
+}
+
+func (r *resolveRefreshHandler) HandleRead(ctx InboundContext, message Message) {
+	ctx.HandleRead(message)
+}
+
+func (r *resolveRefreshHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	r.mutex.Lock()
+	r.ctx = nil
+	if nil != r.timer {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.mutex.Unlock()
+
+	ctx.HandleInactive(ex)
+}
+
+func (r *resolveRefreshHandler) resolve(ctx context.Context) ([]transport.Addr, time.Duration, error) {
+	resolveCtx, cancel := context.WithTimeout(ctx, r.options.Interval)
+	defer cancel()
+
+	if resolver, ok := r.options.Resolver.(transport.TTLResolver); ok {
+		return resolver.ResolveTTL(resolveCtx, r.options.Host)
+	}
+	addresses, err := r.options.Resolver.Resolve(resolveCtx, r.options.Host)
+	return addresses, 0, err
+}
+
+func (r *resolveRefreshHandler) refresh() {
+	r.mutex.Lock()
+	ctx := r.ctx
+	r.mutex.Unlock()
+
+	if nil == ctx {
+		return
+	}
+
+	addresses, ttl, err := r.resolve(ctx.Channel().Context())
+
+	delay := r.options.Interval
+	if ttl > 0 {
+		delay = ttl
+		if delay < r.options.MinInterval {
+			delay = r.options.MinInterval
+		}
+	}
+
+	// keep the channel and the addresses if the resolving failed, e.g. the dns server is unavailable.
+	if nil == err && len(addresses) > 0 {
+		resolved := make([]string, 0, len(addresses))
+		for _, address := range addresses {
+			resolved = append(resolved, address.String())
+		}
+		sort.Strings(resolved)
+
+		remote := ctx.Channel().RemoteAddr()
+		index := sort.SearchStrings(resolved, remote)
+		stale := index == len(resolved) || resolved[index] != remote
+
+		r.mutex.Lock()
+		changed := nil != r.addresses && !equalStrings(r.addresses, resolved)
+		r.addresses = resolved
+		r.mutex.Unlock()
+
+		if changed || stale {
+			func() {
+				defer func() {
+					if err := recover(); nil != err {
+						ctx.Channel().Pipeline().FireChannelException(AsException(err))
+					}
+				}()
+				ctx.Trigger(AddressChangedEvent{Host: r.options.Host, Addresses: addresses, Stale: stale})
+			}()
+		}
+
+		if stale && r.options.Reconnect {
+			ctx.Close(fmt.Errorf("%w: %s is not resolved by %s", ErrAddressChanged, remote, r.options.Host))
+			return
+		}
+	}
+
+	r.mutex.Lock()
+	if nil != r.timer {
+		r.timer.Reset(delay)
+	}
+	r.mutex.Unlock()
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
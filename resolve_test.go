@@ -0,0 +1,159 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// ttlResolver resolves the host to the addresses set by the test.
+type ttlResolver struct {
+	mutex     sync.Mutex
+	addresses []string
+	ttl       time.Duration
+	resolved  int
+}
+
+func (r *ttlResolver) set(ttl time.Duration, addresses ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.addresses, r.ttl = addresses, ttl
+}
+
+func (r *ttlResolver) Resolve(ctx context.Context, host string) ([]transport.Addr, error) {
+	addresses, _, err := r.ResolveTTL(ctx, host)
+	return addresses, err
+}
+
+func (r *ttlResolver) ResolveTTL(ctx context.Context, host string) ([]transport.Addr, time.Duration, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.resolved++
+
+	addresses := make([]transport.Addr, 0, len(r.addresses))
+	for _, address := range r.addresses {
+		addr, err := net.ResolveTCPAddr("tcp", address)
+		if nil != err {
+			return nil, 0, err
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses, r.ttl, nil
+}
+
+func (r *ttlResolver) count() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.resolved
+}
+
+func TestResolveRefreshHandler(t *testing.T) {
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	resolver := &ttlResolver{}
+	resolver.set(0, "127.0.0.1:9527", "127.0.0.2:9527")
+
+	events := make(chan AddressChangedEvent, 4)
+	inactive := make(chan Exception, 1)
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		ActiveHandlerFunc(func(ctx ActiveContext) {
+			ctx.Channel().SetAttribute(ClockAttribute, clock)
+			ctx.HandleActive()
+		}),
+		ResolveRefreshHandler(ResolveRefreshOptions{
+			Host:      "backend.local:9527",
+			Resolver:  resolver,
+			Interval:  time.Minute,
+			Reconnect: true,
+		}),
+		discardHandler{},
+		EventHandlerFunc(func(ctx EventContext, event Event) {
+			if e, ok := event.(AddressChangedEvent); ok {
+				events <- e
+			}
+		}),
+		InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+			inactive <- ex
+		}),
+	)
+	defer peer.Close()
+
+	// the first refresh records the addresses.
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	if 1 != resolver.count() || 0 != len(events) {
+		t.Fatal("unexpected refresh:", resolver.count(), len(events))
+	}
+
+	// the ttl takes the place of interval.
+	resolver.set(5*time.Second, "127.0.0.1:9527", "127.0.0.3:9527")
+	clock.Advance(time.Minute)
+	if 2 != resolver.count() {
+		t.Fatal("unexpected refresh:", resolver.count())
+	}
+
+	select {
+	case e := <-events:
+		if e.Stale || 2 != len(e.Addresses) || "backend.local:9527" != e.Host {
+			t.Fatal("unexpected event:", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("address change not triggered")
+	}
+
+	clock.Advance(4 * time.Second)
+	if 2 != resolver.count() {
+		t.Fatal("refreshed before the ttl expired")
+	}
+
+	// the remote address is gone, reconnect.
+	resolver.set(5*time.Second, "127.0.0.3:9527")
+	clock.Advance(time.Second)
+	if 3 != resolver.count() {
+		t.Fatal("not refreshed after the ttl expired:", resolver.count())
+	}
+
+	select {
+	case e := <-events:
+		if !e.Stale {
+			t.Fatal("remote address should be stale")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stale address not triggered")
+	}
+
+	select {
+	case ex := <-inactive:
+		if !errors.Is(ex, ErrAddressChanged) || CloseByApplication != CloseReasonOf(ex) {
+			t.Fatal("unexpected close reason:", ex)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+
+	if ch.IsActive() || 0 != clock.Timers() {
+		t.Fatal("refresh is not stopped")
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrRetryExhausted is wrapped by the error of a message given up after the max retries.
+var ErrRetryExhausted = errors.New("netty: retry exhausted")
+
+// ErrRetryBufferFull is wrapped by the error of a message dropped since the retry buffer is full.
+var ErrRetryBufferFull = errors.New("netty: retry buffer full")
+
+// Backoff returns the delay before the retry, retry starts from 1.
+type Backoff func(retry int) time.Duration
+
+// ExponentialBackoff doubles the delay from initial for each retry, the delay is at most max.
+func ExponentialBackoff(initial, max time.Duration) Backoff {
+	utils.AssertIf(initial <= 0 || max < initial, "invalid backoff: %s ~ %s", initial, max)
+	return func(retry int) time.Duration {
+		delay := initial
+		for i := 1; i < retry && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		return delay
+	}
+}
+
+// RetryOptions defines the options of RetryHandler
+type RetryOptions struct {
+	// Idempotent returns false if the message must not be sent twice, the failure of it is passed on
+	// as usual, nil to retry any message.
+	Idempotent func(message Message) bool
+	// Transient returns true if the write error is worth retry, nil to retry the timeouts, the full
+	// write queue and the errors of a closed connection.
+	Transient func(err error) bool
+	// MaxBuffered is the max number of messages waiting for retry, default to 1024.
+	MaxBuffered int
+	// Dropped is called with the message given up, the error wraps ErrRetryExhausted or ErrRetryBufferFull,
+	// the reference counted message is released after Dropped returned.
+	Dropped func(message Message, err error)
+}
+
+// RetryHandler re-sends the outbound message failed with a transient error up to maxRetries times,
+// the delay before each retry is given by backoff. the messages failed after the channel is closed
+// are kept until the handler is active again, so add the same handler to the reconnected channels
+// to send them on, the handler holds the retry buffer, so create a new one for each logical connection.
+func RetryHandler(maxRetries int, backoff Backoff, options RetryOptions) ChannelOutboundHandler {
+	utils.AssertIf(maxRetries <= 0, "maxRetries must be a positive integer")
+	utils.AssertIf(nil == backoff, "backoff is required")
+
+	if 0 == options.MaxBuffered {
+		options.MaxBuffered = 1024
+	}
+	utils.AssertIf(options.MaxBuffered < 0, "MaxBuffered must be a positive integer")
+
+	if nil == options.Transient {
+		options.Transient = transientError
+	}
+	return &retryHandler{maxRetries: maxRetries, backoff: backoff, options: options}
+}
+
+type retryMessage struct {
+	message Message
+	retry   int
+}
+
+type retryHandler struct {
+	maxRetries int
+	backoff    Backoff
+	options    RetryOptions
+	mutex      sync.Mutex
+	ctx        OutboundContext // the context of active channel.
+	buffered   int             // the messages waiting for retry.
+	parked     []*retryMessage // the messages to send after active.
+}
+
+func (r *retryHandler) HandleActive(ctx ActiveContext) {
+	r.mutex.Lock()
+	r.ctx = ctx.(OutboundContext)
+	parked := r.parked
+	r.parked = nil
+	r.mutex.Unlock()
+
+	ctx.HandleActive()
+
+	// the activation is not blocked by the writes.
+	if len(parked) > 0 {
+		go func() {
+			for _, m := range parked {
+				r.resend(m)
+			}
+		}()
+	}
+}
+
+func (r *retryHandler) HandleWrite(ctx OutboundContext, message Message) {
+	err := r.write(ctx, message)
+	if nil == err {
+		releaseMessage(message)
+		return
+	}
+
+	// pass on the failure as usual.
+	if !r.options.Transient(err) || (nil != r.options.Idempotent && !r.options.Idempotent(message)) {
+		panic(err)
+	}
+
+	r.mutex.Lock()
+	if r.buffered >= r.options.MaxBuffered {
+		r.mutex.Unlock()
+		r.drop(message, fmt.Errorf("%w: %d messages buffered: %v", ErrRetryBufferFull, r.options.MaxBuffered, err))
+		return
+	}
+	r.buffered++
+	r.mutex.Unlock()
+
+	r.schedule(ctx, &retryMessage{message: message, retry: 1})
+}
+
+func (r *retryHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	r.mutex.Lock()
+	r.ctx = nil
+	r.mutex.Unlock()
+	ctx.HandleInactive(ex)
+}
+
+// write the message with the failure recovered, the reference counted message is retained for each write,
+// so the message held by the handler is still valid after the write released it.
+func (r *retryHandler) write(ctx OutboundContext, message Message) (err error) {
+	if rc, ok := message.(utils.ReferenceCounted); ok {
+		rc.Retain()
+		defer func() {
+			if nil != err {
+				rc.Release()
+			}
+		}()
+	}
+
+	defer func() {
+		if e := recover(); nil != e {
+			err = AsException(e)
+		}
+	}()
+
+	ctx.HandleWrite(message)
+	return nil
+}
+
+// rewrite the message out of the outbound traversal, it is serialized with the writes of channel.
+func (r *retryHandler) rewrite(ctx OutboundContext, message Message) error {
+	return serializeWrites(ctx, func() error { return r.write(ctx, message) })
+}
+
+// schedule the retry after the backoff.
+func (r *retryHandler) schedule(ctx OutboundContext, m *retryMessage) {
+	ClockOf(ctx.Channel()).AfterFunc(r.backoff(m.retry), func() {
+		r.resend(m)
+	})
+}
+
+// resend the message by the active channel, or park it until active.
+func (r *retryHandler) resend(m *retryMessage) {
+	r.mutex.Lock()
+	ctx := r.ctx
+	if nil == ctx {
+		r.parked = append(r.parked, m)
+		r.mutex.Unlock()
+		return
+	}
+	r.mutex.Unlock()
+
+	err := r.rewrite(ctx, m.message)
+	if nil != err && r.options.Transient(err) && m.retry < r.maxRetries {
+		m.retry++
+		r.schedule(ctx, m)
+		return
+	}
+
+	r.mutex.Lock()
+	r.buffered--
+	r.mutex.Unlock()
+
+	if nil != err {
+		r.drop(m.message, fmt.Errorf("%w: %d retries: %v", ErrRetryExhausted, m.retry, err))
+		return
+	}
+	releaseMessage(m.message)
+}
+
+func (r *retryHandler) drop(message Message, err error) {
+	if nil != r.options.Dropped {
+		r.options.Dropped(message, err)
+	}
+	releaseMessage(message)
+}
+
+// transientError returns true if the error may be recovered by retry or reconnection.
+func transientError(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) ||
+		errors.Is(err, ErrAsyncNoSpace) ||
+		errors.Is(err, ErrChannelClosed) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+// failWrites fails the first n writes with err, and records the time of writes.
+type failWrites struct {
+	mutex  sync.Mutex
+	n      int
+	err    error
+	writes []time.Time
+}
+
+func (f *failWrites) HandleWrite(ctx OutboundContext, message Message) {
+	f.mutex.Lock()
+	f.writes = append(f.writes, time.Now())
+	fail := len(f.writes) <= f.n
+	f.mutex.Unlock()
+
+	if fail {
+		panic(f.err)
+	}
+	ctx.HandleWrite(message)
+}
+
+func (f *failWrites) count() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.writes)
+}
+
+// readPeer to read the messages written to the peer.
+func readPeer(peer net.Conn) <-chan string {
+	messages := make(chan string, 16)
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			n, err := peer.Read(buffer)
+			if nil != err {
+				return
+			}
+			messages <- string(buffer[:n])
+		}
+	}()
+	return messages
+}
+
+func TestRetryHandler(t *testing.T) {
+
+	failing := &failWrites{n: 2, err: os.ErrDeadlineExceeded}
+	dropped := make(chan error, 1)
+	backoff := func(retry int) time.Duration { return time.Duration(retry) * 20 * time.Millisecond }
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", failing, RetryHandler(3, backoff, RetryOptions{
+		Dropped: func(message Message, err error) { dropped <- err },
+	}))
+	defer ch.Close(nil)
+	messages := readPeer(peer)
+
+	if err := ch.Write([]byte("hello")); nil != err {
+		t.Fatal(err)
+	}
+
+	select {
+	case m := <-messages:
+		if "hello" != m {
+			t.Fatal("unexpected message:", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not retried")
+	}
+
+	// the delays of retries follow the backoff.
+	for i := 1; i < len(failing.writes); i++ {
+		if delay := failing.writes[i].Sub(failing.writes[i-1]); delay < backoff(i) {
+			t.Fatalf("retry #%d delayed: %s, want: %s", i, delay, backoff(i))
+		}
+	}
+
+	// give up after the max retries.
+	failing.mutex.Lock()
+	failing.n, failing.writes = 100, nil
+	failing.mutex.Unlock()
+
+	if err := ch.Write([]byte("hello")); nil != err {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-dropped:
+		if !errors.Is(err, ErrRetryExhausted) {
+			t.Fatal("unexpected error:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not given up")
+	}
+
+	if n := failing.count(); 4 != n {
+		t.Fatal("writes:", n, "want: 4")
+	}
+}
+
+func TestRetryHandlerReleasesBuffer(t *testing.T) {
+
+	failing := &failWrites{n: 2, err: os.ErrDeadlineExceeded}
+	backoff := func(retry int) time.Duration { return time.Millisecond }
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", failing, RetryHandler(3, backoff, RetryOptions{}))
+	defer ch.Close(nil)
+	messages := readPeer(peer)
+
+	buffer := pbytes.NewBuffer(5)
+	copy(buffer.Bytes(), "hello")
+	// hold a reference to check the release.
+	buffer.Retain()
+
+	if err := ch.Write(buffer); nil != err {
+		t.Fatal(err)
+	}
+
+	select {
+	case m := <-messages:
+		if "hello" != m {
+			t.Fatal("unexpected message:", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not retried")
+	}
+
+	// the buffer is released once after written, but not by the failed writes.
+	for deadline := time.Now().Add(time.Second); 1 != buffer.RefCnt() && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if 1 != buffer.RefCnt() {
+		t.Fatal("unexpected reference count:", buffer.RefCnt())
+	}
+	buffer.Release()
+}
+
+func TestRetryHandlerSkipped(t *testing.T) {
+
+	failing := &failWrites{n: 100, err: os.ErrDeadlineExceeded}
+	dropped := make(chan error, 4)
+	exceptions := make(chan Exception, 4)
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+		exceptions <- ex
+	}), failing, RetryHandler(3, ExponentialBackoff(time.Millisecond*10, time.Second), RetryOptions{
+		Idempotent:  func(message Message) bool { return "idempotent" == string(message.([]byte)) },
+		MaxBuffered: 1,
+		Dropped:     func(message Message, err error) { dropped <- err },
+	}))
+	defer ch.Close(nil)
+	defer peer.Close()
+
+	// the failure of non-idempotent message is passed on.
+	if err := ch.Write([]byte("once")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatal("unexpected error:", err)
+	}
+
+	select {
+	case ex := <-exceptions:
+		if !errors.Is(ex, os.ErrDeadlineExceeded) {
+			t.Fatal("unexpected exception:", ex)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("failure is not passed on")
+	}
+
+	// the retry buffer is bounded.
+	_ = ch.Write([]byte("idempotent"))
+	_ = ch.Write([]byte("idempotent"))
+
+	select {
+	case err := <-dropped:
+		if !errors.Is(err, ErrRetryBufferFull) {
+			t.Fatal("unexpected error:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not dropped")
+	}
+}
+
+func TestRetryHandlerReconnect(t *testing.T) {
+
+	retry := RetryHandler(3, ExponentialBackoff(time.Millisecond*10, time.Second), RetryOptions{})
+
+	// the first channel is broken.
+	var broken int32
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", OutboundHandlerFunc(func(ctx OutboundContext, message Message) {
+		if 0 == atomic.LoadInt32(&broken) {
+			atomic.StoreInt32(&broken, 1)
+			ctx.Close(io.EOF)
+		}
+		ctx.HandleWrite(message)
+	}), discardHandler{}, retry)
+	defer peer.Close()
+
+	if err := ch.Write([]byte("hello")); nil != err {
+		t.Fatal(err)
+	}
+
+	// wait for the retry to be parked.
+	time.Sleep(time.Millisecond * 50)
+
+	reconnected, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{}, retry)
+	defer reconnected.Close(nil)
+
+	select {
+	case m := <-readPeer(peer):
+		if "hello" != m {
+			t.Fatal("unexpected message:", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message is not sent by the reconnected channel")
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Millisecond*10, time.Millisecond*50)
+	for retry, want := range []time.Duration{10, 20, 40, 50, 50} {
+		if delay := backoff(retry + 1); want*time.Millisecond != delay {
+			t.Fatalf("retry #%d: %s, want: %s", retry+1, delay, want*time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// SlowStartHandler ramps up the send rate of channel linearly from initialRate to maxRate bytes
+// per second over the warmup period since the channel is active, the writes exceeding the allowed
+// rate are queued and written by a timer in order, so the writers are never blocked, and the handler
+// becomes a no-op after warmed up. add it next to the head, so that the encoded bytes are counted,
+// and create a new one for each channel.
+func SlowStartHandler(initialRate, maxRate int, warmup time.Duration) ChannelOutboundHandler {
+	utils.AssertIf(initialRate <= 0 || maxRate < initialRate, "invalid rate: %d -> %d", initialRate, maxRate)
+	utils.AssertIf(warmup <= 0, "warmup must be a positive duration")
+	return &slowStartHandler{initialRate: float64(initialRate), maxRate: float64(maxRate), warmup: warmup}
+}
+
+// scheduledWrite is a write queued until the due time.
+type scheduledWrite struct {
+	message Message
+	due     time.Time
+}
+
+type slowStartHandler struct {
+	mutex       sync.Mutex
+	initialRate float64
+	maxRate     float64
+	warmup      time.Duration
+	start       time.Time
+	clock       Clock
+	sent        float64
+	warmed      bool
+	closed      bool
+	ctx         OutboundContext
+	queue       []scheduledWrite
+	timer       Timer
+}
+
+func (s *slowStartHandler) HandleActive(ctx ActiveContext) {
+	s.mutex.Lock()
+	s.clock = ClockOf(ctx.Channel())
+	s.start = s.clock.Now()
+	s.mutex.Unlock()
+	ctx.HandleActive()
+}
+
+func (s *slowStartHandler) HandleWrite(ctx OutboundContext, message Message) {
+
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		releaseMessage(message)
+		return
+	}
+
+	// warmed up if the channel is activated before the handler is added, the queued writes go first.
+	if s.warmed || nil == s.clock || (0 == len(s.queue) && s.clock.Now().Sub(s.start) >= s.warmup) {
+		s.warmed = true
+		s.mutex.Unlock()
+		ctx.HandleWrite(message)
+		return
+	}
+
+	// the readers are drained to count the bytes.
+	var size int
+	switch r := message.(type) {
+	case []byte:
+		size = len(r)
+	case [][]byte:
+		size = int(utils.CountOf(r))
+	case interface{ Len() int }:
+		size = r.Len()
+	default:
+		message = utils.MustToBytes(message)
+		size = len(message.([]byte))
+	}
+
+	// reserve the bytes, the concurrent writes are scheduled in order.
+	s.sent += float64(size)
+	due := s.start.Add(s.scheduleAt(s.sent))
+	if 0 == len(s.queue) && !due.After(s.clock.Now()) {
+		s.mutex.Unlock()
+		ctx.HandleWrite(message)
+		return
+	}
+
+	s.ctx = ctx
+	s.queue = append(s.queue, scheduledWrite{message: message, due: due})
+	if nil == s.timer {
+		s.timer = s.clock.AfterFunc(s.queue[0].due.Sub(s.clock.Now()), s.drain)
+	}
+	s.mutex.Unlock()
+}
+
+func (s *slowStartHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	s.mutex.Lock()
+	s.closed = true
+	queue := s.queue
+	s.queue, s.ctx = nil, nil
+	if nil != s.timer {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.mutex.Unlock()
+
+	// the queued writes are dropped.
+	for _, w := range queue {
+		releaseMessage(w.message)
+	}
+	ctx.HandleInactive(ex)
+}
+
+// drain write the queued messages which are due, and wait for the next one.
+func (s *slowStartHandler) drain() {
+	for {
+		s.mutex.Lock()
+		if 0 == len(s.queue) || nil == s.ctx {
+			s.timer = nil
+			s.mutex.Unlock()
+			return
+		}
+
+		if wait := s.queue[0].due.Sub(s.clock.Now()); wait > 0 {
+			s.timer.Reset(wait)
+			s.mutex.Unlock()
+			return
+		}
+
+		ctx, message := s.ctx, s.queue[0].message
+		s.queue[0] = scheduledWrite{}
+		s.queue = s.queue[1:]
+		s.mutex.Unlock()
+
+		// the failed message is held by the handler, so it is released here.
+		if err := writeSerialized(ctx, message); nil != err {
+			releaseMessage(message)
+			ctx.Channel().Pipeline().FireChannelException(AsException(err))
+		}
+	}
+}
+
+// scheduleAt returns the earliest time since the start that the total bytes are allowed to send,
+// the allowed bytes is the integral of rate: initialRate*t + (maxRate-initialRate)*t^2/(2*warmup).
+func (s *slowStartHandler) scheduleAt(total float64) time.Duration {
+
+	w := s.warmup.Seconds()
+	a := (s.maxRate - s.initialRate) / (2 * w)
+	b := s.initialRate
+
+	var t float64
+	if warmed := b*w + a*w*w; total > warmed {
+		t = w + (total-warmed)/s.maxRate
+	} else if 0 == a {
+		t = total / b
+	} else {
+		t = (-b + math.Sqrt(b*b+4*a*total)) / (2 * a)
+	}
+	return time.Duration(t * float64(time.Second))
+}
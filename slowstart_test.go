@@ -0,0 +1,151 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+func TestSlowStartSchedule(t *testing.T) {
+
+	h := SlowStartHandler(1000, 10000, time.Second).(*slowStartHandler)
+
+	// 1000 + (10000-1000)/2 bytes are allowed in the warm-up period.
+	if at := h.scheduleAt(5500); time.Second != at {
+		t.Fatal("unexpected end of warm-up:", at)
+	}
+
+	if at := h.scheduleAt(15500); 2*time.Second != at {
+		t.Fatal("unexpected schedule after warm-up:", at)
+	}
+
+	// the interval of equal chunks decreases as the rate increases.
+	last := time.Duration(0)
+	interval := time.Duration(math.MaxInt64)
+	for total := 500.0; total <= 5500; total += 500 {
+		at := h.scheduleAt(total)
+		if at-last >= interval {
+			t.Fatalf("interval of %v bytes is not decreased: %v >= %v", total, at-last, interval)
+		}
+		interval, last = at-last, at
+	}
+}
+
+func TestSlowStartHandler(t *testing.T) {
+
+	// 22000 bytes are allowed in the warm-up period.
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		SlowStartHandler(20000, 200000, time.Millisecond*200), discardHandler{})
+	defer ch.Close(nil)
+
+	const chunks = 44
+	arrivals := make(chan time.Time, chunks+1)
+	go func() {
+		buffer := make([]byte, 500)
+		for {
+			if _, err := io.ReadFull(peer, buffer); nil != err {
+				return
+			}
+			arrivals <- time.Now()
+		}
+	}()
+
+	start := time.Now()
+	for i := 0; i < chunks; i++ {
+		if err := ch.Write(make([]byte, 500)); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	// the writers are not blocked by the delayed writes.
+	if elapsed := time.Since(start); elapsed > time.Millisecond*50 {
+		t.Fatal("writes are blocked:", elapsed)
+	}
+
+	var times []time.Time
+	for i := 0; i < chunks; i++ {
+		times = append(times, <-arrivals)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Millisecond*150 {
+		t.Fatal("writes are not delayed:", elapsed)
+	}
+
+	if early, late := times[10].Sub(times[0]), times[40].Sub(times[30]); late >= early {
+		t.Fatalf("send rate is not increased: %v >= %v", late, early)
+	}
+
+	// no-op after warmed up.
+	start = time.Now()
+	for i := 0; i < chunks; i++ {
+		if err := ch.Write(make([]byte, 500)); nil != err {
+			t.Fatal(err)
+		}
+		<-arrivals
+	}
+	if elapsed := time.Since(start); elapsed > time.Millisecond*100 {
+		t.Fatal("writes are delayed after warm-up:", elapsed)
+	}
+}
+
+func TestSlowStartHandlerClose(t *testing.T) {
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		ActiveHandlerFunc(func(ctx ActiveContext) {
+			ctx.Channel().SetAttribute(ClockAttribute, clock)
+			ctx.HandleActive()
+		}),
+		SlowStartHandler(1000, 2000, time.Second), discardHandler{})
+	defer peer.Close()
+
+	// the writes are queued until the clock advances.
+	var buffers []*pbytes.Buffer
+	for i := 0; i < 3; i++ {
+		buffer := pbytes.NewBuffer(1000)
+		// hold a reference to check the release.
+		buffer.Retain()
+		buffers = append(buffers, buffer)
+		if err := ch.Write(buffer); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	if 1 != clock.Timers() {
+		t.Fatal("writes are not scheduled:", clock.Timers())
+	}
+
+	// the queued writes are released after closed.
+	ch.Close(nil)
+	for _, buffer := range buffers {
+		for deadline := time.Now().Add(time.Second); 1 != buffer.RefCnt(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("queued write is not released:", buffer.RefCnt())
+			}
+		}
+		buffer.Release()
+	}
+
+	if 0 != clock.Timers() {
+		t.Fatal("timer is not stopped")
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import "crypto/tls"
+
+const (
+	// TLSStateAttribute holds the tls.ConnectionState of the channel over tls.
+	TLSStateAttribute AttributeKey = "netty.tls-state"
+
+	// NegotiatedProtocolAttribute holds the application protocol negotiated by ALPN.
+	NegotiatedProtocolAttribute AttributeKey = "netty.negotiated-protocol"
+
+	// PeerIdentityAttribute holds the identity of peer authenticated by the transport, e.g. tcp.Options.VerifyPeer
+	PeerIdentityAttribute AttributeKey = "netty.peer-identity"
+)
+
+// peerIdentity defines the transport which could report the authenticated identity of peer.
+type peerIdentity interface {
+	PeerIdentity() interface{}
+}
+
+// tlsConn defines the connection which could report the tls state, e.g. *tls.Conn
+type tlsConn interface {
+	ConnectionState() tls.ConnectionState
+}
+
+// exposeTLSState to set the tls attributes of channel, returns the negotiated protocol.
+func exposeTLSState(ch Channel) string {
+	if p, ok := ch.Transport().(peerIdentity); ok {
+		if identity := p.PeerIdentity(); nil != identity {
+			ch.SetAttribute(PeerIdentityAttribute, identity)
+		}
+	}
+
+	conn, ok := ch.Transport().RawTransport().(tlsConn)
+	if !ok {
+		return ""
+	}
+
+	state := conn.ConnectionState()
+	ch.SetAttribute(TLSStateAttribute, state)
+	if "" != state.NegotiatedProtocol {
+		ch.SetAttribute(NegotiatedProtocolAttribute, state.NegotiatedProtocol)
+	}
+	return state.NegotiatedProtocol
+}
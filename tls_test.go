@@ -0,0 +1,321 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport/tcp"
+)
+
+// newTestCertificate create a self-signed certificate for 127.0.0.1
+func newTestCertificate(commonName string, uris ...string) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if nil != err {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+	}
+
+	for _, uri := range uris {
+		u, err := url.Parse(uri)
+		if nil != err {
+			panic(err)
+		}
+		template.URIs = append(template.URIs, u)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if nil != err {
+		panic(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if nil != err {
+		panic(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestTLSProtocolNegotiation(t *testing.T) {
+
+	cert, pool := newTestCertificate("go-netty")
+
+	negotiated := make(chan string, 4)
+	recordInitializer := func(name string) ChannelInitializer {
+		return func(ch Channel) {
+			if p, _ := ch.Attribute(NegotiatedProtocolAttribute).(string); p != name {
+				t.Errorf("initializer of %s got protocol: %s", name, p)
+			}
+			negotiated <- name
+			ch.Pipeline().AddLast(discardHandler{})
+		}
+	}
+
+	bs := NewBootstrap(
+		WithChildInitializer(recordInitializer("http/1.1")),
+		WithClientInitializer(func(ch Channel) { ch.Pipeline().AddLast(discardHandler{}) }),
+		WithProtocolInitializer("h2", recordInitializer("h2")),
+	)
+	defer bs.Shutdown()
+
+	bs.Listen("127.0.0.1:9531", tcp.WithOptions(&tcp.Options{
+		Timeout: time.Second,
+		TLS:     &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h2", "http/1.1"}},
+	})).Async(func(err error) {})
+
+	dial := func(protocols ...string) (Channel, error) {
+		return connectRetry(bs, "tcp://127.0.0.1:9531", tcp.WithOptions(&tcp.Options{
+			Timeout: time.Second,
+			TLS:     &tls.Config{RootCAs: pool, NextProtos: protocols},
+		}))
+	}
+
+	var cases = []struct {
+		protocols []string
+		want      string
+	}{
+		{protocols: []string{"h2", "http/1.1"}, want: "h2"},
+		{protocols: []string{"http/1.1"}, want: "http/1.1"},
+	}
+
+	for _, c := range cases {
+		ch, err := dial(c.protocols...)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if p := ch.Attribute(NegotiatedProtocolAttribute); p != c.want {
+			t.Fatal("client negotiated:", p, "want:", c.want)
+		}
+
+		if _, ok := ch.Attribute(TLSStateAttribute).(tls.ConnectionState); !ok {
+			t.Fatal("tls state not exposed")
+		}
+
+		select {
+		case p := <-negotiated:
+			if p != c.want {
+				t.Fatal("server negotiated:", p, "want:", c.want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("server channel not initialized")
+		}
+		ch.Close(nil)
+	}
+
+	// no common protocol
+	if _, err := bs.Connect("tcp://127.0.0.1:9531", tcp.WithOptions(&tcp.Options{
+		Timeout: time.Second,
+		TLS:     &tls.Config{RootCAs: pool, NextProtos: []string{"h3"}},
+	})); nil == err {
+		t.Fatal("expect negotiation failure")
+	}
+}
+
+func TestTLSPeerVerification(t *testing.T) {
+
+	serverCert, serverPool := newTestCertificate("go-netty")
+	goodCert, clientCAs := newTestCertificate("service-a", "spiffe://example.org/service/a")
+	badCert, _ := newTestCertificate("service-b", "spiffe://evil.org/service/b")
+
+	// trust both client certificates, the trust domain is checked by VerifyPeer.
+	clientCAs.AddCert(badCert.Leaf)
+
+	verifyPeer := func(state tls.ConnectionState) (interface{}, error) {
+		for _, uri := range state.VerifiedChains[0][0].URIs {
+			if "spiffe" == uri.Scheme && "example.org" == uri.Host {
+				return uri.String(), nil
+			}
+		}
+		return nil, errors.New("untrusted spiffe id")
+	}
+
+	identities := make(chan interface{}, 2)
+	bs := NewBootstrap(
+		WithChildInitializer(func(ch Channel) {
+			identities <- ch.Attribute(PeerIdentityAttribute)
+			ch.Pipeline().AddLast(discardHandler{})
+		}),
+		WithClientInitializer(func(ch Channel) {
+			ch.Pipeline().AddLast(discardHandler{}, ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+				ctx.Close(ex)
+			}))
+		}),
+	)
+	defer bs.Shutdown()
+
+	bs.Listen("127.0.0.1:9533", tcp.WithOptions(&tcp.Options{
+		Timeout: time.Second,
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    clientCAs,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		},
+		VerifyPeer: verifyPeer,
+	})).Async(func(err error) {})
+
+	dial := func(cert tls.Certificate) (Channel, error) {
+		return connectRetry(bs, "tcp://127.0.0.1:9533", tcp.WithOptions(&tcp.Options{
+			Timeout: time.Second,
+			TLS:     &tls.Config{RootCAs: serverPool, Certificates: []tls.Certificate{cert}},
+		}))
+	}
+
+	ch, err := dial(goodCert)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer ch.Close(nil)
+
+	select {
+	case identity := <-identities:
+		if "spiffe://example.org/service/a" != identity {
+			t.Fatal("unexpected identity:", identity)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("server channel not initialized")
+	}
+
+	// the client of tls 1.3 finishes the handshake before the server rejects it.
+	if ch, err := dial(badCert); nil == err {
+		select {
+		case <-ch.Context().Done():
+		case <-time.After(time.Second):
+			t.Fatal("rejected client is still active")
+		}
+	}
+
+	select {
+	case identity := <-identities:
+		t.Fatal(fmt.Sprint("rejected client is served: ", identity))
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+// recordConn records the bytes read from the connection.
+type recordConn struct {
+	net.Conn
+	received []byte
+}
+
+func (r *recordConn) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	r.received = append(r.received, p[:n]...)
+	return n, err
+}
+
+// hasAlert to check whether there is an alert record in the tls 1.2 records.
+func (r *recordConn) hasAlert() bool {
+	for records := r.received; len(records) >= 5; {
+		if 21 == records[0] {
+			return true
+		}
+		length := 5 + (int(records[3])<<8 | int(records[4]))
+		if length > len(records) {
+			break
+		}
+		records = records[length:]
+	}
+	return false
+}
+
+func TestTLSCloseNotify(t *testing.T) {
+
+	cert, pool := newTestCertificate("go-netty")
+
+	causes := make(chan error, 1)
+	bs := NewBootstrap(WithChildInitializer(func(ch Channel) {
+		ch.Pipeline().AddLast(discardHandler{}, ActiveHandlerFunc(func(ctx ActiveContext) {
+			ctx.Close(<-causes)
+		}))
+	}))
+	defer bs.Shutdown()
+
+	bs.Listen("127.0.0.1:9563", tcp.WithOptions(&tcp.Options{
+		Timeout: time.Second,
+		// the zero linger resets the connection, the unread alert is discarded by the client.
+		Linger: -1,
+		// the alert record is not encrypted as application data in tls 1.2.
+		TLS: &tls.Config{Certificates: []tls.Certificate{cert}, MaxVersion: tls.VersionTLS12},
+	})).Async(func(err error) {})
+
+	var cases = []struct {
+		cause       error
+		closeNotify bool
+	}{
+		{cause: nil, closeNotify: true},
+		{cause: errors.New("protocol error"), closeNotify: false},
+	}
+
+	for _, c := range cases {
+		var conn net.Conn
+		var err error
+		for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 20) {
+			if conn, err = net.Dial("tcp", "127.0.0.1:9563"); nil == err {
+				break
+			}
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		causes <- c.cause
+		raw := &recordConn{Conn: conn}
+		client := tls.Client(raw, &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"})
+		_ = client.SetDeadline(time.Now().Add(time.Second))
+		if err := client.Handshake(); nil != err {
+			t.Fatal(err)
+		}
+
+		// read until the server closed.
+		if _, err := client.Read(make([]byte, 16)); nil == err {
+			t.Fatal("expect the connection closed")
+		}
+		_ = client.Close()
+
+		if raw.hasAlert() != c.closeNotify {
+			t.Fatalf("close by %v: close_notify sent: %v, want: %v", c.cause, !c.closeNotify, c.closeNotify)
+		}
+	}
+}
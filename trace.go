@@ -0,0 +1,143 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// TraceKind defines the kind of recorded event
+type TraceKind int
+
+// the kinds of recorded events.
+const (
+	TraceActive TraceKind = iota
+	TraceRead
+	TraceWrite
+	TraceUserEvent
+	TraceException
+	TraceInactive
+)
+
+func (k TraceKind) String() string {
+	switch k {
+	case TraceActive:
+		return "active"
+	case TraceRead:
+		return "read"
+	case TraceWrite:
+		return "write"
+	case TraceUserEvent:
+		return "event"
+	case TraceException:
+		return "exception"
+	case TraceInactive:
+		return "inactive"
+	default:
+		return "unknown"
+	}
+}
+
+// TraceRecord defines a recorded event of channel
+type TraceRecord struct {
+	Time time.Time
+	Kind TraceKind
+	// Value is the reflect.Type of message for read & write, the Event for user event,
+	// and the Exception for exception & inactive, the messages are not retained.
+	Value interface{}
+}
+
+// Tracer defines a handler records the event timeline of channel
+type Tracer interface {
+	ChannelHandler
+	EventHandler
+
+	// Events returns a snapshot of the recorded events, the oldest first.
+	Events() []TraceRecord
+}
+
+// TraceHandler create a Tracer keeps the latest capacity events in a ring buffer, it passes
+// all the events on, add it first to trace the raw bytes, and create a new one for each channel.
+func TraceHandler(capacity int) Tracer {
+	utils.AssertIf(capacity <= 0, "capacity must be a positive integer")
+	return &traceHandler{records: make([]TraceRecord, capacity)}
+}
+
+type traceHandler struct {
+	mutex   sync.Mutex
+	records []TraceRecord
+	next    int
+	full    bool
+}
+
+func (t *traceHandler) record(kind TraceKind, value interface{}) {
+	now := time.Now()
+
+	t.mutex.Lock()
+	t.records[t.next] = TraceRecord{Time: now, Kind: kind, Value: value}
+	if t.next++; t.next == len(t.records) {
+		t.next, t.full = 0, true
+	}
+	t.mutex.Unlock()
+}
+
+func (t *traceHandler) Events() []TraceRecord {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.full {
+		return append([]TraceRecord(nil), t.records[:t.next]...)
+	}
+
+	events := make([]TraceRecord, 0, len(t.records))
+	events = append(events, t.records[t.next:]...)
+	return append(events, t.records[:t.next]...)
+}
+
+func (t *traceHandler) HandleActive(ctx ActiveContext) {
+	t.record(TraceActive, nil)
+	ctx.HandleActive()
+}
+
+func (t *traceHandler) HandleRead(ctx InboundContext, message Message) {
+	t.record(TraceRead, reflect.TypeOf(message))
+	ctx.HandleRead(message)
+}
+
+func (t *traceHandler) HandleWrite(ctx OutboundContext, message Message) {
+	t.record(TraceWrite, reflect.TypeOf(message))
+	ctx.HandleWrite(message)
+}
+
+func (t *traceHandler) HandleEvent(ctx EventContext, event Event) {
+	t.record(TraceUserEvent, event)
+	ctx.HandleEvent(event)
+}
+
+func (t *traceHandler) HandleException(ctx ExceptionContext, ex Exception) {
+	t.record(TraceException, ex)
+	ctx.HandleException(ex)
+}
+
+func (t *traceHandler) HandleInactive(ctx InactiveContext, ex Exception) {
+	t.record(TraceInactive, ex)
+	ctx.HandleInactive(ex)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+func TestTraceHandler(t *testing.T) {
+
+	tracer := TraceHandler(16)
+	inactive := make(chan struct{})
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		tracer,
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			buffer := make([]byte, 4)
+			ctx.Write(buffer[:utils.AssertLength(utils.MustToReader(message).Read(buffer))])
+			ctx.Channel().Trigger("ping")
+			panic(errors.New("bang"))
+		}),
+		ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+			ctx.Close(ex)
+		}),
+		InactiveHandlerFunc(func(ctx InactiveContext, ex Exception) {
+			close(inactive)
+		}),
+	)
+	defer peer.Close()
+
+	go func() {
+		_, _ = peer.Write([]byte("ping"))
+		_, _ = io.Copy(io.Discard, peer)
+	}()
+
+	select {
+	case <-inactive:
+	case <-time.After(time.Second):
+		t.Fatal("channel is not closed")
+	}
+
+	var kinds []TraceKind
+	events := tracer.Events()
+	for _, event := range events {
+		kinds = append(kinds, event.Kind)
+	}
+
+	expect := []TraceKind{TraceActive, TraceRead, TraceWrite, TraceUserEvent, TraceException, TraceInactive}
+	if fmt.Sprint(expect) != fmt.Sprint(kinds) {
+		t.Fatal(kinds, "!=", expect)
+	}
+
+	if events[1].Value != reflect.TypeOf(ch.(*channel).reader) || "ping" != events[3].Value {
+		t.Fatal("unexpected values:", events[1].Value, events[3].Value)
+	}
+
+	for i := 1; i < len(events); i++ {
+		if events[i].Time.Before(events[i-1].Time) {
+			t.Fatal("events are not in order")
+		}
+	}
+}
+
+func TestTraceHandlerRing(t *testing.T) {
+
+	tracer := TraceHandler(3).(*traceHandler)
+	for kind := TraceActive; kind <= TraceInactive; kind++ {
+		tracer.record(kind, nil)
+	}
+
+	events := tracer.Events()
+	if 3 != len(events) || TraceUserEvent != events[0].Kind || TraceInactive != events[2].Kind {
+		t.Fatal("unexpected events:", events)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"bufio"
+	"io"
+	"net"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// AdaptiveReader is a buffered reader which adapts the buffer size to the size of reads, like the
+// AdaptiveRecvByteBufAllocator of Netty, the buffer is doubled if a read fills it up, and halved if
+// two consecutive reads use less than half of it, the size is always within [min, max].
+type AdaptiveReader struct {
+	reader   io.Reader
+	buffer   []byte
+	r, w     int
+	min, max int
+	size     int
+	shrink   bool
+}
+
+// NewAdaptiveReader create an AdaptiveReader starts with the initial size.
+func NewAdaptiveReader(reader io.Reader, min, initial, max int) *AdaptiveReader {
+	utils.AssertIf(min <= 0 || min > max, "invalid bounds of buffer size: [%d, %d]", min, max)
+	if initial < min {
+		initial = min
+	} else if initial > max {
+		initial = max
+	}
+	return &AdaptiveReader{reader: reader, min: min, max: max, size: initial}
+}
+
+// Size of the buffer used by the next read.
+func (a *AdaptiveReader) Size() int {
+	return a.size
+}
+
+// Buffered returns the number of bytes that can be read from the buffer.
+func (a *AdaptiveReader) Buffered() int {
+	return a.w - a.r
+}
+
+// Read to impl io.Reader
+func (a *AdaptiveReader) Read(p []byte) (n int, err error) {
+
+	if 0 == len(p) {
+		return 0, nil
+	}
+
+	if a.r < a.w {
+		n = copy(p, a.buffer[a.r:a.w])
+		a.r += n
+		return n, nil
+	}
+
+	// large read, read into p directly to avoid copy.
+	if len(p) >= a.size {
+		n, err = a.reader.Read(p[:a.size])
+		a.record(n)
+		return n, err
+	}
+
+	// the buffer is empty, resize it for the next read.
+	if len(a.buffer) != a.size {
+		a.buffer = make([]byte, a.size)
+	}
+
+	a.r, a.w = 0, 0
+	if a.w, err = a.reader.Read(a.buffer); a.w > 0 {
+		a.record(a.w)
+		n = copy(p, a.buffer[:a.w])
+		a.r = n
+	}
+	return n, err
+}
+
+// record to adapt the buffer size to the number of bytes read.
+func (a *AdaptiveReader) record(n int) {
+	switch {
+	case n >= a.size:
+		a.shrink = false
+		if a.size *= 2; a.size > a.max {
+			a.size = a.max
+		}
+	case n < a.size/2:
+		if a.shrink {
+			if a.size /= 2; a.size < a.min {
+				a.size = a.min
+			}
+		}
+		a.shrink = !a.shrink
+	default:
+		a.shrink = false
+	}
+}
+
+// NewAdaptiveTransport create a transport reads by the AdaptiveReader, the writer is buffered if writeSize > 0.
+func NewAdaptiveTransport(conn net.Conn, minRead, initialRead, maxRead, writeSize int) Transport {
+	adaptive := &adaptiveConn{Conn: conn, reader: NewAdaptiveReader(conn, minRead, initialRead, maxRead)}
+	if writeSize > 0 {
+		adaptive.writer = bufio.NewWriterSize(conn, writeSize)
+	}
+	return adaptive
+}
+
+type adaptiveConn struct {
+	net.Conn
+	reader *AdaptiveReader
+	writer *bufio.Writer
+}
+
+func (a *adaptiveConn) Close() error {
+	_ = a.Flush()
+	return a.Conn.Close()
+}
+
+func (a *adaptiveConn) Read(p []byte) (n int, err error) {
+	return a.reader.Read(p)
+}
+
+func (a *adaptiveConn) Write(p []byte) (n int, err error) {
+	if nil == a.writer {
+		return a.Conn.Write(p)
+	}
+	return a.writer.Write(p)
+}
+
+func (a *adaptiveConn) Writev(buffs Buffers) (int64, error) {
+	if nil == a.writer {
+		return buffs.Buffers.WriteTo(a.Conn)
+	}
+	return buffs.Buffers.WriteTo(a.writer)
+}
+
+func (a *adaptiveConn) Flush() error {
+	if nil == a.writer {
+		return nil
+	}
+	return a.writer.Flush()
+}
+
+func (a *adaptiveConn) Buffered() int {
+	return a.reader.Buffered()
+}
+
+func (a *adaptiveConn) RawTransport() interface{} {
+	return a.Conn
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// chunkReader returns at most the size of the next chunk for each read.
+type chunkReader struct {
+	chunks []int
+	reads  []int
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if 0 == len(c.chunks) {
+		return 0, io.EOF
+	}
+	c.reads = append(c.reads, len(p))
+	n := c.chunks[0]
+	if n > len(p) {
+		n = len(p)
+	}
+	c.chunks = c.chunks[1:]
+	return copy(p, bytes.Repeat([]byte{'x'}, n)), nil
+}
+
+func TestAdaptiveReader(t *testing.T) {
+
+	source := &chunkReader{}
+	reader := NewAdaptiveReader(source, 64, 128, 1024)
+	buffer := make([]byte, 16)
+
+	read := func(chunks ...int) int {
+		source.chunks = append(source.chunks, chunks...)
+		for 0 != len(source.chunks) || 0 != reader.Buffered() {
+			if _, err := reader.Read(buffer); nil != err {
+				t.Fatal(err)
+			}
+		}
+		return reader.Size()
+	}
+
+	// large reads fill up the buffer.
+	if size := read(4096); 256 != size {
+		t.Fatal("buffer should grow, size:", size)
+	}
+
+	if size := read(4096, 4096, 4096); 1024 != size {
+		t.Fatal("buffer should grow to max, size:", size)
+	}
+
+	// a single small read is not sufficient to shrink.
+	if size := read(10); 1024 != size {
+		t.Fatal("buffer should not shrink, size:", size)
+	}
+
+	if size := read(10); 512 != size {
+		t.Fatal("buffer should shrink, size:", size)
+	}
+
+	if size := read(10, 10, 10, 10, 10, 10, 10, 10); 64 != size {
+		t.Fatal("buffer should shrink to min, size:", size)
+	}
+
+	// the source is read by the size of buffer.
+	if last := source.reads[len(source.reads)-1]; 64 != last {
+		t.Fatal("unexpected read size:", last)
+	}
+}
+
+func TestAdaptiveReaderDirect(t *testing.T) {
+
+	source := &chunkReader{chunks: []int{128, 128}}
+	reader := NewAdaptiveReader(source, 64, 64, 1024)
+
+	// the large read bypasses the buffer but still adapts the size.
+	buffer := make([]byte, 4096)
+	if n, err := reader.Read(buffer); nil != err || 64 != n || 0 != reader.Buffered() {
+		t.Fatal(n, err)
+	}
+
+	if n, err := reader.Read(buffer); nil != err || 128 != n || 256 != reader.Size() {
+		t.Fatal(n, err, reader.Size())
+	}
+}
@@ -0,0 +1,205 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// BenchmarkOptions defines the workload of RunBenchmark
+type BenchmarkOptions struct {
+	// Address to listen and connect, e.g. tcp://127.0.0.1:9560.
+	Address string
+	// PayloadSize of each message, at least 8 bytes to carry the send time.
+	PayloadSize int
+	// Count of messages.
+	Count int
+	// Timeout of receiving each message, defaults to 10s.
+	Timeout time.Duration
+	// Options of both the server and client transports.
+	Options []Option
+}
+
+// BenchmarkResult defines the throughput & latency of RunBenchmark
+type BenchmarkResult struct {
+	// Messages & Bytes echoed.
+	Messages int
+	Bytes    int64
+	// Elapsed from the first message sent to the last message received.
+	Elapsed time.Duration
+	// Latencies of the round trips, sorted ascending.
+	Latencies []time.Duration
+}
+
+// Throughput returns the echoed bytes per second.
+func (r *BenchmarkResult) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Elapsed.Seconds()
+}
+
+// Percentile returns the latency of the percentile in [0, 100].
+func (r *BenchmarkResult) Percentile(p float64) time.Duration {
+	if 0 == len(r.Latencies) {
+		return 0
+	}
+	index := int(p / 100 * float64(len(r.Latencies)-1))
+	switch {
+	case index < 0:
+		index = 0
+	case index >= len(r.Latencies):
+		index = len(r.Latencies) - 1
+	}
+	return r.Latencies[index]
+}
+
+// BenchmarkReporter defines the reporter of benchmark metrics, e.g. *testing.B
+type BenchmarkReporter interface {
+	ReportMetric(n float64, unit string)
+}
+
+// Report the throughput & latency percentiles to the reporter.
+func (r *BenchmarkResult) Report(reporter BenchmarkReporter) {
+	reporter.ReportMetric(r.Throughput()/1e6, "MB/s")
+	reporter.ReportMetric(float64(r.Percentile(50).Microseconds()), "p50-us")
+	reporter.ReportMetric(float64(r.Percentile(99).Microseconds()), "p99-us")
+}
+
+// String to describe the result
+func (r *BenchmarkResult) String() string {
+	return fmt.Sprintf("%d messages, %.2f MB/s, p50: %v, p90: %v, p99: %v", r.Messages,
+		r.Throughput()/1e6, r.Percentile(50), r.Percentile(90), r.Percentile(99))
+}
+
+// RunBenchmark to echo the messages over a loopback connection of the factory, the messages are
+// pipelined by the client and echoed by the server, each message carries the send time to measure
+// the round trip, so a packet transport should keep the payload within a packet.
+func RunBenchmark(factory Factory, options BenchmarkOptions) (*BenchmarkResult, error) {
+
+	if options.PayloadSize < 8 {
+		return nil, fmt.Errorf("benchmark: payload size must be at least 8 bytes: %d", options.PayloadSize)
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = time.Second * 10
+	}
+
+	listenOptions, err := ParseOptions(context.Background(), options.Address, options.Options...)
+	if nil != err {
+		return nil, err
+	}
+
+	acceptor, err := factory.Listen(listenOptions)
+	if nil != err {
+		return nil, err
+	}
+	defer acceptor.Close()
+
+	go func() {
+		if t, err := acceptor.Accept(); nil == err {
+			defer t.Close()
+			echoTransport(t, options.PayloadSize)
+		}
+	}()
+
+	connectOptions, err := ParseOptions(context.Background(), options.Address, options.Options...)
+	if nil != err {
+		return nil, err
+	}
+
+	client, err := factory.Connect(connectOptions)
+	if nil != err {
+		return nil, err
+	}
+	defer client.Close()
+
+	sent := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		payload := make([]byte, options.PayloadSize)
+		for i := 0; i < options.Count; i++ {
+			binary.BigEndian.PutUint64(payload, uint64(time.Now().UnixNano()))
+			if _, err := client.Write(payload); nil != err {
+				sent <- err
+				return
+			}
+			if err := client.Flush(); nil != err {
+				sent <- err
+				return
+			}
+		}
+		sent <- nil
+	}()
+
+	result := &BenchmarkResult{Latencies: make([]time.Duration, 0, options.Count)}
+	buffer := make([]byte, options.PayloadSize)
+	for i := 0; i < options.Count; i++ {
+		if err := client.SetReadDeadline(time.Now().Add(options.Timeout)); nil != err {
+			return nil, err
+		}
+
+		if _, err := io.ReadFull(client, buffer); nil != err {
+			// report the cause of writer.
+			select {
+			case werr := <-sent:
+				if nil != werr {
+					err = werr
+				}
+			default:
+			}
+			return nil, fmt.Errorf("benchmark: %d of %d messages received: %w", i, options.Count, err)
+		}
+
+		received := time.Now()
+		result.Latencies = append(result.Latencies, received.Sub(time.Unix(0, int64(binary.BigEndian.Uint64(buffer)))))
+		result.Messages++
+		result.Bytes += int64(options.PayloadSize)
+		result.Elapsed = received.Sub(start)
+	}
+
+	if err := <-sent; nil != err {
+		return nil, err
+	}
+
+	sort.Slice(result.Latencies, func(i, j int) bool { return result.Latencies[i] < result.Latencies[j] })
+	return result, nil
+}
+
+// echoTransport write back the received bytes until the transport is closed.
+func echoTransport(t Transport, size int) {
+	buffer := make([]byte, size)
+	for {
+		n, err := t.Read(buffer)
+		if n > 0 {
+			if _, werr := t.Write(buffer[:n]); nil != werr {
+				return
+			}
+			if ferr := t.Flush(); nil != ferr {
+				return
+			}
+		}
+		if nil != err {
+			return
+		}
+	}
+}
@@ -60,6 +60,10 @@ func (b *bufConn) Flush() error {
 	return b.rw.Writer.Flush()
 }
 
+func (b *bufConn) Buffered() int {
+	return b.rw.Reader.Buffered()
+}
+
 func (b *bufConn) RawTransport() interface{} {
 	return b.Conn
 }
@@ -81,6 +85,10 @@ func (br *bufReadConn) Flush() error {
 	return nil
 }
 
+func (br *bufReadConn) Buffered() int {
+	return br.reader.Buffered()
+}
+
 func (br *bufReadConn) RawTransport() interface{} {
 	return br.Conn
 }
@@ -90,6 +98,11 @@ type bufWriteConn struct {
 	writer *bufio.Writer
 }
 
+func (bw *bufWriteConn) Close() error {
+	_ = bw.Flush()
+	return bw.Conn.Close()
+}
+
 func (bw *bufWriteConn) Write(b []byte) (n int, err error) {
 	return bw.writer.Write(b)
 }
@@ -102,6 +115,10 @@ func (bw *bufWriteConn) Flush() error {
 	return bw.writer.Flush()
 }
 
+func (bw *bufWriteConn) Buffered() int {
+	return 0
+}
+
 func (bw *bufWriteConn) RawTransport() interface{} {
 	return bw.Conn
 }
@@ -118,6 +135,10 @@ func (r *rawConn) Flush() error {
 	return nil
 }
 
+func (r *rawConn) Buffered() int {
+	return 0
+}
+
 func (r *rawConn) RawTransport() interface{} {
 	return r.Conn
 }
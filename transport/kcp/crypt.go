@@ -0,0 +1,81 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kcp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xtaci/kcp-go/v5"
+)
+
+// ErrInvalidCrypt is wrapped by the error of an unknown cipher or a key of invalid size.
+var ErrInvalidCrypt = errors.New("kcp: invalid crypt")
+
+// cipher creates the block crypt of kcp, the key of valid sizes.
+type cipher struct {
+	create func(key []byte) (kcp.BlockCrypt, error)
+	sizes  []int // the valid sizes of key, any non-empty key if nil.
+}
+
+// ciphers are the block encryptions of kcp by name.
+var ciphers = map[string]cipher{
+	"aes":      {create: kcp.NewAESBlockCrypt, sizes: []int{16, 24, 32}},
+	"salsa20":  {create: kcp.NewSalsa20BlockCrypt, sizes: []int{32}},
+	"sm4":      {create: kcp.NewSM4BlockCrypt, sizes: []int{16}},
+	"twofish":  {create: kcp.NewTwofishBlockCrypt, sizes: []int{16, 24, 32}},
+	"3des":     {create: kcp.NewTripleDESBlockCrypt, sizes: []int{24}},
+	"cast5":    {create: kcp.NewCast5BlockCrypt, sizes: []int{16}},
+	"blowfish": {create: kcp.NewBlowfishBlockCrypt},
+	"tea":      {create: kcp.NewTEABlockCrypt, sizes: []int{16}},
+	"xtea":     {create: kcp.NewXTEABlockCrypt, sizes: []int{16}},
+	"xor":      {create: kcp.NewSimpleXORBlockCrypt},
+}
+
+// blockCrypt returns the block crypt of the options, nil if the encryption is disabled.
+func (o *Options) blockCrypt() (kcp.BlockCrypt, error) {
+	if "" == o.Crypt || "none" == o.Crypt {
+		return nil, nil
+	}
+
+	c, ok := ciphers[o.Crypt]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown cipher: %s", ErrInvalidCrypt, o.Crypt)
+	}
+
+	if !validKeySize(len(o.Key), c.sizes) {
+		return nil, fmt.Errorf("%w: %d bytes key of %s, want: %v", ErrInvalidCrypt, len(o.Key), o.Crypt, c.sizes)
+	}
+
+	block, err := c.create(o.Key)
+	if nil != err {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCrypt, err)
+	}
+	return block, nil
+}
+
+func validKeySize(size int, sizes []int) bool {
+	if nil == sizes {
+		return size > 0
+	}
+	for _, s := range sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
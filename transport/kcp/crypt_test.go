@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestKCPTransportCrypt(t *testing.T) {
+
+	for _, crypt := range []string{"aes", "salsa20"} {
+		key := bytes.Repeat([]byte{7}, 32)
+		acceptor := listen(t, &Options{Crypt: crypt, Key: key})
+		echo(acceptor)
+
+		// the peers of the same key communicate.
+		tt := connect(t, acceptor.Addr(), &Options{Crypt: crypt, Key: key})
+		roundTrip(t, tt, "hello")
+		_ = tt.Close()
+
+		// the packets of another key are dropped.
+		other := connect(t, acceptor.Addr(), &Options{Crypt: crypt, Key: bytes.Repeat([]byte{8}, 32)})
+		if _, err := other.Write([]byte("hello")); nil != err {
+			t.Fatal(err)
+		}
+		_ = other.SetReadDeadline(time.Now().Add(time.Millisecond * 200))
+		if _, err := io.ReadFull(other, make([]byte, 5)); !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatal(crypt, "unexpected error of mismatched key:", err)
+		}
+		_ = other.Close()
+		_ = acceptor.Close()
+	}
+}
+
+func TestInvalidCrypt(t *testing.T) {
+
+	var cases = []Options{
+		{Crypt: "rot13", Key: make([]byte, 16)},
+		{Crypt: "aes", Key: make([]byte, 10)},
+		{Crypt: "aes"},
+		{Crypt: "salsa20", Key: make([]byte, 16)},
+		{Crypt: "3des", Key: make([]byte, 16)},
+		{Crypt: "blowfish", Key: make([]byte, 57)},
+	}
+
+	for _, c := range cases {
+		c := c
+		if _, err := transport.ParseOptions(context.Background(), "kcp://127.0.0.1:9000", WithOptions(&c)); !errors.Is(err, ErrInvalidCrypt) {
+			t.Fatalf("%s of %d bytes key: unexpected error: %v", c.Crypt, len(c.Key), err)
+		}
+	}
+
+	for _, c := range []Options{{}, {Crypt: "none"}, {Crypt: "aes", Key: make([]byte, 24)}, {Crypt: "xor", Key: []byte("secret")}} {
+		c := c
+		if _, err := transport.ParseOptions(context.Background(), "kcp://127.0.0.1:9000", WithOptions(&c)); nil != err {
+			t.Fatalf("%s of %d bytes key: %v", c.Crypt, len(c.Key), err)
+		}
+	}
+
+	// the cipher of url is validated too.
+	_, address, opts, err := transport.ParseURL("kcp://127.0.0.1:9000?crypt=aes")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err = transport.ParseOptions(context.Background(), address, opts...); !errors.Is(err, ErrInvalidCrypt) {
+		t.Fatal("unexpected error:", err)
+	}
+}
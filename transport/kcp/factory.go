@@ -0,0 +1,194 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kcp
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/xtaci/kcp-go/v5"
+)
+
+// New kcp factory, the transport is a reliable stream over udp, see https://github.com/xtaci/kcp-go.
+func New() transport.Factory {
+	return new(kcpFactory)
+}
+
+func init() {
+	transport.Register(New())
+}
+
+type kcpFactory struct{}
+
+func (*kcpFactory) Schemes() transport.Schemes {
+	return transport.Schemes{"kcp"}
+}
+
+func (f *kcpFactory) Connect(options *transport.Options) (transport.Transport, error) {
+
+	if err := f.Schemes().FixScheme(options.Address); nil != err {
+		return nil, err
+	}
+
+	kcpOptions := FromContext(options.Context, DefaultOption)
+	block, err := kcpOptions.blockCrypt()
+	if nil != err {
+		return nil, err
+	}
+
+	conn, err := transport.DialAddresses(options.Context, options.Resolver, options.Address.Host, func(ctx context.Context, address string) (net.Conn, error) {
+		return dial(address, block, kcpOptions)
+	})
+	if nil != err {
+		return nil, err
+	}
+	return conn.(*kcpTransport), nil
+}
+
+func (f *kcpFactory) Listen(options *transport.Options) (transport.Acceptor, error) {
+
+	if err := f.Schemes().FixScheme(options.Address); nil != err {
+		return nil, err
+	}
+
+	kcpOptions := FromContext(options.Context, DefaultOption)
+	block, err := kcpOptions.blockCrypt()
+	if nil != err {
+		return nil, err
+	}
+
+	var lc net.ListenConfig
+	pc, err := lc.ListenPacket(options.Context, "udp", options.AddressWithoutHost())
+	if nil != err {
+		return nil, err
+	}
+
+	if err = setBuffers(pc.(*net.UDPConn), kcpOptions); nil != err {
+		_ = pc.Close()
+		return nil, err
+	}
+
+	// the keepalive datagrams of all sessions are filtered out of the listener.
+	acceptor := &kcpAcceptor{conn: pc, options: kcpOptions}
+	if kcpOptions.KeepAliveInterval > 0 {
+		acceptor.keepalive = &keepaliveConn{PacketConn: pc}
+		pc = acceptor.keepalive
+	}
+
+	if acceptor.listener, err = kcp.ServeConn(block, kcpOptions.DataShards, kcpOptions.ParityShards, pc); nil != err {
+		_ = acceptor.conn.Close()
+		return nil, err
+	}
+	return acceptor, nil
+}
+
+// dial the session to the address, the socket is owned by the transport.
+func dial(address string, block kcp.BlockCrypt, kcpOptions *Options) (*kcpTransport, error) {
+	raddr, err := net.ResolveUDPAddr("udp", address)
+	if nil != err {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", nil)
+	if nil != err {
+		return nil, err
+	}
+
+	if err = setBuffers(conn, kcpOptions); nil != err {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	var pc net.PacketConn = conn
+	var keepalive *keepaliveConn
+	if kcpOptions.KeepAliveInterval > 0 {
+		keepalive = &keepaliveConn{PacketConn: conn}
+		pc = keepalive
+	}
+
+	session, err := kcp.NewConn2(raddr, block, kcpOptions.DataShards, kcpOptions.ParityShards, pc)
+	if nil != err {
+		_ = conn.Close()
+		return nil, err
+	}
+	return newKcpTransport(session, kcpOptions, keepalive, conn), nil
+}
+
+type kcpAcceptor struct {
+	conn      net.PacketConn
+	listener  *kcp.Listener
+	options   *Options
+	keepalive *keepaliveConn // nil if the keepalive is disabled.
+}
+
+func (a *kcpAcceptor) Accept() (transport.Transport, error) {
+	session, err := a.listener.AcceptKCP()
+	if nil != err {
+		return nil, err
+	}
+	return newKcpTransport(session, a.options, a.keepalive, nil), nil
+}
+
+func (a *kcpAcceptor) Close() error {
+	err := a.listener.Close()
+	if e := a.conn.Close(); nil == err {
+		err = e
+	}
+	return err
+}
+
+// Addr returns the local address of the listener.
+func (a *kcpAcceptor) Addr() net.Addr {
+	return a.conn.LocalAddr()
+}
+
+// setSession to tune the kcp of session by the options.
+func setSession(session *kcp.UDPSession, kcpOptions *Options) {
+	session.SetNoDelay(boolInt(kcpOptions.NoDelay), int(kcpOptions.Interval/time.Millisecond), kcpOptions.Resend, boolInt(kcpOptions.NoCongestion))
+	session.SetWindowSize(kcpOptions.SendWindow, kcpOptions.RecvWindow)
+	if kcpOptions.MTU > 0 {
+		session.SetMtu(kcpOptions.MTU)
+	}
+	session.SetStreamMode(kcpOptions.StreamMode)
+	session.SetACKNoDelay(kcpOptions.ACKNoDelay)
+	// the writes are flushed by kcp itself.
+	session.SetWriteDelay(false)
+}
+
+func setBuffers(conn *net.UDPConn, kcpOptions *Options) error {
+	if kcpOptions.ReadBufferSize > 0 {
+		if err := conn.SetReadBuffer(kcpOptions.ReadBufferSize); nil != err {
+			return err
+		}
+	}
+
+	if kcpOptions.WriteBufferSize > 0 {
+		if err := conn.SetWriteBuffer(kcpOptions.WriteBufferSize); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
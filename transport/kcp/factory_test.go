@@ -0,0 +1,208 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func listen(t *testing.T, kcpOptions *Options) *kcpAcceptor {
+	options, err := transport.ParseOptions(context.Background(), "kcp://127.0.0.1:0", WithOptions(kcpOptions))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	acceptor, err := New().Listen(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return acceptor.(*kcpAcceptor)
+}
+
+func connect(t *testing.T, addr net.Addr, kcpOptions *Options) transport.Transport {
+	address := fmt.Sprintf("kcp://127.0.0.1:%d", addr.(*net.UDPAddr).Port)
+	options, err := transport.ParseOptions(context.Background(), address, WithOptions(kcpOptions))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	tt, err := New().Connect(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return tt
+}
+
+// echo the bytes of the accepted transports.
+func echo(acceptor transport.Acceptor) <-chan transport.Transport {
+	accepted := make(chan transport.Transport, 1)
+	go func() {
+		for {
+			tt, err := acceptor.Accept()
+			if nil != err {
+				return
+			}
+			accepted <- tt
+			go func() { _, _ = io.Copy(tt, tt) }()
+		}
+	}()
+	return accepted
+}
+
+func roundTrip(t *testing.T, tt transport.Transport, message string) {
+	if _, err := tt.Write([]byte(message)); nil != err {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, len(message))
+	_ = tt.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(tt, buffer); nil != err || message != string(buffer) {
+		t.Fatalf("unexpected echo: %q, %v", buffer, err)
+	}
+}
+
+func TestKCPTransport(t *testing.T) {
+
+	acceptor := listen(t, &Options{StreamMode: true})
+	defer acceptor.Close()
+	echo(acceptor)
+
+	tt := connect(t, acceptor.Addr(), &Options{StreamMode: true})
+	defer tt.Close()
+
+	roundTrip(t, tt, "hello")
+
+	large := bytes.Repeat([]byte("0123456789"), 10000)
+	if _, err := tt.Writev(transport.Buffers{Buffers: net.Buffers{large[:500], large[500:]}}); nil != err {
+		t.Fatal(err)
+	}
+
+	received := make([]byte, len(large))
+	_ = tt.SetReadDeadline(time.Now().Add(time.Second * 3))
+	if _, err := io.ReadFull(tt, received); nil != err || !bytes.Equal(large, received) {
+		t.Fatal("unexpected echo of the large message:", err)
+	}
+
+	// the timeout is told as the other transports.
+	_ = tt.SetReadDeadline(time.Now().Add(time.Millisecond * 20))
+	if _, err := tt.Read(received); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+func TestKCPTransportKeepAlive(t *testing.T) {
+
+	kcpOptions := &Options{KeepAliveInterval: time.Millisecond * 20, KeepAliveMaxMissed: 3}
+	acceptor := listen(t, kcpOptions)
+	defer acceptor.Close()
+	accepted := echo(acceptor)
+
+	tt := connect(t, acceptor.Addr(), kcpOptions)
+	roundTrip(t, tt, "hello")
+	server := <-accepted
+
+	// the idle session is kept alive by the pings.
+	time.Sleep(time.Millisecond * 200)
+	roundTrip(t, tt, "still alive")
+
+	// the server closes the session of the dead client.
+	_ = tt.(*kcpTransport).conn.Close()
+	_ = server.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := server.Read(make([]byte, 16)); !errors.Is(err, ErrKeepAliveTimeout) {
+		t.Fatal("unexpected error:", err)
+	}
+	_ = tt.Close()
+}
+
+func TestKCPTransportKeepAliveDeadPeer(t *testing.T) {
+
+	// the peer receives the datagrams without answer.
+	dead, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer dead.Close()
+
+	pings := make(chan struct{}, 16)
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			n, _, err := dead.ReadFrom(buffer)
+			if nil != err {
+				return
+			}
+			if bytes.Equal(pingDatagram, buffer[:n]) {
+				pings <- struct{}{}
+			}
+		}
+	}()
+
+	tt := connect(t, dead.LocalAddr(), &Options{KeepAliveInterval: time.Millisecond * 20, KeepAliveMaxMissed: 3})
+	defer tt.Close()
+
+	start := time.Now()
+	_ = tt.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := tt.Read(make([]byte, 16)); !errors.Is(err, ErrKeepAliveTimeout) {
+		t.Fatal("unexpected error:", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*60 {
+		t.Fatal("closed before the pings are missed:", elapsed)
+	}
+	if 3 != len(pings) {
+		t.Fatal("unexpected pings:", len(pings))
+	}
+
+	if _, err := tt.Write([]byte("hello")); !errors.Is(err, ErrKeepAliveTimeout) {
+		t.Fatal("unexpected error of write:", err)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+
+	factory, address, opts, err := transport.ParseURL("kcp://127.0.0.1:9000?streamMode&keepAliveInterval=10s&dataShards=10&parityShards=3")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if !factory.Schemes().Valid("kcp") || "kcp://127.0.0.1:9000" != address {
+		t.Fatal("unexpected transport:", factory.Schemes(), address)
+	}
+
+	options, err := transport.ParseOptions(context.Background(), address, opts...)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	o := FromContext(options.Context, nil)
+	if nil == o || !o.StreamMode || 10*time.Second != o.KeepAliveInterval || 10 != o.DataShards || 3 != o.ParityShards || !o.NoDelay {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+
+	if _, _, _, err := transport.ParseURL("kcp://127.0.0.1:9000?nodelay2=true"); nil == err {
+		t.Fatal("expect unknown option error")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kcp
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// the keepalive datagrams are shorter than the header of kcp, so they are never taken as the packets of kcp,
+// they are sent aside kcp, neither retransmitted nor covered by the forward error correction, and any datagram
+// of the peer counts as an answer, e.g. the acks of the retransmitted packets.
+var (
+	pingDatagram = []byte("\x00kcp-ping")
+	pongDatagram = []byte("\x00kcp-pong")
+)
+
+// peer records the time of the last datagram received from the remote address.
+type peer struct {
+	seen int64 // unix nano
+}
+
+func (p *peer) touch() {
+	atomic.StoreInt64(&p.seen, time.Now().UnixNano())
+}
+
+// keepaliveConn answers the pings of the peers and records the datagrams received from the watched addresses,
+// the keepalive datagrams are not passed on to kcp.
+type keepaliveConn struct {
+	net.PacketConn
+	peers sync.Map // the address to *peer
+}
+
+func (c *keepaliveConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	for {
+		n, addr, err := c.PacketConn.ReadFrom(p)
+		if nil != err {
+			return n, addr, err
+		}
+
+		if v, ok := c.peers.Load(addr.String()); ok {
+			v.(*peer).touch()
+		}
+
+		switch {
+		case bytes.Equal(pingDatagram, p[:n]):
+			_, _ = c.PacketConn.WriteTo(pongDatagram, addr)
+		case bytes.Equal(pongDatagram, p[:n]):
+		default:
+			return n, addr, nil
+		}
+	}
+}
+
+// watch the datagrams received from the address.
+func (c *keepaliveConn) watch(addr net.Addr) *peer {
+	p := &peer{}
+	p.touch()
+	v, _ := c.peers.LoadOrStore(addr.String(), p)
+	return v.(*peer)
+}
+
+// unwatch the address if it is still watched by p.
+func (c *keepaliveConn) unwatch(addr net.Addr, p *peer) {
+	if v, ok := c.peers.Load(addr.String()); ok && v == p {
+		c.peers.Delete(addr.String())
+	}
+}
+
+// keepAlive pings the peer if no datagram is received from it in the interval, the session is closed
+// with ErrKeepAliveTimeout after maxMissed pings are not answered.
+func (t *kcpTransport) keepAlive(interval time.Duration, maxMissed int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var missed int
+	last := time.Now().UnixNano()
+	for {
+		select {
+		case <-t.closed:
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UnixNano()
+		if atomic.LoadInt64(&t.peer.seen) >= last {
+			missed = 0
+		} else if missed >= maxMissed {
+			atomic.StoreInt32(&t.expired, 1)
+			_ = t.Close()
+			return
+		} else {
+			missed++
+			_, _ = t.keepalive.WriteTo(pingDatagram, t.RemoteAddr())
+		}
+		last = now
+	}
+}
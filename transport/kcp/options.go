@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// DefaultOption default kcp options
+var DefaultOption = &Options{
+	NoDelay:            true,
+	Interval:           10 * time.Millisecond,
+	Resend:             2,
+	NoCongestion:       true,
+	KeepAliveMaxMissed: 3,
+}
+
+// Options fot kcp transport
+type Options struct {
+	// Crypt is the cipher to encrypt the packets, one of aes, salsa20, sm4, twofish, 3des, cast5, blowfish, tea,
+	// xtea & xor, disabled if empty or none, the peers should share the same cipher and key, the keepalive
+	// pings are not encrypted.
+	Crypt string `json:"crypt"`
+	// Key of the cipher, 16, 24 or 32 bytes for aes & twofish, 32 bytes for salsa20, 24 bytes for 3des,
+	// 1 to 56 bytes for blowfish, any non-empty key for xor and 16 bytes for the others.
+	Key []byte `json:"-"`
+	// DataShards & ParityShards of the forward error correction, disabled if <= 0.
+	DataShards   int `json:"dataShards"`
+	ParityShards int `json:"parityShards"`
+	// NoDelay, Interval, Resend & NoCongestion tune the retransmission of kcp, see kcp.UDPSession.SetNoDelay,
+	// the default is the fast mode: nodelay, 10ms, 2 & no congestion control.
+	NoDelay      bool          `json:"noDelay"`
+	Interval     time.Duration `json:"interval"`
+	Resend       int           `json:"resend"`
+	NoCongestion bool          `json:"noCongestion"`
+	// SendWindow & RecvWindow set the window size of packets if > 0.
+	SendWindow int `json:"sendWindow"`
+	RecvWindow int `json:"recvWindow"`
+	// MTU sets the max size of the packets if > 0, default 1400 of kcp.
+	MTU int `json:"mtu"`
+	// StreamMode merges the writes into the packets of a stream, otherwise each write is sent as a message.
+	StreamMode bool `json:"streamMode"`
+	// ACKNoDelay flush the acks immediately once the packets are received.
+	ACKNoDelay bool `json:"ackNoDelay"`
+	// ReadBufferSize & WriteBufferSize set the size of socket buffers if > 0.
+	ReadBufferSize  int `json:"readBufferSize"`
+	WriteBufferSize int `json:"writeBufferSize"`
+	// KeepAliveInterval pings the peer if no datagram is received from it in the interval, and closes the session
+	// with ErrKeepAliveTimeout after KeepAliveMaxMissed pings are not answered, disabled if <= 0, the peer should
+	// enable it too to answer the pings, the batch io of linux is not used by the sessions with keepalive.
+	KeepAliveInterval time.Duration `json:"keepAliveInterval"`
+	// KeepAliveMaxMissed is the number of unanswered pings to close the session, default 3.
+	KeepAliveMaxMissed int `json:"keepAliveMaxMissed"`
+}
+
+func (o *Options) keepAliveMaxMissed() int {
+	if o.KeepAliveMaxMissed > 0 {
+		return o.KeepAliveMaxMissed
+	}
+	return DefaultOption.KeepAliveMaxMissed
+}
+
+// ErrKeepAliveTimeout is returned by the session closed by the keepalive if the peer does not answer the pings.
+var ErrKeepAliveTimeout = errors.New("kcp: keepalive timeout")
+
+// ParseQuery parse the kcp options from query based on DefaultOption, e.g. streamMode&keepAliveInterval=10s, see transport.ParseURL.
+func (*kcpFactory) ParseQuery(query url.Values) ([]transport.Option, url.Values, error) {
+	option := *DefaultOption
+	unused, err := transport.DecodeQuery(&option, query)
+	if nil != err {
+		return nil, nil, err
+	}
+	if len(unused) > 0 {
+		return nil, nil, fmt.Errorf("kcp: unknown options: %s", unused.Encode())
+	}
+	return []transport.Option{WithOptions(&option)}, nil, nil
+}
+
+type contextKey struct{}
+
+// WithOptions to wrap the kcp options, it fails with ErrInvalidCrypt if the cipher or the size of key is invalid.
+func WithOptions(option *Options) transport.Option {
+	return func(options *transport.Options) error {
+		if _, err := option.blockCrypt(); nil != err {
+			return err
+		}
+		options.Context = context.WithValue(options.Context, contextKey{}, option)
+		return nil
+	}
+}
+
+// FromContext to unwrap the kcp options
+func FromContext(ctx context.Context, def *Options) *Options {
+	if v, ok := ctx.Value(contextKey{}).(*Options); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kcp
+
+import (
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/xtaci/kcp-go/v5"
+)
+
+type kcpTransport struct {
+	*kcp.UDPSession
+	conn          net.Conn       // the socket owned by the client session, nil for the accepted one.
+	keepalive     *keepaliveConn // nil if the keepalive is disabled.
+	peer          *peer
+	once          sync.Once
+	closed        chan struct{}
+	expired       int32 // 1 if closed by the keepalive.
+	readDeadline  int64 // unix nano, to tell the timeout of kcp.
+	writeDeadline int64
+}
+
+func newKcpTransport(session *kcp.UDPSession, kcpOptions *Options, keepalive *keepaliveConn, conn net.Conn) *kcpTransport {
+	setSession(session, kcpOptions)
+
+	t := &kcpTransport{UDPSession: session, conn: conn, keepalive: keepalive, closed: make(chan struct{})}
+	if nil != keepalive {
+		t.peer = keepalive.watch(session.RemoteAddr())
+		go t.keepAlive(kcpOptions.KeepAliveInterval, kcpOptions.keepAliveMaxMissed())
+	}
+	return t
+}
+
+func (t *kcpTransport) Read(p []byte) (int, error) {
+	n, err := t.UDPSession.Read(p)
+	return n, t.error(err, &t.readDeadline)
+}
+
+func (t *kcpTransport) Write(p []byte) (int, error) {
+	n, err := t.UDPSession.Write(p)
+	return n, t.error(err, &t.writeDeadline)
+}
+
+func (t *kcpTransport) Writev(buffs transport.Buffers) (int64, error) {
+	n, err := t.UDPSession.WriteBuffers(buffs.Buffers)
+	return int64(n), t.error(err, &t.writeDeadline)
+}
+
+// Flush is a no-op, the writes are flushed by kcp.
+func (t *kcpTransport) Flush() error {
+	return nil
+}
+
+func (t *kcpTransport) SetDeadline(deadline time.Time) error {
+	atomic.StoreInt64(&t.readDeadline, unixNano(deadline))
+	atomic.StoreInt64(&t.writeDeadline, unixNano(deadline))
+	return t.UDPSession.SetDeadline(deadline)
+}
+
+func (t *kcpTransport) SetReadDeadline(deadline time.Time) error {
+	atomic.StoreInt64(&t.readDeadline, unixNano(deadline))
+	return t.UDPSession.SetReadDeadline(deadline)
+}
+
+func (t *kcpTransport) SetWriteDeadline(deadline time.Time) error {
+	atomic.StoreInt64(&t.writeDeadline, unixNano(deadline))
+	return t.UDPSession.SetWriteDeadline(deadline)
+}
+
+func (t *kcpTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	err := t.UDPSession.Close()
+	if nil != t.keepalive && nil == t.conn {
+		t.keepalive.unwatch(t.RemoteAddr(), t.peer)
+	}
+	if nil != t.conn {
+		if e := t.conn.Close(); nil == err {
+			err = e
+		}
+	}
+	return err
+}
+
+func (t *kcpTransport) RawTransport() interface{} {
+	return t.UDPSession
+}
+
+// error returns ErrKeepAliveTimeout if closed by the keepalive, or os.ErrDeadlineExceeded if the deadline is exceeded,
+// so the timeouts are told as the other transports.
+func (t *kcpTransport) error(err error, deadline *int64) error {
+	switch {
+	case nil == err:
+		return nil
+	case 1 == atomic.LoadInt32(&t.expired):
+		return ErrKeepAliveTimeout
+	}
+
+	if d := atomic.LoadInt64(deadline); 0 != d && time.Now().UnixNano() >= d {
+		select {
+		case <-t.closed:
+		default:
+			return os.ErrDeadlineExceeded
+		}
+	}
+	return err
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+var _ transport.Transport = (*kcpTransport)(nil)
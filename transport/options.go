@@ -40,6 +40,9 @@ type Options struct {
 
 	// Attachment defines the object or data associated with the Channel
 	Attachment interface{}
+
+	// Resolver to resolve the connect address, DefaultResolver if nil.
+	Resolver Resolver
 }
 
 // AddressWithoutHost convert host:port to :port
@@ -100,3 +103,11 @@ func WithAttachment(attachment interface{}) Option {
 		return nil
 	}
 }
+
+// WithResolver to resolve the connect address by a custom resolver
+func WithResolver(resolver Resolver) Option {
+	return func(options *Options) error {
+		options.Resolver = resolver
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryParser is implemented by the factory which accepts its options in the query of url, see ParseURL.
+type QueryParser interface {
+	// ParseQuery returns the options from the query, the unused parameters stay in the address.
+	ParseQuery(query url.Values) (options []Option, unused url.Values, err error)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// DecodeQuery set the fields of struct pointed by v from the query parameters, a parameter matches
+// the field by the name of json tag case-insensitively, the empty value of a bool means true, e.g.
+// nodelay&timeout=3s&linger=0, the parameters matched no field are returned.
+func DecodeQuery(v interface{}, query url.Values) (url.Values, error) {
+	value := reflect.ValueOf(v)
+	if reflect.Ptr != value.Kind() || reflect.Struct != value.Elem().Kind() {
+		return nil, fmt.Errorf("transport: decode query into %T", v)
+	}
+
+	value = value.Elem()
+	unused := url.Values{}
+	for key, values := range query {
+		field, ok := fieldByTag(value, key)
+		if !ok {
+			unused[key] = values
+			continue
+		}
+		if err := setField(field, values); nil != err {
+			return nil, fmt.Errorf("transport: invalid option %s=%s: %w", key, strings.Join(values, ","), err)
+		}
+	}
+	return unused, nil
+}
+
+func fieldByTag(value reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if "" == name || "-" == name || !field.IsExported() {
+			continue
+		}
+		if strings.EqualFold(name, key) {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setField(field reflect.Value, values []string) error {
+	s := values[len(values)-1]
+
+	if durationType == field.Type() {
+		d, err := time.ParseDuration(s)
+		if nil == err {
+			field.SetInt(int64(d))
+		}
+		return err
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		if "" == s {
+			field.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if nil == err {
+			field.SetBool(b)
+		}
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, field.Type().Bits())
+		if nil == err {
+			field.SetInt(n)
+		}
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, field.Type().Bits())
+		if nil == err {
+			field.SetUint(n)
+		}
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if nil == err {
+			field.SetFloat(f)
+		}
+		return err
+	case reflect.String:
+		field.SetString(s)
+		return nil
+	case reflect.Slice:
+		if reflect.String != field.Type().Elem().Kind() {
+			break
+		}
+		// repeated or comma separated: a=x&a=y or a=x,y
+		var list []string
+		for _, v := range values {
+			list = append(list, strings.Split(v, ",")...)
+		}
+		field.Set(reflect.ValueOf(list).Convert(field.Type()))
+		return nil
+	}
+	return fmt.Errorf("unsupported type: %s", field.Type())
+}
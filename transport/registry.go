@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownTransport is returned if no transport is registered for the name.
+var ErrUnknownTransport = errors.New("transport: unknown transport")
+
+var registry = struct {
+	sync.RWMutex
+	factories map[string]Factory
+}{factories: make(map[string]Factory)}
+
+// Register the factory by its schemes, so it could be selected by TransportByName, the builtin
+// transports are registered once their packages are imported, a later one replaces the former.
+func Register(factory Factory) {
+	registry.Lock()
+	defer registry.Unlock()
+	for _, scheme := range factory.Schemes() {
+		registry.factories[scheme] = factory
+	}
+}
+
+// TransportByName returns the factory registered for the name, e.g. tcp, udp or ws.
+func TransportByName(name string) (Factory, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	if factory, ok := registry.factories[name]; ok {
+		return factory, nil
+	}
+	return nil, fmt.Errorf("%w: %s, registered: %v", ErrUnknownTransport, name, registeredLocked())
+}
+
+// ParseURL select the factory by the scheme of rawurl, and parse the options from the query if the factory
+// is a QueryParser, e.g. tcp://127.0.0.1:8080?nodelay=false&timeout=3s, the returned address keeps
+// the scheme, host, path and the unused query parameters.
+func ParseURL(rawurl string) (Factory, string, []Option, error) {
+	u, err := url.Parse(rawurl)
+	if nil != err {
+		return nil, "", nil, err
+	}
+
+	if "" == u.Scheme || "" == u.Host {
+		return nil, "", nil, fmt.Errorf("transport: missing scheme or host in url: %s", rawurl)
+	}
+
+	factory, err := TransportByName(u.Scheme)
+	if nil != err {
+		return nil, "", nil, err
+	}
+
+	var options []Option
+	if parser, ok := factory.(QueryParser); ok && "" != u.RawQuery {
+		var unused url.Values
+		if options, unused, err = parser.ParseQuery(u.Query()); nil != err {
+			return nil, "", nil, err
+		}
+		u.RawQuery = unused.Encode()
+	}
+	return factory, u.String(), options, nil
+}
+
+// Registered returns the sorted names of registered transports.
+func Registered() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registeredLocked()
+}
+
+func registeredLocked() []string {
+	names := make([]string, 0, len(registry.factories))
+	for name := range registry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
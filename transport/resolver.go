@@ -0,0 +1,111 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Resolver defines how a host:port becomes the addresses to dial,
+// the returned addresses are dialed in order until one succeeds.
+type Resolver interface {
+	Resolve(ctx context.Context, host string) ([]Addr, error)
+}
+
+// ResolverFunc impl Resolver
+type ResolverFunc func(ctx context.Context, host string) ([]Addr, error)
+
+// Resolve to impl Resolver
+func (fn ResolverFunc) Resolve(ctx context.Context, host string) ([]Addr, error) {
+	return fn(ctx, host)
+}
+
+// DefaultResolver resolve the host by net.DefaultResolver
+var DefaultResolver Resolver = NetResolver(net.DefaultResolver)
+
+// NetResolver create a Resolver from net.Resolver
+func NetResolver(resolver *net.Resolver) Resolver {
+	return ResolverFunc(func(ctx context.Context, host string) ([]Addr, error) {
+		hostname, port, err := net.SplitHostPort(host)
+		if nil != err {
+			return nil, err
+		}
+
+		portNum, err := strconv.Atoi(port)
+		if nil != err {
+			if portNum, err = resolver.LookupPort(ctx, "tcp", port); nil != err {
+				return nil, err
+			}
+		}
+
+		// ip address need not be resolved.
+		if ip := net.ParseIP(hostname); nil != ip {
+			return []Addr{&net.TCPAddr{IP: ip, Port: portNum}}, nil
+		}
+
+		ips, err := resolver.LookupIPAddr(ctx, hostname)
+		if nil != err {
+			return nil, err
+		}
+
+		addresses := make([]Addr, 0, len(ips))
+		for _, ip := range ips {
+			addresses = append(addresses, &net.TCPAddr{IP: ip.IP, Port: portNum, Zone: ip.Zone})
+		}
+		return addresses, nil
+	})
+}
+
+// DialAddresses resolve the host and dial the addresses in order until one succeeds.
+func DialAddresses(ctx context.Context, resolver Resolver, host string, dial func(ctx context.Context, address string) (net.Conn, error)) (net.Conn, error) {
+	if nil == resolver {
+		resolver = DefaultResolver
+	}
+
+	addresses, err := resolver.Resolve(ctx, host)
+	if nil != err {
+		return nil, err
+	}
+
+	if 0 == len(addresses) {
+		return nil, fmt.Errorf("no address resolved: %s", host)
+	}
+
+	for _, address := range addresses {
+		var conn net.Conn
+		if conn, err = dial(ctx, address.String()); nil == err {
+			return conn, nil
+		}
+
+		// stop failover if the dial is canceled.
+		if nil != ctx.Err() {
+			break
+		}
+	}
+	return nil, fmt.Errorf("dial %s (%d addresses): %w", host, len(addresses), err)
+}
+
+// TTLResolver defines the Resolver which reports the time-to-live of the resolved addresses,
+// the shortest one if the addresses have different ttl, zero if the ttl is unknown.
+type TTLResolver interface {
+	Resolver
+	ResolveTTL(ctx context.Context, host string) ([]Addr, time.Duration, error)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestDialAddresses(t *testing.T) {
+
+	resolver := ResolverFunc(func(ctx context.Context, host string) ([]Addr, error) {
+		return []Addr{
+			&net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 80},
+			&net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 80},
+			&net.TCPAddr{IP: net.IPv4(10, 0, 0, 3), Port: 80},
+		}, nil
+	})
+
+	var dialed []string
+	errRefused := errors.New("refused")
+	conn, err := DialAddresses(context.Background(), resolver, "service:80", func(ctx context.Context, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		if "10.0.0.2:80" == address {
+			c, _ := net.Pipe()
+			return c, nil
+		}
+		return nil, errRefused
+	})
+
+	if nil != err || nil == conn {
+		t.Fatal(err)
+	}
+
+	if 2 != len(dialed) || "10.0.0.1:80" != dialed[0] || "10.0.0.2:80" != dialed[1] {
+		t.Fatal("unexpected dial order:", dialed)
+	}
+
+	// all addresses failed
+	dialed = dialed[:0]
+	_, err = DialAddresses(context.Background(), resolver, "service:80", func(ctx context.Context, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, errRefused
+	})
+
+	if !errors.Is(err, errRefused) || 3 != len(dialed) {
+		t.Fatal("unexpected result:", err, dialed)
+	}
+}
+
+func TestDefaultResolver(t *testing.T) {
+
+	addresses, err := DefaultResolver.Resolve(context.Background(), "127.0.0.1:9527")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if 1 != len(addresses) || "127.0.0.1:9527" != addresses[0].String() {
+		t.Fatal("unexpected addresses:", addresses)
+	}
+}
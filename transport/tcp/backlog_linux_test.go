@@ -0,0 +1,66 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestListenBacklog(t *testing.T) {
+
+	options, err := transport.ParseOptions(context.Background(), "tcp://127.0.0.1:0", WithOptions(&Options{Backlog: 17}))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	acceptor, err := New().Listen(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer acceptor.Close()
+
+	rawConn, err := acceptor.(*tcpAcceptor).listener.(*net.TCPListener).SyscallConn()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	// tcpi_sacked is the max backlog of the listening socket in linux.
+	var info syscall.TCPInfo
+	var errno syscall.Errno
+	_ = rawConn.Control(func(fd uintptr) {
+		size := uint32(unsafe.Sizeof(info))
+		_, _, errno = syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, syscall.IPPROTO_TCP, syscall.TCP_INFO,
+			uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&size)), 0)
+	})
+	if 0 != errno {
+		t.Fatal(errno)
+	}
+
+	if 17 != info.Sacked {
+		t.Fatal("unexpected backlog:", info.Sacked)
+	}
+}
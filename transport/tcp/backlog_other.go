@@ -0,0 +1,33 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+)
+
+// setBacklog is not supported, the default backlog is used.
+func setBacklog(l *net.TCPListener, backlog int) error {
+	fmt.Fprintf(os.Stderr, "netty: listen backlog %d is ignored on %s, the system default is used.\n", backlog, runtime.GOOS)
+	return nil
+}
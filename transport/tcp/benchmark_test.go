@@ -0,0 +1,72 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestRunBenchmark(t *testing.T) {
+
+	result, err := transport.RunBenchmark(New(), transport.BenchmarkOptions{
+		Address:     "tcp://127.0.0.1:9552",
+		PayloadSize: 1024,
+		Count:       1000,
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if 1000 != result.Messages || 1000*1024 != result.Bytes || 1000 != len(result.Latencies) {
+		t.Fatal("unexpected result:", result)
+	}
+
+	if result.Throughput() <= 0 || result.Percentile(50) > result.Percentile(99) || result.Percentile(99) > result.Elapsed {
+		t.Fatal("unexpected metrics:", result)
+	}
+}
+
+func BenchmarkTransport(b *testing.B) {
+
+	var cases = []struct {
+		name    string
+		options *Options
+	}{
+		{name: "default", options: DefaultOption},
+		{name: "buffered", options: &Options{Timeout: DefaultOption.Timeout, NoDelay: true, ReadBufferSize: 64 << 10, WriteBufferSize: 64 << 10}},
+	}
+
+	for _, c := range cases {
+		for _, size := range []int{64, 1024, 16384} {
+			b.Run(fmt.Sprintf("%s/%d", c.name, size), func(b *testing.B) {
+				result, err := transport.RunBenchmark(New(), transport.BenchmarkOptions{
+					Address:     "tcp://127.0.0.1:9552",
+					PayloadSize: size,
+					Count:       b.N,
+					Options:     []transport.Option{WithOptions(c.options)},
+				})
+				if nil != err {
+					b.Fatal(err)
+				}
+				result.Report(b)
+			})
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertificateReloader loads the certificate & key pair from files, and reloads them periodically
+// or on signals, set GetCertificate to tls.Config so the new handshakes use the latest certificate,
+// the established connections are not affected.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Value // *tls.Certificate
+	once     sync.Once
+	done     chan struct{}
+}
+
+// NewCertificateReloader load the certificate and reload it every interval, 0 to disable.
+func NewCertificateReloader(certFile, keyFile string, interval time.Duration) (*CertificateReloader, error) {
+	r := &CertificateReloader{certFile: certFile, keyFile: keyFile, done: make(chan struct{})}
+	if err := r.Reload(); nil != err {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					r.reload()
+				case <-r.done:
+					return
+				}
+			}
+		}()
+	}
+	return r, nil
+}
+
+// GetCertificate to impl tls.Config.GetCertificate
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load().(*tls.Certificate), nil
+}
+
+// Reload the certificate, the current certificate is kept if the new one is invalid.
+func (r *CertificateReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if nil != err {
+		return err
+	}
+
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); nil != err {
+		return err
+	}
+
+	if now := time.Now(); now.Before(cert.Leaf.NotBefore) || now.After(cert.Leaf.NotAfter) {
+		return fmt.Errorf("certificate %s is not valid at %s, validity: %s ~ %s", r.certFile,
+			now.Format(time.RFC3339), cert.Leaf.NotBefore.Format(time.RFC3339), cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	r.cert.Store(&cert)
+	return nil
+}
+
+// ReloadOnSignal reload the certificate when the signals are received, e.g. syscall.SIGHUP
+func (r *CertificateReloader) ReloadOnSignal(signals ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				r.reload()
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+// Close to stop reloading.
+func (r *CertificateReloader) Close() {
+	r.once.Do(func() { close(r.done) })
+}
+
+// reload in background, the failure is reported to stderr.
+func (r *CertificateReloader) reload() {
+	if err := r.Reload(); nil != err {
+		fmt.Fprintln(os.Stderr, "netty: reload certificate failed, the current one is kept:", err)
+	}
+}
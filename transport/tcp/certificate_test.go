@@ -0,0 +1,172 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// writeTestCertificate write a self-signed certificate & key to files
+func writeTestCertificate(t *testing.T, certFile, keyFile, commonName string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); nil != err {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificateReloader(t *testing.T) {
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certFile, keyFile, "v1", time.Now().Add(time.Hour))
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, 0)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer reloader.Close()
+
+	options, err := transport.ParseOptions(context.Background(), "tcp://127.0.0.1:0",
+		WithOptions(&Options{Timeout: time.Second, TLS: &tls.Config{GetCertificate: reloader.GetCertificate}}))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	acceptor, err := New().Listen(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer acceptor.Close()
+
+	go func() {
+		for {
+			tt, err := acceptor.Accept()
+			if nil != err {
+				return
+			}
+			defer tt.Close()
+		}
+	}()
+
+	address := acceptor.(*tcpAcceptor).listener.Addr().String()
+	handshake := func() string {
+		options, err := transport.ParseOptions(context.Background(), "tcp://"+address,
+			WithOptions(&Options{Timeout: time.Second, TLS: &tls.Config{InsecureSkipVerify: true}}))
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		tt, err := New().Connect(options)
+		if nil != err {
+			t.Fatal(err)
+		}
+		defer tt.Close()
+		return tt.RawTransport().(*tls.Conn).ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+
+	if cn := handshake(); "v1" != cn {
+		t.Fatal("unexpected certificate:", cn)
+	}
+
+	// rotate the certificate.
+	writeTestCertificate(t, certFile, keyFile, "v2", time.Now().Add(time.Hour))
+	if err := reloader.Reload(); nil != err {
+		t.Fatal(err)
+	}
+	if cn := handshake(); "v2" != cn {
+		t.Fatal("unexpected certificate:", cn)
+	}
+
+	// the expired certificate is rejected.
+	writeTestCertificate(t, certFile, keyFile, "v3", time.Now().Add(-time.Minute))
+	if err := reloader.Reload(); nil == err {
+		t.Fatal("expired certificate should be rejected")
+	}
+
+	// the mismatched key is rejected.
+	writeTestCertificate(t, filepath.Join(dir, "other.pem"), keyFile, "v4", time.Now().Add(time.Hour))
+	if err := reloader.Reload(); nil == err {
+		t.Fatal("mismatched key should be rejected")
+	}
+
+	// the current certificate is kept.
+	if cn := handshake(); "v2" != cn {
+		t.Fatal("unexpected certificate:", cn)
+	}
+}
+
+func TestCertificateReloaderInterval(t *testing.T) {
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certFile, keyFile, "v1", time.Now().Add(time.Hour))
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, time.Millisecond*20)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer reloader.Close()
+
+	writeTestCertificate(t, certFile, keyFile, "v2", time.Now().Add(time.Hour))
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond * 10) {
+		if cert, _ := reloader.GetCertificate(nil); "v2" == cert.Leaf.Subject.CommonName {
+			return
+		}
+	}
+	t.Fatal("certificate is not reloaded")
+}
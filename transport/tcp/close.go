@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import "time"
+
+// DefaultCloseNotifyTimeout bounds the time to send the tls close_notify if Options.CloseNotifyTimeout is not set.
+const DefaultCloseNotifyTimeout = time.Second
+
+// AbortTransport defines the tcp transport which could be closed without the graceful shutdown,
+// the Close of tls transport sends the close_notify alert before the connection is closed, so the peer
+// could tell the end of stream from a truncation, Abort closes the connection without it, e.g. on errors.
+type AbortTransport interface {
+	Abort() error
+}
+
+func (t *tcpTransport) Close() error {
+	if nil != t.tls {
+		t.closeNotify()
+	}
+	return t.Transport.Close()
+}
+
+func (t *tcpTransport) Abort() error {
+	// the close_notify could not be sent over the closed connection.
+	err := t.conn.Close()
+	_ = t.Transport.Close()
+	return err
+}
+
+// closeNotify to flush the writes and send the close_notify, the connection is closed if it is not sent in time.
+func (t *tcpTransport) closeNotify() {
+	timeout := t.options.CloseNotifyTimeout
+	if timeout <= 0 {
+		timeout = DefaultCloseNotifyTimeout
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if nil == t.Transport.Flush() {
+			_ = t.tls.CloseWrite()
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		// unblock the writes of a peer stops reading.
+		_ = t.conn.Close()
+		<-done
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+// EffectiveOptions are the options applied to a connection, the socket options are read back from the socket,
+// which may differ from the configured ones, e.g. the kernel may double or clamp the socket buffers.
+type EffectiveOptions struct {
+	// Options is a copy of the options of connection, NoDelay & KeepAlive are read back from the socket.
+	Options
+	// ReadSockBuf is the SO_RCVBUF of socket.
+	ReadSockBuf int `json:"readSockBuf"`
+	// WriteSockBuf is the SO_SNDBUF of socket.
+	WriteSockBuf int `json:"writeSockBuf"`
+}
+
+// EffectiveTransport defines the tcp transport which reports the effective options, e.g. for debugging the tuning
+type EffectiveTransport interface {
+	// EffectiveOptions returns the options applied to the connection, ErrNotSupported if the socket options
+	// could not be read on the platform.
+	EffectiveOptions() (*EffectiveOptions, error)
+}
+
+func (t *tcpTransport) EffectiveOptions() (*EffectiveOptions, error) {
+	effective := &EffectiveOptions{Options: *t.options}
+	if err := readSocketOptions(t.conn, effective); nil != err {
+		return nil, err
+	}
+	return effective, nil
+}
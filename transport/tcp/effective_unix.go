@@ -0,0 +1,55 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"net"
+	"syscall"
+)
+
+// readSocketOptions to read back the socket options of connection.
+func readSocketOptions(conn *net.TCPConn, effective *EffectiveOptions) error {
+	rawConn, err := conn.SyscallConn()
+	if nil != err {
+		return err
+	}
+
+	var sockErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		var noDelay, keepAlive int
+		for _, opt := range []struct {
+			level, name int
+			value       *int
+		}{
+			{syscall.SOL_SOCKET, syscall.SO_RCVBUF, &effective.ReadSockBuf},
+			{syscall.SOL_SOCKET, syscall.SO_SNDBUF, &effective.WriteSockBuf},
+			{syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, &keepAlive},
+			{syscall.IPPROTO_TCP, syscall.TCP_NODELAY, &noDelay},
+		} {
+			if *opt.value, sockErr = syscall.GetsockoptInt(int(fd), opt.level, opt.name); nil != sockErr {
+				return
+			}
+		}
+		effective.NoDelay, effective.KeepAlive = 0 != noDelay, 0 != keepAlive
+	}); nil != err {
+		return err
+	}
+	return sockErr
+}
@@ -0,0 +1,76 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestEffectiveOptions(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// the kernel may double or clamp the large buffer.
+	const sockBuf = 8 << 20
+	options, err := transport.ParseOptions(context.Background(), "tcp://"+l.Addr().String(),
+		WithOptions(&Options{SockBuf: sockBuf, NoDelay: true}))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	tt, err := New().Connect(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer tt.Close()
+
+	effective, err := tt.(EffectiveTransport).EffectiveOptions()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	rawConn, err := tt.RawTransport().(*net.TCPConn).SyscallConn()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	var rcvBuf, sndBuf int
+	_ = rawConn.Control(func(fd uintptr) {
+		rcvBuf, _ = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+		sndBuf, _ = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_SNDBUF)
+	})
+
+	if rcvBuf != effective.ReadSockBuf || sndBuf != effective.WriteSockBuf {
+		t.Fatal("unexpected sock buf:", effective.ReadSockBuf, effective.WriteSockBuf, "want:", rcvBuf, sndBuf)
+	}
+	if sockBuf != effective.SockBuf || !effective.NoDelay {
+		t.Fatal("unexpected options:", effective.SockBuf, effective.NoDelay)
+	}
+	t.Log("SO_RCVBUF:", effective.ReadSockBuf, "SO_SNDBUF:", effective.WriteSockBuf)
+}
@@ -17,7 +17,10 @@
 package tcp
 
 import (
+	"context"
+	"fmt"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -29,6 +32,10 @@ func New() transport.Factory {
 	return new(tcpFactory)
 }
 
+func init() {
+	transport.Register(New())
+}
+
 type tcpFactory struct{}
 
 func (*tcpFactory) Schemes() transport.Schemes {
@@ -43,17 +50,35 @@ func (f *tcpFactory) Connect(options *transport.Options) (transport.Transport, e
 
 	tcpOptions := FromContext(options.Context, DefaultOption)
 
+	resolver := options.Resolver
+	if nil == resolver {
+		resolver = transport.DefaultResolver
+	}
+
+	// time the resolve, the dial starts right after it.
+	start := time.Now()
+	var resolved time.Time
+	timedResolver := transport.ResolverFunc(func(ctx context.Context, host string) ([]transport.Addr, error) {
+		defer func() { resolved = time.Now() }()
+		return resolver.Resolve(ctx, host)
+	})
+
 	var d = net.Dialer{Timeout: tcpOptions.Timeout}
-	conn, err := d.DialContext(options.Context, options.Address.Scheme, options.Address.Host)
+	conn, err := transport.DialAddresses(options.Context, timedResolver, options.Address.Host, func(ctx context.Context, address string) (net.Conn, error) {
+		return d.DialContext(ctx, options.Address.Scheme, address)
+	})
 	if nil != err {
 		return nil, err
 	}
+	connected := time.Now()
 
-	tt, err := newTcpTransport(conn.(*net.TCPConn), tcpOptions, true)
+	tt, err := newTcpTransport(conn.(*net.TCPConn), tcpOptions, true, options.Address.Hostname())
 	if nil != err {
 		_ = conn.Close()
 		return nil, err
 	}
+
+	tt.timing.Start, tt.timing.Resolve, tt.timing.Connect = start, resolved.Sub(start), connected.Sub(resolved)
 	return tt, nil
 }
 
@@ -63,60 +88,142 @@ func (f *tcpFactory) Listen(options *transport.Options) (transport.Acceptor, err
 		return nil, err
 	}
 
-	l, err := net.Listen(options.Address.Scheme, options.AddressWithoutHost())
+	tcpOptions := FromContext(options.Context, DefaultOption)
+	network, err := listenNetwork(options.Address.Scheme, tcpOptions.Stack)
+	if nil != err {
+		return nil, err
+	}
+
+	l, err := net.Listen(network, options.AddressWithoutHost())
 	if nil != err {
 		return nil, err
 	}
 
-	return &tcpAcceptor{listener: l.(*net.TCPListener), options: FromContext(options.Context, DefaultOption)}, nil
+	if tcpOptions.Backlog > 0 {
+		if err = setBacklog(l.(*net.TCPListener), tcpOptions.Backlog); nil != err {
+			_ = l.Close()
+			return nil, err
+		}
+	}
+
+	return &tcpAcceptor{
+		listener: l.(*net.TCPListener),
+		options:  tcpOptions,
+		accepted: make(chan acceptResult),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// listenNetwork to apply the ip stack to the scheme, the network of tcp6 listener is ipv6-only by Go.
+func listenNetwork(scheme string, stack IPStack) (string, error) {
+	switch stack {
+	case DefaultStack:
+		return scheme, nil
+	case IPv4Only:
+		if "tcp6" == scheme {
+			return "", fmt.Errorf("tcp: ipv4-only listener conflicts with scheme %s", scheme)
+		}
+		return "tcp4", nil
+	case IPv6Only:
+		if "tcp4" == scheme {
+			return "", fmt.Errorf("tcp: ipv6-only listener conflicts with scheme %s", scheme)
+		}
+		return "tcp6", nil
+	default:
+		return "", fmt.Errorf("tcp: unrecognized ip stack: %d", stack)
+	}
+}
+
+// tcpListener is the listener of tcpAcceptor, e.g. *net.TCPListener
+type tcpListener interface {
+	AcceptTCP() (*net.TCPConn, error)
+	Addr() net.Addr
+	Close() error
 }
 
 type tcpAcceptor struct {
-	listener *net.TCPListener
+	listener tcpListener
 	options  *Options
 	closed   int32
+	once     sync.Once
+	accepted chan acceptResult
+	done     chan struct{}
+}
+
+type acceptResult struct {
+	transport transport.Transport
+	err       error
 }
 
 func (t *tcpAcceptor) Accept() (transport.Transport, error) {
 
-	var tempDelay time.Duration // how long to sleep on accept failure
+	// the tls handshake is done concurrently to avoid the accept loop blocked by slow clients.
+	if nil != t.options.TLS {
+		t.once.Do(func() { go t.handshakeLoop() })
 
+		select {
+		case r := <-t.accepted:
+			return r.transport, r.err
+		case <-t.done:
+			return nil, net.ErrClosed
+		}
+	}
+
+	conn, err := t.acceptTCP()
+	if nil != err {
+		return nil, err
+	}
+
+	tt, err := newTcpTransport(conn, t.options, false, "")
+	if nil != err {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tt, nil
+}
+
+func (t *tcpAcceptor) handshakeLoop() {
 	for {
-		conn, err := t.listener.AcceptTCP()
+		conn, err := t.acceptTCP()
 		if nil != err {
-			if 0 != atomic.LoadInt32(&t.closed) {
-				return nil, err // listener closed
+			// the next accept waits for the backoff of bootstrap to receive the error.
+			select {
+			case t.accepted <- acceptResult{err: err}:
+			case <-t.done:
+				return
 			}
-
-			if ne, ok := err.(net.Error); ok && ne.Timeout() {
-				if tempDelay == 0 {
-					tempDelay = 5 * time.Millisecond
-				} else {
-					tempDelay *= 2
-				}
-				if max := 1 * time.Second; tempDelay > max {
-					tempDelay = max
-				}
-				time.Sleep(tempDelay)
+			if transport.IsTemporary(err) {
 				continue
 			}
-			return nil, err
+			return
 		}
 
-		// reset delay time
-		tempDelay = 0
+		go func() {
+			tt, err := newTcpTransport(conn, t.options, false, "")
+			if nil != err {
+				// drop the connection which failed to handshake.
+				_ = conn.Close()
+				return
+			}
 
-		tt, err := newTcpTransport(conn, t.options, false)
-		if nil != err {
-			_ = conn.Close()
-			return nil, err
-		}
-		return tt, nil
+			select {
+			case t.accepted <- acceptResult{transport: tt}:
+			case <-t.done:
+				_ = tt.Close()
+			}
+		}()
 	}
 }
 
+// acceptTCP returns the temporary errors to the accept loop of bootstrap, which retries with the backoff
+// of netty.WithAcceptBackoff.
+func (t *tcpAcceptor) acceptTCP() (*net.TCPConn, error) {
+	return t.listener.AcceptTCP()
+}
+
 func (t *tcpAcceptor) Close() error {
 	if atomic.CompareAndSwapInt32(&t.closed, 0, 1) {
+		close(t.done)
 		return t.listener.Close()
 	}
 	return nil
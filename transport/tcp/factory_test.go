@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestResolverFailover(t *testing.T) {
+
+	// a closed port to refuse the connection.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	_ = closed.Close()
+
+	var listeners []net.Listener
+	for i := 0; i < 2; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if nil != err {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		listeners = append(listeners, l)
+	}
+
+	var resolved []string
+	resolver := transport.ResolverFunc(func(ctx context.Context, host string) ([]transport.Addr, error) {
+		resolved = append(resolved, host)
+		// service-discovery results ranked by the resolver.
+		return []transport.Addr{closed.Addr(), listeners[0].Addr(), listeners[1].Addr()}, nil
+	})
+
+	options, err := transport.ParseOptions(context.Background(), "tcp://echo.service.consul:9527", transport.WithResolver(resolver))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	tt, err := New().Connect(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer tt.Close()
+
+	if 1 != len(resolved) || "echo.service.consul:9527" != resolved[0] {
+		t.Fatal("unexpected resolved hosts:", resolved)
+	}
+
+	// the refused address is skipped, and the first available one is used.
+	if tt.RemoteAddr().String() != listeners[0].Addr().String() {
+		t.Fatal("unexpected remote address:", tt.RemoteAddr(), "want:", listeners[0].Addr())
+	}
+}
+
+// faultListener fails the first accepts with the errors.
+type faultListener struct {
+	*net.TCPListener
+	errs []error
+}
+
+func (l *faultListener) AcceptTCP() (*net.TCPConn, error) {
+	if len(l.errs) > 0 {
+		err := l.errs[0]
+		l.errs = l.errs[1:]
+		return nil, err
+	}
+	return l.TCPListener.AcceptTCP()
+}
+
+func TestAcceptTemporaryError(t *testing.T) {
+
+	options, err := transport.ParseOptions(context.Background(), "tcp://127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	acceptor, err := New().Listen(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer acceptor.Close()
+
+	// the accept errors of the fd exhaustion.
+	ta := acceptor.(*tcpAcceptor)
+	emfile := &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	listener := &faultListener{TCPListener: ta.listener.(*net.TCPListener), errs: []error{emfile, emfile}}
+	ta.listener = listener
+
+	// the temporary errors are returned at once for the backoff of bootstrap.
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		if _, err := acceptor.Accept(); !transport.IsTemporary(err) {
+			t.Fatal("unexpected error:", err)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Fatal("temporary error is retried by the acceptor:", elapsed)
+		}
+	}
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tt, err := acceptor.Accept()
+	if nil != err {
+		t.Fatal(err)
+	}
+	_ = tt.Close()
+}
+
+func TestParseURL(t *testing.T) {
+
+	factory, address, opts, err := transport.ParseURL("tcp4://127.0.0.1:8080?nodelay=false&timeout=3s&keep-alive&linger=0&sockbuf=4096")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if !factory.Schemes().Valid("tcp4") || "tcp4://127.0.0.1:8080" != address {
+		t.Fatal("unexpected transport:", factory.Schemes(), address)
+	}
+
+	options, err := transport.ParseOptions(context.Background(), address, opts...)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	o := FromContext(options.Context, nil)
+	if nil == o || o.NoDelay || 3*time.Second != o.Timeout || !o.KeepAlive || 0 != o.Linger || 4096 != o.SockBuf {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+
+	// the others are default.
+	if DefaultOption.KeepAlivePeriod != o.KeepAlivePeriod {
+		t.Fatal("unexpected keep-alive period:", o.KeepAlivePeriod)
+	}
+
+	for _, rawurl := range []string{"tcp://127.0.0.1:8080?nodelay=maybe", "tcp://127.0.0.1:8080?timeout=3", "tcp://127.0.0.1:8080?unknown=1"} {
+		if _, _, _, err := transport.ParseURL(rawurl); nil == err {
+			t.Fatal("expect error:", rawurl)
+		}
+	}
+}
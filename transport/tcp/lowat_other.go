@@ -0,0 +1,31 @@
+//go:build !(linux || darwin)
+// +build !linux,!darwin
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// setNotSentLowat is not supported.
+func setNotSentLowat(conn *net.TCPConn, lowat int) error {
+	return fmt.Errorf("%w: TCP_NOTSENT_LOWAT on %s", ErrNotSupported, runtime.GOOS)
+}
@@ -0,0 +1,68 @@
+//go:build linux || darwin
+// +build linux darwin
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestNotSentLowat(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	options, err := transport.ParseOptions(context.Background(), "tcp://"+l.Addr().String(),
+		WithOptions(&Options{NotSentLowat: 16384}))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	tt, err := New().Connect(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer tt.Close()
+
+	rawConn, err := tt.RawTransport().(*net.TCPConn).SyscallConn()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	var lowat int
+	var sockErr error
+	_ = rawConn.Control(func(fd uintptr) {
+		lowat, sockErr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, tcpNotSentLowat)
+	})
+	if nil != sockErr {
+		t.Fatal(sockErr)
+	}
+
+	if 16384 != lowat {
+		t.Fatal("unexpected TCP_NOTSENT_LOWAT:", lowat)
+	}
+}
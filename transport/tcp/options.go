@@ -18,6 +18,10 @@ package tcp
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/mijingduI/go-netty/transport"
@@ -42,6 +46,62 @@ type Options struct {
 	SockBuf         int           `json:"sockbuf"`
 	ReadBufferSize  int           `json:"readBufferSize"`
 	WriteBufferSize int           `json:"writeBufferSize"`
+	// MaxReadBufferSize enables the adaptive read buffer if > 0, the buffer starts with ReadBufferSize
+	// and adapts to the size of reads within [MinReadBufferSize, MaxReadBufferSize], MinReadBufferSize defaults to 64.
+	MinReadBufferSize int `json:"minReadBufferSize"`
+	MaxReadBufferSize int `json:"maxReadBufferSize"`
+	// NotSentLowat limits the unsent bytes in the kernel buffer by TCP_NOTSENT_LOWAT if > 0,
+	// it is supported on linux & darwin only, the connection fails with ErrNotSupported elsewhere.
+	NotSentLowat int `json:"notSentLowat"`
+	// OOBInline sets SO_OOBINLINE to receive the urgent byte in the normal stream, linux only.
+	OOBInline bool `json:"oobInline"`
+	// UrgentData watches the urgent byte received out of band, see UrgentTransport, linux only,
+	// it could not be used with OOBInline or TLS, note that the kernel discards the urgent byte if
+	// the stream is read past it before it is received by the watcher.
+	UrgentData bool `json:"urgentData"`
+	// Stack controls the ip stack of listener on dual-stack hosts, e.g. IPv6Only to disable the ipv4-mapped addresses.
+	Stack IPStack `json:"stack"`
+	// Backlog overrides the size of accept queue if > 0, it is limited by the system, e.g. SOMAXCONN.
+	Backlog int `json:"backlog"`
+	// TLS enables tls over tcp if not nil, the handshake is finished before the channel is active.
+	TLS *tls.Config `json:"-"`
+	// VerifyPeer is called after the certificate chain of peer is verified in the tls handshake,
+	// the returned identity is exposed by the transport, and the connection is rejected on error.
+	VerifyPeer PeerVerifier `json:"-"`
+	// CloseNotifyTimeout bounds the time to send the close_notify of tls on Close, DefaultCloseNotifyTimeout if not set.
+	CloseNotifyTimeout time.Duration `json:"closeNotifyTimeout"`
+}
+
+// IPStack defines the ip stack of listener
+type IPStack int
+
+const (
+	// DefaultStack follows the scheme like Go, the tcp listener of an unspecified address accepts
+	// both ipv4 & ipv6 clients, and the tcp6 listener is ipv6-only.
+	DefaultStack IPStack = iota
+	// IPv4Only listens on ipv4 only.
+	IPv4Only
+	// IPv6Only listens on ipv6 only with IPV6_V6ONLY set.
+	IPv6Only
+)
+
+// ErrNotSupported is returned if the option is not supported on the platform.
+var ErrNotSupported = errors.New("tcp: not supported on this platform")
+
+// PeerVerifier to verify the peer of a tls connection, e.g. check the SPIFFE ID of the client certificate.
+type PeerVerifier func(state tls.ConnectionState) (identity interface{}, err error)
+
+// ParseQuery parse the tcp options from query based on DefaultOption, e.g. nodelay=false&timeout=3s, see transport.ParseURL.
+func (*tcpFactory) ParseQuery(query url.Values) ([]transport.Option, url.Values, error) {
+	option := *DefaultOption
+	unused, err := transport.DecodeQuery(&option, query)
+	if nil != err {
+		return nil, nil, err
+	}
+	if len(unused) > 0 {
+		return nil, nil, fmt.Errorf("tcp: unknown options: %s", unused.Encode())
+	}
+	return []transport.Option{WithOptions(&option)}, nil, nil
 }
 
 type contextKey struct{}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+// PushTransport defines the tcp transport which could push the written bytes immediately
+type PushTransport interface {
+	// Push flush the buffered bytes and send them without waiting for Nagle's algorithm by enabling
+	// TCP_NODELAY temporarily, so the small writes are batched and the last one is sent at once,
+	// it is the same as Flush if Options.NoDelay is set.
+	Push() error
+}
+
+func (t *tcpTransport) Push() error {
+	if err := t.Flush(); nil != err {
+		return err
+	}
+
+	if t.options.NoDelay {
+		return nil
+	}
+
+	// setting TCP_NODELAY sends the pending segments.
+	if err := t.conn.SetNoDelay(true); nil != err {
+		return err
+	}
+	return t.conn.SetNoDelay(false)
+}
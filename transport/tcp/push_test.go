@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestPush(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	options, err := transport.ParseOptions(context.Background(), "tcp://"+l.Addr().String(),
+		WithOptions(&Options{NoDelay: false, WriteBufferSize: 1024}))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	tt, err := New().Connect(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer tt.Close()
+
+	peer, err := l.Accept()
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+
+	// the small writes are batched.
+	for _, s := range []string{"hello", " ", "push"} {
+		if _, err = tt.Write([]byte(s)); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	buffer := make([]byte, 16)
+	_ = peer.SetReadDeadline(time.Now().Add(time.Millisecond * 50))
+	if n, err := peer.Read(buffer); nil == err {
+		t.Fatal("sent before pushed:", string(buffer[:n]))
+	}
+
+	start := time.Now()
+	if err = tt.(PushTransport).Push(); nil != err {
+		t.Fatal(err)
+	}
+
+	_ = peer.SetReadDeadline(time.Now().Add(time.Second))
+	var received []byte
+	for len(received) < len("hello push") {
+		n, err := peer.Read(buffer)
+		if nil != err {
+			t.Fatal(err)
+		}
+		received = append(received, buffer[:n]...)
+	}
+	if "hello push" != string(received) {
+		t.Fatal("unexpected push:", string(received))
+	}
+	if elapsed := time.Since(start); elapsed > time.Millisecond*40 {
+		t.Fatal("the push is delayed:", elapsed)
+	}
+
+	// Nagle's algorithm is restored.
+	effective, err := tt.(EffectiveTransport).EffectiveOptions()
+	switch {
+	case errors.Is(err, ErrNotSupported):
+	case nil != err:
+		t.Fatal(err)
+	case effective.NoDelay:
+		t.Fatal("TCP_NODELAY is not restored")
+	}
+}
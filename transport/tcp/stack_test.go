@@ -0,0 +1,87 @@
+//go:build linux || darwin
+// +build linux darwin
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestListenIPStack(t *testing.T) {
+
+	if l, err := net.Listen("tcp6", "[::1]:0"); nil != err {
+		t.Skip("ipv6 is not available:", err)
+	} else {
+		_ = l.Close()
+	}
+
+	listen := func(stack IPStack) (transport.Acceptor, int) {
+		options, err := transport.ParseOptions(context.Background(), "tcp://[::]:0", WithOptions(&Options{Stack: stack}))
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		acceptor, err := New().Listen(options)
+		if nil != err {
+			t.Fatal(err)
+		}
+		return acceptor, acceptor.(*tcpAcceptor).listener.Addr().(*net.TCPAddr).Port
+	}
+
+	dial := func(address string, port int) error {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", address, port), time.Second)
+		if nil == err {
+			_ = conn.Close()
+		}
+		return err
+	}
+
+	var cases = []struct {
+		stack IPStack
+		ipv4  bool
+	}{
+		{stack: DefaultStack, ipv4: true},
+		{stack: IPv6Only, ipv4: false},
+	}
+
+	for _, c := range cases {
+		acceptor, port := listen(c.stack)
+
+		if err := dial("[::1]", port); nil != err {
+			t.Fatal("ipv6 client:", err)
+		}
+
+		if err := dial("127.0.0.1", port); c.ipv4 != (nil == err) {
+			t.Fatalf("stack %d, ipv4 client: %v", c.stack, err)
+		}
+		_ = acceptor.Close()
+	}
+
+	// conflicts with the scheme.
+	options, _ := transport.ParseOptions(context.Background(), "tcp4://0.0.0.0:0", WithOptions(&Options{Stack: IPv6Only}))
+	if _, err := New().Listen(options); nil == err {
+		t.Fatal("expect conflict error")
+	}
+}
@@ -17,17 +17,43 @@
 package tcp
 
 import (
+	"crypto/tls"
 	"net"
+	"time"
 
 	"github.com/mijingduI/go-netty/transport"
 )
 
 type tcpTransport struct {
 	transport.Transport
-	client bool
+	conn     *net.TCPConn
+	tls      *tls.Conn // nil if not over tls
+	options  *Options
+	client   bool
+	identity interface{}
+	urgent   chan byte
+	timing   transport.ConnectTiming
 }
 
-func newTcpTransport(conn *net.TCPConn, tcpOptions *Options, client bool) (*tcpTransport, error) {
+// PeerIdentity returns the identity of peer verified by Options.VerifyPeer
+func (t *tcpTransport) PeerIdentity() interface{} {
+	return t.identity
+}
+
+// ConnectTiming returns the timing of connection establishment, only the tls handshake is timed for the accepted one.
+func (t *tcpTransport) ConnectTiming() transport.ConnectTiming {
+	return t.timing
+}
+
+// Buffered returns the inbound bytes buffered by the transport.
+func (t *tcpTransport) Buffered() int {
+	if b, ok := t.Transport.(transport.BufferedTransport); ok {
+		return b.Buffered()
+	}
+	return 0
+}
+
+func newTcpTransport(conn *net.TCPConn, tcpOptions *Options, client bool, serverName string) (*tcpTransport, error) {
 
 	if err := conn.SetKeepAlive(tcpOptions.KeepAlive); nil != err {
 		return nil, err
@@ -45,6 +71,12 @@ func newTcpTransport(conn *net.TCPConn, tcpOptions *Options, client bool) (*tcpT
 		return nil, err
 	}
 
+	if tcpOptions.NotSentLowat > 0 {
+		if err := setNotSentLowat(conn, tcpOptions.NotSentLowat); nil != err {
+			return nil, err
+		}
+	}
+
 	if tcpOptions.SockBuf > 0 {
 		if err := conn.SetReadBuffer(tcpOptions.SockBuf); nil != err {
 			return nil, err
@@ -55,8 +87,101 @@ func newTcpTransport(conn *net.TCPConn, tcpOptions *Options, client bool) (*tcpT
 		}
 	}
 
+	var netConn net.Conn = conn
+	var tlsConn *tls.Conn
+	var identity interface{}
+	var timing transport.ConnectTiming
+	if nil != tcpOptions.TLS {
+		start := time.Now()
+		tc, id, err := handshake(conn, tcpOptions, client, serverName)
+		if nil != err {
+			return nil, err
+		}
+		netConn, tlsConn, identity = tc, tc, id
+		timing.TLS, timing.Handshake = true, time.Since(start)
+	}
+
+	var t transport.Transport
+	if tcpOptions.MaxReadBufferSize > 0 {
+		minRead := tcpOptions.MinReadBufferSize
+		if minRead <= 0 {
+			minRead = 64
+		}
+		if minRead > tcpOptions.MaxReadBufferSize {
+			minRead = tcpOptions.MaxReadBufferSize
+		}
+		t = transport.NewAdaptiveTransport(netConn, minRead, tcpOptions.ReadBufferSize,
+			tcpOptions.MaxReadBufferSize, tcpOptions.WriteBufferSize)
+	} else {
+		t = transport.NewTransport(netConn, tcpOptions.ReadBufferSize, tcpOptions.WriteBufferSize)
+	}
+
+	urgent, err := startUrgent(conn, tcpOptions)
+	if nil != err {
+		return nil, err
+	}
+
 	return &tcpTransport{
-		Transport: transport.NewTransport(conn, tcpOptions.ReadBufferSize, tcpOptions.WriteBufferSize),
+		Transport: t,
+		conn:      conn,
+		tls:       tlsConn,
+		options:   tcpOptions,
 		client:    client,
+		identity:  identity,
+		urgent:    urgent,
+		timing:    timing,
 	}, nil
 }
+
+// handshake to finish the tls handshake in time, returns the identity of peer if VerifyPeer is set.
+func handshake(conn net.Conn, tcpOptions *Options, client bool, serverName string) (*tls.Conn, interface{}, error) {
+
+	config := tcpOptions.TLS
+	if client && "" == config.ServerName && !config.InsecureSkipVerify {
+		config = config.Clone()
+		config.ServerName = serverName
+	}
+
+	// verify the peer in the handshake, so the rejection is reported to the peer by alert.
+	var identity interface{}
+	if nil != tcpOptions.VerifyPeer {
+		if config == tcpOptions.TLS {
+			config = config.Clone()
+		}
+		verifyConnection := config.VerifyConnection
+		config.VerifyConnection = func(state tls.ConnectionState) (err error) {
+			if nil != verifyConnection {
+				if err = verifyConnection(state); nil != err {
+					return err
+				}
+			}
+			identity, err = tcpOptions.VerifyPeer(state)
+			return err
+		}
+	}
+
+	var tlsConn *tls.Conn
+	if client {
+		tlsConn = tls.Client(conn, config)
+	} else {
+		tlsConn = tls.Server(conn, config)
+	}
+
+	if tcpOptions.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(tcpOptions.Timeout)); nil != err {
+			return nil, nil, err
+		}
+	}
+
+	// a failed negotiation is reported to the peer by alert, e.g. no_application_protocol.
+	if err := tlsConn.Handshake(); nil != err {
+		return nil, nil, err
+	}
+
+	if tcpOptions.Timeout > 0 {
+		if err := conn.SetDeadline(time.Time{}); nil != err {
+			return nil, nil, err
+		}
+	}
+	return tlsConn, identity, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// UrgentTransport defines the tcp transport which supports the urgent data, aka out-of-band data.
+type UrgentTransport interface {
+	// WriteUrgent to flush the buffered bytes, then send p with MSG_OOB, the last byte of p is the
+	// urgent byte, it bypasses the write queue of channel, so do not call it concurrently with writes.
+	WriteUrgent(p []byte) error
+
+	// Urgent returns the received urgent bytes if Options.UrgentData is enabled, otherwise nil,
+	// it is closed when the connection is closed.
+	Urgent() <-chan byte
+}
+
+// urgentPollInterval is the max time of the fd held by the urgent watcher, it delays the close of connection.
+const urgentPollInterval = time.Millisecond * 50
+
+func (t *tcpTransport) WriteUrgent(p []byte) error {
+	if nil != t.options.TLS {
+		return errors.New("tcp: urgent data is not supported over tls")
+	}
+
+	if err := t.Flush(); nil != err {
+		return err
+	}
+	return writeUrgent(t.conn, p)
+}
+
+func (t *tcpTransport) Urgent() <-chan byte {
+	return t.urgent
+}
+
+// startUrgent to apply the urgent options, returns the channel of urgent bytes if UrgentData is enabled.
+func startUrgent(conn *net.TCPConn, tcpOptions *Options) (chan byte, error) {
+	switch {
+	case tcpOptions.UrgentData && nil != tcpOptions.TLS:
+		return nil, errors.New("tcp: urgent data is not supported over tls")
+	case tcpOptions.UrgentData && tcpOptions.OOBInline:
+		return nil, errors.New("tcp: urgent data is received inline, disable OOBInline to watch it")
+	case tcpOptions.OOBInline:
+		return nil, setOOBInline(conn)
+	case tcpOptions.UrgentData:
+		urgent := make(chan byte, 16)
+		if err := watchUrgent(conn, urgent); nil != err {
+			return nil, err
+		}
+		return urgent, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,126 @@
+//go:build linux
+// +build linux
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	pollPri  = 0x2
+	pollErr  = 0x8
+	pollHup  = 0x10
+	pollNval = 0x20
+)
+
+type pollFd struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+// setOOBInline to receive the urgent byte in the normal stream.
+func setOOBInline(conn *net.TCPConn) error {
+	rawConn, err := conn.SyscallConn()
+	if nil != err {
+		return err
+	}
+
+	var sockErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_OOBINLINE, 1)
+	}); nil != err {
+		return err
+	}
+	return sockErr
+}
+
+// writeUrgent to send p with MSG_OOB.
+func writeUrgent(conn *net.TCPConn, p []byte) error {
+	rawConn, err := conn.SyscallConn()
+	if nil != err {
+		return err
+	}
+
+	var sendErr error
+	if err = rawConn.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendto(int(fd), p, syscall.MSG_OOB, nil)
+		return syscall.EAGAIN != sendErr
+	}); nil != err {
+		return err
+	}
+	return sendErr
+}
+
+// watchUrgent to poll the urgent byte by POLLPRI, which is not watched by the netpoller of Go.
+func watchUrgent(conn *net.TCPConn, urgent chan<- byte) error {
+	rawConn, err := conn.SyscallConn()
+	if nil != err {
+		return err
+	}
+
+	go func() {
+		defer close(urgent)
+
+		buffer := make([]byte, 1)
+		for {
+			var n int
+			var recvErr error
+			if err := rawConn.Control(func(fd uintptr) {
+				// the timeout is updated to the remaining time by ppoll.
+				pfd := pollFd{fd: int32(fd), events: pollPri}
+				timeout := syscall.NsecToTimespec(int64(urgentPollInterval))
+				_, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&pfd)), 1,
+					uintptr(unsafe.Pointer(&timeout)), 0, 0, 0)
+				switch {
+				case 0 != errno && syscall.EINTR != errno:
+					recvErr = errno
+				case 0 != pfd.revents&(pollErr|pollHup|pollNval):
+					recvErr = syscall.ECONNRESET
+				case 0 != pfd.revents&pollPri:
+					n, _, recvErr = syscall.Recvfrom(int(fd), buffer, syscall.MSG_OOB)
+				}
+			}); nil != err {
+				// closed.
+				return
+			}
+
+			switch recvErr {
+			case nil:
+			case syscall.EAGAIN, syscall.EINVAL:
+				// the urgent byte is not arrived or read already.
+				continue
+			default:
+				return
+			}
+
+			if n > 0 {
+				select {
+				case urgent <- buffer[0]:
+				default:
+					// drop the urgent byte if nobody reads.
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,114 @@
+//go:build linux
+// +build linux
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func TestUrgentData(t *testing.T) {
+
+	var cases = []struct {
+		options *Options
+		stream  string
+		urgent  bool
+	}{
+		{options: &Options{UrgentData: true}, stream: "abcd", urgent: true},
+		{options: &Options{OOBInline: true}, stream: "ab!cd", urgent: false},
+	}
+
+	for _, c := range cases {
+		listenOptions, err := transport.ParseOptions(context.Background(), "tcp://127.0.0.1:0", WithOptions(c.options))
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		acceptor, err := New().Listen(listenOptions)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		connectOptions, err := transport.ParseOptions(context.Background(),
+			"tcp://"+acceptor.(*tcpAcceptor).listener.Addr().String())
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		client, err := New().Connect(connectOptions)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		server, err := acceptor.Accept()
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if _, err = client.Write([]byte("ab")); nil != err {
+			t.Fatal(err)
+		}
+		if err = client.(UrgentTransport).WriteUrgent([]byte("!")); nil != err {
+			t.Fatal(err)
+		}
+		urgent := server.(UrgentTransport).Urgent()
+		if !c.urgent {
+			if nil != urgent {
+				t.Fatal("urgent data should be inline")
+			}
+		} else {
+			// the urgent byte is discarded if the stream is read past it before received.
+			select {
+			case data := <-urgent:
+				if '!' != data {
+					t.Fatal("unexpected urgent byte:", data)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("urgent byte is not received")
+			}
+		}
+
+		if _, err = client.Write([]byte("cd")); nil != err {
+			t.Fatal(err)
+		}
+
+		stream := make([]byte, len(c.stream))
+		if _, err = io.ReadFull(server, stream); nil != err || c.stream != string(stream) {
+			t.Fatalf("unexpected stream: %q, %v", stream, err)
+		}
+
+		_ = client.Close()
+		_ = server.Close()
+		_ = acceptor.Close()
+
+		// the watcher exits after closed.
+		if nil != urgent {
+			select {
+			case <-urgent:
+			case <-time.After(time.Second):
+				t.Fatal("urgent watcher is not stopped")
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !linux
+// +build !linux
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+func setOOBInline(conn *net.TCPConn) error {
+	return fmt.Errorf("%w: SO_OOBINLINE on %s", ErrNotSupported, runtime.GOOS)
+}
+
+func writeUrgent(conn *net.TCPConn, p []byte) error {
+	return fmt.Errorf("%w: urgent data on %s", ErrNotSupported, runtime.GOOS)
+}
+
+func watchUrgent(conn *net.TCPConn, urgent chan<- byte) error {
+	return fmt.Errorf("%w: urgent data on %s", ErrNotSupported, runtime.GOOS)
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transport
+
+import "time"
+
+// ConnectTiming is the time spent on each phase of establishing a client connection.
+type ConnectTiming struct {
+	// Start is the time the connect started.
+	Start time.Time `json:"start"`
+	// Resolve is the time to resolve the host.
+	Resolve time.Duration `json:"resolve"`
+	// Connect is the time to dial the resolved addresses until connected.
+	Connect time.Duration `json:"connect"`
+	// TLS is true if the connection is over tls.
+	TLS bool `json:"tls"`
+	// Handshake is the time of tls handshake, zero if not over tls.
+	Handshake time.Duration `json:"handshake"`
+}
+
+// Elapsed returns the total time to establish the connection.
+func (t ConnectTiming) Elapsed() time.Duration {
+	return t.Resolve + t.Connect + t.Handshake
+}
+
+// TimingTransport defines the transport which reports the timing of connection establishment, e.g. the tcp transport
+type TimingTransport interface {
+	// ConnectTiming returns the timing of the client connection, zero for the accepted one.
+	ConnectTiming() ConnectTiming
+}
@@ -17,9 +17,11 @@
 package transport
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
+	"syscall"
 )
 
 // 传输层定义，一般按照传输协议可以简单分类为两种:
@@ -61,12 +63,39 @@ type Transport interface {
 	RawTransport() interface{}
 }
 
+// BufferedTransport defines a transport buffers the inbound bytes.
+type BufferedTransport interface {
+	// Buffered returns the number of inbound bytes that can be read without reading the connection.
+	Buffered() int
+}
+
 // Acceptor defines transport acceptor
 type Acceptor interface {
 	Accept() (Transport, error)
 	Close() error
 }
 
+// IsTemporary return true if the accept error is transient and the accept should be retried,
+// such as timeout or the exhaustion of file descriptors.
+func IsTemporary(err error) bool {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+
+	var te interface{ Temporary() bool }
+	if errors.As(err, &te) && te.Temporary() {
+		return true
+	}
+
+	for _, errno := range []syscall.Errno{syscall.EMFILE, syscall.ENFILE, syscall.ENOBUFS, syscall.ENOMEM, syscall.ECONNABORTED} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
 // Factory defines transport factory
 type Factory interface {
 
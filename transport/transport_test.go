@@ -17,8 +17,10 @@
 package transport
 
 import (
+	"errors"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func TestSchemes(t *testing.T) {
@@ -51,3 +53,86 @@ func TestSchemes(t *testing.T) {
 	}
 
 }
+
+type schemeFactory Schemes
+
+func (f schemeFactory) Schemes() Schemes                          { return Schemes(f) }
+func (schemeFactory) Connect(options *Options) (Transport, error) { return nil, nil }
+func (schemeFactory) Listen(options *Options) (Acceptor, error)   { return nil, nil }
+
+func TestRegistry(t *testing.T) {
+
+	factory := schemeFactory{"test", "test4"}
+	Register(factory)
+
+	for _, name := range []string{"test", "test4"} {
+		if f, err := TransportByName(name); nil != err || f.Schemes()[0] != "test" {
+			t.Fatal(name, f, err)
+		}
+	}
+
+	if _, err := TransportByName("test6"); !errors.Is(err, ErrUnknownTransport) {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// the later one replaces the former.
+	Register(schemeFactory{"test4"})
+	if f, _ := TransportByName("test4"); 1 != len(f.Schemes()) {
+		t.Fatal("factory is not replaced")
+	}
+}
+
+func TestParseURL(t *testing.T) {
+
+	Register(schemeFactory{"test"})
+
+	factory, address, options, err := ParseURL("test://127.0.0.1:8080/path?a=1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	// the query stays in the address if the factory is not a QueryParser.
+	if "test" != factory.Schemes()[0] || "test://127.0.0.1:8080/path?a=1" != address || 0 != len(options) {
+		t.Fatal("unexpected result:", factory, address, options)
+	}
+
+	if _, _, _, err := ParseURL("quic://127.0.0.1:8080"); !errors.Is(err, ErrUnknownTransport) {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, _, _, err := ParseURL("127.0.0.1:8080"); nil == err {
+		t.Fatal("expect missing scheme error")
+	}
+}
+
+func TestDecodeQuery(t *testing.T) {
+
+	var v struct {
+		Enabled  bool          `json:"enabled"`
+		Size     int           `json:"size"`
+		Mask     uint8         `json:"mask"`
+		Ratio    float64       `json:"ratio"`
+		Name     string        `json:"name"`
+		Timeout  time.Duration `json:"timeout"`
+		Names    []string      `json:"names"`
+		Internal int           `json:"-"`
+	}
+
+	query, _ := url.ParseQuery("enabled&SIZE=16&mask=0xff&ratio=0.5&name=go&timeout=1m&names=a,b&names=c&Internal=1")
+	unused, err := DecodeQuery(&v, query)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if !v.Enabled || 16 != v.Size || 0xff != v.Mask || 0.5 != v.Ratio || "go" != v.Name || time.Minute != v.Timeout || 3 != len(v.Names) || 0 != v.Internal {
+		t.Fatalf("unexpected decoded: %+v", v)
+	}
+
+	if "Internal=1" != unused.Encode() {
+		t.Fatal("unexpected unused:", unused)
+	}
+
+	if _, err := DecodeQuery(&v, url.Values{"mask": {"256"}}); nil == err {
+		t.Fatal("expect overflow error")
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"context"
+	"net"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// New udp factory
+func New() transport.Factory {
+	return new(udpFactory)
+}
+
+func init() {
+	transport.Register(New())
+}
+
+type udpFactory struct{}
+
+func (*udpFactory) Schemes() transport.Schemes {
+	return transport.Schemes{"udp", "udp4", "udp6"}
+}
+
+func (f *udpFactory) Connect(options *transport.Options) (transport.Transport, error) {
+
+	if err := f.Schemes().FixScheme(options.Address); nil != err {
+		return nil, err
+	}
+
+	udpOptions := FromContext(options.Context, DefaultOption)
+
+	var d net.Dialer
+	conn, err := transport.DialAddresses(options.Context, options.Resolver, options.Address.Host, func(ctx context.Context, address string) (net.Conn, error) {
+		return d.DialContext(ctx, options.Address.Scheme, address)
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	if err = setBuffers(conn.(*net.UDPConn), udpOptions); nil != err {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// send to the multicast group.
+	if remote := conn.RemoteAddr().(*net.UDPAddr); remote.IP.IsMulticast() {
+		if err = dialMulticast(conn.(*net.UDPConn), remote.IP, udpOptions); nil != err {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return &connTransport{UDPConn: conn.(*net.UDPConn), options: udpOptions}, nil
+}
+
+func (f *udpFactory) Listen(options *transport.Options) (transport.Acceptor, error) {
+
+	if err := f.Schemes().FixScheme(options.Address); nil != err {
+		return nil, err
+	}
+
+	udpOptions := FromContext(options.Context, DefaultOption)
+
+	// the listeners of multicast group share the port.
+	var lc net.ListenConfig
+	if "" != udpOptions.MulticastGroup {
+		lc.Control = reuseAddress
+	}
+
+	pc, err := lc.ListenPacket(options.Context, options.Address.Scheme, options.AddressWithoutHost())
+	if nil != err {
+		return nil, err
+	}
+	conn := pc.(*net.UDPConn)
+
+	if err = setBuffers(conn, udpOptions); nil != err {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	var group *membership
+	if "" != udpOptions.MulticastGroup {
+		if group, err = joinGroup(conn, udpOptions); nil != err {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	if udpOptions.Connected {
+		return newPeerAcceptor(conn, udpOptions, group), nil
+	}
+	return &packetAcceptor{
+		transport: &packetTransport{UDPConn: conn, options: udpOptions, group: group},
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// dialMulticast to set the interface, ttl & loopback of the socket sending to the multicast group.
+func dialMulticast(conn *net.UDPConn, group net.IP, udpOptions *Options) error {
+	ifi, err := multicastInterface(udpOptions.MulticastInterface)
+	if nil != err {
+		return err
+	}
+	return setMulticast(conn, group, ifi, udpOptions)
+}
+
+func setBuffers(conn *net.UDPConn, udpOptions *Options) error {
+	if udpOptions.ReadBufferSize > 0 {
+		if err := conn.SetReadBuffer(udpOptions.ReadBufferSize); nil != err {
+			return err
+		}
+	}
+
+	if udpOptions.WriteBufferSize > 0 {
+		if err := conn.SetWriteBuffer(udpOptions.WriteBufferSize); nil != err {
+			return err
+		}
+	}
+	return nil
+}
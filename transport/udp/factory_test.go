@@ -0,0 +1,244 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func listen(t *testing.T, udpOptions *Options) transport.Acceptor {
+	options, err := transport.ParseOptions(context.Background(), "udp://127.0.0.1:0", WithOptions(udpOptions))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	acceptor, err := New().Listen(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return acceptor
+}
+
+func dial(t *testing.T, addr net.Addr) *net.UDPConn {
+	conn, err := net.DialUDP("udp", nil, addr.(*net.UDPAddr))
+	if nil != err {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestPacketTransport(t *testing.T) {
+
+	acceptor := listen(t, &Options{})
+	defer acceptor.Close()
+
+	tt, err := acceptor.Accept()
+	if nil != err {
+		t.Fatal(err)
+	}
+	pt := tt.(PacketTransport)
+
+	clients := []*net.UDPConn{dial(t, pt.LocalAddr()), dial(t, pt.LocalAddr())}
+	for i, client := range clients {
+		defer client.Close()
+		if _, err := client.Write([]byte{'a' + byte(i)}); nil != err {
+			t.Fatal(err)
+		}
+
+		datagram, err := pt.ReadDatagram()
+		if nil != err {
+			t.Fatal(err)
+		}
+		if string(rune('a'+i)) != string(datagram.Payload) || datagram.Addr.String() != client.LocalAddr().String() {
+			t.Fatalf("unexpected datagram: %q from %s", datagram.Payload, datagram.Addr)
+		}
+
+		if err := pt.WriteDatagram(Datagram{Addr: datagram.Addr, Payload: []byte("ack")}); nil != err {
+			t.Fatal(err)
+		}
+
+		buffer := make([]byte, 16)
+		_ = client.SetReadDeadline(time.Now().Add(time.Second))
+		if n, err := client.Read(buffer); nil != err || "ack" != string(buffer[:n]) {
+			t.Fatal("unexpected reply:", string(buffer[:n]), err)
+		}
+	}
+
+	if _, err := pt.Write([]byte("no destination")); ErrNoDestination != err {
+		t.Fatal("expect ErrNoDestination, got:", err)
+	}
+}
+
+func TestConnectedTransport(t *testing.T) {
+
+	acceptor := listen(t, &Options{Connected: true, IdleTimeout: time.Millisecond * 200})
+	defer acceptor.Close()
+
+	addr := acceptor.(*peerAcceptor).conn.LocalAddr()
+	clients := []*net.UDPConn{dial(t, addr), dial(t, addr)}
+	for _, client := range clients {
+		defer client.Close()
+	}
+
+	accept := func() transport.Transport {
+		result := make(chan transport.Transport, 1)
+		go func() {
+			tt, _ := acceptor.Accept()
+			result <- tt
+		}()
+		select {
+		case tt := <-result:
+			return tt
+		case <-time.After(time.Second):
+			t.Fatal("no transport accepted")
+			return nil
+		}
+	}
+
+	var peers []transport.Transport
+	for _, client := range clients {
+		if _, err := client.Write([]byte("hello")); nil != err {
+			t.Fatal(err)
+		}
+		peer := accept()
+		if peer.RemoteAddr().String() != client.LocalAddr().String() {
+			t.Fatal("unexpected remote address:", peer.RemoteAddr(), "want:", client.LocalAddr())
+		}
+		peers = append(peers, peer)
+	}
+
+	// the datagrams of a remote address are delivered to its own transport.
+	if _, err := clients[1].Write([]byte("world")); nil != err {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, 16)
+	for _, want := range []string{"hello", "world"} {
+		if n, err := peers[1].Read(buffer); nil != err || want != string(buffer[:n]) {
+			t.Fatal("unexpected datagram:", string(buffer[:n]), err, "want:", want)
+		}
+	}
+	if n, err := peers[0].Read(buffer); nil != err || "hello" != string(buffer[:n]) {
+		t.Fatal("unexpected datagram:", string(buffer[:n]), err)
+	}
+
+	// each message is sent as one datagram.
+	if _, err := peers[0].Writev(transport.Buffers{Buffers: net.Buffers{[]byte("a"), []byte("b"), []byte("c")}, Indexes: []int{2, 3}}); nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"ab", "c"} {
+		_ = clients[0].SetReadDeadline(time.Now().Add(time.Second))
+		if n, err := clients[0].Read(buffer); nil != err || want != string(buffer[:n]) {
+			t.Fatal("unexpected datagram:", string(buffer[:n]), err, "want:", want)
+		}
+	}
+
+	// the idle transports are evicted.
+	if _, err := peers[0].Read(buffer); io.EOF != err {
+		t.Fatal("expect idle transport evicted, got:", err)
+	}
+
+	// a new transport is accepted if the evicted address sends again.
+	if _, err := clients[0].Write([]byte("again")); nil != err {
+		t.Fatal(err)
+	}
+	if peer := accept(); peer == peers[0] || peer.RemoteAddr().String() != clients[0].LocalAddr().String() {
+		t.Fatal("evicted transport is reused")
+	}
+
+	_ = acceptor.Close()
+	if _, err := acceptor.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatal("expect acceptor closed, got:", err)
+	}
+}
+
+func TestConnect(t *testing.T) {
+
+	acceptor := listen(t, &Options{})
+	defer acceptor.Close()
+
+	tt, err := acceptor.Accept()
+	if nil != err {
+		t.Fatal(err)
+	}
+	server := tt.(PacketTransport)
+
+	options, err := transport.ParseOptions(context.Background(), "udp://"+server.LocalAddr().String())
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	client, err := New().Connect(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.Write([]byte("ping")); nil != err {
+		t.Fatal(err)
+	}
+
+	datagram, err := server.ReadDatagram()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if "ping" != string(datagram.Payload) {
+		t.Fatal("unexpected datagram:", string(datagram.Payload))
+	}
+
+	if err := server.WriteDatagram(Datagram{Addr: datagram.Addr, Payload: []byte("pong")}); nil != err {
+		t.Fatal(err)
+	}
+
+	received, err := client.(PacketTransport).ReadDatagram()
+	if nil != err || "pong" != string(received.Payload) {
+		t.Fatal("unexpected datagram:", string(received.Payload), err)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+
+	factory, address, opts, err := transport.ParseURL("udp://127.0.0.1:9000?connected=true&idleTimeout=30s&queueSize=16")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if !factory.Schemes().Valid("udp") || "udp://127.0.0.1:9000" != address {
+		t.Fatal("unexpected transport:", factory.Schemes(), address)
+	}
+
+	options, err := transport.ParseOptions(context.Background(), address, opts...)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	o := FromContext(options.Context, nil)
+	if nil == o || !o.Connected || 30*time.Second != o.IdleTimeout || 16 != o.QueueSize || DefaultOption.MaxDatagramSize != o.MaxDatagramSize {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+
+	if _, _, _, err := transport.ParseURL("udp://127.0.0.1:9000?nodelay=true"); nil == err {
+		t.Fatal("expect unknown option error")
+	}
+}
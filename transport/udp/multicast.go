@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"fmt"
+	"net"
+)
+
+// membership of the multicast group joined by listener.
+type membership struct {
+	conn  *net.UDPConn
+	group net.IP
+	ifi   *net.Interface
+}
+
+// joinGroup to join the multicast group of options.
+func joinGroup(conn *net.UDPConn, options *Options) (*membership, error) {
+	group := net.ParseIP(options.MulticastGroup)
+	if nil == group || !group.IsMulticast() {
+		return nil, fmt.Errorf("udp: invalid multicast group: %s", options.MulticastGroup)
+	}
+
+	ifi, err := multicastInterface(options.MulticastInterface)
+	if nil != err {
+		return nil, err
+	}
+
+	if err = setMulticast(conn, group, ifi, options); nil != err {
+		return nil, err
+	}
+
+	if err = setMembership(conn, group, ifi, true); nil != err {
+		return nil, err
+	}
+	return &membership{conn: conn, group: group, ifi: ifi}, nil
+}
+
+// leave the multicast group before the socket is closed.
+func (m *membership) leave() error {
+	if nil == m {
+		return nil
+	}
+	return setMembership(m.conn, m.group, m.ifi, false)
+}
+
+// multicastInterface to find the interface by name, nil if the name is empty.
+func multicastInterface(name string) (*net.Interface, error) {
+	if "" == name {
+		return nil, nil
+	}
+	return net.InterfaceByName(name)
+}
+
+// interfaceIPv4 returns the ipv4 address of interface, the unspecified address if the interface is nil.
+func interfaceIPv4(ifi *net.Interface) ([4]byte, error) {
+	var ip [4]byte
+	if nil == ifi {
+		return ip, nil
+	}
+
+	addrs, err := ifi.Addrs()
+	if nil != err {
+		return ip, err
+	}
+
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			if ip4 := ipNet.IP.To4(); nil != ip4 {
+				copy(ip[:], ip4)
+				return ip, nil
+			}
+		}
+	}
+	return ip, fmt.Errorf("udp: no ipv4 address on interface: %s", ifi.Name)
+}
@@ -0,0 +1,27 @@
+//go:build darwin
+// +build darwin
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import "syscall"
+
+// setsockoptIPv4 to set the multicast option of ipv4 which is an u_char on darwin.
+func setsockoptIPv4(fd, opt, value int) error {
+	return syscall.SetsockoptByte(fd, syscall.IPPROTO_IP, opt, byte(value))
+}
@@ -0,0 +1,128 @@
+//go:build linux || darwin
+// +build linux darwin
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// loopbackInterface returns the name of loopback interface which supports multicast.
+func loopbackInterface(t *testing.T) string {
+	interfaces, err := net.Interfaces()
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, ifi := range interfaces {
+		if 0 != ifi.Flags&net.FlagLoopback && 0 != ifi.Flags&net.FlagUp {
+			return ifi.Name
+		}
+	}
+	t.Skip("no loopback interface")
+	return ""
+}
+
+func TestMulticast(t *testing.T) {
+
+	udpOptions := &Options{
+		MulticastGroup:     "239.255.0.1",
+		MulticastInterface: loopbackInterface(t),
+		MulticastTTL:       1,
+		MulticastLoopback:  true,
+	}
+
+	options, err := transport.ParseOptions(context.Background(), "udp://:9542", WithOptions(udpOptions))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	// the listeners of group share the port.
+	var listeners []PacketTransport
+	for i := 0; i < 2; i++ {
+		acceptor, err := New().Listen(options)
+		if nil != err {
+			t.Skip("multicast is not available:", err)
+		}
+		defer acceptor.Close()
+
+		tt, err := acceptor.Accept()
+		if nil != err {
+			t.Fatal(err)
+		}
+		listeners = append(listeners, tt.(PacketTransport))
+	}
+
+	options, err = transport.ParseOptions(context.Background(), "udp://239.255.0.1:9542", WithOptions(udpOptions))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	sender, err := New().Connect(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	if _, err := sender.Write([]byte("discover")); nil != err {
+		t.Fatal(err)
+	}
+
+	for _, listener := range listeners {
+		_ = listener.SetReadDeadline(time.Now().Add(time.Second))
+		datagram, err := listener.ReadDatagram()
+		if nil != err {
+			t.Fatal(err)
+		}
+		if "discover" != string(datagram.Payload) || datagram.Addr.String() != sender.LocalAddr().String() {
+			t.Fatalf("unexpected datagram: %q from %s", datagram.Payload, datagram.Addr)
+		}
+	}
+}
+
+func TestMulticastMembership(t *testing.T) {
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	group, err := joinGroup(conn, &Options{MulticastGroup: "239.255.0.2", MulticastInterface: loopbackInterface(t)})
+	if nil != err {
+		t.Skip("multicast is not available:", err)
+	}
+
+	if err := group.leave(); nil != err {
+		t.Fatal(err)
+	}
+
+	// not a member anymore.
+	if err := group.leave(); nil == err {
+		t.Fatal("membership is not dropped")
+	}
+
+	if _, err := joinGroup(conn, &Options{MulticastGroup: "10.0.0.1"}); nil == err {
+		t.Fatal("expect error of invalid group")
+	}
+}
@@ -0,0 +1,128 @@
+//go:build linux || darwin
+// +build linux darwin
+
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"net"
+	"syscall"
+)
+
+// setMulticast to set the interface, ttl & loopback of the multicast datagrams sent to the group.
+func setMulticast(conn *net.UDPConn, group net.IP, ifi *net.Interface, options *Options) error {
+	rawConn, err := conn.SyscallConn()
+	if nil != err {
+		return err
+	}
+
+	var loopback int
+	if options.MulticastLoopback {
+		loopback = 1
+	}
+
+	var sockErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		s := int(fd)
+		if nil == group.To4() {
+			if nil != ifi {
+				if sockErr = syscall.SetsockoptInt(s, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_IF, ifi.Index); nil != sockErr {
+					return
+				}
+			}
+			if options.MulticastTTL > 0 {
+				if sockErr = syscall.SetsockoptInt(s, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_HOPS, options.MulticastTTL); nil != sockErr {
+					return
+				}
+			}
+			sockErr = syscall.SetsockoptInt(s, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_LOOP, loopback)
+			return
+		}
+
+		if nil != ifi {
+			var addr [4]byte
+			if addr, sockErr = interfaceIPv4(ifi); nil != sockErr {
+				return
+			}
+			if sockErr = syscall.SetsockoptInet4Addr(s, syscall.IPPROTO_IP, syscall.IP_MULTICAST_IF, addr); nil != sockErr {
+				return
+			}
+		}
+		if options.MulticastTTL > 0 {
+			if sockErr = setsockoptIPv4(s, syscall.IP_MULTICAST_TTL, options.MulticastTTL); nil != sockErr {
+				return
+			}
+		}
+		sockErr = setsockoptIPv4(s, syscall.IP_MULTICAST_LOOP, loopback)
+	}); nil != err {
+		return err
+	}
+	return sockErr
+}
+
+// setMembership to join or leave the multicast group on the interface.
+func setMembership(conn *net.UDPConn, group net.IP, ifi *net.Interface, join bool) error {
+	rawConn, err := conn.SyscallConn()
+	if nil != err {
+		return err
+	}
+
+	var sockErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		if nil == group.To4() {
+			mreq := &syscall.IPv6Mreq{}
+			copy(mreq.Multiaddr[:], group.To16())
+			if nil != ifi {
+				mreq.Interface = uint32(ifi.Index)
+			}
+
+			opt := syscall.IPV6_LEAVE_GROUP
+			if join {
+				opt = syscall.IPV6_JOIN_GROUP
+			}
+			sockErr = syscall.SetsockoptIPv6Mreq(int(fd), syscall.IPPROTO_IPV6, opt, mreq)
+			return
+		}
+
+		mreq := &syscall.IPMreq{}
+		copy(mreq.Multiaddr[:], group.To4())
+		if mreq.Interface, sockErr = interfaceIPv4(ifi); nil != sockErr {
+			return
+		}
+
+		opt := syscall.IP_DROP_MEMBERSHIP
+		if join {
+			opt = syscall.IP_ADD_MEMBERSHIP
+		}
+		sockErr = syscall.SetsockoptIPMreq(int(fd), syscall.IPPROTO_IP, opt, mreq)
+	}); nil != err {
+		return err
+	}
+	return sockErr
+}
+
+// reuseAddress allows the listeners of multicast group to share the port.
+func reuseAddress(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	if err := c.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+	}); nil != err {
+		return err
+	}
+	return sockErr
+}
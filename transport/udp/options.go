@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// DefaultOption default udp options
+var DefaultOption = &Options{
+	MaxDatagramSize: 65535,
+	IdleTimeout:     time.Minute,
+	QueueSize:       64,
+}
+
+// Options fot udp transport
+type Options struct {
+	// ReadBufferSize & WriteBufferSize set the size of socket buffers if > 0.
+	ReadBufferSize  int `json:"readBufferSize"`
+	WriteBufferSize int `json:"writeBufferSize"`
+	// MaxDatagramSize is the max size of inbound datagrams, the longer ones are truncated, default 65535.
+	MaxDatagramSize int `json:"maxDatagramSize"`
+	// Connected mode accepts a transport for each remote address, otherwise the listener accepts
+	// a single transport receiving the datagrams of all peers, see PacketTransport.
+	Connected bool `json:"connected"`
+	// IdleTimeout evicts the transport of a remote address if no datagram is received or sent
+	// within the timeout in connected mode, default 1 minute.
+	IdleTimeout time.Duration `json:"idleTimeout"`
+	// QueueSize is the number of datagrams queued for each remote address in connected mode,
+	// the datagrams are dropped if the queue is full, default 64.
+	QueueSize int `json:"queueSize"`
+	// MulticastGroup joins the multicast group on listening, e.g. 239.255.0.1 or ff02::fb, the group is left
+	// when the listener is closed, the address of listener should be the port only, e.g. udp://:5353,
+	// linux & darwin only, ErrNotSupported elsewhere.
+	MulticastGroup string `json:"multicastGroup"`
+	// MulticastInterface is the name of interface to join the group and send the multicast datagrams,
+	// the interface is chosen by system if empty.
+	MulticastInterface string `json:"multicastInterface"`
+	// MulticastTTL sets the ttl (hop limit of ipv6) of the multicast datagrams if > 0, the system default is 1.
+	MulticastTTL int `json:"multicastTTL"`
+	// MulticastLoopback delivers the sent multicast datagrams to the listeners of local host.
+	MulticastLoopback bool `json:"multicastLoopback"`
+}
+
+func (o *Options) maxDatagramSize() int {
+	if o.MaxDatagramSize > 0 {
+		return o.MaxDatagramSize
+	}
+	return DefaultOption.MaxDatagramSize
+}
+
+func (o *Options) idleTimeout() time.Duration {
+	if o.IdleTimeout > 0 {
+		return o.IdleTimeout
+	}
+	return DefaultOption.IdleTimeout
+}
+
+func (o *Options) queueSize() int {
+	if o.QueueSize > 0 {
+		return o.QueueSize
+	}
+	return DefaultOption.QueueSize
+}
+
+// ErrNotSupported is returned if the option is not supported on the platform.
+var ErrNotSupported = errors.New("udp: not supported on this platform")
+
+// ParseQuery parse the udp options from query based on DefaultOption, e.g. connected&idleTimeout=30s, see transport.ParseURL.
+func (*udpFactory) ParseQuery(query url.Values) ([]transport.Option, url.Values, error) {
+	option := *DefaultOption
+	unused, err := transport.DecodeQuery(&option, query)
+	if nil != err {
+		return nil, nil, err
+	}
+	if len(unused) > 0 {
+		return nil, nil, fmt.Errorf("udp: unknown options: %s", unused.Encode())
+	}
+	return []transport.Option{WithOptions(&option)}, nil, nil
+}
+
+type contextKey struct{}
+
+// WithOptions to wrap the udp options
+func WithOptions(option *Options) transport.Option {
+	return func(options *transport.Options) error {
+		options.Context = context.WithValue(options.Context, contextKey{}, option)
+		return nil
+	}
+}
+
+// FromContext to unwrap the udp options
+func FromContext(ctx context.Context, def *Options) *Options {
+	if v, ok := ctx.Value(contextKey{}).(*Options); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,318 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// peerAcceptor demultiplex the datagrams of a udp socket into the transports of remote addresses.
+type peerAcceptor struct {
+	conn     *net.UDPConn
+	options  *Options
+	group    *membership
+	mutex    sync.Mutex
+	peers    map[string]*peerTransport
+	accepted chan *peerTransport
+	done     chan struct{}
+	once     sync.Once
+	closed   int32
+	err      error
+}
+
+func newPeerAcceptor(conn *net.UDPConn, options *Options, group *membership) *peerAcceptor {
+	return &peerAcceptor{
+		conn:     conn,
+		options:  options,
+		group:    group,
+		peers:    make(map[string]*peerTransport),
+		accepted: make(chan *peerTransport, options.queueSize()),
+		done:     make(chan struct{}),
+	}
+}
+
+func (a *peerAcceptor) Accept() (transport.Transport, error) {
+	a.once.Do(func() {
+		go a.readLoop()
+		go a.evictLoop()
+	})
+
+	select {
+	case peer := <-a.accepted:
+		return peer, nil
+	case <-a.done:
+		if nil != a.err {
+			return nil, a.err
+		}
+		return nil, net.ErrClosed
+	}
+}
+
+func (a *peerAcceptor) readLoop() {
+	buffer := make([]byte, a.options.maxDatagramSize())
+	for {
+		n, addr, err := a.conn.ReadFromUDP(buffer)
+		if nil != err {
+			if 0 != atomic.LoadInt32(&a.closed) {
+				return
+			}
+			if transport.IsTemporary(err) {
+				continue
+			}
+			a.closeWith(err)
+			return
+		}
+
+		datagram := make([]byte, n)
+		copy(datagram, buffer[:n])
+
+		if peer := a.peer(addr); nil != peer {
+			peer.deliver(datagram)
+		}
+	}
+}
+
+// peer to get the transport of remote address, a new one is accepted for the unknown address,
+// nil if the accept queue is full.
+func (a *peerAcceptor) peer(addr *net.UDPAddr) *peerTransport {
+	key := addr.String()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if peer, ok := a.peers[key]; ok {
+		return peer
+	}
+
+	peer := &peerTransport{
+		acceptor: a,
+		addr:     addr,
+		key:      key,
+		inbound:  make(chan []byte, a.options.queueSize()),
+		done:     make(chan struct{}),
+	}
+	peer.touch()
+
+	select {
+	case a.accepted <- peer:
+		a.peers[key] = peer
+		return peer
+	default:
+		// drop the datagram of new peer.
+		return nil
+	}
+}
+
+func (a *peerAcceptor) evictLoop() {
+	timeout := a.options.idleTimeout()
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case now := <-ticker.C:
+			var idle []*peerTransport
+			a.mutex.Lock()
+			for _, peer := range a.peers {
+				if now.Sub(time.Unix(0, atomic.LoadInt64(&peer.lastActive))) >= timeout {
+					idle = append(idle, peer)
+				}
+			}
+			a.mutex.Unlock()
+
+			for _, peer := range idle {
+				peer.closeWith(io.EOF)
+			}
+		}
+	}
+}
+
+func (a *peerAcceptor) remove(peer *peerTransport) {
+	a.mutex.Lock()
+	if a.peers[peer.key] == peer {
+		delete(a.peers, peer.key)
+	}
+	a.mutex.Unlock()
+}
+
+func (a *peerAcceptor) closeWith(err error) error {
+	if !atomic.CompareAndSwapInt32(&a.closed, 0, 1) {
+		return nil
+	}
+
+	a.err = err
+	close(a.done)
+
+	a.mutex.Lock()
+	peers := a.peers
+	a.peers = map[string]*peerTransport{}
+	a.mutex.Unlock()
+
+	for _, peer := range peers {
+		peer.closeWith(net.ErrClosed)
+	}
+
+	_ = a.group.leave()
+	return a.conn.Close()
+}
+
+func (a *peerAcceptor) Close() error {
+	return a.closeWith(nil)
+}
+
+// peerTransport is the transport of a remote address, each Read returns one datagram
+// which is truncated if the buffer is shorter, like a udp socket.
+type peerTransport struct {
+	acceptor   *peerAcceptor
+	addr       *net.UDPAddr
+	key        string
+	inbound    chan []byte
+	done       chan struct{}
+	once       sync.Once
+	err        error
+	lastActive int64
+	deadline   atomic.Value
+}
+
+func (p *peerTransport) touch() {
+	atomic.StoreInt64(&p.lastActive, time.Now().UnixNano())
+}
+
+func (p *peerTransport) deliver(datagram []byte) {
+	select {
+	case p.inbound <- datagram:
+		p.touch()
+	default:
+		// drop the datagram if the queue is full.
+	}
+}
+
+func (p *peerTransport) read() ([]byte, error) {
+	// the pending datagrams are read before closed.
+	select {
+	case datagram := <-p.inbound:
+		return datagram, nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if deadline, _ := p.deadline.Load().(time.Time); !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case datagram := <-p.inbound:
+		return datagram, nil
+	case <-p.done:
+		return nil, p.err
+	case <-timeout:
+		return nil, os.ErrDeadlineExceeded
+	}
+}
+
+func (p *peerTransport) Read(b []byte) (int, error) {
+	datagram, err := p.read()
+	if nil != err {
+		return 0, err
+	}
+	return copy(b, datagram), nil
+}
+
+func (p *peerTransport) ReadDatagram() (Datagram, error) {
+	datagram, err := p.read()
+	if nil != err {
+		return Datagram{}, err
+	}
+	return Datagram{Addr: p.addr, Payload: datagram}, nil
+}
+
+func (p *peerTransport) Write(b []byte) (int, error) {
+	p.touch()
+	return p.acceptor.conn.WriteToUDP(b, p.addr)
+}
+
+func (p *peerTransport) WriteDatagram(datagram Datagram) error {
+	addr := datagram.Addr
+	if nil == addr {
+		addr = p.addr
+	}
+	p.touch()
+	_, err := p.acceptor.conn.WriteToUDP(datagram.Payload, addr)
+	return err
+}
+
+func (p *peerTransport) Writev(buffs transport.Buffers) (int64, error) {
+	return writeDatagrams(p.Write, buffs)
+}
+
+func (p *peerTransport) Flush() error {
+	return nil
+}
+
+func (p *peerTransport) closeWith(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.done)
+		p.acceptor.remove(p)
+	})
+}
+
+// Close the transport of remote address, the socket is kept for other peers, and a new
+// transport is accepted if the address sends again.
+func (p *peerTransport) Close() error {
+	p.closeWith(net.ErrClosed)
+	return nil
+}
+
+func (p *peerTransport) LocalAddr() net.Addr {
+	return p.acceptor.conn.LocalAddr()
+}
+
+func (p *peerTransport) RemoteAddr() net.Addr {
+	return p.addr
+}
+
+func (p *peerTransport) SetDeadline(t time.Time) error {
+	return p.SetReadDeadline(t)
+}
+
+func (p *peerTransport) SetReadDeadline(t time.Time) error {
+	p.deadline.Store(t)
+	return nil
+}
+
+// SetWriteDeadline is not supported, the writes of udp are not blocked by the peer.
+func (p *peerTransport) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func (p *peerTransport) RawTransport() interface{} {
+	return p.acceptor.conn
+}
+
+var _ PacketTransport = (*peerTransport)(nil)
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// ErrNoDestination is returned when writing bytes to the transport without a remote address,
+// write Datagram by PacketTransport instead.
+var ErrNoDestination = errors.New("udp: destination address required")
+
+// Datagram defines a datagram received from or sent to the remote address.
+type Datagram struct {
+	Addr    *net.UDPAddr
+	Payload []byte
+}
+
+// PacketTransport defines the transport of datagrams, each Read of the transport returns one datagram
+// and each message written to the transport is sent as one datagram.
+type PacketTransport interface {
+	transport.Transport
+
+	// ReadDatagram returns the next datagram with the remote address.
+	ReadDatagram() (Datagram, error)
+
+	// WriteDatagram send the payload to the address, the remote address of transport if the address is nil.
+	WriteDatagram(datagram Datagram) error
+}
+
+// writeDatagrams to send each message of the buffers as one datagram.
+func writeDatagrams(write func(p []byte) (int, error), buffs transport.Buffers) (int64, error) {
+
+	var written int64
+	var from int
+	indexes := buffs.Indexes
+	if 0 == len(indexes) {
+		indexes = []int{len(buffs.Buffers)}
+	}
+
+	for _, to := range indexes {
+		// the failed messages are marked as -1.
+		if to < 0 {
+			continue
+		}
+
+		var datagram []byte
+		if 1 == to-from {
+			datagram = buffs.Buffers[from]
+		} else {
+			for _, b := range buffs.Buffers[from:to] {
+				datagram = append(datagram, b...)
+			}
+		}
+		from = to
+
+		n, err := write(datagram)
+		written += int64(n)
+		if nil != err {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// connTransport is the transport of a connected udp socket.
+type connTransport struct {
+	*net.UDPConn
+	options *Options
+}
+
+func (c *connTransport) Read(p []byte) (int, error) {
+	return c.UDPConn.Read(p)
+}
+
+func (c *connTransport) ReadDatagram() (Datagram, error) {
+	buffer := make([]byte, c.options.maxDatagramSize())
+	n, addr, err := c.UDPConn.ReadFromUDP(buffer)
+	if nil != err {
+		return Datagram{}, err
+	}
+	return Datagram{Addr: addr, Payload: buffer[:n]}, nil
+}
+
+func (c *connTransport) WriteDatagram(datagram Datagram) error {
+	_, err := c.UDPConn.Write(datagram.Payload)
+	return err
+}
+
+func (c *connTransport) Writev(buffs transport.Buffers) (int64, error) {
+	return writeDatagrams(c.UDPConn.Write, buffs)
+}
+
+func (c *connTransport) Flush() error {
+	return nil
+}
+
+func (c *connTransport) RawTransport() interface{} {
+	return c.UDPConn
+}
+
+// packetTransport is the transport of an unconnected udp socket, it receives the datagrams of all peers.
+type packetTransport struct {
+	*net.UDPConn
+	options *Options
+	group   *membership
+}
+
+func (p *packetTransport) Read(b []byte) (int, error) {
+	n, _, err := p.UDPConn.ReadFromUDP(b)
+	return n, err
+}
+
+func (p *packetTransport) Write(b []byte) (int, error) {
+	return 0, ErrNoDestination
+}
+
+func (p *packetTransport) ReadDatagram() (Datagram, error) {
+	buffer := make([]byte, p.options.maxDatagramSize())
+	n, addr, err := p.UDPConn.ReadFromUDP(buffer)
+	if nil != err {
+		return Datagram{}, err
+	}
+	return Datagram{Addr: addr, Payload: buffer[:n]}, nil
+}
+
+func (p *packetTransport) WriteDatagram(datagram Datagram) error {
+	if nil == datagram.Addr {
+		return ErrNoDestination
+	}
+	_, err := p.UDPConn.WriteToUDP(datagram.Payload, datagram.Addr)
+	return err
+}
+
+func (p *packetTransport) Writev(buffs transport.Buffers) (int64, error) {
+	return 0, ErrNoDestination
+}
+
+func (p *packetTransport) Flush() error {
+	return nil
+}
+
+func (p *packetTransport) Close() error {
+	_ = p.group.leave()
+	return p.UDPConn.Close()
+}
+
+func (p *packetTransport) RawTransport() interface{} {
+	return p.UDPConn
+}
+
+// packetAcceptor accepts the packet transport once.
+type packetAcceptor struct {
+	transport *packetTransport
+	once      sync.Once
+	done      chan struct{}
+	closed    sync.Once
+}
+
+func (p *packetAcceptor) Accept() (transport.Transport, error) {
+	var first bool
+	p.once.Do(func() { first = true })
+	if first {
+		return p.transport, nil
+	}
+
+	// the socket is served by the accepted transport.
+	<-p.done
+	return nil, net.ErrClosed
+}
+
+func (p *packetAcceptor) Close() error {
+	var err error
+	p.closed.Do(func() {
+		close(p.done)
+		err = p.transport.Close()
+	})
+	return err
+}
+
+var _ PacketTransport = (*packetTransport)(nil)
+var _ PacketTransport = (*connTransport)(nil)
@@ -0,0 +1,307 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/transport/tcp"
+)
+
+// ErrBadHandshake is wrapped by the error of a failed upgrade handshake.
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// websocketGUID is appended to Sec-WebSocket-Key to compute Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// New websocket factory, the transport finishes the upgrade handshake over tcp, and carries the
+// websocket frames, so add xhttp.WebSocketCodec to the pipeline to read & write the frames.
+func New() transport.Factory {
+	return new(websocketFactory)
+}
+
+func init() {
+	transport.Register(New())
+}
+
+type websocketFactory struct{}
+
+func (*websocketFactory) Schemes() transport.Schemes {
+	return transport.Schemes{"ws", "wss"}
+}
+
+func (f *websocketFactory) Connect(options *transport.Options) (transport.Transport, error) {
+
+	if err := f.Schemes().FixScheme(options.Address); nil != err {
+		return nil, err
+	}
+
+	wsOptions := FromContext(options.Context, DefaultOption)
+	tcpOptions, err := withTLS(options.Address, wsOptions.tcpOptions(), true)
+	if nil != err {
+		return nil, err
+	}
+
+	t, err := tcp.New().Connect(tcpTransportOptions(options, tcpOptions))
+	if nil != err {
+		return nil, err
+	}
+
+	wt, err := clientHandshake(t, options.Address, wsOptions)
+	if nil != err {
+		_ = t.Close()
+		return nil, err
+	}
+	return wt, nil
+}
+
+func (f *websocketFactory) Listen(options *transport.Options) (transport.Acceptor, error) {
+
+	if err := f.Schemes().FixScheme(options.Address); nil != err {
+		return nil, err
+	}
+
+	wsOptions := FromContext(options.Context, DefaultOption)
+	tcpOptions, err := withTLS(options.Address, wsOptions.tcpOptions(), false)
+	if nil != err {
+		return nil, err
+	}
+
+	acceptor, err := tcp.New().Listen(tcpTransportOptions(options, tcpOptions))
+	if nil != err {
+		return nil, err
+	}
+	return &websocketAcceptor{Acceptor: acceptor, options: wsOptions, path: options.Address.Path}, nil
+}
+
+// withTLS to check the tls of wss, the client verifies the host of url by default.
+func withTLS(u *url.URL, tcpOptions *tcp.Options, client bool) (*tcp.Options, error) {
+	if "wss" != u.Scheme || nil != tcpOptions.TLS {
+		return tcpOptions, nil
+	}
+
+	if !client {
+		return nil, fmt.Errorf("websocket: wss requires the tls config of tcp options")
+	}
+
+	clone := *tcpOptions
+	clone.TLS = &tls.Config{ServerName: u.Hostname()}
+	return &clone, nil
+}
+
+// tcpTransportOptions to create the options of the underlying tcp transport.
+func tcpTransportOptions(options *transport.Options, tcpOptions *tcp.Options) *transport.Options {
+	address := *options.Address
+	address.Scheme = "tcp"
+
+	inner := *options
+	inner.Address = &address
+	_ = inner.Apply(tcp.WithOptions(tcpOptions))
+	return &inner
+}
+
+type websocketAcceptor struct {
+	transport.Acceptor
+	options *Options
+	path    string
+}
+
+func (w *websocketAcceptor) Accept() (transport.Transport, error) {
+	for {
+		t, err := w.Acceptor.Accept()
+		if nil != err {
+			return nil, err
+		}
+
+		// the failed handshake is responded, and the connection is dropped.
+		wt, err := serverHandshake(t, w.path, w.options)
+		if nil != err {
+			_ = t.Close()
+			continue
+		}
+		return wt, nil
+	}
+}
+
+// clientHandshake to upgrade the connection to websocket, it fails if the handshake is not finished in time.
+func clientHandshake(t transport.Transport, u *url.URL, options *Options) (*websocketTransport, error) {
+
+	if err := handshakeDeadline(t, options); nil != err {
+		return nil, err
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); nil != err {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	request := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.Path, RawQuery: u.RawQuery},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for name, values := range options.Header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	if len(options.Subprotocols) > 0 {
+		request.Header.Set("Sec-WebSocket-Protocol", strings.Join(options.Subprotocols, ", "))
+	}
+
+	if err := request.Write(t); nil != err {
+		return nil, err
+	}
+	if err := t.Flush(); nil != err {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(t)
+	response, err := http.ReadResponse(reader, request)
+	if nil != err {
+		return nil, err
+	}
+	_ = response.Body.Close()
+
+	if http.StatusSwitchingProtocols != response.StatusCode {
+		return nil, fmt.Errorf("%w: %s", ErrBadHandshake, response.Status)
+	}
+
+	if !strings.EqualFold("websocket", response.Header.Get("Upgrade")) || acceptKey(key) != response.Header.Get("Sec-WebSocket-Accept") {
+		return nil, fmt.Errorf("%w: unexpected upgrade response", ErrBadHandshake)
+	}
+
+	subprotocol := response.Header.Get("Sec-WebSocket-Protocol")
+	if "" != subprotocol && -1 == indexOf(options.Subprotocols, subprotocol) {
+		return nil, fmt.Errorf("%w: unexpected subprotocol: %s", ErrBadHandshake, subprotocol)
+	}
+
+	// the deadlines of handshake are not applied to the websocket traffic.
+	if err := t.SetDeadline(time.Time{}); nil != err {
+		return nil, err
+	}
+	return &websocketTransport{Transport: t, reader: reader, response: response, subprotocol: subprotocol}, nil
+}
+
+// serverHandshake to accept the upgrade request of path, the invalid request is responded with an error status.
+func serverHandshake(t transport.Transport, path string, options *Options) (*websocketTransport, error) {
+
+	if err := handshakeDeadline(t, options); nil != err {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(t)
+	request, err := http.ReadRequest(reader)
+	if nil != err {
+		return nil, err
+	}
+
+	reject := func(status int, reason string) (*websocketTransport, error) {
+		response := &http.Response{StatusCode: status, ProtoMajor: 1, ProtoMinor: 1, Header: make(http.Header),
+			ContentLength: int64(len(reason)), Body: io.NopCloser(strings.NewReader(reason))}
+		if http.StatusUpgradeRequired == status {
+			response.Header.Set("Sec-WebSocket-Version", "13")
+		}
+		_ = response.Write(t)
+		_ = t.Flush()
+		return nil, fmt.Errorf("%w: %s", ErrBadHandshake, reason)
+	}
+
+	key := request.Header.Get("Sec-WebSocket-Key")
+	switch decoded, err := base64.StdEncoding.DecodeString(key); {
+	case http.MethodGet != request.Method || !strings.EqualFold("websocket", request.Header.Get("Upgrade")):
+		return reject(http.StatusBadRequest, "not a websocket upgrade request")
+	case "" != path && "/" != path && path != request.URL.Path:
+		return reject(http.StatusNotFound, "unexpected path: "+request.URL.Path)
+	case "13" != request.Header.Get("Sec-WebSocket-Version"):
+		return reject(http.StatusUpgradeRequired, "unsupported version")
+	case nil != err || 16 != len(decoded):
+		return reject(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+
+	// the first subprotocol supported by server.
+	var subprotocol string
+	for _, value := range request.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(value, ",") {
+			if p = strings.TrimSpace(p); "" == subprotocol && -1 != indexOf(options.Subprotocols, p) {
+				subprotocol = p
+			}
+		}
+	}
+
+	response := &http.Response{StatusCode: http.StatusSwitchingProtocols, ProtoMajor: 1, ProtoMinor: 1, Header: make(http.Header)}
+	response.Header.Set("Upgrade", "websocket")
+	response.Header.Set("Connection", "Upgrade")
+	response.Header.Set("Sec-WebSocket-Accept", acceptKey(key))
+	if "" != subprotocol {
+		response.Header.Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+
+	if err := response.Write(t); nil != err {
+		return nil, err
+	}
+	if err := t.Flush(); nil != err {
+		return nil, err
+	}
+
+	if err := t.SetDeadline(time.Time{}); nil != err {
+		return nil, err
+	}
+	return &websocketTransport{Transport: t, reader: reader, request: request, subprotocol: subprotocol}, nil
+}
+
+// handshakeDeadline to bound the handshake by the timeout.
+func handshakeDeadline(t transport.Transport, options *Options) error {
+	if options.HandshakeTimeout > 0 {
+		return t.SetDeadline(time.Now().Add(options.HandshakeTimeout))
+	}
+	return nil
+}
+
+// acceptKey to compute Sec-WebSocket-Accept of the key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func indexOf(values []string, value string) int {
+	for index, v := range values {
+		if v == value {
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+func connect(address string, options *Options) (transport.Transport, error) {
+	transportOptions, err := transport.ParseOptions(context.Background(), address, WithOptions(options))
+	if nil != err {
+		return nil, err
+	}
+	return New().Connect(transportOptions)
+}
+
+func TestWebSocketTransport(t *testing.T) {
+
+	listenOptions, err := transport.ParseOptions(context.Background(), "ws://127.0.0.1:9555/chat",
+		WithOptions(&Options{Subprotocols: []string{"chat"}, HandshakeTimeout: time.Second}))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	acceptor, err := New().Listen(listenOptions)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer acceptor.Close()
+
+	accepted := make(chan Transport, 1)
+	go func() {
+		for {
+			tt, err := acceptor.Accept()
+			if nil != err {
+				return
+			}
+			accepted <- tt.(Transport)
+			go func() { _, _ = io.Copy(tt, tt) }()
+		}
+	}()
+
+	// the request of unexpected path is rejected.
+	if _, err := connect("ws://127.0.0.1:9555/other", &Options{HandshakeTimeout: time.Second}); !errors.Is(err, ErrBadHandshake) {
+		t.Fatal("unexpected error:", err)
+	}
+
+	tt, err := connect("ws://127.0.0.1:9555/chat", &Options{
+		Header:           http.Header{"Authorization": {"Bearer token"}},
+		Subprotocols:     []string{"superchat", "chat"},
+		HandshakeTimeout: time.Millisecond * 100,
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer tt.Close()
+
+	server := <-accepted
+	if "chat" != tt.(Transport).Subprotocol() || "chat" != server.Subprotocol() {
+		t.Fatal("unexpected subprotocol:", tt.(Transport).Subprotocol(), server.Subprotocol())
+	}
+	if "Bearer token" != server.Request().Header.Get("Authorization") {
+		t.Fatal("header is not sent:", server.Request().Header)
+	}
+
+	// the handshake timeout is not applied after upgraded.
+	time.Sleep(time.Millisecond * 200)
+	if _, err := tt.Write([]byte("hello")); nil != err {
+		t.Fatal(err)
+	}
+	buffer := make([]byte, 5)
+	if _, err := io.ReadFull(tt, buffer); nil != err || "hello" != string(buffer) {
+		t.Fatal("unexpected echo:", string(buffer), err)
+	}
+}
+
+func TestWebSocketTransportAuthorization(t *testing.T) {
+
+	server := &http.Server{Addr: "127.0.0.1:9556", Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if "Bearer secret" != request.Header.Get("Authorization") {
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		conn, rw, err := writer.(http.Hijacker).Hijack()
+		if nil != err {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(request.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		_ = rw.Flush()
+		_, _ = io.Copy(conn, rw)
+	})}
+	listener, err := net.Listen("tcp", server.Addr)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	go server.Serve(listener)
+
+	tt, err := connect("ws://127.0.0.1:9556/", &Options{HandshakeTimeout: time.Second})
+	if !errors.Is(err, ErrBadHandshake) {
+		t.Fatal("unexpected error without authorization:", err)
+	}
+
+	tt, err = connect("ws://127.0.0.1:9556/", &Options{
+		Header:           http.Header{"Authorization": {"Bearer secret"}},
+		HandshakeTimeout: time.Second,
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer tt.Close()
+
+	if _, err := tt.Write([]byte("ping")); nil != err {
+		t.Fatal(err)
+	}
+	if line, err := bufio.NewReader(tt).Peek(4); nil != err || "ping" != string(line) {
+		t.Fatal("unexpected echo:", string(line), err)
+	}
+}
+
+func TestWebSocketTransportHandshakeTimeout(t *testing.T) {
+
+	// the server stalls the handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:9557")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if nil != err {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	_, err = connect("ws://127.0.0.1:9557/", &Options{HandshakeTimeout: time.Millisecond * 100})
+
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatal("unexpected error:", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatal("handshake is not bounded:", elapsed)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+
+	factory, address, opts, err := transport.ParseURL("wss://localhost:8443/chat?subprotocols=v2,v1&handshakeTimeout=1s&tcp.nodelay=false&token=abc")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	// the unknown parameters stay in the request uri.
+	if !factory.Schemes().Valid("wss") || "wss://localhost:8443/chat?token=abc" != address {
+		t.Fatal("unexpected transport:", factory.Schemes(), address)
+	}
+
+	options, err := transport.ParseOptions(context.Background(), address, opts...)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	o := FromContext(options.Context, nil)
+	if nil == o || 2 != len(o.Subprotocols) || "v2" != o.Subprotocols[0] || time.Second != o.HandshakeTimeout {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+
+	if nil == o.TCP || o.TCP.NoDelay || DefaultOption.tcpOptions().Timeout != o.TCP.Timeout {
+		t.Fatalf("unexpected tcp options: %+v", o.TCP)
+	}
+
+	if _, _, _, err := transport.ParseURL("ws://localhost:8080/?tcp.unknown=1"); nil == err {
+		t.Fatal("expect unknown tcp option error")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mijingduI/go-netty/transport"
+	"github.com/mijingduI/go-netty/transport/tcp"
+)
+
+// DefaultOption default websocket options
+var DefaultOption = &Options{
+	HandshakeTimeout: time.Second * 10,
+}
+
+// Options fot websocket transport
+type Options struct {
+	// Header is sent with the upgrade request of the client, e.g. Authorization or Cookie.
+	Header http.Header `json:"-"`
+	// Subprotocols requested by the client in order of preference, or supported by the server.
+	Subprotocols []string `json:"subprotocols"`
+	// HandshakeTimeout bounds the upgrade handshake, the deadlines of connection are cleared after
+	// the handshake, no timeout if <= 0.
+	HandshakeTimeout time.Duration `json:"handshakeTimeout"`
+	// TCP options of the underlying connection, tcp.DefaultOption if nil, the TLS is required by wss.
+	TCP *tcp.Options `json:"tcp"`
+}
+
+func (o *Options) tcpOptions() *tcp.Options {
+	if nil != o.TCP {
+		return o.TCP
+	}
+	return tcp.DefaultOption
+}
+
+// ParseQuery parse the websocket options from query based on DefaultOption, the parameters prefixed
+// with tcp. are the tcp options, e.g. subprotocols=chat&tcp.nodelay=false, the others stay in the
+// request uri, see transport.ParseURL.
+func (*websocketFactory) ParseQuery(query url.Values) ([]transport.Option, url.Values, error) {
+	option := *DefaultOption
+	wsQuery, tcpQuery := url.Values{}, url.Values{}
+	for key, values := range query {
+		if name := strings.TrimPrefix(key, "tcp."); name != key {
+			tcpQuery[name] = values
+		} else {
+			wsQuery[key] = values
+		}
+	}
+
+	unused, err := transport.DecodeQuery(&option, wsQuery)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	if len(tcpQuery) > 0 {
+		tcpOptions, _, err := tcp.New().(transport.QueryParser).ParseQuery(tcpQuery)
+		if nil != err {
+			return nil, nil, err
+		}
+		// unwrap the parsed tcp options.
+		parsed := &transport.Options{Context: context.Background()}
+		if err = parsed.Apply(tcpOptions...); nil != err {
+			return nil, nil, err
+		}
+		option.TCP = tcp.FromContext(parsed.Context, nil)
+	}
+	return []transport.Option{WithOptions(&option)}, unused, nil
+}
+
+type contextKey struct{}
+
+// WithOptions to wrap the websocket options
+func WithOptions(option *Options) transport.Option {
+	return func(options *transport.Options) error {
+		options.Context = context.WithValue(options.Context, contextKey{}, option)
+		return nil
+	}
+}
+
+// FromContext to unwrap the websocket options
+func FromContext(ctx context.Context, def *Options) *Options {
+	if v, ok := ctx.Value(contextKey{}).(*Options); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"net/http"
+
+	"github.com/mijingduI/go-netty/transport"
+)
+
+// Transport defines a websocket transport upgraded from http
+type Transport interface {
+	transport.Transport
+
+	// Subprotocol negotiated by the handshake, empty if none.
+	Subprotocol() string
+
+	// Request returns the upgrade request accepted by the server, nil for the client.
+	Request() *http.Request
+
+	// Response returns the upgrade response received by the client, nil for the server.
+	Response() *http.Response
+}
+
+type websocketTransport struct {
+	transport.Transport
+	reader      *bufio.Reader // the bytes read after the handshake.
+	request     *http.Request
+	response    *http.Response
+	subprotocol string
+}
+
+func (w *websocketTransport) Read(p []byte) (int, error) {
+	return w.reader.Read(p)
+}
+
+// Buffered returns the inbound bytes buffered by the handshake and the transport.
+func (w *websocketTransport) Buffered() int {
+	n := w.reader.Buffered()
+	if b, ok := w.Transport.(transport.BufferedTransport); ok {
+		n += b.Buffered()
+	}
+	return n
+}
+
+func (w *websocketTransport) Subprotocol() string {
+	return w.subprotocol
+}
+
+func (w *websocketTransport) Request() *http.Request {
+	return w.request
+}
+
+func (w *websocketTransport) Response() *http.Response {
+	return w.response
+}
+
+var _ Transport = (*websocketTransport)(nil)
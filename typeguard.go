@@ -0,0 +1,95 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// ErrUnexpectedType is raised by TypeGuardHandler if the type of message is not allowed.
+var ErrUnexpectedType = errors.New("netty: unexpected message type")
+
+// TypeGuardHandler create a handler to assert the messages passing it are of the allowed types, the interface
+// types allow the messages implementing them, the unexpected message raises ErrUnexpectedType to the exception
+// handlers, e.g. to check the codec produces the messages expected by the next handler. it works in both
+// directions, and the check is a map lookup of the concrete type, cheap enough to leave it on.
+func TypeGuardHandler(allowed ...reflect.Type) CodecHandler {
+	utils.AssertIf(0 == len(allowed), "allowed types are required")
+
+	guard := &typeGuardHandler{concrete: make(map[reflect.Type]struct{}, len(allowed))}
+	for _, t := range allowed {
+		utils.AssertIf(nil == t, "allowed type must not be nil")
+		if reflect.Interface == t.Kind() {
+			guard.interfaces = append(guard.interfaces, t)
+		} else {
+			guard.concrete[t] = struct{}{}
+		}
+		guard.names = append(guard.names, t.String())
+	}
+	return guard
+}
+
+type typeGuardHandler struct {
+	concrete   map[reflect.Type]struct{}
+	interfaces []reflect.Type
+	names      []string
+}
+
+func (*typeGuardHandler) CodecName() string {
+	return "type-guard-handler"
+}
+
+func (g *typeGuardHandler) HandleRead(ctx InboundContext, message Message) {
+	if !g.allowed(message) {
+		releaseMessage(message)
+		panic(g.unexpected("inbound", message))
+	}
+	ctx.HandleRead(message)
+}
+
+func (g *typeGuardHandler) HandleWrite(ctx OutboundContext, message Message) {
+	if !g.allowed(message) {
+		panic(g.unexpected("outbound", message))
+	}
+	ctx.HandleWrite(message)
+}
+
+func (g *typeGuardHandler) allowed(message Message) bool {
+	t := reflect.TypeOf(message)
+	if nil == t {
+		return false
+	}
+
+	if _, ok := g.concrete[t]; ok {
+		return true
+	}
+
+	for _, i := range g.interfaces {
+		if t.Implements(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *typeGuardHandler) unexpected(direction string, message Message) error {
+	return fmt.Errorf("%w: %s message of %T, allowed: %v", ErrUnexpectedType, direction, message, g.names)
+}
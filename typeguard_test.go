@@ -0,0 +1,97 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypeGuardHandler(t *testing.T) {
+
+	var cases = []struct {
+		allowed []reflect.Type
+		err     error
+	}{
+		{allowed: []reflect.Type{reflect.TypeOf("")}},
+		{allowed: []reflect.Type{reflect.TypeOf([]byte(nil)), reflect.TypeOf((*error)(nil)).Elem()}, err: ErrUnexpectedType},
+	}
+
+	for _, c := range cases {
+		received := make(chan Message, 1)
+		exceptions := make(chan Exception, 1)
+
+		ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+			delimiterCodec{maxFrameLength: 1024, delimiter: []byte("\n"), stripDelimiter: true},
+			&textCodec{},
+			TypeGuardHandler(c.allowed...),
+			InboundHandlerFunc(func(ctx InboundContext, message Message) {
+				received <- message
+			}),
+			ExceptionHandlerFunc(func(ctx ExceptionContext, ex Exception) {
+				exceptions <- ex
+			}),
+		)
+
+		_, _ = peer.Write([]byte("hello\n"))
+		select {
+		case message := <-received:
+			if nil != c.err {
+				t.Fatal("unexpected message passed:", message)
+			}
+		case ex := <-exceptions:
+			if !errors.Is(ex, c.err) || !strings.Contains(ex.Error(), "inbound message of string") {
+				t.Fatal("unexpected exception:", ex)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("message is not handled")
+		}
+
+		ch.Close(nil)
+		_ = peer.Close()
+	}
+}
+
+func TestTypeGuardHandlerOutbound(t *testing.T) {
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527",
+		discardHandler{},
+		TypeGuardHandler(reflect.TypeOf(""), reflect.TypeOf((*io.Reader)(nil)).Elem()),
+	)
+	defer ch.Close(nil)
+	received := readPeer(peer)
+
+	if err := ch.Write("ok"); nil != err {
+		t.Fatal(err)
+	}
+	if err := ch.Write(strings.NewReader("reader")); nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"ok", "reader"} {
+		if got := <-received; want != got {
+			t.Fatal("unexpected write:", got, "want:", want)
+		}
+	}
+
+	if err := ch.Write([]byte("bytes")); !errors.Is(err, ErrUnexpectedType) || !strings.Contains(err.Error(), "outbound message of []uint8") {
+		t.Fatal("expect unexpected type, got:", err)
+	}
+}
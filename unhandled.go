@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/mijingduI/go-netty/utils"
+)
+
+// UnhandledHandler handles the inbound message which reached the tail of pipeline, that is no handler
+// consumed it, the utils.ReferenceCounted message is released after it returned.
+type UnhandledHandler func(ch Channel, message Message)
+
+// NewPipelineWith create a PipelineFactory with the UnhandledHandler at tail, e.g. WithPipeline(NewPipelineWith(DropUnhandled))
+func NewPipelineWith(unhandled UnhandledHandler) PipelineFactory {
+	utils.AssertIf(nil == unhandled, "unhandled handler is required")
+	return func() Pipeline {
+		return newPipelineWith(unhandled)
+	}
+}
+
+// DropUnhandled drops the unhandled messages silently.
+func DropUnhandled(ch Channel, message Message) {}
+
+// WarnUnhandled logs the type and a hex snippet of the unhandled messages to stderr, once per message type.
+func WarnUnhandled() UnhandledHandler {
+	var warned sync.Map // reflect.Type - struct{}
+	return func(ch Channel, message Message) {
+		if _, loaded := warned.LoadOrStore(reflect.TypeOf(message), struct{}{}); loaded {
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "An unhandled message of %T reached at the tail of the pipeline on %s, "+
+			"please check the handlers of the pipeline, the message is dropped: %s\n", message, describeChannel(ch), snippetOf(message))
+	}
+}
+
+// defaultUnhandled is shared by the pipelines created by NewPipeline.
+var defaultUnhandled = WarnUnhandled()
+
+// snippetLength is the max count of bytes of the message to log.
+const snippetLength = 32
+
+// snippetOf returns the hex snippet of bytes or the description of message.
+func snippetOf(message Message) string {
+	var data []byte
+	switch m := message.(type) {
+	case []byte:
+		data = m
+	case interface{ Bytes() []byte }:
+		data = m.Bytes()
+	case string:
+		data = []byte(m)
+	case io.Reader:
+		// the stream of the channel is read and dropped.
+		buffer := make([]byte, snippetLength+1)
+		n, _ := m.Read(buffer)
+		data = buffer[:n]
+	default:
+		return fmt.Sprintf("%+v", message)
+	}
+
+	if len(data) > snippetLength {
+		return fmt.Sprintf("[%d bytes] %s...", len(data), hex.EncodeToString(data[:snippetLength]))
+	}
+	return fmt.Sprintf("[%d bytes] %s", len(data), hex.EncodeToString(data))
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mijingduI/go-netty/utils/pool/pbytes"
+)
+
+func TestUnhandledHandler(t *testing.T) {
+
+	unhandled := make(chan Message, 2)
+	buffer := pbytes.NewBuffer(4)
+
+	ch, peer := pipeChannelWith(NewPipelineWith(func(ch Channel, message Message) {
+		unhandled <- message
+	})(), NewChannel(), "127.0.0.1:9527",
+		InboundHandlerFunc(func(ctx InboundContext, message Message) {
+			discardHandler{}.HandleRead(ctx, message)
+			// the messages are not consumed by any handler.
+			ctx.HandleRead([]byte("unhandled"))
+			ctx.HandleRead(buffer)
+		}),
+	)
+	defer ch.Close(nil)
+
+	go func() { _, _ = peer.Write([]byte("x")) }()
+
+	for _, want := range []Message{[]byte("unhandled"), buffer} {
+		select {
+		case message := <-unhandled:
+			if !reflect.DeepEqual(want, message) {
+				t.Fatal("unexpected message:", message)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("unhandled message is not reported")
+		}
+	}
+
+	// the ReferenceCounted message is released after reported.
+	for deadline := time.Now().Add(time.Second); 0 != buffer.RefCnt(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("buffer is not released:", buffer.RefCnt())
+		}
+	}
+}
+
+func TestWarnUnhandled(t *testing.T) {
+
+	ch, peer := pipeChannel(NewChannel(), "127.0.0.1:9527", discardHandler{})
+	defer peer.Close()
+	defer ch.Close(nil)
+
+	r, w, err := os.Pipe()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	warn := WarnUnhandled()
+	warn(ch, []byte("0123456789"))
+	warn(ch, []byte("abc"))
+	warn(ch, "text")
+	warn(ch, strings.Repeat("a", 64))
+	os.Stderr = stderr
+	_ = w.Close()
+
+	var lines []string
+	for scanner := bufio.NewScanner(r); scanner.Scan(); {
+		lines = append(lines, scanner.Text())
+	}
+
+	// once per message type.
+	if 2 != len(lines) {
+		t.Fatal("unexpected warnings:", lines)
+	}
+	if !strings.Contains(lines[0], "[]uint8") || !strings.Contains(lines[0], "[10 bytes] 30313233343536373839") {
+		t.Fatal("unexpected warning:", lines[0])
+	}
+	if !strings.Contains(lines[1], "string") || !strings.Contains(lines[1], "[4 bytes] 74657874") {
+		t.Fatal("unexpected warning:", lines[1])
+	}
+
+	if s := snippetOf(strings.Repeat("a", 64)); !strings.HasPrefix(s, "[64 bytes] ") || !strings.HasSuffix(s, "...") {
+		t.Fatal("unexpected snippet:", s)
+	}
+}
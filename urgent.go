@@ -0,0 +1,51 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netty
+
+// UrgentDataEvent is triggered by UrgentDataHandler when an urgent byte is received out of band.
+type UrgentDataEvent struct {
+	Data byte
+}
+
+// urgentTransport defines the transport which could receive the urgent data, e.g. tcp.UrgentTransport
+type urgentTransport interface {
+	Urgent() <-chan byte
+}
+
+// UrgentDataHandler fires UrgentDataEvent for the urgent bytes received by the transport,
+// enable it by tcp.Options.UrgentData, it does nothing if the transport does not support it.
+func UrgentDataHandler() ActiveHandler {
+	return ActiveHandlerFunc(func(ctx ActiveContext) {
+		if t, ok := ctx.Channel().Transport().(urgentTransport); ok && nil != t.Urgent() {
+			ch, urgent := ctx.Channel(), t.Urgent()
+			go func() {
+				for {
+					select {
+					case <-ch.Context().Done():
+						return
+					case data, ok := <-urgent:
+						if !ok {
+							return
+						}
+						ch.Trigger(UrgentDataEvent{Data: data})
+					}
+				}
+			}()
+		}
+		ctx.HandleActive()
+	})
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// The typed readers read the exact number of bytes from r like io.ReadFull, the error is io.EOF if no byte
+// is read, and io.ErrUnexpectedEOF if the reader ends in the middle of the value, e.g. the end of a frame.
+
+// ReadUint8 read a byte.
+func ReadUint8(r io.Reader) (uint8, error) {
+	if br, ok := r.(io.ByteReader); ok {
+		return br.ReadByte()
+	}
+	var buff [1]byte
+	_, err := io.ReadFull(r, buff[:])
+	return buff[0], err
+}
+
+// ReadUint16BE read an uint16 in big endian.
+func ReadUint16BE(r io.Reader) (uint16, error) {
+	var buff [2]byte
+	_, err := io.ReadFull(r, buff[:])
+	return binary.BigEndian.Uint16(buff[:]), err
+}
+
+// ReadUint16LE read an uint16 in little endian.
+func ReadUint16LE(r io.Reader) (uint16, error) {
+	var buff [2]byte
+	_, err := io.ReadFull(r, buff[:])
+	return binary.LittleEndian.Uint16(buff[:]), err
+}
+
+// ReadUint32BE read an uint32 in big endian.
+func ReadUint32BE(r io.Reader) (uint32, error) {
+	var buff [4]byte
+	_, err := io.ReadFull(r, buff[:])
+	return binary.BigEndian.Uint32(buff[:]), err
+}
+
+// ReadUint32LE read an uint32 in little endian.
+func ReadUint32LE(r io.Reader) (uint32, error) {
+	var buff [4]byte
+	_, err := io.ReadFull(r, buff[:])
+	return binary.LittleEndian.Uint32(buff[:]), err
+}
+
+// ReadUint64BE read an uint64 in big endian.
+func ReadUint64BE(r io.Reader) (uint64, error) {
+	var buff [8]byte
+	_, err := io.ReadFull(r, buff[:])
+	return binary.BigEndian.Uint64(buff[:]), err
+}
+
+// ReadUint64LE read an uint64 in little endian.
+func ReadUint64LE(r io.Reader) (uint64, error) {
+	var buff [8]byte
+	_, err := io.ReadFull(r, buff[:])
+	return binary.LittleEndian.Uint64(buff[:]), err
+}
+
+// ReadBytes read n bytes, the bytes read are returned with the error of a short read.
+func ReadBytes(r io.Reader, n int) ([]byte, error) {
+	AssertIf(n < 0, "negative length: %d", n)
+	buff := make([]byte, n)
+	read, err := io.ReadFull(r, buff)
+	return buff[:read], err
+}
+
+// ReadString read a string of n bytes.
+func ReadString(r io.Reader, n int) (string, error) {
+	buff, err := ReadBytes(r, n)
+	return string(buff), err
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadTyped(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+	var cases = []struct {
+		name string
+		read func(r io.Reader) (uint64, error)
+		want uint64
+	}{
+		{name: "uint8", read: func(r io.Reader) (uint64, error) { v, err := ReadUint8(r); return uint64(v), err }, want: 0x01},
+		{name: "uint16be", read: func(r io.Reader) (uint64, error) { v, err := ReadUint16BE(r); return uint64(v), err }, want: 0x0102},
+		{name: "uint16le", read: func(r io.Reader) (uint64, error) { v, err := ReadUint16LE(r); return uint64(v), err }, want: 0x0201},
+		{name: "uint32be", read: func(r io.Reader) (uint64, error) { v, err := ReadUint32BE(r); return uint64(v), err }, want: 0x01020304},
+		{name: "uint32le", read: func(r io.Reader) (uint64, error) { v, err := ReadUint32LE(r); return uint64(v), err }, want: 0x04030201},
+		{name: "uint64be", read: ReadUint64BE, want: 0x0102030405060708},
+		{name: "uint64le", read: ReadUint64LE, want: 0x0807060504030201},
+	}
+
+	for _, c := range cases {
+		// the reader returns one byte per read.
+		for _, r := range []io.Reader{bytes.NewReader(data), iotest.OneByteReader(bytes.NewReader(data))} {
+			if v, err := c.read(r); nil != err || c.want != v {
+				t.Fatalf("%s: %#x, %v, want: %#x", c.name, v, err, c.want)
+			}
+		}
+
+		// no byte left.
+		if _, err := c.read(bytes.NewReader(nil)); io.EOF != err {
+			t.Fatalf("%s: unexpected error of empty reader: %v", c.name, err)
+		}
+	}
+
+	// short reads of the multi-bytes values.
+	for _, c := range cases[1:] {
+		if _, err := c.read(bytes.NewReader(data[:1])); io.ErrUnexpectedEOF != err {
+			t.Fatalf("%s: unexpected error of short read: %v", c.name, err)
+		}
+	}
+}
+
+func TestReadString(t *testing.T) {
+	r := NewByteReader(testReader{bytes.NewReader([]byte("\x00\x05hello!"))})
+
+	n, err := ReadUint16BE(r)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if s, err := ReadString(r, int(n)); nil != err || "hello" != s {
+		t.Fatalf("unexpected string: %q, %v", s, err)
+	}
+
+	// the bytes read are returned with the error.
+	if b, err := ReadBytes(r, 4); io.ErrUnexpectedEOF != err || "!" != string(b) {
+		t.Fatalf("unexpected short read: %q, %v", b, err)
+	}
+
+	if s, err := ReadString(r, 0); nil != err || "" != s {
+		t.Fatalf("unexpected empty string: %q, %v", s, err)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbytes
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Buffer is a reference counted byte buffer borrowed from DefaultPool, the bytes return to
+// the pool when the reference count drops to zero, it must not be used after released.
+type Buffer struct {
+	data   *[]byte
+	off    int
+	refCnt int32
+}
+
+var bufferPool = sync.Pool{New: func() interface{} { return new(Buffer) }}
+
+// NewBuffer returns a Buffer with n bytes and the reference count of 1.
+func NewBuffer(n int) *Buffer {
+	b := bufferPool.Get().(*Buffer)
+	data := Get(n)
+	*data = (*data)[:n]
+	b.data, b.off, b.refCnt = data, 0, 1
+	return b
+}
+
+// Bytes returns the unread bytes.
+func (b *Buffer) Bytes() []byte {
+	return (*b.data)[b.off:]
+}
+
+// Len returns the count of unread bytes.
+func (b *Buffer) Len() int {
+	return len(*b.data) - b.off
+}
+
+// Read to impl io.Reader
+func (b *Buffer) Read(p []byte) (int, error) {
+	if b.off >= len(*b.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, (*b.data)[b.off:])
+	b.off += n
+	return n, nil
+}
+
+// WriteTo to impl io.WriterTo
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write((*b.data)[b.off:])
+	b.off += n
+	return int64(n), err
+}
+
+// RefCnt returns the reference count.
+func (b *Buffer) RefCnt() int32 {
+	return atomic.LoadInt32(&b.refCnt)
+}
+
+// Retain increases the reference count by 1.
+func (b *Buffer) Retain() {
+	if atomic.AddInt32(&b.refCnt, 1) <= 1 {
+		panic("pbytes: retain a released buffer")
+	}
+}
+
+// Release decreases the reference count by 1, the bytes return to the pool if it drops to zero.
+func (b *Buffer) Release() bool {
+	switch refCnt := atomic.AddInt32(&b.refCnt, -1); {
+	case refCnt > 0:
+		return false
+	case refCnt < 0:
+		panic("pbytes: release a released buffer")
+	}
+
+	data := (*b.data)[:0]
+	*b.data = data
+	Put(b.data)
+	b.data, b.off = nil, 0
+	bufferPool.Put(b)
+	return true
+}
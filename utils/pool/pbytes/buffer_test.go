@@ -0,0 +1,62 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pbytes
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+
+	b := NewBuffer(10)
+	if 10 != b.Len() || 1 != b.RefCnt() {
+		t.Fatal("unexpected buffer:", b.Len(), b.RefCnt())
+	}
+
+	copy(b.Bytes(), "0123456789")
+
+	head := make([]byte, 4)
+	if n, err := b.Read(head); nil != err || 4 != n || "0123" != string(head) {
+		t.Fatal("unexpected read:", n, err, string(head))
+	}
+
+	var w bytes.Buffer
+	if n, err := b.WriteTo(&w); nil != err || 6 != n || "456789" != w.String() {
+		t.Fatal("unexpected write to:", n, err, w.String())
+	}
+
+	if _, err := b.Read(head); io.EOF != err {
+		t.Fatal("expect EOF, got:", err)
+	}
+
+	b.Retain()
+	if b.Release() {
+		t.Fatal("buffer is still retained")
+	}
+	if !b.Release() {
+		t.Fatal("buffer should be deallocated")
+	}
+
+	defer func() {
+		if nil == recover() {
+			t.Fatal("expect panic of releasing a released buffer")
+		}
+	}()
+	b.Release()
+}
@@ -60,6 +60,8 @@ func ToReader(message interface{}) (io.Reader, error) {
 			readers = append(readers, bytes.NewReader(b))
 		}
 		return io.MultiReader(readers...), nil
+	case []io.Reader:
+		return MultiReader(r...), nil
 	case string:
 		return strings.NewReader(r), nil
 	case io.Reader:
@@ -69,6 +71,50 @@ func ToReader(message interface{}) (io.Reader, error) {
 	}
 }
 
+// MultiReader returns a reader reads the readers sequentially like io.MultiReader, the reader implements
+// io.Closer is closed once it is drained, Close of the returned reader closes the readers not yet drained.
+func MultiReader(readers ...io.Reader) io.ReadCloser {
+	return &multiReader{readers: append([]io.Reader(nil), readers...)}
+}
+
+type multiReader struct {
+	readers []io.Reader
+}
+
+func (m *multiReader) Read(p []byte) (n int, err error) {
+	for len(m.readers) > 0 {
+		n, err = m.readers[0].Read(p)
+		if io.EOF == err {
+			if err = m.next(); nil == err && 0 == n {
+				continue
+			}
+		}
+		if n > 0 || nil != err {
+			return n, err
+		}
+	}
+	return 0, io.EOF
+}
+
+// next to close the drained reader and move on.
+func (m *multiReader) next() (err error) {
+	if c, ok := m.readers[0].(io.Closer); ok {
+		err = c.Close()
+	}
+	m.readers[0] = nil
+	m.readers = m.readers[1:]
+	return
+}
+
+func (m *multiReader) Close() (err error) {
+	for len(m.readers) > 0 {
+		if e := m.next(); nil == err {
+			err = e
+		}
+	}
+	return
+}
+
 // MustToReader any error to panic
 func MustToReader(message interface{}) io.Reader {
 	r, err := ToReader(message)
@@ -90,6 +136,10 @@ func ToBytes(message interface{}) ([]byte, error) {
 			buffer.Write(b)
 		}
 		return buffer.Bytes(), nil
+	case []io.Reader:
+		reader := MultiReader(r...)
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
 	case string:
 		return []byte(r), nil
 	case *bytes.Buffer:
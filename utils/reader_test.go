@@ -73,6 +73,7 @@ func TestToReader(t *testing.T) {
 	t.Run("bytes.NewReader", runTest(ToReader(bytes.NewReader(byteString))))
 	t.Run("bytes.NewBuffer", runTest(ToReader(bytes.NewBuffer(byteString))))
 	t.Run("strings.NewReader", runTest(ToReader(strings.NewReader(string(byteString)))))
+	t.Run("[]io.Reader", runTest(ToReader([]io.Reader{bytes.NewReader(byteString[:2]), strings.NewReader(string(byteString[2:]))})))
 }
 
 func TestToBytes(t *testing.T) {
@@ -95,6 +96,51 @@ func TestToBytes(t *testing.T) {
 	t.Run("bytes.NewReader", runTest(ToBytes(bytes.NewReader(byteString))))
 	t.Run("bytes.NewBuffer", runTest(ToBytes(bytes.NewBuffer(byteString))))
 	t.Run("strings.NewReader", runTest(ToBytes(strings.NewReader(string(byteString)))))
+	t.Run("[]io.Reader", runTest(ToBytes([]io.Reader{bytes.NewReader(byteString[:2]), strings.NewReader(string(byteString[2:]))})))
+}
+
+// closeReader records the count of Close.
+type closeReader struct {
+	io.Reader
+	closed int
+}
+
+func (c *closeReader) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestMultiReader(t *testing.T) {
+	header := &closeReader{Reader: strings.NewReader("header|")}
+	body := testReader{strings.NewReader("body|")}
+	empty := &closeReader{Reader: strings.NewReader("")}
+	footer := &closeReader{Reader: strings.NewReader("footer")}
+
+	reader := MultiReader(header, body, empty, footer)
+	buffer := make([]byte, 10)
+	if n, err := io.ReadFull(reader, buffer); nil != err || "header|bod" != string(buffer[:n]) {
+		t.Fatalf("unexpected read: %q, err: %v", buffer[:n], err)
+	}
+
+	// the drained reader is closed.
+	if 1 != header.closed || 0 != empty.closed || 0 != footer.closed {
+		t.Fatal("unexpected closes:", header.closed, empty.closed, footer.closed)
+	}
+
+	if rest, err := ioutil.ReadAll(reader); nil != err || "y|footer" != string(rest) {
+		t.Fatalf("unexpected read: %q, err: %v", rest, err)
+	}
+
+	if err := reader.Close(); nil != err || 1 != header.closed || 1 != empty.closed || 1 != footer.closed {
+		t.Fatal("unexpected closes:", header.closed, empty.closed, footer.closed, err)
+	}
+
+	// the readers not drained are closed by Close.
+	header, footer = &closeReader{Reader: strings.NewReader("header")}, &closeReader{Reader: strings.NewReader("footer")}
+	reader = MultiReader(header, footer)
+	if err := reader.Close(); nil != err || 1 != header.closed || 1 != footer.closed {
+		t.Fatal("unexpected closes:", header.closed, footer.closed, err)
+	}
 }
 
 func TestCountOf(t *testing.T) {
@@ -0,0 +1,29 @@
+/*
+ * Copyright 2023 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+// ReferenceCounted defines a message which must be released explicitly, the handler which consumes
+// it should Release it, and Retain it before sharing it with others, the message reached the head of pipeline
+// is released after written, and the failed one is left to the writer.
+type ReferenceCounted interface {
+	// RefCnt returns the reference count.
+	RefCnt() int32
+	// Retain increases the reference count by 1.
+	Retain()
+	// Release decreases the reference count by 1, returns true if the object is deallocated.
+	Release() bool
+}